@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+	telemetryrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/telemetry"
+)
+
+// Abuse signal kinds recorded on the request path.
+const (
+	abuseKindAuthFailure  = "auth_failure"
+	abuseKindInvalidToken = "invalid_token"
+	abuseKindRateLimited  = "rate_limited"
+)
+
+// Abuse dimensions a signal is counted under.
+const (
+	abuseDimensionIP         = "ip"
+	abuseDimensionCredential = "credential"
+	abuseDimensionSession    = "session"
+	abuseDimensionRateLimit  = "rate_limit"
+)
+
+const abuseResponseTimeout = 5 * time.Second
+
+// abuseConfig holds thresholds; a zero threshold disables that dimension.
+type abuseConfig struct {
+	Enabled             bool
+	Window              time.Duration
+	IPThreshold         int
+	CredentialThreshold int
+	SessionThreshold    int
+	RateLimitThreshold  int
+	RevokeFor           time.Duration
+}
+
+// abuseSignal is one failed auth attempt, invalid token or rate-limit hit.
+// Well-behaved clients hit rate limits too, so those are counted per client
+// IP under their own threshold and never revoke anything.
+type abuseSignal struct {
+	Kind         string
+	ClientIP     string
+	CredentialID string // authenticated credential, empty when unknown
+	TokenPrint   string // fingerprint of a presented but unresolved token
+	// SessionID is only set for authenticated callers; the X-Session-ID of
+	// an unauthenticated request is anyone's to claim.
+	SessionID string
+	TenantID  *string
+}
+
+// abuseBreach is emitted once per key when its threshold is crossed in a window.
+// WindowBreaches counts the breaches for the signal's tenant in the current
+// window, including this one; the threat level is derived from it.
+type abuseBreach struct {
+	Dimension      string
+	Key            string
+	Count          int
+	WindowBreaches int
+	Signal         abuseSignal
+}
+
+type abuseCounter struct {
+	windowStart time.Time
+	count       int
+	kinds       map[string]int
+}
+
+// abuseThreat tracks a tenant's breaches for the threat level: the count in
+// the current window, and when the last one happened so a quiet tenant's
+// level can be lowered again.
+type abuseThreat struct {
+	tenantID    *string
+	windowStart time.Time
+	breaches    int
+	lastBreach  time.Time
+}
+
+// abuseDetector counts abuse signals per client IP, credential and session
+// (subject) in fixed windows and blocks IPs that breached until their window ends.
+type abuseDetector struct {
+	mu         sync.Mutex
+	cfg        abuseConfig
+	counters   map[string]*abuseCounter
+	blockedIPs map[string]time.Time
+	threats    map[string]*abuseThreat
+	lastSweep  time.Time
+	clockFn    func() time.Time
+}
+
+func newAbuseDetector(cfg abuseConfig) *abuseDetector {
+	return &abuseDetector{
+		cfg:        cfg,
+		counters:   make(map[string]*abuseCounter),
+		blockedIPs: make(map[string]time.Time),
+		threats:    make(map[string]*abuseThreat),
+		clockFn:    time.Now,
+	}
+}
+
+// record counts the signal and returns any thresholds it crossed.
+func (d *abuseDetector) record(sig abuseSignal) []abuseBreach {
+	if d == nil || !d.cfg.Enabled {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clockFn()
+	d.sweepLocked(now)
+
+	credentialKey := sig.CredentialID
+	if credentialKey == "" && sig.TokenPrint != "" {
+		credentialKey = "token:" + sig.TokenPrint
+	}
+	// Sessions are counted per credential, so one caller presenting another
+	// caller's session id cannot add to that session's count.
+	sessionScope := ""
+	if sig.SessionID != "" && sig.CredentialID != "" {
+		sessionScope = sig.CredentialID + "/"
+	}
+	type dimension struct {
+		name      string
+		scope     string
+		key       string
+		threshold int
+	}
+	dims := []dimension{
+		{abuseDimensionIP, "", sig.ClientIP, d.cfg.IPThreshold},
+		{abuseDimensionCredential, "", credentialKey, d.cfg.CredentialThreshold},
+		{abuseDimensionSession, sessionScope, sig.SessionID, d.cfg.SessionThreshold},
+	}
+	if sig.Kind == abuseKindRateLimited {
+		dims = []dimension{{abuseDimensionRateLimit, "", sig.ClientIP, d.cfg.RateLimitThreshold}}
+	}
+	var breaches []abuseBreach
+	for _, dim := range dims {
+		if dim.key == "" || dim.threshold <= 0 {
+			continue
+		}
+		if dim.name == abuseDimensionSession && dim.scope == "" {
+			continue
+		}
+		mapKey := dim.name + ":" + dim.scope + dim.key
+		c, ok := d.counters[mapKey]
+		if !ok || now.Sub(c.windowStart) >= d.cfg.Window {
+			c = &abuseCounter{windowStart: now, kinds: map[string]int{}}
+			d.counters[mapKey] = c
+		}
+		c.count++
+		c.kinds[sig.Kind]++
+		if c.count != dim.threshold {
+			continue
+		}
+		breaches = append(breaches, abuseBreach{
+			Dimension:      dim.name,
+			Key:            dim.key,
+			Count:          c.count,
+			WindowBreaches: d.noteBreachLocked(now, sig.TenantID),
+			Signal:         sig,
+		})
+		if dim.name == abuseDimensionIP || dim.name == abuseDimensionRateLimit {
+			d.blockedIPs[dim.key] = c.windowStart.Add(d.cfg.Window)
+		}
+	}
+	return breaches
+}
+
+// noteBreachLocked counts a breach against the tenant's current window and
+// returns the window's total.
+func (d *abuseDetector) noteBreachLocked(now time.Time, tenantID *string) int {
+	key := ""
+	if tenantID != nil {
+		key = *tenantID
+	}
+	t, ok := d.threats[key]
+	if !ok {
+		t = &abuseThreat{tenantID: tenantID}
+		d.threats[key] = t
+	}
+	if now.Sub(t.windowStart) >= d.cfg.Window {
+		t.windowStart, t.breaches = now, 0
+	}
+	t.breaches++
+	t.lastBreach = now
+	return t.breaches
+}
+
+// quietTenants returns the tenants with no breach for a full window. Each
+// is forgotten once its level is back to normal; see forgetThreat.
+func (d *abuseDetector) quietTenants() []*string {
+	if d == nil || !d.cfg.Enabled {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.clockFn()
+	var out []*string
+	for _, t := range d.threats {
+		if now.Sub(t.lastBreach) >= d.cfg.Window {
+			out = append(out, t.tenantID)
+		}
+	}
+	return out
+}
+
+// forgetThreat drops a tenant's breach history unless it breached again
+// since quietTenants reported it.
+func (d *abuseDetector) forgetThreat(tenantID *string) {
+	key := ""
+	if tenantID != nil {
+		key = *tenantID
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t, ok := d.threats[key]; ok && d.clockFn().Sub(t.lastBreach) >= d.cfg.Window {
+		delete(d.threats, key)
+	}
+}
+
+// isBlocked reports whether the client IP breached its threshold in the current window.
+func (d *abuseDetector) isBlocked(clientIP string) bool {
+	if d == nil || !d.cfg.Enabled || clientIP == "" {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	until, ok := d.blockedIPs[clientIP]
+	if !ok {
+		return false
+	}
+	if !d.clockFn().Before(until) {
+		delete(d.blockedIPs, clientIP)
+		return false
+	}
+	return true
+}
+
+// sweepLocked drops expired counters and blocks at most once per window.
+func (d *abuseDetector) sweepLocked(now time.Time) {
+	if now.Sub(d.lastSweep) < d.cfg.Window {
+		return
+	}
+	d.lastSweep = now
+	for k, c := range d.counters {
+		if now.Sub(c.windowStart) >= d.cfg.Window {
+			delete(d.counters, k)
+		}
+	}
+	for ip, until := range d.blockedIPs {
+		if !now.Before(until) {
+			delete(d.blockedIPs, ip)
+		}
+	}
+}
+
+// recordAbuse counts a signal derived from the request and responds to
+// breaches. sessionID is ignored unless the caller authenticated.
+func (a *httpAPI) recordAbuse(kind string, clientIP string, caller callerIdentity, tokenPrint string, sessionID string) {
+	if caller.CredentialID == "" || caller.CredentialID == "anonymous" {
+		caller.CredentialID, sessionID = "", ""
+	}
+	breaches := a.abuse.record(abuseSignal{
+		Kind:         kind,
+		ClientIP:     clientIP,
+		CredentialID: caller.CredentialID,
+		TokenPrint:   tokenPrint,
+		SessionID:    strings.TrimSpace(sessionID),
+		TenantID:     caller.TenantID,
+	})
+	for _, b := range breaches {
+		a.abuseWork.Add(1)
+		go func() {
+			defer a.abuseWork.Done()
+			a.respondToAbuse(b)
+		}()
+	}
+}
+
+// waitForAbuseWork waits for in-flight breach responses and the threat decay
+// loop so none of them touches the pool after it closes.
+func (a *httpAPI) waitForAbuseWork(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.abuseWork.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// respondToAbuse revokes the offending credential/session, writes a security
+// event, and raises the node threat level to match the tenant's breaches in
+// the current window.
+func (a *httpAPI) respondToAbuse(b abuseBreach) {
+	if a.rt == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), abuseResponseTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	revoked := ""
+	if a.rt.Security != nil && a.rt.Security.RevocationStore != nil && a.securityCfg.Abuse.RevokeFor > 0 {
+		until := now.Add(a.securityCfg.Abuse.RevokeFor)
+		switch {
+		case b.Dimension == abuseDimensionCredential && b.Signal.CredentialID != "":
+			if err := a.rt.Security.RevocationStore.RevokeToken(b.Signal.CredentialID, until); err != nil {
+				logging.FromContext(ctx).Error("abuse revoke credential failed", "credential_id", b.Signal.CredentialID, "error", err)
+			} else {
+				revoked = "credential"
+			}
+		case b.Dimension == abuseDimensionSession && b.Signal.CredentialID != "":
+			if err := a.rt.Security.RevocationStore.RevokeSession(sessionRevocationKey(b.Signal.CredentialID, b.Key), until); err != nil {
+				logging.FromContext(ctx).Error("abuse revoke session failed", "error", err)
+			} else {
+				revoked = "session"
+			}
+		}
+	}
+
+	severity := "warn"
+	if revoked != "" {
+		severity = "error"
+	}
+	details := map[string]interface{}{
+		"dimension":   b.Dimension,
+		"key":         b.Key,
+		"count":       b.Count,
+		"window_secs": int64(a.securityCfg.Abuse.Window / time.Second),
+		"last_kind":   b.Signal.Kind,
+		"client_ip":   b.Signal.ClientIP,
+		"revoked":     revoked,
+	}
+	var eventID *string
+	if a.rt.TelemetryRepo != nil {
+		id, err := a.rt.RecordSecurityEvent(ctx, telemetryrepo.SecurityEventRecord{
+			TenantID:  b.Signal.TenantID,
+			ActorType: "system",
+			EventType: "security.abuse_detected",
+			Severity:  severity,
+			Message:   fmt.Sprintf("abuse threshold exceeded for %s", b.Dimension),
+			EventJSON: mustMarshalJSON(details),
+		}, nil)
+		if err != nil {
+			logging.FromContext(ctx).Error("abuse security event failed", "error", err)
+		} else {
+			eventID = &id
+		}
+	}
+
+	target := threatLevelForBreaches(b.WindowBreaches)
+	a.moveThreatLevel(ctx, b.Signal.TenantID, func(current string) string {
+		if threatLevelRank(target) > threatLevelRank(current) {
+			return target
+		}
+		return current
+	}, securitypkg.ThreatLevelTransition{
+		ReasonCode:      "abuse." + b.Dimension + "_threshold",
+		Reason:          fmt.Sprintf("%d abuse breaches within %s, the last for %s", b.WindowBreaches, a.securityCfg.Abuse.Window, b.Dimension),
+		SecurityEventID: eventID,
+		MetadataJSON:    mustMarshalJSON(details),
+	})
+}
+
+// startThreatDecay lowers the threat level one step per quiet window for
+// each tenant abuse detection raised, until it is back to normal or ctx ends.
+func (a *httpAPI) startThreatDecay(ctx context.Context) {
+	a.abuseWork.Add(1)
+	go func() {
+		defer a.abuseWork.Done()
+		ticker := time.NewTicker(a.securityCfg.Abuse.Window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.decayThreatLevels(ctx)
+			}
+		}
+	}()
+}
+
+func (a *httpAPI) decayThreatLevels(ctx context.Context) {
+	for _, tenantID := range a.abuse.quietTenants() {
+		level := a.moveThreatLevel(ctx, tenantID, lowerThreatLevel, securitypkg.ThreatLevelTransition{
+			ReasonCode: "abuse.decay",
+			Reason:     fmt.Sprintf("no abuse breaches within %s", a.securityCfg.Abuse.Window),
+		})
+		if level == securitypkg.ThreatLevelNormal {
+			a.abuse.forgetThreat(tenantID)
+		}
+	}
+}
+
+// moveThreatLevel records a transition to next(current) for the node and
+// tenant and returns the resulting level, or "" when it could not be read.
+// Transitions are serialized so concurrent breaches do not race between
+// reading the level and writing the next one.
+func (a *httpAPI) moveThreatLevel(ctx context.Context, tenantID *string, next func(string) string, rec securitypkg.ThreatLevelTransition) string {
+	if a.rt == nil || a.rt.ThreatRepo == nil || a.nodeName == "" {
+		return ""
+	}
+	a.threatMu.Lock()
+	defer a.threatMu.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, abuseResponseTimeout)
+	defer cancel()
+	current, err := a.rt.ThreatRepo.CurrentThreatLevel(ctx, a.nodeName, tenantID)
+	if err != nil {
+		logging.FromContext(ctx).Error("abuse threat level lookup failed", "node_name", a.nodeName, "error", err)
+		return ""
+	}
+	rec.NewLevel = next(current)
+	if rec.NewLevel == current {
+		return current
+	}
+	rec.NodeName, rec.TenantID = a.nodeName, tenantID
+	if _, err := a.rt.ThreatRepo.RecordThreatLevelTransition(ctx, rec); err != nil {
+		logging.FromContext(ctx).Error("abuse threat transition failed", "node_name", a.nodeName, "new_level", rec.NewLevel, "error", err)
+		return current
+	}
+	return rec.NewLevel
+}
+
+// sessionRevocationKey scopes a revoked X-Session-ID to the credential that
+// presented it, so revoking one caller's session never locks out another
+// caller sending the same id.
+func sessionRevocationKey(credentialID, sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	return credentialID + "/" + sessionID
+}
+
+// threatLevelForBreaches maps a tenant's breaches in one window to a level,
+// so a trickle of breaches over days never climbs past elevated.
+func threatLevelForBreaches(n int) string {
+	switch {
+	case n >= 6:
+		return securitypkg.ThreatLevelCritical
+	case n >= 3:
+		return securitypkg.ThreatLevelHigh
+	case n >= 1:
+		return securitypkg.ThreatLevelElevated
+	default:
+		return securitypkg.ThreatLevelNormal
+	}
+}
+
+// lowerThreatLevel returns the next level down, floored at normal.
+func lowerThreatLevel(current string) string {
+	switch current {
+	case securitypkg.ThreatLevelCritical:
+		return securitypkg.ThreatLevelHigh
+	case securitypkg.ThreatLevelHigh:
+		return securitypkg.ThreatLevelElevated
+	default:
+		return securitypkg.ThreatLevelNormal
+	}
+}
+
+func threatLevelRank(level string) int {
+	switch level {
+	case securitypkg.ThreatLevelElevated:
+		return 1
+	case securitypkg.ThreatLevelHigh:
+		return 2
+	case securitypkg.ThreatLevelCritical:
+		return 3
+	default:
+		return 0
+	}
+}
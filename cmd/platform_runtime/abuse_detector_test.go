@@ -0,0 +1,163 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestAbuseDetector(now *time.Time) *abuseDetector {
+	d := newAbuseDetector(abuseConfig{
+		Enabled:             true,
+		Window:              time.Minute,
+		IPThreshold:         3,
+		CredentialThreshold: 2,
+		SessionThreshold:    0,
+		RevokeFor:           time.Hour,
+	})
+	d.clockFn = func() time.Time { return *now }
+	return d
+}
+
+func TestAbuseDetectorBreachesOncePerWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := newTestAbuseDetector(&now)
+
+	sig := abuseSignal{Kind: abuseKindInvalidToken, ClientIP: "10.0.0.1", TokenPrint: "abc", SessionID: "s1"}
+	if b := d.record(sig); len(b) != 0 {
+		t.Fatalf("expected no breach on first signal, got %+v", b)
+	}
+	b := d.record(sig)
+	if len(b) != 1 || b[0].Dimension != abuseDimensionCredential || b[0].Key != "token:abc" {
+		t.Fatalf("expected credential breach, got %+v", b)
+	}
+	b = d.record(sig)
+	if len(b) != 1 || b[0].Dimension != abuseDimensionIP {
+		t.Fatalf("expected ip breach only, got %+v", b)
+	}
+	if b := d.record(sig); len(b) != 0 {
+		t.Fatalf("expected no repeated breach in same window, got %+v", b)
+	}
+}
+
+func TestAbuseDetectorBlocksIPUntilWindowEnds(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := newTestAbuseDetector(&now)
+
+	for i := 0; i < 3; i++ {
+		d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.2"})
+	}
+	if !d.isBlocked("10.0.0.2") {
+		t.Fatalf("expected ip to be blocked after breach")
+	}
+	if d.isBlocked("10.0.0.3") {
+		t.Fatalf("expected other ip to stay unblocked")
+	}
+	now = now.Add(time.Minute)
+	if d.isBlocked("10.0.0.2") {
+		t.Fatalf("expected block to expire with the window")
+	}
+	if b := d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.2"}); len(b) != 0 {
+		t.Fatalf("expected counters to reset in new window, got %+v", b)
+	}
+}
+
+func TestAbuseDetectorCountsSessionsPerCredential(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := newAbuseDetector(abuseConfig{Enabled: true, Window: time.Minute, SessionThreshold: 2})
+	d.clockFn = func() time.Time { return now }
+
+	// Without an authenticated credential the session header is not trusted.
+	for i := 0; i < 3; i++ {
+		if b := d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.5", SessionID: "victim"}); len(b) != 0 {
+			t.Fatalf("expected an unauthenticated session id to be ignored, got %+v", b)
+		}
+	}
+	// Another credential presenting the same session id has its own count.
+	d.record(abuseSignal{Kind: abuseKindAuthFailure, CredentialID: "cred-a", SessionID: "victim"})
+	if b := d.record(abuseSignal{Kind: abuseKindAuthFailure, CredentialID: "cred-b", SessionID: "victim"}); len(b) != 0 {
+		t.Fatalf("expected sessions to be counted per credential, got %+v", b)
+	}
+	b := d.record(abuseSignal{Kind: abuseKindAuthFailure, CredentialID: "cred-a", SessionID: "victim"})
+	if len(b) != 1 || b[0].Dimension != abuseDimensionSession || b[0].Key != "victim" {
+		t.Fatalf("expected a session breach for cred-a, got %+v", b)
+	}
+	// The revocation is keyed by credential, so cred-b's "victim" stays valid.
+	if got := sessionRevocationKey(b[0].Signal.CredentialID, b[0].Key); got != "cred-a/victim" || got == sessionRevocationKey("cred-b", "victim") {
+		t.Fatalf("unexpected session revocation key %q", got)
+	}
+	if got := sessionRevocationKey("cred-a", ""); got != "" {
+		t.Fatalf("expected no revocation key without a session, got %q", got)
+	}
+}
+
+func TestAbuseDetectorCountsRateLimitHitsSeparately(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := newAbuseDetector(abuseConfig{Enabled: true, Window: time.Minute, IPThreshold: 2, CredentialThreshold: 2, RateLimitThreshold: 3})
+	d.clockFn = func() time.Time { return now }
+
+	sig := abuseSignal{Kind: abuseKindRateLimited, ClientIP: "10.0.0.6", CredentialID: "cred-a"}
+	for i := 0; i < 2; i++ {
+		if b := d.record(sig); len(b) != 0 {
+			t.Fatalf("expected rate-limit hits to skip the auth thresholds, got %+v", b)
+		}
+	}
+	b := d.record(sig)
+	if len(b) != 1 || b[0].Dimension != abuseDimensionRateLimit || b[0].Key != "10.0.0.6" {
+		t.Fatalf("expected a rate limit breach, got %+v", b)
+	}
+	if !d.isBlocked("10.0.0.6") {
+		t.Fatalf("expected ip to be blocked after a rate limit breach")
+	}
+}
+
+func TestAbuseDetectorDisabled(t *testing.T) {
+	d := newAbuseDetector(abuseConfig{Enabled: false, Window: time.Minute, IPThreshold: 1})
+	if b := d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.4"}); len(b) != 0 {
+		t.Fatalf("expected disabled detector to ignore signals, got %+v", b)
+	}
+	if d.isBlocked("10.0.0.4") {
+		t.Fatalf("expected disabled detector to never block")
+	}
+}
+
+func TestAbuseThreatLevelFollowsWindowBreaches(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	d := newAbuseDetector(abuseConfig{Enabled: true, Window: time.Minute, IPThreshold: 1})
+	d.clockFn = func() time.Time { return now }
+	tenant := "tenant-a"
+
+	b := d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.7", TenantID: &tenant})
+	b = append(b, d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.8", TenantID: &tenant})...)
+	if len(b) != 2 || b[1].WindowBreaches != 2 {
+		t.Fatalf("expected two breaches in the window, got %+v", b)
+	}
+	if got := d.quietTenants(); len(got) != 0 {
+		t.Fatalf("expected no quiet tenants right after a breach, got %v", got)
+	}
+	now = now.Add(time.Minute)
+	b = d.record(abuseSignal{Kind: abuseKindAuthFailure, ClientIP: "10.0.0.9", TenantID: &tenant})
+	if len(b) != 1 || b[0].WindowBreaches != 1 {
+		t.Fatalf("expected the breach total to reset with the window, got %+v", b)
+	}
+	now = now.Add(time.Minute)
+	if got := d.quietTenants(); len(got) != 1 || *got[0] != tenant {
+		t.Fatalf("expected tenant-a to be quiet, got %v", got)
+	}
+	d.forgetThreat(&tenant)
+	if got := d.quietTenants(); len(got) != 0 {
+		t.Fatalf("expected a forgotten tenant, got %v", got)
+	}
+}
+
+func TestThreatLevelSteps(t *testing.T) {
+	for n, want := range map[int]string{0: "normal", 1: "elevated", 2: "elevated", 3: "high", 6: "critical", 40: "critical"} {
+		if got := threatLevelForBreaches(n); got != want {
+			t.Fatalf("threatLevelForBreaches(%d) = %q, want %q", n, got, want)
+		}
+	}
+	for in, want := range map[string]string{"critical": "high", "high": "elevated", "elevated": "normal", "normal": "normal"} {
+		if got := lowerThreatLevel(in); got != want {
+			t.Fatalf("lowerThreatLevel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
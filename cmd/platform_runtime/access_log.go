@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+const maxLoggedRequestIDLen = 128
+
+// runtimeLogConfig is the logger setup (RUNTIME_LOG_*, RUNTIME_ACCESS_LOG).
+type runtimeLogConfig struct {
+	Logger    logging.Config
+	AccessLog bool
+}
+
+func loadRuntimeLogConfigFromEnv() (runtimeLogConfig, error) {
+	cfg := runtimeLogConfig{Logger: logging.Config{Level: slog.LevelInfo, Format: logging.FormatJSON}, AccessLog: true}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_LOG_LEVEL")); v != "" {
+		level, err := logging.ParseLevel(v)
+		if err != nil {
+			return runtimeLogConfig{}, fmt.Errorf("runtime: invalid RUNTIME_LOG_LEVEL")
+		}
+		cfg.Logger.Level = level
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_LOG_FORMAT")); v != "" {
+		format, err := logging.ParseFormat(v)
+		if err != nil {
+			return runtimeLogConfig{}, fmt.Errorf("runtime: invalid RUNTIME_LOG_FORMAT")
+		}
+		cfg.Logger.Format = format
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ACCESS_LOG")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return runtimeLogConfig{}, fmt.Errorf("runtime: invalid RUNTIME_ACCESS_LOG")
+		}
+		cfg.AccessLog = b
+	}
+	return cfg, nil
+}
+
+// accessLogCaller is filled in by authorizeAndRateLimit once the caller is
+// known, and by withTracing with the request's trace id.
+type accessLogCaller struct {
+	mu           sync.Mutex
+	credentialID string
+	tenantID     string
+	traceID      string
+}
+
+type accessLogCallerKey struct{}
+
+// noteAccessLogCaller records the authenticated caller for the access log line.
+func noteAccessLogCaller(ctx context.Context, caller callerIdentity) {
+	c, ok := ctx.Value(accessLogCallerKey{}).(*accessLogCaller)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.credentialID = caller.CredentialID
+	c.tenantID = trimmedPtr(caller.TenantID)
+	c.mu.Unlock()
+}
+
+// noteAccessLogTrace records the request's trace id for the access log line.
+func noteAccessLogTrace(ctx context.Context, traceID string) {
+	c, ok := ctx.Value(accessLogCallerKey{}).(*accessLogCaller)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.traceID = traceID
+	c.mu.Unlock()
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// withAccessLog attaches a request-scoped logger (carrying request_id) to the
+// context and writes one line per request. Credentials are never logged:
+// headers are skipped and sensitive query parameters are redacted.
+func withAccessLog(next http.Handler, logger *slog.Logger, enabled, trustProxyHeaders bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		reqLogger := logger
+		requestID := loggableRequestID(r.Header.Get("X-Request-ID"))
+		if requestID != "" {
+			reqLogger = logger.With("request_id", requestID)
+		}
+		caller := &accessLogCaller{}
+		ctx := logging.NewContext(r.Context(), reqLogger)
+		ctx = context.WithValue(ctx, accessLogCallerKey{}, caller)
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+		if !enabled {
+			return
+		}
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		caller.mu.Lock()
+		attrs := []any{
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", rec.bytes,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", extractClientIP(r, trustProxyHeaders),
+			"credential_id", caller.credentialID,
+			"tenant_id", caller.tenantID,
+		}
+		if caller.traceID != "" {
+			attrs = append(attrs, "trace_id", caller.traceID)
+		}
+		caller.mu.Unlock()
+		if q := redactedQuery(r.URL.RawQuery); q != "" {
+			attrs = append(attrs, "query", q)
+		}
+		if ua := r.UserAgent(); ua != "" {
+			attrs = append(attrs, "user_agent", ua)
+		}
+		reqLogger.Log(r.Context(), accessLogLevel(r.URL.Path, status), "http request", attrs...)
+	})
+}
+
+// accessLogLevel keeps probe and scrape traffic at debug and flags server errors.
+func accessLogLevel(path string, status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case path == "/livez" || path == "/healthz" || path == "/readyz" || path == "/metrics":
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// loggableRequestID bounds a client-supplied X-Request-ID and drops control characters.
+func loggableRequestID(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxLoggedRequestIDLen {
+		raw = raw[:maxLoggedRequestIDLen]
+	}
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+}
+
+func redactedQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return "[unparseable]"
+	}
+	for k := range values {
+		if logging.IsSensitiveKey(k) {
+			values[k] = []string{logging.Redacted}
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+func TestAccessLogRecordsRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.Config{Level: slog.LevelInfo, Format: logging.FormatJSON})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/security/anomaly-reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		tenant := "tenant-a"
+		noteAccessLogCaller(r.Context(), callerIdentity{CredentialID: "cred-1", TenantID: &tenant})
+		logging.FromContext(r.Context()).Info("handler")
+		writeJSONError(w, http.StatusNotFound, "anomaly report not found")
+	})
+	h := withAccessLog(mux, logger, true, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/security/anomaly-reports/abc?access_token=secret-1&limit=5", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("Authorization", "Bearer secret-2")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	if strings.Contains(out, "secret-1") || strings.Contains(out, "secret-2") {
+		t.Fatalf("expected credentials to be redacted, got %s", out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"request_id":"req-1"`) {
+		t.Fatalf("expected handler log correlated by request id, got %s", out)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("decode access log: %v", err)
+	}
+	want := map[string]interface{}{
+		"msg":           "http request",
+		"method":        "GET",
+		"route":         "/v1/security/anomaly-reports/{id}",
+		"status":        float64(404),
+		"credential_id": "cred-1",
+		"tenant_id":     "tenant-a",
+		"request_id":    "req-1",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Fatalf("access log %s = %v, want %v (%s)", k, entry[k], v, lines[1])
+		}
+	}
+	if q, _ := entry["query"].(string); !strings.Contains(q, "access_token=%5BREDACTED%5D") || !strings.Contains(q, "limit=5") {
+		t.Fatalf("unexpected query %q", q)
+	}
+}
+
+func TestAccessLogLevelAndRequestID(t *testing.T) {
+	if accessLogLevel("/livez", 200) != slog.LevelDebug || accessLogLevel("/v1/decisions", 503) != slog.LevelError || accessLogLevel("/v1/decisions", 202) != slog.LevelInfo {
+		t.Fatalf("unexpected access log levels")
+	}
+	if got := loggableRequestID(" id\n-1 "); got != "id-1" {
+		t.Fatalf("expected control characters stripped, got %q", got)
+	}
+	if got := loggableRequestID(strings.Repeat("x", 300)); len(got) != maxLoggedRequestIDLen {
+		t.Fatalf("expected request id truncated, got %d chars", len(got))
+	}
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
+)
+
+const (
+	defaultAdminHost = "127.0.0.1"
+	redactedValue    = "[REDACTED]"
+)
+
+// adminListenerConfig is the optional operator listener (--admin-port). It
+// authenticates with its own bearer tokens, never the API's, and binds to
+// loopback unless told otherwise.
+type adminListenerConfig struct {
+	Host   string
+	Port   int
+	Tokens map[string]struct{}
+	Pprof  bool
+}
+
+func (c adminListenerConfig) Enabled() bool {
+	return c.Port > 0
+}
+
+func (c adminListenerConfig) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("runtime: invalid admin port %d", c.Port)
+	}
+	if c.Enabled() && len(c.Tokens) == 0 {
+		return fmt.Errorf("runtime: the admin listener requires RUNTIME_ADMIN_TOKENS")
+	}
+	if _, ok := c.Tokens[""]; ok {
+		return fmt.Errorf("runtime: admin tokens must not be empty")
+	}
+	return nil
+}
+
+// loadAdminListenerConfig layers the RUNTIME_ADMIN_* environment over the
+// config file's admin section over the defaults. The --admin-host and
+// --admin-port flags are applied on top by loadServeOptions.
+func loadAdminListenerConfig(file adminFileConfig) (adminListenerConfig, error) {
+	cfg := adminListenerConfig{Host: defaultAdminHost, Tokens: map[string]struct{}{}, Pprof: true}
+	setIf(&cfg.Host, file.Host)
+	setIf(&cfg.Port, file.Port)
+	if file.Tokens != nil {
+		cfg.Tokens = stringSet(file.Tokens)
+	}
+	setIf(&cfg.Pprof, file.Pprof)
+
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ADMIN_HOST")); v != "" {
+		cfg.Host = v
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ADMIN_PORT")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return adminListenerConfig{}, fmt.Errorf("runtime: invalid RUNTIME_ADMIN_PORT")
+		}
+		cfg.Port = n
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ADMIN_TOKENS")); v != "" {
+		cfg.Tokens = stringSet(splitCSV(v))
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ADMIN_PPROF")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return adminListenerConfig{}, fmt.Errorf("runtime: invalid RUNTIME_ADMIN_PPROF")
+		}
+		cfg.Pprof = b
+	}
+	return cfg, nil
+}
+
+// adminAPI serves the admin listener: metrics, detailed health, job status,
+// the effective config and pprof, plus operator actions. With the listener
+// enabled, /metrics and /v1/admin/* are not served on the public port.
+type adminAPI struct {
+	api  *httpAPI
+	opts serveOptions
+}
+
+func newAdminHandler(api *httpAPI, opts serveOptions) http.Handler {
+	s := &adminAPI{api: api, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/jobs", s.handleJobs)
+	mux.HandleFunc("/jobs/{name}/run", s.handleRunJob)
+	mux.HandleFunc("/idempotency-keys/{key}", s.handleIdempotencyKey)
+	mux.HandleFunc("/config", s.handleConfig)
+	mux.HandleFunc("/caches/flush", s.handleFlushCaches)
+	if opts.admin.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return withAdminAuth(mux, opts.admin.Tokens)
+}
+
+// withAdminAuth admits requests bearing one of the admin tokens; API tokens,
+// database credentials and OIDC tokens are not accepted here.
+func withAdminAuth(next http.Handler, tokens map[string]struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := extractAuthToken(r)
+		if token == "" {
+			writeAuthError(w, errMissingCredentials)
+			return
+		}
+		match := 0
+		for t := range tokens {
+			match |= subtle.ConstantTimeCompare([]byte(token), []byte(t))
+		}
+		if match != 1 {
+			writeAuthError(w, errInvalidCredentials)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *adminAPI) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.api.metrics == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "metrics disabled")
+		return
+	}
+	s.api.metrics.registry.Handler().ServeHTTP(w, r)
+}
+
+func (s *adminAPI) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.api.healthReport(r.Context()))
+}
+
+// handleJobs takes the same query as GET /v1/admin/maintenance.
+func (s *adminAPI) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if cerr := checkRequest(operationRequest(http.MethodGet, "/v1/admin/maintenance", r.URL.Query()), nil); cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	s.api.writeMaintenanceStatus(w, r)
+}
+
+// handleRunJob runs a maintenance job now and waits for it. Like a scheduled
+// run it takes the job's advisory lock, so it is skipped while another
+// replica runs the same job.
+func (s *adminAPI) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	scheduler := s.api.scheduler
+	if scheduler == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "maintenance scheduler disabled")
+		return
+	}
+	name := r.PathValue("name")
+	if !slices.ContainsFunc(scheduler.Status(), func(st platform.MaintenanceJobStatus) bool { return st.Name == name }) {
+		writeJSONError(w, http.StatusNotFound, "maintenance job not found")
+		return
+	}
+	status, err := scheduler.RunOnce(r.Context(), name)
+	resp := runtimeapi.MaintenanceTrigger{JobName: name, NodeName: scheduler.NodeName(), Status: status}
+	if err != nil {
+		msg := err.Error()
+		resp.ErrorText = &msg
+	}
+	logging.FromContext(r.Context()).Info("maintenance job triggered", "job", name, "status", status, "error", err)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleIdempotencyKey takes the same query as
+// GET /v1/admin/idempotency-keys/{key}.
+func (s *adminAPI) handleIdempotencyKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	path := "/v1/admin/idempotency-keys/" + url.PathEscape(r.PathValue("key"))
+	if cerr := checkRequest(operationRequest(http.MethodGet, path, r.URL.Query()), nil); cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	s.api.writeIdempotencyKey(w, r)
+}
+
+func (s *adminAPI) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, redactedServeConfig(s.opts, *s.api.liveSecurity()))
+}
+
+// handleFlushCaches drops cached tenant quotas, writes pending usage counters
+// and refetches the OIDC issuer's keys, so control-plane and issuer changes
+// apply without waiting for their TTLs.
+func (s *adminAPI) handleFlushCaches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.api.writeTimeout)
+	defer cancel()
+	resp := runtimeapi.CacheFlush{Flushed: make([]string, 0, 3)}
+	if s.api.quotaCache != nil {
+		s.api.quotaCache.clear()
+		resp.Flushed = append(resp.Flushed, "quotas")
+	}
+	if s.api.quotas != nil {
+		if err := s.api.usage.flush(ctx, s.api.quotas); err != nil {
+			logging.FromContext(ctx).Error("usage flush failed", "error", err)
+			writeJSONError(w, http.StatusServiceUnavailable, "failed to write usage counters; they are kept for the next flush")
+			return
+		}
+		resp.Flushed = append(resp.Flushed, "usage")
+	}
+	if s.api.oidc != nil {
+		if err := s.api.oidc.RefreshKeys(ctx); err != nil {
+			logging.FromContext(ctx).Error("oidc jwks refresh failed", "error", err)
+			writeJSONError(w, http.StatusServiceUnavailable, "failed to refetch the oidc issuer keys")
+			return
+		}
+		resp.Flushed = append(resp.Flushed, "oidc_jwks")
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// redactedServeConfig is the effective serve config for GET /config. Secrets
+// are replaced by redactedValue and token sets by their size; the security
+// section reflects the last reload.
+func redactedServeConfig(opts serveOptions, sec serveSecurityConfig) map[string]any {
+	return map[string]any{
+		"config_file": opts.configFile,
+		"server": map[string]any{
+			"host":                    opts.host,
+			"port":                    opts.port,
+			"grpc_port":               opts.grpcPort,
+			"node_name":               opts.nodeName,
+			"nonce_scope":             opts.nonceScope,
+			"nonce_window":            opts.nonceWindow,
+			"health_timeout":          opts.healthTimeout.String(),
+			"write_timeout":           opts.writeTimeout.String(),
+			"shutdown_timeout":        opts.shutdownTimeout.String(),
+			"drain_delay":             opts.drainDelay.String(),
+			"idempotency_ttl":         opts.idempotencyTTL.String(),
+			"idempotency_lease":       opts.idempotencyLease.String(),
+			"idempotency_fingerprint": opts.fingerprint.String(),
+		},
+		"tls": map[string]any{
+			"enabled":         opts.tls.Enabled(),
+			"cert_file":       opts.tls.CertFile,
+			"key_file":        opts.tls.KeyFile,
+			"client_ca_file":  opts.tls.ClientCAFile,
+			"client_auth":     opts.tls.ClientAuth,
+			"reload_interval": opts.tls.ReloadInterval.String(),
+		},
+		"database": map[string]any{
+			"driver":             opts.db.DriverName,
+			"url":                redactDatabaseURL(opts.db.DatabaseURL),
+			"connect_timeout":    opts.db.ConnectTimeout.String(),
+			"max_idle_conns":     opts.db.MaxIdleConns,
+			"max_open_conns":     opts.db.MaxOpenConns,
+			"conn_max_idle_time": opts.db.ConnMaxIdleTime.String(),
+			"conn_max_lifetime":  opts.db.ConnMaxLifetime.String(),
+			"statement_timeout":  strconv.Itoa(opts.db.StatementTimeoutMS) + "ms",
+		},
+		"security": map[string]any{
+			"require_auth":            sec.RequireAuth,
+			"api_tokens":              len(sec.AllowedTokens),
+			"db_credentials":          sec.DBCredentials,
+			"allowed_origins":         slices.Sorted(maps.Keys(sec.AllowedOrigins)),
+			"trust_proxy_headers":     sec.TrustProxyHeaders,
+			"require_request_signing": sec.RequireRequestSigning,
+			"signature_max_skew":      sec.SignatureMaxSkew.String(),
+			"mtls_principals":         sec.MTLSPrincipals,
+			"jwks_max_age":            sec.JWKSMaxAge.String(),
+			"rate_limit": map[string]any{
+				"per_minute": sec.RateLimitPerMinute,
+				"burst":      sec.RateLimitBurst,
+				"key":        sec.RateLimitKey,
+				"store":      sec.RateLimitStore,
+				"max_keys":   sec.RateLimitMaxKeys,
+			},
+			"abuse": map[string]any{
+				"enabled":              sec.Abuse.Enabled,
+				"window":               sec.Abuse.Window.String(),
+				"ip_threshold":         sec.Abuse.IPThreshold,
+				"credential_threshold": sec.Abuse.CredentialThreshold,
+				"session_threshold":    sec.Abuse.SessionThreshold,
+				"rate_limit_threshold": sec.Abuse.RateLimitThreshold,
+				"revoke_for":           sec.Abuse.RevokeFor.String(),
+			},
+			"oidc": map[string]any{
+				"issuer":        sec.OIDC.Issuer,
+				"audiences":     sec.OIDC.Audiences,
+				"provider":      sec.OIDC.Provider,
+				"jwks_file":     sec.OIDC.JWKSFile,
+				"jwks_url":      sec.OIDC.JWKSURL,
+				"jwks_refresh":  sec.OIDC.JWKSRefresh.String(),
+				"clock_skew":    sec.OIDC.ClockSkew.String(),
+				"jit_provision": sec.OIDC.JITProvision,
+			},
+			"metrics": map[string]any{
+				"enabled": sec.Metrics.Enabled,
+				"token":   redactSecret(sec.Metrics.Token),
+			},
+			"quotas": map[string]any{
+				"enabled":              sec.Quotas.Enabled,
+				"cache_ttl":            sec.Quotas.CacheTTL.String(),
+				"usage_flush_interval": sec.Quotas.FlushInterval.String(),
+			},
+		},
+		"jobs": map[string]any{
+			"maintenance":      opts.maintenance,
+			"cleanup_interval": opts.jobs.CleanupInterval.String(),
+			"refresh_interval": opts.jobs.RefreshInterval.String(),
+			"job_timeout":      opts.jobs.JobTimeout.String(),
+			"run_retention":    opts.jobs.RunRetention.String(),
+			"outbox_retention": opts.jobs.OutboxRetention.String(),
+		},
+		"admin": map[string]any{
+			"host":   opts.admin.Host,
+			"port":   opts.admin.Port,
+			"tokens": len(opts.admin.Tokens),
+			"pprof":  opts.admin.Pprof,
+		},
+		"outbox": map[string]any{
+			"enabled":          opts.outbox.Enabled,
+			"webhook_url":      redactWebhookURL(opts.outbox.WebhookURL),
+			"webhook_secret":   redactSecret(opts.outbox.WebhookSecret),
+			"batch_size":       opts.outbox.Dispatch.BatchSize,
+			"poll_interval":    opts.outbox.Dispatch.PollInterval.String(),
+			"lease":            opts.outbox.Dispatch.Lease.String(),
+			"delivery_timeout": opts.outbox.Dispatch.DeliveryTimeout.String(),
+			"max_attempts":     opts.outbox.Dispatch.MaxAttempts,
+			"base_backoff":     opts.outbox.Dispatch.BaseBackoff.String(),
+			"max_backoff":      opts.outbox.Dispatch.MaxBackoff.String(),
+		},
+	}
+}
+
+func redactSecret(v string) string {
+	if v == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// redactDatabaseURL masks the password of a URL-form DATABASE_URL, including
+// password query parameters. Anything that does not parse as a URL, such as a
+// keyword/value DSN, is withheld entirely.
+func redactDatabaseURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return redactedValue
+	}
+	q := u.Query()
+	for _, k := range []string{"password", "sslpassword"} {
+		if q.Has(k) {
+			q.Set(k, redactedValue)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.Redacted()
+}
+
+// redactWebhookURL keeps only the scheme and host of a webhook URL; its
+// userinfo, path and query often carry a token.
+func redactWebhookURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return redactedValue
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+const (
+	scopeDecisionsWrite = "decisions:write"
+	scopeTelemetryWrite = "telemetry:write"
+	scopePoliciesAdmin  = "policies:admin"
+	scopeWildcard       = "*"
+)
+
+var (
+	errMissingCredentials = errors.New("missing bearer token or x-api-key")
+	errInvalidCredentials = errors.New("invalid api token")
+	errInsufficientScope  = errors.New("credential lacks required scope")
+	errTenantMismatch     = errors.New("tenant_id does not match credential tenant")
+	errWorkspaceMismatch  = errors.New("workspace_id does not belong to credential tenant")
+	errInvalidTenantRef   = errors.New("tenant_id and workspace_id must be UUIDs")
+	errRevokedCredentials = errors.New("credential or session revoked")
+	errAuthUnavailable    = errors.New("authentication temporarily unavailable")
+	errRateLimited        = errors.New("rate limit exceeded")
+	errClientBlocked      = errors.New("client temporarily blocked")
+)
+
+// credentialStore is the control-plane subset needed to identify API callers.
+type credentialStore interface {
+	LookupAPICredential(ctx context.Context, keyHash string) (controlplanerepo.APICredential, bool, error)
+	WorkspaceInTenant(ctx context.Context, tenantID, workspaceID string) (bool, error)
+}
+
+// callerIdentity is the authenticated principal behind a request.
+//
+// Static env tokens and unauthenticated mode produce an unbound caller with
+// the wildcard scope; database credentials and mTLS principals carry their
+// configured tenant and scopes.
+type callerIdentity struct {
+	CredentialID string
+	TenantID     *string
+	WorkspaceID  *string
+	Scopes       map[string]struct{}
+	SigningKeyID string
+}
+
+func unboundCaller(credentialID string) callerIdentity {
+	return callerIdentity{
+		CredentialID: credentialID,
+		Scopes:       map[string]struct{}{scopeWildcard: {}},
+	}
+}
+
+func callerFromCredential(cred controlplanerepo.APICredential) callerIdentity {
+	tenantID := cred.TenantID
+	caller := callerIdentity{
+		CredentialID: cred.ID,
+		TenantID:     &tenantID,
+		WorkspaceID:  cred.WorkspaceID,
+		Scopes:       make(map[string]struct{}, len(cred.Scopes)),
+	}
+	for _, s := range cred.Scopes {
+		caller.Scopes[s] = struct{}{}
+	}
+	return caller
+}
+
+func (c callerIdentity) hasScope(scope string) bool {
+	if _, ok := c.Scopes[scopeWildcard]; ok {
+		return true
+	}
+	_, ok := c.Scopes[scope]
+	return ok
+}
+
+// stampContext records the caller's credential (and signing key) in audit JSON.
+func (c callerIdentity) stampContext(m map[string]interface{}) {
+	m["credential_id"] = c.CredentialID
+	if c.SigningKeyID != "" {
+		m["signing_key_id"] = c.SigningKeyID
+	}
+}
+
+// bindTenant pins tenant/workspace ids from the body to the caller's tenant.
+//
+// Missing ids are filled from the credential. The returned flag is true when a
+// body-provided workspace still needs to be verified against the tenant.
+func (c callerIdentity) bindTenant(tenantID, workspaceID *string) (*string, *string, bool, error) {
+	for _, id := range []*string{tenantID, workspaceID} {
+		if v := trimmedPtr(id); v != "" && !uuidPattern.MatchString(v) {
+			return nil, nil, false, errInvalidTenantRef
+		}
+	}
+	if c.TenantID == nil {
+		return tenantID, workspaceID, false, nil
+	}
+	if v := trimmedPtr(tenantID); v != "" && !strings.EqualFold(v, *c.TenantID) {
+		return nil, nil, false, errTenantMismatch
+	}
+	boundTenant := *c.TenantID
+	if c.WorkspaceID != nil {
+		if v := trimmedPtr(workspaceID); v != "" && !strings.EqualFold(v, *c.WorkspaceID) {
+			return nil, nil, false, errWorkspaceMismatch
+		}
+		boundWorkspace := *c.WorkspaceID
+		return &boundTenant, &boundWorkspace, false, nil
+	}
+	if v := trimmedPtr(workspaceID); v != "" {
+		return &boundTenant, &v, true, nil
+	}
+	return &boundTenant, nil, false, nil
+}
+
+// authenticate resolves the caller and rejects revoked credentials/sessions.
+func (a *httpAPI) authenticate(r *http.Request) (callerIdentity, error) {
+	if !a.securityCfg.RequireAuth {
+		return unboundCaller("anonymous"), nil
+	}
+	caller, err := a.identifyCaller(r)
+	if err != nil {
+		return callerIdentity{}, err
+	}
+	if err := a.checkRevoked(r, caller); err != nil {
+		return callerIdentity{}, err
+	}
+	return caller, nil
+}
+
+// identifyCaller resolves the caller from a mapped mTLS client certificate,
+// static tokens, OIDC issuer tokens, then database credentials.
+func (a *httpAPI) identifyCaller(r *http.Request) (callerIdentity, error) {
+	if principal, ok := mtlsPrincipal(r, a.securityCfg.MTLSPrincipals); ok {
+		return principal.caller(), nil
+	}
+	token := extractAuthToken(r)
+	if token == "" {
+		return callerIdentity{}, errMissingCredentials
+	}
+	tokenHash := dbpkg.SHA256Hex([]byte(token))
+	if _, ok := a.liveSecurity().AllowedTokens[token]; ok {
+		return unboundCaller("static:" + tokenHash[:12]), nil
+	}
+	if a.oidc != nil && securitypkg.LooksLikeJWT(token) {
+		return a.federatedCaller(r, token)
+	}
+	if !a.securityCfg.DBCredentials || a.credentials == nil {
+		return callerIdentity{}, errInvalidCredentials
+	}
+	cred, found, err := a.credentials.LookupAPICredential(r.Context(), tokenHash)
+	if err != nil || !found {
+		return callerIdentity{}, errInvalidCredentials
+	}
+	return callerFromCredential(cred), nil
+}
+
+// checkRevoked consults the RevocationStore for the credential and its X-Session-ID.
+func (a *httpAPI) checkRevoked(r *http.Request, caller callerIdentity) error {
+	if a.revocations == nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	revoked, err := a.revocations.IsTokenRevoked(caller.CredentialID, now)
+	if err != nil {
+		return errAuthUnavailable
+	}
+	if revoked {
+		return errRevokedCredentials
+	}
+	revoked, err = a.revocations.IsSessionRevoked(sessionRevocationKey(caller.CredentialID, strings.TrimSpace(r.Header.Get("X-Session-ID"))), now)
+	if err != nil {
+		return errAuthUnavailable
+	}
+	if revoked {
+		return errRevokedCredentials
+	}
+	return nil
+}
+
+// tokenFingerprint identifies a presented token in abuse counters without storing it.
+func tokenFingerprint(r *http.Request) string {
+	token := extractAuthToken(r)
+	if token == "" {
+		return ""
+	}
+	return dbpkg.SHA256Hex([]byte(token))[:12]
+}
+
+// bindCallerTenant applies bindTenant and verifies body-provided workspaces.
+func (a *httpAPI) bindCallerTenant(ctx context.Context, caller callerIdentity, tenantID, workspaceID *string) (*string, *string, error) {
+	boundTenant, boundWorkspace, verify, err := caller.bindTenant(tenantID, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if verify {
+		if a.credentials == nil {
+			return nil, nil, errWorkspaceMismatch
+		}
+		ok, err := a.credentials.WorkspaceInTenant(ctx, *boundTenant, *boundWorkspace)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, errWorkspaceMismatch
+		}
+	}
+	return boundTenant, boundWorkspace, nil
+}
+
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidTenantRef):
+		return http.StatusBadRequest
+	case errors.Is(err, errRateLimited), errors.Is(err, errClientBlocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, errAuthUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, errInsufficientScope), errors.Is(err, errTenantMismatch), errors.Is(err, errWorkspaceMismatch),
+		errors.Is(err, errNoTenantMembership):
+		return http.StatusForbidden
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+func trimmedPtr(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return strings.TrimSpace(*v)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+)
+
+type fakeCredentialStore struct {
+	creds      map[string]controlplanerepo.APICredential
+	workspaces map[string]string
+}
+
+func (f fakeCredentialStore) LookupAPICredential(_ context.Context, keyHash string) (controlplanerepo.APICredential, bool, error) {
+	cred, ok := f.creds[keyHash]
+	return cred, ok, nil
+}
+
+func (f fakeCredentialStore) WorkspaceInTenant(_ context.Context, tenantID, workspaceID string) (bool, error) {
+	return f.workspaces[workspaceID] == tenantID, nil
+}
+
+func TestAuthenticateDatabaseCredentialScopes(t *testing.T) {
+	api := &httpAPI{
+		securityCfg: serveSecurityConfig{RequireAuth: true, DBCredentials: true},
+		credentials: fakeCredentialStore{creds: map[string]controlplanerepo.APICredential{
+			dbpkg.SHA256Hex([]byte("tenant-token")): {
+				ID:       "cred-1",
+				TenantID: "tenant-a",
+				Scopes:   []string{scopeTelemetryWrite},
+			},
+		}},
+	}
+	req := httptest.NewRequest("POST", "/v1/decisions", nil)
+	req.Header.Set("Authorization", "Bearer tenant-token")
+	caller, err := api.authenticate(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caller.CredentialID != "cred-1" {
+		t.Fatalf("expected cred-1, got %q", caller.CredentialID)
+	}
+	if !caller.hasScope(scopeTelemetryWrite) || caller.hasScope(scopeDecisionsWrite) {
+		t.Fatalf("unexpected scope set: %v", caller.Scopes)
+	}
+
+	req.Header.Set("Authorization", "Bearer unknown-token")
+	if _, err := api.authenticate(req); !errors.Is(err, errInvalidCredentials) {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+}
+
+func TestBindTenantRejectsCrossTenantWrites(t *testing.T) {
+	const tenantA, tenantB = "0b6f3c52-1d2e-4f7a-9c1b-2a3d4e5f6a01", "0b6f3c52-1d2e-4f7a-9c1b-2a3d4e5f6a02"
+	caller := callerFromCredential(controlplanerepo.APICredential{ID: "cred-1", TenantID: tenantA})
+	other := tenantB
+	if _, _, _, err := caller.bindTenant(&other, nil); !errors.Is(err, errTenantMismatch) {
+		t.Fatalf("expected tenant mismatch, got %v", err)
+	}
+
+	tenant, workspace, verify, err := caller.bindTenant(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tenant == nil || *tenant != tenantA || workspace != nil || verify {
+		t.Fatalf("expected tenant filled from credential, got %v %v %t", tenant, workspace, verify)
+	}
+}
+
+func TestBindTenantRejectsMalformedIDs(t *testing.T) {
+	malformed := "tenant-b"
+	for _, caller := range []callerIdentity{unboundCaller("static:abc"), callerFromCredential(controlplanerepo.APICredential{ID: "cred-1", TenantID: "0b6f3c52-1d2e-4f7a-9c1b-2a3d4e5f6a01"})} {
+		if _, _, _, err := caller.bindTenant(&malformed, nil); !errors.Is(err, errInvalidTenantRef) {
+			t.Fatalf("expected a malformed tenant id to be refused, got %v", err)
+		}
+		if _, _, _, err := caller.bindTenant(nil, &malformed); !errors.Is(err, errInvalidTenantRef) {
+			t.Fatalf("expected a malformed workspace id to be refused, got %v", err)
+		}
+	}
+	if cerr := tenantBindingCallError(errInvalidTenantRef); cerr.problem.Status != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", cerr.problem.Status)
+	}
+}
+
+func TestBindCallerTenantVerifiesWorkspace(t *testing.T) {
+	const tenantA, tenantB = "0b6f3c52-1d2e-4f7a-9c1b-2a3d4e5f6a01", "0b6f3c52-1d2e-4f7a-9c1b-2a3d4e5f6a02"
+	const wsA, wsB = "7c1d2e3f-4a5b-4c6d-8e9f-0a1b2c3d4e01", "7c1d2e3f-4a5b-4c6d-8e9f-0a1b2c3d4e02"
+	api := &httpAPI{credentials: fakeCredentialStore{workspaces: map[string]string{wsA: tenantA, wsB: tenantB}}}
+	caller := callerFromCredential(controlplanerepo.APICredential{ID: "cred-1", TenantID: tenantA})
+
+	ws := wsA
+	if _, _, err := api.bindCallerTenant(context.Background(), caller, nil, &ws); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ws = wsB
+	if _, _, err := api.bindCallerTenant(context.Background(), caller, nil, &ws); !errors.Is(err, errWorkspaceMismatch) {
+		t.Fatalf("expected workspace mismatch, got %v", err)
+	}
+}
+
+func TestUnboundCallerHasAllScopes(t *testing.T) {
+	caller := unboundCaller("static:abc")
+	if !caller.hasScope(scopePoliciesAdmin) {
+		t.Fatalf("expected wildcard scope for static token caller")
+	}
+	tenant := "0b6f3c52-1d2e-4f7a-9c1b-2a3d4e5f6a09"
+	got, _, _, err := caller.bindTenant(&tenant, nil)
+	if err != nil || got == nil || *got != tenant {
+		t.Fatalf("expected unbound caller to pass tenant through, got %v %v", got, err)
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	authzrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/authz"
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+	telemetryrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/telemetry"
+)
+
+// The API operations below are shared by the HTTP handlers and the gRPC
+// service: each transport authenticates the caller and extracts its inputs,
+// then calls in here, so both get the same validation, tenant binding, quota
+// and idempotency behavior.
+
+// writeCall carries one write's transport inputs. claim is the idempotency
+// key the transport claimed for the write; the response is stored under it
+// in the write's transaction. The transport releases it if the write fails.
+type writeCall struct {
+	caller    callerIdentity
+	requestID string
+	claim     *idempotency.Claim
+}
+
+// writeResult is the JSON response of a write.
+type writeResult struct {
+	status int
+	body   []byte
+}
+
+// recordDecision runs POST /v1/decisions for a JSON body.
+func (a *httpAPI) recordDecision(ctx context.Context, call writeCall, body []byte) (writeResult, *callError) {
+	const scope = "v1/decisions"
+	if cerr := checkRequest(operationRequest(http.MethodPost, "/v1/decisions", nil), body); cerr != nil {
+		return writeResult{}, cerr
+	}
+	var req runtimeapi.DecisionWriteRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return writeResult{}, newCallError(http.StatusBadRequest, runtimeapi.CodeInvalidJSON, "invalid JSON payload")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	var err error
+	req.TenantID, req.WorkspaceID, err = a.bindCallerTenant(ctx, call.caller, req.TenantID, req.WorkspaceID)
+	if err != nil {
+		return writeResult{}, tenantBindingCallError(err)
+	}
+	charged, err := a.consumeEventQuota(ctx, req.TenantID, 1)
+	if err != nil {
+		a.metrics.quotaRejected(scope, "daily_events")
+		return writeResult{}, quotaCallError(time.Now())
+	}
+
+	decisionCtx := map[string]interface{}{"request_id": call.requestID}
+	for k, v := range req.DecisionContext {
+		decisionCtx[k] = v
+	}
+	call.caller.stampContext(decisionCtx)
+	stampTraceID(ctx, decisionCtx)
+	eventCtx := map[string]interface{}{"request_id": call.requestID}
+	for k, v := range req.Event {
+		eventCtx[k] = v
+	}
+	call.caller.stampContext(eventCtx)
+	stampTraceID(ctx, eventCtx)
+
+	traceHash := dbpkg.SHA256Hex(body)
+	decisionRecord := authzrepo.DecisionRecord{
+		TenantID:      req.TenantID,
+		WorkspaceID:   req.WorkspaceID,
+		Subject:       req.Subject,
+		SessionID:     req.SessionID,
+		PolicySetID:   req.PolicySetID,
+		PolicySetKey:  req.PolicySetKey,
+		Tier:          req.Tier,
+		Action:        req.Action,
+		ResourceRef:   req.ResourceRef,
+		Allow:         req.Allow,
+		ReasonCode:    req.ReasonCode,
+		MatchedRuleID: req.MatchedRuleID,
+		TraceHash:     &traceHash,
+		ContextJSON:   mustMarshalJSON(decisionCtx),
+	}
+	trace := make([]authzrepo.TraceStep, 0, len(req.Trace))
+	for _, s := range req.Trace {
+		trace = append(trace, authzrepo.TraceStep{
+			StepOrder: s.StepOrder,
+			RuleID:    s.RuleID,
+			Matched:   s.Matched,
+			Outcome:   s.Outcome,
+			Reason:    s.Reason,
+		})
+	}
+	eventRecord := telemetryrepo.SecurityEventRecord{
+		TenantID:    req.TenantID,
+		WorkspaceID: req.WorkspaceID,
+		ActorType:   req.ActorType,
+		ActorID:     req.ActorID,
+		EventType:   req.EventType,
+		Severity:    req.Severity,
+		Message:     req.Message,
+		TraceHash:   &traceHash,
+		EventJSON:   mustMarshalJSON(eventCtx),
+	}
+
+	var resp []byte
+	_, _, err = a.rt.RecordDecisionAndEvent(ctx, decisionRecord, trace, eventRecord,
+		call.respond(http.StatusAccepted, func(ids platform.WriteIDs) []byte {
+			resp = mustMarshalJSON(runtimeapi.DecisionWriteResponse{
+				RequestID:  call.requestID,
+				DecisionID: ids.DecisionID,
+				EventID:    ids.EventID,
+				Status:     "accepted",
+			})
+			return resp
+		}))
+	if err != nil {
+		a.refundEventQuota(ctx, req.TenantID, charged)
+		return writeResult{}, a.writeFailed(ctx, scope, err, "failed to persist decision/event")
+	}
+	a.recordUsage(req.TenantID, controlplanerepo.UsageMetricDecisions, 1)
+	return writeResult{status: http.StatusAccepted, body: resp}, nil
+}
+
+// recordTelemetryEvent runs POST /v1/telemetry/events for a JSON body.
+func (a *httpAPI) recordTelemetryEvent(ctx context.Context, call writeCall, body []byte) (writeResult, *callError) {
+	const scope = "v1/telemetry/events"
+	req, cerr := decodeTelemetryEvent(body)
+	if cerr != nil {
+		return writeResult{}, cerr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	var err error
+	req.TenantID, req.WorkspaceID, err = a.bindCallerTenant(ctx, call.caller, req.TenantID, req.WorkspaceID)
+	if err != nil {
+		return writeResult{}, tenantBindingCallError(err)
+	}
+	charged, err := a.consumeEventQuota(ctx, req.TenantID, 1)
+	if err != nil {
+		a.metrics.quotaRejected(scope, "daily_events")
+		return writeResult{}, quotaCallError(time.Now())
+	}
+
+	ev := telemetryBatchEvent(ctx, call, req)
+	var resp []byte
+	_, err = a.rt.RecordSecurityEvent(ctx, ev.Record, ev.Links,
+		call.respond(http.StatusAccepted, func(ids platform.WriteIDs) []byte {
+			resp = mustMarshalJSON(runtimeapi.TelemetryWriteResponse{
+				RequestID: call.requestID,
+				EventID:   ids.EventID,
+				Status:    "accepted",
+			})
+			return resp
+		}))
+	if err != nil {
+		a.refundEventQuota(ctx, req.TenantID, charged)
+		return writeResult{}, a.writeFailed(ctx, scope, err, "failed to persist telemetry event")
+	}
+	return writeResult{status: http.StatusAccepted, body: resp}, nil
+}
+
+// decodeTelemetryEvent validates and decodes one telemetry event body.
+func decodeTelemetryEvent(body []byte) (runtimeapi.TelemetryWriteRequest, *callError) {
+	if cerr := checkRequest(operationRequest(http.MethodPost, "/v1/telemetry/events", nil), body); cerr != nil {
+		return runtimeapi.TelemetryWriteRequest{}, cerr
+	}
+	var req runtimeapi.TelemetryWriteRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return runtimeapi.TelemetryWriteRequest{}, newCallError(http.StatusBadRequest, runtimeapi.CodeInvalidJSON, "invalid JSON payload")
+	}
+	return req, nil
+}
+
+// telemetryBatchEvent builds the rows of a tenant-bound event, stamping its
+// context with the request id, caller and trace.
+func telemetryBatchEvent(ctx context.Context, call writeCall, req runtimeapi.TelemetryWriteRequest) telemetryrepo.BatchEvent {
+	eventCtx := map[string]interface{}{"request_id": call.requestID}
+	for k, v := range req.Event {
+		eventCtx[k] = v
+	}
+	call.caller.stampContext(eventCtx)
+	stampTraceID(ctx, eventCtx)
+	ev := telemetryrepo.BatchEvent{
+		Record: telemetryrepo.SecurityEventRecord{
+			TenantID:    req.TenantID,
+			WorkspaceID: req.WorkspaceID,
+			ActorType:   req.ActorType,
+			ActorID:     req.ActorID,
+			EventType:   req.EventType,
+			Severity:    req.Severity,
+			Message:     req.Message,
+			TraceHash:   req.TraceHash,
+			EventJSON:   mustMarshalJSON(eventCtx),
+		},
+		Links: make([]telemetryrepo.EventLink, 0, len(req.Links)),
+	}
+	for _, link := range req.Links {
+		ev.Links = append(ev.Links, telemetryrepo.EventLink{
+			LinkKind:     link.LinkKind,
+			LinkedID:     link.LinkedID,
+			MetadataJSON: mustMarshalJSON(link.Metadata),
+		})
+	}
+	return ev
+}
+
+// respond returns the write hook that stores the response under the call's
+// claim, so the write and its cached response commit together. body builds
+// the response from the ids of the written rows.
+func (c writeCall) respond(status int, body func(platform.WriteIDs) []byte) platform.WriteHook {
+	return func(ctx context.Context, tx *sql.Tx, ids platform.WriteIDs) error {
+		resp := body(ids)
+		if c.claim == nil {
+			return nil
+		}
+		return c.claim.CompleteTx(ctx, tx, idempotency.Response{
+			Status: status,
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   resp,
+		})
+	}
+}
+
+// writeFailed maps the error of a write that rolled back. A lost lease means
+// a retry took the key over, so the caller is told the request is in progress.
+func (a *httpAPI) writeFailed(ctx context.Context, scope string, err error, action string) *callError {
+	if errors.Is(err, idempotency.ErrLeaseLost) {
+		a.metrics.idempotencyHit(scope, idempotency.OutcomeInProgress)
+		return idempotencyCallError(ctx, err)
+	}
+	return storeCallError(ctx, err, action)
+}
+
+// threatLevels runs GET /v1/security/threat-levels.
+func (a *httpAPI) threatLevels(ctx context.Context, caller callerIdentity, query url.Values) ([]runtimeapi.ThreatLevel, *callError) {
+	filter, cerr := securityReadFilter(caller, "/v1/security/threat-levels", query)
+	if cerr != nil {
+		return nil, cerr
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	history, err := a.rt.ThreatRepo.ListThreatLevelHistory(ctx, filter)
+	if err != nil {
+		return nil, newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to load threat level history")
+	}
+	items := make([]runtimeapi.ThreatLevel, 0, len(history))
+	for _, t := range history {
+		items = append(items, runtimeapi.ThreatLevel{
+			ID:              t.ID,
+			NodeName:        t.NodeName,
+			TenantID:        t.TenantID,
+			PreviousLevel:   t.PreviousLevel,
+			NewLevel:        t.NewLevel,
+			ReasonCode:      t.ReasonCode,
+			Reason:          t.Reason,
+			SecurityEventID: t.SecurityEventID,
+			Metadata:        json.RawMessage(t.MetadataJSON),
+			CreatedAt:       t.CreatedAt.UTC(),
+		})
+	}
+	return items, nil
+}
+
+// anomalyReports runs GET /v1/security/anomaly-reports.
+func (a *httpAPI) anomalyReports(ctx context.Context, caller callerIdentity, query url.Values) ([]runtimeapi.AnomalyReport, *callError) {
+	filter, cerr := securityReadFilter(caller, "/v1/security/anomaly-reports", query)
+	if cerr != nil {
+		return nil, cerr
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	reports, err := a.rt.ThreatRepo.ListAnomalyReports(ctx, filter)
+	if err != nil {
+		return nil, newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to load anomaly reports")
+	}
+	items := make([]runtimeapi.AnomalyReport, 0, len(reports))
+	for _, rep := range reports {
+		items = append(items, newAnomalyReportView(rep, nil))
+	}
+	return items, nil
+}
+
+// anomalyReport runs GET /v1/security/anomaly-reports/{id}.
+func (a *httpAPI) anomalyReport(ctx context.Context, caller callerIdentity, id string) (runtimeapi.AnomalyReport, *callError) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return runtimeapi.AnomalyReport{}, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "anomaly report id is required")
+	}
+	if cerr := checkRequest(operationRequest(http.MethodGet, "/v1/security/anomaly-reports/"+id, nil), nil); cerr != nil {
+		return runtimeapi.AnomalyReport{}, cerr
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	rep, links, found, err := a.rt.ThreatRepo.GetAnomalyReport(ctx, id)
+	if err != nil {
+		return runtimeapi.AnomalyReport{}, newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to load anomaly report")
+	}
+	// Reports of other tenants are indistinguishable from missing ones.
+	if !found || (caller.TenantID != nil && !strings.EqualFold(trimmedPtr(rep.TenantID), *caller.TenantID)) {
+		return runtimeapi.AnomalyReport{}, newCallError(http.StatusNotFound, runtimeapi.CodeNotFound, "anomaly report not found")
+	}
+	return newAnomalyReportView(rep, links), nil
+}
+
+// securityReadFilter validates a security read and builds a tenant-pinned filter.
+func securityReadFilter(caller callerIdentity, path string, query url.Values) (securitypkg.ThreatFilter, *callError) {
+	if cerr := checkRequest(operationRequest(http.MethodGet, path, query), nil); cerr != nil {
+		return securitypkg.ThreatFilter{}, cerr
+	}
+	filter := securitypkg.ThreatFilter{
+		NodeName: strings.TrimSpace(query.Get("node_name")),
+		Status:   strings.TrimSpace(query.Get("status")),
+	}
+	if v := strings.TrimSpace(query.Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return securitypkg.ThreatFilter{}, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "limit must be a positive integer")
+		}
+		filter.Limit = n
+	}
+	var tenantID *string
+	if v := strings.TrimSpace(query.Get("tenant_id")); v != "" {
+		tenantID = &v
+	}
+	boundTenant, _, _, err := caller.bindTenant(tenantID, nil)
+	if err != nil {
+		return securitypkg.ThreatFilter{}, authCallError(err)
+	}
+	filter.TenantID = boundTenant
+	return filter, nil
+}
+
+// usageReport runs GET /v1/usage.
+func (a *httpAPI) usageReport(ctx context.Context, caller callerIdentity, query url.Values) (runtimeapi.UsageReport, *callError) {
+	if cerr := checkRequest(operationRequest(http.MethodGet, "/v1/usage", query), nil); cerr != nil {
+		return runtimeapi.UsageReport{}, cerr
+	}
+	if a.quotas == nil {
+		return runtimeapi.UsageReport{}, newCallError(http.StatusServiceUnavailable, runtimeapi.CodeUnavailable, "quotas disabled")
+	}
+	var tenantID *string
+	if v := strings.TrimSpace(query.Get("tenant_id")); v != "" {
+		tenantID = &v
+	}
+	boundTenant, _, _, err := caller.bindTenant(tenantID, nil)
+	if err != nil {
+		return runtimeapi.UsageReport{}, authCallError(err)
+	}
+	if boundTenant == nil {
+		return runtimeapi.UsageReport{}, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "tenant_id is required")
+	}
+	from, to, err := parseUsageWindow(query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		return runtimeapi.UsageReport{}, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	a.flushUsage(ctx)
+	quota, found, err := a.quotas.ResolveTenantQuota(ctx, *boundTenant, controlplanerepo.QuotaEndpointDefault)
+	if err != nil {
+		return runtimeapi.UsageReport{}, newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to load tenant quota")
+	}
+	if !found {
+		return runtimeapi.UsageReport{}, newCallError(http.StatusNotFound, runtimeapi.CodeNotFound, "tenant not found")
+	}
+	counters, err := a.quotas.ListUsage(ctx, *boundTenant, from, to)
+	if err != nil {
+		return runtimeapi.UsageReport{}, newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to load usage counters")
+	}
+	resp := runtimeapi.UsageReport{
+		TenantID: *boundTenant,
+		Plan:     quota.Plan,
+		Quota:    runtimeapi.Quota(quota),
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Items:    make([]runtimeapi.UsageCounter, 0, len(counters)),
+	}
+	for _, c := range counters {
+		resp.Items = append(resp.Items, runtimeapi.UsageCounter(c))
+	}
+	return resp, nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+)
+
+// runtimeConfigFile is the optional serve config file named by --config or
+// RUNTIME_CONFIG_FILE, in YAML or JSON. Every field is optional: flags win
+// over env, env wins over the file, and the file wins over the defaults.
+// Durations are Go duration strings such as "90s" or "15m".
+type runtimeConfigFile struct {
+	Security securityFileConfig `yaml:"security"`
+	Database databaseFileConfig `yaml:"database"`
+	Jobs     jobsFileConfig     `yaml:"jobs"`
+	Admin    adminFileConfig    `yaml:"admin"`
+}
+
+// securityFileConfig mirrors serveSecurityConfig; see the RUNTIME_* variable
+// of the same name for each field. Lists replace the defaults outright.
+type securityFileConfig struct {
+	RequireAuth           *bool                              `yaml:"require_auth"`
+	APITokens             []string                           `yaml:"api_tokens"`
+	DBCredentials         *bool                              `yaml:"db_credentials"`
+	AllowedOrigins        []string                           `yaml:"allowed_origins"`
+	RateLimit             rateLimitFileConfig                `yaml:"rate_limit"`
+	TrustProxyHeaders     *bool                              `yaml:"trust_proxy_headers"`
+	RequireRequestSigning *bool                              `yaml:"require_request_signing"`
+	SignatureMaxSkew      *time.Duration                     `yaml:"signature_max_skew"`
+	MTLSPrincipals        map[string]mtlsPrincipalFileConfig `yaml:"mtls_principals"` // keyed by certificate CN
+	Abuse                 abuseFileConfig                    `yaml:"abuse"`
+	OIDC                  oidcFileConfig                     `yaml:"oidc"`
+	JWKSMaxAge            *time.Duration                     `yaml:"jwks_max_age"`
+	Metrics               metricsFileConfig                  `yaml:"metrics"`
+	Quotas                quotaFileConfig                    `yaml:"quotas"`
+}
+
+type rateLimitFileConfig struct {
+	PerMinute *int    `yaml:"per_minute"`
+	Burst     *int    `yaml:"burst"`
+	Key       *string `yaml:"key"`
+	Store     *string `yaml:"store"`
+	MaxKeys   *int    `yaml:"max_keys"`
+}
+
+type mtlsPrincipalFileConfig struct {
+	Principal string   `yaml:"principal"`
+	TenantID  string   `yaml:"tenant_id"`
+	Scopes    []string `yaml:"scopes"`
+}
+
+type abuseFileConfig struct {
+	Enabled             *bool          `yaml:"enabled"`
+	Window              *time.Duration `yaml:"window"`
+	IPThreshold         *int           `yaml:"ip_threshold"`
+	CredentialThreshold *int           `yaml:"credential_threshold"`
+	SessionThreshold    *int           `yaml:"session_threshold"`
+	RateLimitThreshold  *int           `yaml:"rate_limit_threshold"`
+	RevokeFor           *time.Duration `yaml:"revoke_for"`
+}
+
+type oidcFileConfig struct {
+	Issuer       *string        `yaml:"issuer"`
+	Audiences    []string       `yaml:"audiences"`
+	Provider     *string        `yaml:"provider"`
+	JWKSFile     *string        `yaml:"jwks_file"`
+	JWKSURL      *string        `yaml:"jwks_url"`
+	JWKSRefresh  *time.Duration `yaml:"jwks_refresh"`
+	ClockSkew    *time.Duration `yaml:"clock_skew"`
+	JITProvision *bool          `yaml:"jit_provision"`
+}
+
+type metricsFileConfig struct {
+	Enabled *bool   `yaml:"enabled"`
+	Token   *string `yaml:"token"`
+}
+
+type quotaFileConfig struct {
+	Enabled       *bool          `yaml:"enabled"`
+	CacheTTL      *time.Duration `yaml:"cache_ttl"`
+	FlushInterval *time.Duration `yaml:"usage_flush_interval"`
+}
+
+// databaseFileConfig holds pool tuning only; the connection string stays in
+// DATABASE_URL so credentials are not copied into config files.
+type databaseFileConfig struct {
+	ConnectTimeout   *time.Duration `yaml:"connect_timeout"`
+	MaxIdleConns     *int           `yaml:"max_idle_conns"`
+	MaxOpenConns     *int           `yaml:"max_open_conns"`
+	ConnMaxIdleTime  *time.Duration `yaml:"conn_max_idle_time"`
+	ConnMaxLifetime  *time.Duration `yaml:"conn_max_lifetime"`
+	StatementTimeout *time.Duration `yaml:"statement_timeout"`
+}
+
+// jobsFileConfig sets the --maintenance* flags the command line leaves unset.
+type jobsFileConfig struct {
+	Maintenance     *bool          `yaml:"maintenance"`
+	CleanupInterval *time.Duration `yaml:"cleanup_interval"`
+	RefreshInterval *time.Duration `yaml:"refresh_interval"`
+	JobTimeout      *time.Duration `yaml:"job_timeout"`
+	RunRetention    *time.Duration `yaml:"run_retention"`
+	OutboxRetention *time.Duration `yaml:"outbox_retention"`
+}
+
+// adminFileConfig configures the admin listener; see the RUNTIME_ADMIN_*
+// variable of the same name for each field.
+type adminFileConfig struct {
+	Host   *string  `yaml:"host"`
+	Port   *int     `yaml:"port"`
+	Tokens []string `yaml:"tokens"`
+	Pprof  *bool    `yaml:"pprof"`
+}
+
+// configFilePath returns the --config flag, or RUNTIME_CONFIG_FILE without it.
+func configFilePath(flagValue string) string {
+	if v := strings.TrimSpace(flagValue); v != "" {
+		return v
+	}
+	return strings.TrimSpace(os.Getenv("RUNTIME_CONFIG_FILE"))
+}
+
+// loadRuntimeConfigFile reads and strictly decodes the config file; unknown
+// keys are errors so a typo does not silently fall back to a default. An
+// empty path yields an empty file.
+func loadRuntimeConfigFile(path string) (runtimeConfigFile, error) {
+	var file runtimeConfigFile
+	if path == "" {
+		return file, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return runtimeConfigFile{}, fmt.Errorf("runtime: read config file: %w", err)
+	}
+	// JSON is a subset of YAML, so one decoder reads both.
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return runtimeConfigFile{}, fmt.Errorf("runtime: parse config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+func setIf[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+func (f securityFileConfig) apply(cfg *serveSecurityConfig) error {
+	setIf(&cfg.RequireAuth, f.RequireAuth)
+	if f.APITokens != nil {
+		cfg.AllowedTokens = stringSet(f.APITokens)
+	}
+	setIf(&cfg.DBCredentials, f.DBCredentials)
+	if f.AllowedOrigins != nil {
+		cfg.AllowedOrigins = stringSet(f.AllowedOrigins)
+	}
+	setIf(&cfg.RateLimitPerMinute, f.RateLimit.PerMinute)
+	setIf(&cfg.RateLimitBurst, f.RateLimit.Burst)
+	setIf(&cfg.RateLimitKey, f.RateLimit.Key)
+	setIf(&cfg.RateLimitStore, f.RateLimit.Store)
+	setIf(&cfg.RateLimitMaxKeys, f.RateLimit.MaxKeys)
+	setIf(&cfg.TrustProxyHeaders, f.TrustProxyHeaders)
+	setIf(&cfg.RequireRequestSigning, f.RequireRequestSigning)
+	setIf(&cfg.SignatureMaxSkew, f.SignatureMaxSkew)
+	if f.MTLSPrincipals != nil {
+		cfg.MTLSPrincipals = make(map[string]mtlsPrincipalConfig, len(f.MTLSPrincipals))
+		for subject, p := range f.MTLSPrincipals {
+			subject = strings.TrimSpace(subject)
+			principal := mtlsPrincipalConfig{
+				Principal: strings.TrimSpace(p.Principal),
+				TenantID:  strings.TrimSpace(p.TenantID),
+				Scopes:    []string{},
+			}
+			if subject == "" || principal.Principal == "" {
+				return fmt.Errorf("runtime: invalid security.mtls_principals entry %q", subject)
+			}
+			for _, scope := range p.Scopes {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					principal.Scopes = append(principal.Scopes, scope)
+				}
+			}
+			cfg.MTLSPrincipals[subject] = principal
+		}
+	}
+	setIf(&cfg.Abuse.Enabled, f.Abuse.Enabled)
+	setIf(&cfg.Abuse.Window, f.Abuse.Window)
+	setIf(&cfg.Abuse.IPThreshold, f.Abuse.IPThreshold)
+	setIf(&cfg.Abuse.CredentialThreshold, f.Abuse.CredentialThreshold)
+	setIf(&cfg.Abuse.SessionThreshold, f.Abuse.SessionThreshold)
+	setIf(&cfg.Abuse.RateLimitThreshold, f.Abuse.RateLimitThreshold)
+	setIf(&cfg.Abuse.RevokeFor, f.Abuse.RevokeFor)
+	setIf(&cfg.OIDC.Issuer, f.OIDC.Issuer)
+	if f.OIDC.Audiences != nil {
+		cfg.OIDC.Audiences = f.OIDC.Audiences
+	}
+	setIf(&cfg.OIDC.Provider, f.OIDC.Provider)
+	setIf(&cfg.OIDC.JWKSFile, f.OIDC.JWKSFile)
+	setIf(&cfg.OIDC.JWKSURL, f.OIDC.JWKSURL)
+	setIf(&cfg.OIDC.JWKSRefresh, f.OIDC.JWKSRefresh)
+	setIf(&cfg.OIDC.ClockSkew, f.OIDC.ClockSkew)
+	setIf(&cfg.OIDC.JITProvision, f.OIDC.JITProvision)
+	setIf(&cfg.JWKSMaxAge, f.JWKSMaxAge)
+	setIf(&cfg.Metrics.Enabled, f.Metrics.Enabled)
+	setIf(&cfg.Metrics.Token, f.Metrics.Token)
+	setIf(&cfg.Quotas.Enabled, f.Quotas.Enabled)
+	setIf(&cfg.Quotas.CacheTTL, f.Quotas.CacheTTL)
+	setIf(&cfg.Quotas.FlushInterval, f.Quotas.FlushInterval)
+	return nil
+}
+
+// loadDBConfig layers the DB_* environment over the file's database section
+// over the pool defaults.
+func loadDBConfig(file databaseFileConfig) (dbpkg.Config, error) {
+	cfg := dbpkg.Defaults()
+	setIf(&cfg.ConnectTimeout, file.ConnectTimeout)
+	setIf(&cfg.MaxIdleConns, file.MaxIdleConns)
+	setIf(&cfg.MaxOpenConns, file.MaxOpenConns)
+	setIf(&cfg.ConnMaxIdleTime, file.ConnMaxIdleTime)
+	setIf(&cfg.ConnMaxLifetime, file.ConnMaxLifetime)
+	if file.StatementTimeout != nil {
+		cfg.StatementTimeoutMS = int(file.StatementTimeout.Milliseconds())
+	}
+	return dbpkg.LoadEnv(cfg)
+}
+
+// applyFlags sets the job flags that were not given on the command line, so
+// flags keep precedence over the file.
+func (f jobsFileConfig) applyFlags(fs *flag.FlagSet) error {
+	given := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+	values := map[string]string{}
+	if f.Maintenance != nil {
+		values["maintenance"] = fmt.Sprint(*f.Maintenance)
+	}
+	for name, d := range map[string]*time.Duration{
+		"maintenance-cleanup-interval": f.CleanupInterval,
+		"maintenance-refresh-interval": f.RefreshInterval,
+		"maintenance-job-timeout":      f.JobTimeout,
+		"maintenance-run-retention":    f.RunRetention,
+		"maintenance-outbox-retention": f.OutboxRetention,
+	} {
+		if d != nil {
+			values[name] = d.String()
+		}
+	}
+	for name, v := range values {
+		if given[name] {
+			continue
+		}
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("runtime: invalid jobs setting for --%s: %w", name, err)
+		}
+	}
+	return nil
+}
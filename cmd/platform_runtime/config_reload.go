@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"reflect"
+)
+
+// reloadableSettings are the serveSecurityConfig fields a SIGHUP applies to
+// the running server; changes to any other field wait for a restart.
+var reloadableSettings = map[string]bool{
+	"AllowedTokens":      true,
+	"AllowedOrigins":     true,
+	"RateLimitPerMinute": true,
+	"RateLimitBurst":     true,
+}
+
+// liveSecurity returns the security settings in force, including any reload.
+func (a *httpAPI) liveSecurity() *serveSecurityConfig {
+	if cfg := a.live.Load(); cfg != nil {
+		return cfg
+	}
+	return &a.securityCfg
+}
+
+// reloadSecurity applies the reloadable fields of next: static tokens, CORS
+// origins and the global rate limit. In-flight requests and open connections
+// are untouched; the next request sees the new values. It returns the fields
+// that differ from the running config but need a restart.
+func (a *httpAPI) reloadSecurity(next serveSecurityConfig) ([]string, error) {
+	if a.rateLimiter != nil {
+		if err := a.rateLimiter.SetPolicy(rateLimitPolicy(next)); err != nil {
+			return nil, err
+		}
+	}
+	applied := *a.liveSecurity()
+	applied.AllowedTokens = next.AllowedTokens
+	applied.AllowedOrigins = next.AllowedOrigins
+	applied.RateLimitPerMinute = next.RateLimitPerMinute
+	applied.RateLimitBurst = next.RateLimitBurst
+	a.live.Store(&applied)
+	return changedSettings(applied, next), nil
+}
+
+// changedSettings names the top-level fields that differ between a and b.
+func changedSettings(a, b serveSecurityConfig) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var changed []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, va.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
+
+// reloadOnSignal reloads the config on every signal from hup until ctx ends.
+func reloadOnSignal(ctx context.Context, hup <-chan os.Signal, path string, api *httpAPI) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reloadConfig(path, api)
+		}
+	}
+}
+
+// reloadConfig re-reads the config file and env like startup does. An
+// invalid config is logged and the running one kept.
+func reloadConfig(path string, api *httpAPI) {
+	file, err := loadRuntimeConfigFile(path)
+	if err != nil {
+		slog.Error("config reload failed, keeping the running config", "error", err)
+		return
+	}
+	next, err := loadServeSecurityConfig(file.Security)
+	if err != nil {
+		slog.Error("config reload failed, keeping the running config", "error", err)
+		return
+	}
+	restart, err := api.reloadSecurity(next)
+	if err != nil {
+		slog.Error("config reload failed, keeping the running config", "error", err)
+		return
+	}
+	slog.Info(
+		"config reloaded",
+		"config_file", path,
+		"token_count", len(next.AllowedTokens),
+		"allowed_origins", len(next.AllowedOrigins),
+		"rate_limit_per_min", next.RateLimitPerMinute,
+		"rate_limit_burst", next.RateLimitBurst,
+	)
+	if len(restart) > 0 {
+		slog.Warn("config changes need a restart to take effect", "settings", restart)
+	}
+}
@@ -0,0 +1,569 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	runtimepb "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/runtimepb"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+)
+
+// grpcErrorDomain is the ErrorInfo domain of every gRPC error; the reason is
+// the problem code the HTTP API would have returned.
+const grpcErrorDomain = "vedic-platform"
+
+// grpcMaxMessageSize matches the HTTP request body limit.
+const grpcMaxMessageSize = 1 << 20
+
+// grpcAPI serves runtimepb.RuntimeService on top of the same operations as
+// the HTTP handlers (see calls.go).
+type grpcAPI struct {
+	runtimepb.UnimplementedRuntimeServiceServer
+	api       *httpAPI
+	tracer    *tracing.Tracer
+	logger    *slog.Logger
+	accessLog bool
+}
+
+// newGRPCServer builds the gRPC server; creds is nil for plaintext.
+func newGRPCServer(g *grpcAPI, creds credentials.TransportCredentials) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(grpcMaxMessageSize),
+		grpc.ChainUnaryInterceptor(g.unaryInterceptor),
+		grpc.ChainStreamInterceptor(g.streamInterceptor),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	srv := grpc.NewServer(opts...)
+	runtimepb.RegisterRuntimeServiceServer(srv, g)
+	return srv
+}
+
+func (g *grpcAPI) RecordDecision(ctx context.Context, in *runtimepb.RecordDecisionRequest) (*runtimepb.RecordDecisionResponse, error) {
+	caller, r, err := g.authorizeUnary(ctx, "v1/decisions", scopeDecisionsWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.verifySignature(r, in, &caller); err != nil {
+		return nil, err
+	}
+	call, cerr := grpcWriteCall(caller, in.GetRequestId())
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	res, replayed, cerr := g.write(ctx, "v1/decisions", "/v1/decisions", in.GetIdempotencyKey(), call,
+		mustMarshalJSON(decisionFromProto(in.GetDecision())), g.api.recordDecision)
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	if replayed {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(idempotency.HeaderReplayed), "true"))
+	}
+	var out runtimeapi.DecisionWriteResponse
+	if err := json.Unmarshal(res.body, &out); err != nil {
+		return nil, status.Error(codes.Internal, "failed to decode stored response")
+	}
+	return &runtimepb.RecordDecisionResponse{
+		RequestId:  out.RequestID,
+		DecisionId: out.DecisionID,
+		EventId:    out.EventID,
+		Status:     out.Status,
+		Replayed:   replayed,
+	}, nil
+}
+
+func (g *grpcAPI) RecordTelemetryEvent(ctx context.Context, in *runtimepb.RecordTelemetryEventRequest) (*runtimepb.RecordTelemetryEventResponse, error) {
+	caller, r, err := g.authorizeUnary(ctx, "v1/telemetry/events", scopeTelemetryWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.verifySignature(r, in, &caller); err != nil {
+		return nil, err
+	}
+	out, cerr := g.recordTelemetryEvent(ctx, caller, in)
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	if out.Replayed {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(idempotency.HeaderReplayed), "true"))
+	}
+	return out, nil
+}
+
+// StreamTelemetryEvents authenticates once per stream; each message is then
+// rate limited and recorded like a unary call. Stream metadata cannot carry a
+// signature per message, so signed streams are refused, as are all streams
+// when request signing is required. Only failed messages get a result entry,
+// so a large healthy stream answers with counts alone.
+func (g *grpcAPI) StreamTelemetryEvents(stream runtimepb.RuntimeService_StreamTelemetryEventsServer) error {
+	ctx := stream.Context()
+	method, _ := grpc.MethodFromServerStream(stream)
+	r := grpcRequest(ctx, method)
+	caller, err := g.api.authorize(r, scopeTelemetryWrite)
+	if err != nil {
+		return grpcCallError(authCallError(err))
+	}
+	if g.api.securityCfg.RequireRequestSigning {
+		g.api.metrics.authFailure(authFailureReason(errSignatureRequired))
+		return grpcCallError(newCallError(http.StatusUnauthorized, runtimeapi.CodeSignatureRequired,
+			"request signing is required and streamed events cannot be signed; send each event with RecordTelemetryEvent"))
+	}
+	if r.Header.Get(securitypkg.HeaderSignature) != "" {
+		return grpcCallError(newCallError(http.StatusBadRequest, runtimeapi.CodeInvalidSignature,
+			"streamed events cannot be signed; send signed events with RecordTelemetryEvent"))
+	}
+	clientIP := extractClientIP(r, g.api.securityCfg.TrustProxyHeaders)
+
+	summary := &runtimepb.StreamTelemetryEventsResponse{}
+	for index := int32(0); ; index++ {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(summary)
+		}
+		if err != nil {
+			return err
+		}
+		var cerr *callError
+		var out *runtimepb.RecordTelemetryEventResponse
+		if err := g.api.rateLimit(ctx, newGRPCHeaderWriter(), caller, clientIP, "v1/telemetry/events"); err != nil {
+			cerr = authCallError(err)
+		} else {
+			out, cerr = g.recordTelemetryEvent(ctx, caller, in)
+		}
+		switch {
+		case cerr != nil:
+			summary.Failed++
+			summary.Results = append(summary.Results, &runtimepb.StreamTelemetryEventResult{
+				Index:       index,
+				RequestId:   in.GetRequestId(),
+				ErrorCode:   cerr.problem.Code,
+				ErrorDetail: cerr.problem.Detail,
+			})
+		case out.Replayed:
+			summary.Replayed++
+		default:
+			summary.Accepted++
+		}
+	}
+}
+
+func (g *grpcAPI) recordTelemetryEvent(ctx context.Context, caller callerIdentity, in *runtimepb.RecordTelemetryEventRequest) (*runtimepb.RecordTelemetryEventResponse, *callError) {
+	call, cerr := grpcWriteCall(caller, in.GetRequestId())
+	if cerr != nil {
+		return nil, cerr
+	}
+	res, replayed, cerr := g.write(ctx, "v1/telemetry/events", "/v1/telemetry/events", in.GetIdempotencyKey(), call,
+		mustMarshalJSON(telemetryEventFromProto(in.GetEvent())), g.api.recordTelemetryEvent)
+	if cerr != nil {
+		return nil, cerr
+	}
+	var out runtimeapi.TelemetryWriteResponse
+	if err := json.Unmarshal(res.body, &out); err != nil {
+		return nil, newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to decode stored response")
+	}
+	return &runtimepb.RecordTelemetryEventResponse{
+		RequestId: out.RequestID,
+		EventId:   out.EventID,
+		Status:    out.Status,
+		Replayed:  replayed,
+	}, nil
+}
+
+func (g *grpcAPI) ListThreatLevels(ctx context.Context, in *runtimepb.ListThreatLevelsRequest) (*runtimepb.ListThreatLevelsResponse, error) {
+	caller, _, err := g.authorizeUnary(ctx, "v1/security/threat-levels", scopeSecurityRead)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	setQueryValue(query, "tenant_id", in.GetTenantId())
+	setQueryValue(query, "node_name", in.GetNodeName())
+	if in.GetLimit() != 0 {
+		query.Set("limit", strconv.Itoa(int(in.GetLimit())))
+	}
+	items, cerr := g.api.threatLevels(ctx, caller, query)
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	out := &runtimepb.ListThreatLevelsResponse{Items: make([]*runtimepb.ThreatLevel, 0, len(items))}
+	for _, t := range items {
+		out.Items = append(out.Items, threatLevelToProto(t))
+	}
+	return out, nil
+}
+
+func (g *grpcAPI) ListAnomalyReports(ctx context.Context, in *runtimepb.ListAnomalyReportsRequest) (*runtimepb.ListAnomalyReportsResponse, error) {
+	caller, _, err := g.authorizeUnary(ctx, "v1/security/anomaly-reports", scopeSecurityRead)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	setQueryValue(query, "tenant_id", in.GetTenantId())
+	setQueryValue(query, "node_name", in.GetNodeName())
+	setQueryValue(query, "status", in.GetStatus())
+	if in.GetLimit() != 0 {
+		query.Set("limit", strconv.Itoa(int(in.GetLimit())))
+	}
+	reports, cerr := g.api.anomalyReports(ctx, caller, query)
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	out := &runtimepb.ListAnomalyReportsResponse{Items: make([]*runtimepb.AnomalyReport, 0, len(reports))}
+	for _, rep := range reports {
+		out.Items = append(out.Items, anomalyReportToProto(rep))
+	}
+	return out, nil
+}
+
+func (g *grpcAPI) GetAnomalyReport(ctx context.Context, in *runtimepb.GetAnomalyReportRequest) (*runtimepb.AnomalyReport, error) {
+	caller, _, err := g.authorizeUnary(ctx, "v1/security/anomaly-reports", scopeSecurityRead)
+	if err != nil {
+		return nil, err
+	}
+	rep, cerr := g.api.anomalyReport(ctx, caller, in.GetId())
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	return anomalyReportToProto(rep), nil
+}
+
+func (g *grpcAPI) GetUsage(ctx context.Context, in *runtimepb.GetUsageRequest) (*runtimepb.UsageReport, error) {
+	caller, _, err := g.authorizeUnary(ctx, "v1/usage", scopeUsageRead)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	setQueryValue(query, "tenant_id", in.GetTenantId())
+	setQueryValue(query, "from", in.GetFrom())
+	setQueryValue(query, "to", in.GetTo())
+	report, cerr := g.api.usageReport(ctx, caller, query)
+	if cerr != nil {
+		return nil, grpcCallError(cerr)
+	}
+	return usageReportToProto(report), nil
+}
+
+// authorizeUnary authenticates and rate limits a unary call under the same
+// scope as its HTTP route. RateLimit headers are sent as response metadata.
+func (g *grpcAPI) authorizeUnary(ctx context.Context, scope, requiredScope string) (callerIdentity, *http.Request, error) {
+	method, _ := grpc.Method(ctx)
+	r := grpcRequest(ctx, method)
+	hw := newGRPCHeaderWriter()
+	caller, err := g.api.authorizeAndRateLimit(hw, r, scope, requiredScope)
+	if md := hw.metadata(); md.Len() > 0 {
+		_ = grpc.SetHeader(ctx, md)
+	}
+	if err != nil {
+		cerr := authCallError(err)
+		if secs, convErr := strconv.Atoi(hw.header.Get("Retry-After")); convErr == nil && secs > 0 {
+			cerr.retryAfter = time.Duration(secs) * time.Second
+		}
+		return callerIdentity{}, nil, grpcCallError(cerr)
+	}
+	return caller, r, nil
+}
+
+// verifySignature checks a unary request signature, which covers the
+// deterministic protobuf encoding of the request message.
+func (g *grpcAPI) verifySignature(r *http.Request, msg proto.Message, caller *callerIdentity) error {
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "failed to encode request")
+	}
+	if err := g.api.verifyRequestSignature(r, body, caller); err != nil {
+		if !errors.Is(err, errAuthUnavailable) {
+			g.api.recordAbuse(abuseKindAuthFailure, extractClientIP(r, g.api.securityCfg.TrustProxyHeaders), *caller, "", r.Header.Get("X-Session-ID"))
+		}
+		g.api.metrics.authFailure(authFailureReason(err))
+		return grpcCallError(authCallError(err))
+	}
+	return nil
+}
+
+// grpcWriteCall builds the write inputs of a gRPC message.
+func grpcWriteCall(caller callerIdentity, requestID string) (writeCall, *callError) {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		return writeCall{}, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "request_id is required")
+	}
+	return writeCall{caller: caller, requestID: requestID}, nil
+}
+
+// write claims the idempotency key of a write and runs it, releasing the key
+// if the write fails. body is the JSON form of the message, which is also
+// what the key's fingerprint covers, so a retry with a fresh request_id
+// still replays. replayed reports a response served from the store.
+func (g *grpcAPI) write(
+	ctx context.Context,
+	scope, path, key string,
+	call writeCall,
+	body []byte,
+	run func(context.Context, writeCall, []byte) (writeResult, *callError),
+) (res writeResult, replayed bool, cerr *callError) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return writeResult{}, false, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "idempotency_key is required")
+	}
+	claim, stored, cerr := g.api.claimWrite(ctx, scope, path, key, call.caller, body)
+	if cerr != nil {
+		return writeResult{}, false, cerr
+	}
+	if stored != nil {
+		return writeResult{status: stored.Status, body: stored.Body}, true, nil
+	}
+	call.claim = claim
+	res, cerr = run(ctx, call, body)
+	if cerr != nil {
+		g.api.releaseClaim(ctx, claim)
+		return writeResult{}, false, cerr
+	}
+	return res, false, nil
+}
+
+// grpcRequest presents a gRPC call to the HTTP auth path: metadata become
+// headers, the peer becomes RemoteAddr and its TLS state r.TLS, and the full
+// method name is the path a request signature covers.
+func grpcRequest(ctx context.Context, fullMethod string) *http.Request {
+	r := &http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: fullMethod},
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     http.Header{},
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		if strings.HasPrefix(k, ":") || strings.HasSuffix(k, "-bin") {
+			continue
+		}
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			r.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state := info.State
+			r.TLS = &state
+		}
+	}
+	return r.WithContext(ctx)
+}
+
+// grpcHeaderWriter collects the headers the rate limiter writes so they can
+// be sent as gRPC response metadata.
+type grpcHeaderWriter struct {
+	header http.Header
+}
+
+func newGRPCHeaderWriter() *grpcHeaderWriter {
+	return &grpcHeaderWriter{header: http.Header{}}
+}
+
+func (w *grpcHeaderWriter) Header() http.Header         { return w.header }
+func (w *grpcHeaderWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *grpcHeaderWriter) WriteHeader(int)             {}
+
+func (w *grpcHeaderWriter) metadata() metadata.MD {
+	md := metadata.MD{}
+	for k, vs := range w.header {
+		md.Append(strings.ToLower(k), vs...)
+	}
+	return md
+}
+
+// grpcCallError converts a failed operation to a status carrying the problem
+// code as ErrorInfo, field errors as BadRequest and Retry-After as RetryInfo.
+func grpcCallError(e *callError) error {
+	st := status.New(grpcCode(e.problem), e.problem.Detail)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: e.problem.Code, Domain: grpcErrorDomain}}
+	if len(e.problem.Fields) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, f := range e.problem.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		details = append(details, br)
+	}
+	if e.retryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.retryAfter)})
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func grpcCode(p runtimeapi.Problem) codes.Code {
+	switch p.Code {
+	case runtimeapi.CodeRequestInProgress:
+		return codes.Aborted
+	case runtimeapi.CodeConflict:
+		return codes.AlreadyExists
+	case runtimeapi.CodeIdempotencyKeyReused, runtimeapi.CodeReferenceNotFound:
+		return codes.FailedPrecondition
+	}
+	switch p.Status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// grpcServerFault reports the codes that count as server errors in spans
+// and logs, like 5xx statuses on the HTTP side.
+func grpcServerFault(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+func (g *grpcAPI) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	requestID := ""
+	if m, ok := req.(interface{ GetRequestId() string }); ok {
+		requestID = m.GetRequestId()
+	}
+	var resp any
+	err := g.observe(ctx, info.FullMethod, requestID, func(ctx context.Context) error {
+		var err error
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (g *grpcAPI) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return g.observe(ss.Context(), info.FullMethod, "", func(ctx context.Context) error {
+		return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	})
+}
+
+// observe does for one RPC what the HTTP middleware chain does for a
+// request: a server span continuing the caller's traceparent, a
+// request-scoped logger, the grpc_* metrics and one "grpc request" log line.
+func (g *grpcAPI) observe(ctx context.Context, method, requestID string, call func(context.Context) error) error {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+	reqLogger := g.logger
+	if requestID = loggableRequestID(requestID); requestID != "" {
+		reqLogger = reqLogger.With("request_id", requestID)
+	}
+	caller := &accessLogCaller{}
+	ctx = logging.NewContext(ctx, reqLogger)
+	ctx = context.WithValue(ctx, accessLogCallerKey{}, caller)
+	if tp := md.Get(tracing.TraceparentHeader); len(tp) > 0 {
+		if sc, err := tracing.ParseTraceparent(tp[0]); err == nil {
+			ctx = tracing.ContextWithRemoteParent(ctx, sc)
+		}
+	}
+	ctx, span := g.tracer.Start(ctx, method,
+		tracing.WithKind(tracing.SpanKindServer),
+		tracing.WithAttributes("rpc.system", "grpc", "rpc.method", method),
+	)
+	defer span.End()
+	traceID := span.SpanContext().TraceID.String()
+	ctx = logging.With(ctx, "trace_id", traceID)
+
+	err := call(ctx)
+	code := status.Code(err)
+	span.SetAttributes("rpc.grpc.status_code", int(code))
+	if grpcServerFault(code) {
+		span.SetStatus(tracing.StatusError, code.String())
+	}
+	g.api.metrics.observeGRPC(method, code.String(), time.Since(start))
+	if !g.accessLog {
+		return err
+	}
+	level := slog.LevelInfo
+	if grpcServerFault(code) {
+		level = slog.LevelError
+	}
+	clientIP := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		clientIP = extractClientIP(&http.Request{RemoteAddr: p.Addr.String(), Header: http.Header{}}, false)
+	}
+	caller.mu.Lock()
+	attrs := []any{
+		"method", method,
+		"code", code.String(),
+		"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		"client_ip", clientIP,
+		"credential_id", caller.credentialID,
+		"tenant_id", caller.tenantID,
+		"trace_id", traceID,
+	}
+	caller.mu.Unlock()
+	reqLogger.Log(ctx, level, "grpc request", attrs...)
+	return err
+}
+
+// contextServerStream carries the interceptor's context into a stream handler.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context { return s.ctx }
+
+func setQueryValue(v url.Values, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		v.Set(key, value)
+	}
+}
+
+// stopGRPCServer drains in-flight RPCs, cancelling whatever is still running
+// when ctx expires.
+func stopGRPCServer(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
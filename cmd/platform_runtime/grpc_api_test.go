@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	ratelimit "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/ratelimit"
+	runtimepb "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/runtimepb"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+)
+
+func newTestGRPCClient(t *testing.T, api *httpAPI) runtimepb.RuntimeServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := newGRPCServer(&grpcAPI{api: api, tracer: tracing.NewTracer(tracing.Config{}), logger: slog.New(slog.NewTextHandler(io.Discard, nil))}, nil)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return runtimepb.NewRuntimeServiceClient(conn)
+}
+
+func newTestGRPCRuntime(t *testing.T, burst int) *httpAPI {
+	t.Helper()
+	limiter, err := ratelimit.NewLimiter(ratelimit.Policy{Capacity: burst, RefillPerMinute: 1}, nil)
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	return &httpAPI{
+		rateLimiter: limiter,
+		metrics:     newRuntimeMetrics(nil),
+		securityCfg: serveSecurityConfig{RequireAuth: true, AllowedTokens: map[string]struct{}{"grpc-token": {}}},
+	}
+}
+
+func errorReason(t *testing.T, err error) (codes.Code, string, time.Duration) {
+	t.Helper()
+	st := status.Convert(err)
+	var reason string
+	var retry time.Duration
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() != grpcErrorDomain {
+				t.Fatalf("unexpected error domain %q", d.GetDomain())
+			}
+			reason = d.GetReason()
+		case *errdetails.RetryInfo:
+			retry = d.GetRetryDelay().AsDuration()
+		}
+	}
+	return st.Code(), reason, retry
+}
+
+func TestGRPCAuthAndRateLimitMatchHTTP(t *testing.T) {
+	api := newTestGRPCRuntime(t, 1)
+	client := newTestGRPCClient(t, api)
+
+	_, err := client.GetUsage(context.Background(), &runtimepb.GetUsageRequest{})
+	if code, reason, _ := errorReason(t, err); code != codes.Unauthenticated || reason != runtimeapi.CodeMissingCredentials {
+		t.Fatalf("expected unauthenticated missing_credentials, got %v %q", code, reason)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer grpc-token")
+	var header metadata.MD
+	_, err = client.GetUsage(ctx, &runtimepb.GetUsageRequest{}, grpc.Header(&header))
+	if code, reason, _ := errorReason(t, err); code != codes.Unavailable || reason != runtimeapi.CodeUnavailable {
+		t.Fatalf("expected the usage operation to run and report quotas disabled, got %v %q", code, reason)
+	}
+	if got := header.Get("ratelimit-remaining"); len(got) != 1 || got[0] != "0" {
+		t.Fatalf("expected RateLimit headers as metadata, got %v", header)
+	}
+
+	_, err = client.GetUsage(ctx, &runtimepb.GetUsageRequest{})
+	if code, reason, retry := errorReason(t, err); code != codes.ResourceExhausted || reason != runtimeapi.CodeRateLimited || retry <= 0 {
+		t.Fatalf("expected rate_limited with retry info, got %v %q %v", code, reason, retry)
+	}
+
+	rec := httptest.NewRecorder()
+	api.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	want := `grpc_requests_total{method="/vedic.platform.runtime.v1.RuntimeService/GetUsage",code="ResourceExhausted"} 1`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected %q in exposition:\n%s", want, rec.Body.String())
+	}
+}
+
+func TestGRPCWritesRequireIdentifiers(t *testing.T) {
+	client := newTestGRPCClient(t, newTestGRPCRuntime(t, 10))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "grpc-token")
+
+	_, err := client.RecordDecision(ctx, &runtimepb.RecordDecisionRequest{IdempotencyKey: "k1", Decision: &runtimepb.Decision{Subject: "user:1"}})
+	if code, reason, _ := errorReason(t, err); code != codes.InvalidArgument || reason != runtimeapi.CodeBadRequest {
+		t.Fatalf("expected invalid argument for missing request_id, got %v %q", code, reason)
+	}
+
+	stream, err := client.StreamTelemetryEvents(ctx)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	for _, key := range []string{"", ""} {
+		if err := stream.Send(&runtimepb.RecordTelemetryEventRequest{RequestId: "r1", IdempotencyKey: key}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	summary, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("close stream: %v", err)
+	}
+	if summary.GetFailed() != 2 || len(summary.GetResults()) != 2 || summary.GetResults()[1].GetIndex() != 1 ||
+		summary.GetResults()[0].GetErrorCode() != runtimeapi.CodeBadRequest {
+		t.Fatalf("expected per-message failures without ending the stream, got %+v", summary)
+	}
+}
+
+func TestGRPCStreamRefusesSigning(t *testing.T) {
+	api := newTestGRPCRuntime(t, 10)
+	client := newTestGRPCClient(t, api)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "grpc-token")
+
+	signed := metadata.AppendToOutgoingContext(ctx, "x-signature", "abcd")
+	stream, err := client.StreamTelemetryEvents(signed)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	_, err = stream.CloseAndRecv()
+	if code, reason, _ := errorReason(t, err); code != codes.InvalidArgument || reason != runtimeapi.CodeInvalidSignature {
+		t.Fatalf("expected a signed stream to be refused, got %v %q", code, reason)
+	}
+
+	api.securityCfg.RequireRequestSigning = true
+	stream, err = client.StreamTelemetryEvents(ctx)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	_, err = stream.CloseAndRecv()
+	if code, reason, _ := errorReason(t, err); code != codes.Unauthenticated || reason != runtimeapi.CodeSignatureRequired {
+		t.Fatalf("expected streams to be refused when signing is required, got %v %q", code, reason)
+	}
+}
+
+func TestDecisionFromProtoMatchesJSON(t *testing.T) {
+	tenant := "tenant-a"
+	ctxStruct, _ := structpb.NewStruct(map[string]interface{}{"ip": "10.0.0.1", "score": 0.5})
+	got := decisionFromProto(&runtimepb.Decision{
+		TenantId:        &tenant,
+		Subject:         "user:1",
+		Action:          "read",
+		Allow:           true,
+		Trace:           []*runtimepb.DecisionTraceStep{{StepOrder: 1, RuleId: "r1", Matched: true, Outcome: "allow"}},
+		DecisionContext: ctxStruct,
+	})
+	var want runtimeapi.DecisionWriteRequest
+	if err := json.Unmarshal([]byte(`{"tenant_id":"tenant-a","subject":"user:1","action":"read","allow":true,
+		"trace":[{"step_order":1,"rule_id":"r1","matched":true,"outcome":"allow"}],
+		"decision_context":{"ip":"10.0.0.1","score":0.5},"event":null}`), &want); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if string(mustMarshalJSON(got)) != string(mustMarshalJSON(want)) {
+		t.Fatalf("proto decision encodes as\n%s\nwant\n%s", mustMarshalJSON(got), mustMarshalJSON(want))
+	}
+}
+
+func TestGRPCCodeMapping(t *testing.T) {
+	cases := []struct {
+		err  *callError
+		want codes.Code
+	}{
+		{newCallError(422, runtimeapi.CodeValidationFailed, ""), codes.InvalidArgument},
+		{newCallError(422, runtimeapi.CodeReferenceNotFound, ""), codes.FailedPrecondition},
+		{newCallError(409, runtimeapi.CodeRequestInProgress, ""), codes.Aborted},
+		{newCallError(409, runtimeapi.CodeIdempotencyKeyReused, ""), codes.FailedPrecondition},
+		{newCallError(403, runtimeapi.CodeTenantMismatch, ""), codes.PermissionDenied},
+		{newCallError(429, runtimeapi.CodeQuotaExceeded, ""), codes.ResourceExhausted},
+		{newCallError(500, runtimeapi.CodeInternal, ""), codes.Internal},
+	}
+	for _, c := range cases {
+		if got := status.Code(grpcCallError(c.err)); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.err.problem.Code, got, c.want)
+		}
+	}
+}
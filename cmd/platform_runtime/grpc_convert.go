@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	runtimepb "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/runtimepb"
+)
+
+// Conversions between the gRPC messages and the JSON wire types. Requests are
+// converted to pkg/api and encoded, so the gRPC service validates exactly the
+// JSON the HTTP handlers would see.
+
+// decisionFromProto leaves a missing message empty, for validation to reject.
+func decisionFromProto(d *runtimepb.Decision) runtimeapi.DecisionWriteRequest {
+	if d == nil {
+		return runtimeapi.DecisionWriteRequest{}
+	}
+	req := runtimeapi.DecisionWriteRequest{
+		TenantID:        d.TenantId,
+		WorkspaceID:     d.WorkspaceId,
+		Subject:         d.GetSubject(),
+		SessionID:       d.SessionId,
+		PolicySetID:     d.PolicySetId,
+		PolicySetKey:    d.PolicySetKey,
+		Tier:            d.Tier,
+		Action:          d.GetAction(),
+		ResourceRef:     d.GetResourceRef(),
+		Allow:           d.GetAllow(),
+		ReasonCode:      d.GetReasonCode(),
+		MatchedRuleID:   d.MatchedRuleId,
+		DecisionContext: structMap(d.GetDecisionContext()),
+		ActorType:       d.GetActorType(),
+		ActorID:         d.ActorId,
+		EventType:       d.GetEventType(),
+		Severity:        d.GetSeverity(),
+		Message:         d.GetMessage(),
+		Event:           structMap(d.GetEvent()),
+	}
+	for _, s := range d.GetTrace() {
+		req.Trace = append(req.Trace, runtimeapi.DecisionTraceStep{
+			StepOrder: int(s.GetStepOrder()),
+			RuleID:    s.GetRuleId(),
+			Matched:   s.GetMatched(),
+			Outcome:   s.GetOutcome(),
+			Reason:    s.GetReason(),
+		})
+	}
+	return req
+}
+
+func telemetryEventFromProto(e *runtimepb.TelemetryEvent) runtimeapi.TelemetryWriteRequest {
+	if e == nil {
+		return runtimeapi.TelemetryWriteRequest{}
+	}
+	req := runtimeapi.TelemetryWriteRequest{
+		TenantID:    e.TenantId,
+		WorkspaceID: e.WorkspaceId,
+		ActorType:   e.GetActorType(),
+		ActorID:     e.ActorId,
+		EventType:   e.GetEventType(),
+		Severity:    e.GetSeverity(),
+		Message:     e.GetMessage(),
+		TraceHash:   e.TraceHash,
+		Event:       structMap(e.GetEvent()),
+	}
+	for _, link := range e.GetLinks() {
+		req.Links = append(req.Links, runtimeapi.TelemetryEventLink{
+			LinkKind: link.GetLinkKind(),
+			LinkedID: link.GetLinkedId(),
+			Metadata: structMap(link.GetMetadata()),
+		})
+	}
+	return req
+}
+
+func threatLevelToProto(t runtimeapi.ThreatLevel) *runtimepb.ThreatLevel {
+	return &runtimepb.ThreatLevel{
+		Id:              t.ID,
+		NodeName:        t.NodeName,
+		TenantId:        t.TenantID,
+		PreviousLevel:   t.PreviousLevel,
+		NewLevel:        t.NewLevel,
+		ReasonCode:      t.ReasonCode,
+		Reason:          t.Reason,
+		SecurityEventId: t.SecurityEventID,
+		Metadata:        rawStruct(t.Metadata),
+		CreatedAt:       timestamppb.New(t.CreatedAt),
+	}
+}
+
+func anomalyReportToProto(r runtimeapi.AnomalyReport) *runtimepb.AnomalyReport {
+	out := &runtimepb.AnomalyReport{
+		Id:          r.ID,
+		NodeName:    r.NodeName,
+		TenantId:    r.TenantID,
+		AnomalyType: r.AnomalyType,
+		Severity:    r.Severity,
+		Status:      r.Status,
+		Score:       r.Score,
+		Summary:     r.Summary,
+		Details:     rawStruct(r.Details),
+		DetectedAt:  timestamppb.New(r.DetectedAt),
+		ResolvedAt:  timestampPtr(r.ResolvedAt),
+	}
+	for _, l := range r.Links {
+		out.Links = append(out.Links, &runtimepb.AnomalyReportLink{
+			SecurityEventId: l.SecurityEventID,
+			EventType:       l.EventType,
+			Severity:        l.Severity,
+			DecisionId:      l.DecisionID,
+			LinkedAt:        timestamppb.New(l.LinkedAt),
+		})
+	}
+	return out
+}
+
+func usageReportToProto(r runtimeapi.UsageReport) *runtimepb.UsageReport {
+	out := &runtimepb.UsageReport{
+		TenantId: r.TenantID,
+		Plan:     r.Plan,
+		Quota: &runtimepb.Quota{
+			TenantId:          r.Quota.TenantID,
+			Plan:              r.Quota.Plan,
+			Endpoint:          r.Quota.Endpoint,
+			RequestsPerMinute: int32Ptr(r.Quota.RequestsPerMinute),
+			Burst:             int32Ptr(r.Quota.Burst),
+			DailyEventLimit:   r.Quota.DailyEventLimit,
+			MaxBatchDecisions: int32Ptr(r.Quota.MaxBatchDecisions),
+		},
+		From: r.From,
+		To:   r.To,
+	}
+	for _, c := range r.Items {
+		out.Items = append(out.Items, &runtimepb.UsageCounter{
+			UsageDate: timestamppb.New(c.UsageDate),
+			Metric:    c.Metric,
+			Count:     c.Count,
+			UpdatedAt: timestamppb.New(c.UpdatedAt),
+		})
+	}
+	return out
+}
+
+// structMap returns nil for an unset Struct, which encodes as JSON null.
+func structMap(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+// rawStruct converts a stored JSON object; anything else (null, arrays,
+// scalars) has no Struct form and is dropped.
+func rawStruct(raw json.RawMessage) *structpb.Struct {
+	var m map[string]interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &m) != nil || m == nil {
+		return nil
+	}
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func int32Ptr(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	n := int32(*v)
+	return &n
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
+)
+
+// handleReadyz passes once the database answers, its schema matches the
+// migrations embedded in the binary, pgcrypto is installed and a current
+// signing key exists, and fails without checking anything once the replica
+// is draining. The failing checks are named in the 503; their errors are
+// only logged, since the probe is unauthenticated.
+func (a *httpAPI) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if a.draining.Load() {
+		writeJSONError(w, http.StatusServiceUnavailable, "not-ready: draining")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), a.healthTimeout)
+	defer cancel()
+	var failing []string
+	for _, c := range a.rt.Readiness(ctx, a.migrations) {
+		if c.Status != platform.HealthOK {
+			failing = append(failing, c.Name)
+			logging.FromContext(ctx).Warn("readiness check failed", "check", c.Name, "error", c.Err)
+		}
+	}
+	if len(failing) > 0 {
+		writeJSONError(w, http.StatusServiceUnavailable, "not-ready: "+strings.Join(failing, ", "))
+		return
+	}
+	writeJSON(w, http.StatusOK, runtimeapi.Status{Status: "ready"})
+}
+
+// handleHealthDetails reports every dependency of this replica with its
+// status and check latency. It answers 200 whatever the status, so monitors
+// read the report rather than the response code.
+func (a *httpAPI) handleHealthDetails(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, err := a.authorizeAndRateLimit(w, r, "v1/admin/health", scopeOpsAdmin); err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	if !validateRequest(w, r, nil) {
+		return
+	}
+	writeJSON(w, http.StatusOK, a.healthReport(r.Context()))
+}
+
+// healthReport checks every component within the health timeout.
+func (a *httpAPI) healthReport(ctx context.Context) runtimeapi.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, a.healthTimeout)
+	defer cancel()
+	components := append(a.rt.Readiness(ctx, a.migrations), a.rt.PoolHealth(), platform.JobsHealth(a.scheduler))
+
+	resp := runtimeapi.HealthReport{
+		Status:     platform.WorstHealth(components),
+		NodeName:   a.nodeName,
+		CheckedAt:  time.Now().UTC(),
+		Components: make([]runtimeapi.ComponentHealth, 0, len(components)),
+	}
+	for _, c := range components {
+		resp.Components = append(resp.Components, componentHealthToAPI(c))
+	}
+	return resp
+}
+
+func componentHealthToAPI(c platform.ComponentHealth) runtimeapi.ComponentHealth {
+	out := runtimeapi.ComponentHealth{
+		Name:      c.Name,
+		Status:    c.Status,
+		LatencyMS: float64(c.Latency.Microseconds()) / 1000,
+		Detail:    c.Detail,
+	}
+	if c.Err != nil {
+		out.Error = c.Err.Error()
+	}
+	return out
+}
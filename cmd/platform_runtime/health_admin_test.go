@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+)
+
+func TestReadyzNamesFailingChecks(t *testing.T) {
+	api := &httpAPI{healthTimeout: time.Second}
+	rec := httptest.NewRecorder()
+	api.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var p runtimeapi.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 problem, got %d %q: %v", rec.Code, rec.Body, err)
+	}
+	if p.Detail != "not-ready: db, schema, extensions, keyring" {
+		t.Fatalf("expected the failing checks to be named, got %q", p.Detail)
+	}
+
+	api.draining.Store(true)
+	rec = httptest.NewRecorder()
+	api.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || rec.Code != http.StatusServiceUnavailable || p.Detail != "not-ready: draining" {
+		t.Fatalf("expected a draining replica to be not ready, got %d %q", rec.Code, rec.Body)
+	}
+}
+
+func TestHealthDetailsReportsComponents(t *testing.T) {
+	api := newTestGRPCRuntime(t, 10)
+	api.healthTimeout = time.Second
+	get := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/admin/health", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		api.handleHealthDetails(rec, r)
+		return rec
+	}
+	if rec := get(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without credentials, got %d", rec.Code)
+	}
+	rec := get("grpc-token")
+	var out runtimeapi.HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %q: %v", rec.Code, rec.Body, err)
+	}
+	var names []string
+	for _, c := range out.Components {
+		names = append(names, c.Name+"="+c.Status)
+	}
+	want := "db=down,schema=down,extensions=down,keyring=down,pool=down,jobs=ok"
+	if out.Status != "down" || strings.Join(names, ",") != want {
+		t.Fatalf("expected %s overall down, got %s %v", want, out.Status, names)
+	}
+	if out.Components[0].Error == "" {
+		t.Fatalf("expected component errors in the authenticated report")
+	}
+}
@@ -3,40 +3,101 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	authzrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/authz"
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
 	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
 	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
-	telemetryrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/telemetry"
+	ratelimit "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/ratelimit"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
 )
 
 type httpAPI struct {
-	rt             *platform.Runtime
-	healthTimeout  time.Duration
-	writeTimeout   time.Duration
-	idempotencyTTL time.Duration
-	securityCfg    serveSecurityConfig
-	rateLimiter    *requestRateLimiter
+	rt            *platform.Runtime
+	healthTimeout time.Duration
+	writeTimeout  time.Duration
+	// idempotencyKeys guards the write routes; nil refuses writes.
+	idempotencyKeys        idempotencyStore
+	idempotencyFingerprint idempotency.Fingerprint
+	securityCfg            serveSecurityConfig
+	live                   atomic.Pointer[serveSecurityConfig] // set by a config reload; read via liveSecurity
+	rateLimiter            *ratelimit.Limiter
+	credentials            credentialStore
+	signatures             signatureVerifier
+	revocations            securitypkg.RevocationStore
+	abuse                  *abuseDetector
+	threatMu               sync.Mutex     // serializes threat level transitions
+	abuseWork              sync.WaitGroup // breach responses and the threat decay loop
+	nodeName               string
+	scheduler              *platform.Scheduler
+	oidc                   *securitypkg.OIDCVerifier
+	federation             federationStore
+	quotas                 quotaStore
+	quotaCache             *quotaCache
+	usage                  *usageRecorder
+	metrics                *runtimeMetrics
+	// migrations are the embedded migrations readiness checks the schema against.
+	migrations []dbpkg.Migration
+	// draining is set on SIGTERM so /readyz fails while requests drain.
+	draining atomic.Bool
 }
 
 func newHTTPAPI(
 	rt *platform.Runtime,
-	healthTimeout, writeTimeout, idempotencyTTL time.Duration,
+	healthTimeout, writeTimeout time.Duration,
 	securityCfg serveSecurityConfig,
-) *httpAPI {
-	return &httpAPI{
-		rt:             rt,
-		healthTimeout:  healthTimeout,
-		writeTimeout:   writeTimeout,
-		idempotencyTTL: idempotencyTTL,
-		securityCfg:    securityCfg,
-		rateLimiter:    newRequestRateLimiter(securityCfg.RateLimitPerMinute),
+) (*httpAPI, error) {
+	api := &httpAPI{
+		rt:                     rt,
+		healthTimeout:          healthTimeout,
+		writeTimeout:           writeTimeout,
+		idempotencyFingerprint: idempotency.DefaultFingerprint,
+		securityCfg:            securityCfg,
+		abuse:                  newAbuseDetector(securityCfg.Abuse),
+		quotaCache:             newQuotaCache(securityCfg.Quotas.CacheTTL),
+		usage:                  newUsageRecorder(),
+	}
+	limiter, err := newRateLimiter(rt, securityCfg)
+	if err != nil {
+		return nil, err
+	}
+	api.rateLimiter = limiter
+	if rt != nil && rt.ControlPlane != nil {
+		api.credentials = rt.ControlPlane
+		api.federation = rt.ControlPlane
+		if securityCfg.Quotas.Enabled {
+			api.quotas = rt.ControlPlane
+		}
+	}
+	if securityCfg.Metrics.Enabled {
+		api.metrics = newRuntimeMetrics(rt)
+	}
+	if rt != nil && rt.Security != nil {
+		api.revocations = rt.Security.RevocationStore
+		if rt.Security.NonceStore != nil {
+			api.nodeName = rt.Security.NonceStore.NodeName()
+		}
+	}
+	if rt != nil && rt.Security != nil && rt.Security.RequestNonces != nil {
+		verifier, err := securitypkg.NewRequestVerifier(rt.Security.KeyResolver, rt.Security.RequestNonces, securityCfg.SignatureMaxSkew)
+		if err != nil {
+			return nil, fmt.Errorf("build request verifier: %w", err)
+		}
+		api.signatures = verifier
 	}
+	if rt != nil && securityCfg.RequireRequestSigning && api.signatures == nil {
+		return nil, fmt.Errorf("request signing is required but the runtime has no request nonce store")
+	}
+	return api, nil
 }
 
 func (a *httpAPI) handleLiveness(w http.ResponseWriter, r *http.Request) {
@@ -44,7 +105,7 @@ func (a *httpAPI) handleLiveness(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+	writeJSON(w, http.StatusOK, runtimeapi.Status{Status: "alive"})
 }
 
 func (a *httpAPI) handleHealthz(w http.ResponseWriter, r *http.Request) {
@@ -58,261 +119,91 @@ func (a *httpAPI) handleHealthz(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusServiceUnavailable, "unhealthy")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-}
-
-func (a *httpAPI) handleReadyz(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
-	}
-	ctx, cancel := context.WithTimeout(r.Context(), a.healthTimeout)
-	defer cancel()
-	if err := a.rt.HealthCheck(ctx, a.healthTimeout); err != nil {
-		writeJSONError(w, http.StatusServiceUnavailable, "not-ready")
-		return
-	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	writeJSON(w, http.StatusOK, runtimeapi.Status{Status: "ok"})
 }
 
+// handleDecisionWrite runs behind writeRoute.
 func (a *httpAPI) handleDecisionWrite(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
-	}
-	if err := a.authorizeAndRateLimit(r, "v1/decisions"); err != nil {
-		status := http.StatusUnauthorized
-		if err.Error() == "rate limit exceeded" {
-			status = http.StatusTooManyRequests
-		}
-		writeJSONError(w, status, err.Error())
-		return
-	}
-	requestID := strings.TrimSpace(r.Header.Get("X-Request-ID"))
-	if requestID == "" {
-		writeJSONError(w, http.StatusBadRequest, "X-Request-ID header is required")
-		return
-	}
-	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
-	if idempotencyKey == "" {
-		writeJSONError(w, http.StatusBadRequest, "Idempotency-Key header is required")
-		return
-	}
-
-	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
 		return
 	}
-	if len(body) == 0 {
-		writeJSONError(w, http.StatusBadRequest, "request body is required")
-		return
-	}
-	reqHash := dbpkg.SHA256Hex(append([]byte("decision:"), body...))
-
-	ctx, cancel := context.WithTimeout(r.Context(), a.writeTimeout)
-	defer cancel()
-	reservedKey, cached, err := reserveIdempotencyKey(ctx, a.rt.DB, "v1/decisions", idempotencyKey, reqHash, a.idempotencyTTL)
-	if err != nil {
-		writeJSONError(w, http.StatusConflict, err.Error())
-		return
-	}
-	if !reservedKey {
-		if cached != nil {
-			writeRawJSON(w, cached.ResponseCode, cached.ResponseJSON)
-			return
-		}
-		writeJSONError(w, http.StatusConflict, "request is already in progress")
+	res, cerr := a.recordDecision(r.Context(), httpWriteCall(r), body)
+	if cerr != nil {
+		writeCallError(w, cerr)
 		return
 	}
-
-	var req decisionWriteRequest
-	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid JSON payload")
-		return
-	}
-
-	decisionCtx := map[string]interface{}{"request_id": requestID}
-	for k, v := range req.DecisionContext {
-		decisionCtx[k] = v
-	}
-	eventCtx := map[string]interface{}{"request_id": requestID}
-	for k, v := range req.Event {
-		eventCtx[k] = v
-	}
-
-	traceHash := dbpkg.SHA256Hex(body)
-	decisionRecord := authzrepo.DecisionRecord{
-		TenantID:      req.TenantID,
-		WorkspaceID:   req.WorkspaceID,
-		Subject:       req.Subject,
-		SessionID:     req.SessionID,
-		PolicySetID:   req.PolicySetID,
-		PolicySetKey:  req.PolicySetKey,
-		Tier:          req.Tier,
-		Action:        req.Action,
-		ResourceRef:   req.ResourceRef,
-		Allow:         req.Allow,
-		ReasonCode:    req.ReasonCode,
-		MatchedRuleID: req.MatchedRuleID,
-		TraceHash:     &traceHash,
-		ContextJSON:   mustMarshalJSON(decisionCtx),
-	}
-	trace := make([]authzrepo.TraceStep, 0, len(req.Trace))
-	for _, s := range req.Trace {
-		trace = append(trace, authzrepo.TraceStep{
-			StepOrder: s.StepOrder,
-			RuleID:    s.RuleID,
-			Matched:   s.Matched,
-			Outcome:   s.Outcome,
-			Reason:    s.Reason,
-		})
-	}
-	eventRecord := telemetryrepo.SecurityEventRecord{
-		TenantID:    req.TenantID,
-		WorkspaceID: req.WorkspaceID,
-		ActorType:   req.ActorType,
-		ActorID:     req.ActorID,
-		EventType:   req.EventType,
-		Severity:    req.Severity,
-		Message:     req.Message,
-		TraceHash:   &traceHash,
-		EventJSON:   mustMarshalJSON(eventCtx),
-	}
-
-	decisionID, eventID, err := a.rt.RecordDecisionAndEvent(ctx, decisionRecord, trace, eventRecord)
-	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("failed to persist decision/event: %v", err))
-		return
-	}
-	resp := map[string]string{
-		"request_id":  requestID,
-		"decision_id": decisionID,
-		"event_id":    eventID,
-		"status":      "accepted",
-	}
-	respBody := mustMarshalJSON(resp)
-	if err := storeIdempotencyResponse(ctx, a.rt.DB, "v1/decisions", idempotencyKey, http.StatusAccepted, respBody); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "failed to store idempotency response")
-		return
-	}
-	writeRawJSON(w, http.StatusAccepted, respBody)
+	writeRawJSON(w, res.status, res.body)
 }
 
+// handleTelemetryWrite runs behind writeRoute.
 func (a *httpAPI) handleTelemetryWrite(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
-	}
-	if err := a.authorizeAndRateLimit(r, "v1/telemetry/events"); err != nil {
-		status := http.StatusUnauthorized
-		if err.Error() == "rate limit exceeded" {
-			status = http.StatusTooManyRequests
-		}
-		writeJSONError(w, status, err.Error())
-		return
-	}
-	requestID := strings.TrimSpace(r.Header.Get("X-Request-ID"))
-	if requestID == "" {
-		writeJSONError(w, http.StatusBadRequest, "X-Request-ID header is required")
-		return
-	}
-	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
-	if idempotencyKey == "" {
-		writeJSONError(w, http.StatusBadRequest, "Idempotency-Key header is required")
-		return
-	}
-
-	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
 		return
 	}
-	if len(body) == 0 {
-		writeJSONError(w, http.StatusBadRequest, "request body is required")
+	res, cerr := a.recordTelemetryEvent(r.Context(), httpWriteCall(r), body)
+	if cerr != nil {
+		writeCallError(w, cerr)
 		return
 	}
-	reqHash := dbpkg.SHA256Hex(append([]byte("telemetry:"), body...))
+	writeRawJSON(w, res.status, res.body)
+}
 
-	ctx, cancel := context.WithTimeout(r.Context(), a.writeTimeout)
-	defer cancel()
-	reservedKey, cached, err := reserveIdempotencyKey(ctx, a.rt.DB, "v1/telemetry/events", idempotencyKey, reqHash, a.idempotencyTTL)
+func (a *httpAPI) authorizeAndRateLimit(w http.ResponseWriter, r *http.Request, scope, requiredScope string) (callerIdentity, error) {
+	caller, err := a.authorize(r, requiredScope)
 	if err != nil {
-		writeJSONError(w, http.StatusConflict, err.Error())
-		return
+		return callerIdentity{}, err
 	}
-	if !reservedKey {
-		if cached != nil {
-			writeRawJSON(w, cached.ResponseCode, cached.ResponseJSON)
-			return
-		}
-		writeJSONError(w, http.StatusConflict, "request is already in progress")
-		return
+	clientIP := extractClientIP(r, a.securityCfg.TrustProxyHeaders)
+	if err := a.rateLimit(r.Context(), w, caller, clientIP, scope); err != nil {
+		return callerIdentity{}, err
 	}
+	return caller, nil
+}
 
-	var req telemetryWriteRequest
-	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid JSON payload")
-		return
-	}
-	eventCtx := map[string]interface{}{"request_id": requestID}
-	for k, v := range req.Event {
-		eventCtx[k] = v
-	}
-	record := telemetryrepo.SecurityEventRecord{
-		TenantID:    req.TenantID,
-		WorkspaceID: req.WorkspaceID,
-		ActorType:   req.ActorType,
-		ActorID:     req.ActorID,
-		EventType:   req.EventType,
-		Severity:    req.Severity,
-		Message:     req.Message,
-		TraceHash:   req.TraceHash,
-		EventJSON:   mustMarshalJSON(eventCtx),
-	}
-	links := make([]telemetryrepo.EventLink, 0, len(req.Links))
-	for _, link := range req.Links {
-		links = append(links, telemetryrepo.EventLink{
-			LinkKind:     link.LinkKind,
-			LinkedID:     link.LinkedID,
-			MetadataJSON: mustMarshalJSON(link.Metadata),
-		})
+// authorize authenticates the request and checks requiredScope, feeding
+// failures to the abuse detector.
+func (a *httpAPI) authorize(r *http.Request, requiredScope string) (callerIdentity, error) {
+	clientIP := extractClientIP(r, a.securityCfg.TrustProxyHeaders)
+	if a.abuse.isBlocked(clientIP) {
+		a.metrics.authFailure(authFailureReason(errClientBlocked))
+		return callerIdentity{}, errClientBlocked
 	}
-	eventID, err := a.rt.TelemetryRepo.PersistSecurityEventWithLinks(ctx, record, links)
+	sessionID := r.Header.Get("X-Session-ID")
+	caller, err := a.authenticate(r)
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("failed to persist telemetry event: %v", err))
-		return
-	}
-
-	resp := map[string]string{
-		"request_id": requestID,
-		"event_id":   eventID,
-		"status":     "accepted",
-	}
-	respBody := mustMarshalJSON(resp)
-	if err := storeIdempotencyResponse(ctx, a.rt.DB, "v1/telemetry/events", idempotencyKey, http.StatusAccepted, respBody); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "failed to store idempotency response")
-		return
-	}
-	writeRawJSON(w, http.StatusAccepted, respBody)
-}
-
-func (a *httpAPI) authorizeAndRateLimit(r *http.Request, scope string) error {
-	if a.securityCfg.RequireAuth {
-		token := extractAuthToken(r)
-		if token == "" {
-			return fmt.Errorf("missing bearer token or x-api-key")
+		kind := abuseKindAuthFailure
+		if errors.Is(err, errInvalidCredentials) || errors.Is(err, errRevokedCredentials) {
+			kind = abuseKindInvalidToken
 		}
-		if _, ok := a.securityCfg.AllowedTokens[token]; !ok {
-			return fmt.Errorf("invalid api token")
+		if !errors.Is(err, errAuthUnavailable) {
+			a.recordAbuse(kind, clientIP, callerIdentity{}, tokenFingerprint(r), "")
 		}
+		a.metrics.authFailure(authFailureReason(err))
+		return callerIdentity{}, err
+	}
+	noteAccessLogCaller(r.Context(), caller)
+	if !caller.hasScope(requiredScope) {
+		a.recordAbuse(abuseKindAuthFailure, clientIP, caller, "", sessionID)
+		a.metrics.authFailure(authFailureReason(errInsufficientScope))
+		return callerIdentity{}, errInsufficientScope
 	}
+	return caller, nil
+}
 
-	clientIP := extractClientIP(r, a.securityCfg.TrustProxyHeaders)
-	key := clientIP + ":" + scope
-	if !a.rateLimiter.allow(key, a.securityCfg.RateLimitBurst) {
-		return fmt.Errorf("rate limit exceeded")
+// rateLimit spends one token of the caller's bucket for scope, writing the
+// RateLimit headers to w, and counts the request against the tenant's usage.
+func (a *httpAPI) rateLimit(ctx context.Context, w http.ResponseWriter, caller callerIdentity, clientIP, scope string) error {
+	key, policy, policyName := a.requestRateLimit(ctx, caller, clientIP, scope)
+	if !a.applyRateLimit(ctx, w, key, policy) {
+		a.metrics.rateLimitRejected(scope, policyName)
+		a.recordAbuse(abuseKindRateLimited, clientIP, caller, "", "")
+		return errRateLimited
 	}
+	a.recordUsage(caller.TenantID, controlplanerepo.RequestUsageMetric(scope), 1)
 	return nil
 }
 
@@ -331,56 +222,6 @@ func extractAuthToken(r *http.Request) string {
 	return ""
 }
 
-type decisionWriteRequest struct {
-	TenantID        *string                `json:"tenant_id"`
-	WorkspaceID     *string                `json:"workspace_id"`
-	Subject         string                 `json:"subject"`
-	SessionID       *string                `json:"session_id"`
-	PolicySetID     *string                `json:"policy_set_id"`
-	PolicySetKey    *string                `json:"policy_set_key"`
-	Tier            *string                `json:"tier"`
-	Action          string                 `json:"action"`
-	ResourceRef     string                 `json:"resource_ref"`
-	Allow           bool                   `json:"allow"`
-	ReasonCode      string                 `json:"reason_code"`
-	MatchedRuleID   *string                `json:"matched_rule_id"`
-	Trace           []decisionTraceStep    `json:"trace"`
-	DecisionContext map[string]interface{} `json:"decision_context"`
-	ActorType       string                 `json:"actor_type"`
-	ActorID         *string                `json:"actor_id"`
-	EventType       string                 `json:"event_type"`
-	Severity        string                 `json:"severity"`
-	Message         string                 `json:"message"`
-	Event           map[string]interface{} `json:"event"`
-}
-
-type decisionTraceStep struct {
-	StepOrder int    `json:"step_order"`
-	RuleID    string `json:"rule_id"`
-	Matched   bool   `json:"matched"`
-	Outcome   string `json:"outcome"`
-	Reason    string `json:"reason"`
-}
-
-type telemetryWriteRequest struct {
-	TenantID    *string                  `json:"tenant_id"`
-	WorkspaceID *string                  `json:"workspace_id"`
-	ActorType   string                   `json:"actor_type"`
-	ActorID     *string                  `json:"actor_id"`
-	EventType   string                   `json:"event_type"`
-	Severity    string                   `json:"severity"`
-	Message     string                   `json:"message"`
-	TraceHash   *string                  `json:"trace_hash"`
-	Event       map[string]interface{}   `json:"event"`
-	Links       []telemetryEventLinkSpec `json:"links"`
-}
-
-type telemetryEventLinkSpec struct {
-	LinkKind string                 `json:"link_kind"`
-	LinkedID string                 `json:"linked_id"`
-	Metadata map[string]interface{} `json:"metadata"`
-}
-
 func mustMarshalJSON(v interface{}) []byte {
 	out, err := json.Marshal(v)
 	if err != nil {
@@ -389,10 +230,6 @@ func mustMarshalJSON(v interface{}) []byte {
 	return out
 }
 
-func writeJSONError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
-}
-
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	body := mustMarshalJSON(payload)
 	writeRawJSON(w, status, body)
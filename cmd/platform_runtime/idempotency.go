@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+// idempotencyReleaseTimeout bounds releasing a claim after a failed write.
+const idempotencyReleaseTimeout = 2 * time.Second
+
+// maxWriteBody bounds the body of a single-record write.
+const maxWriteBody = 1 << 20
+
+// idempotencyStore is the idempotency.Store subset the runtime needs.
+type idempotencyStore interface {
+	idempotency.Backend
+	Lookup(ctx context.Context, scope, key string) (idempotency.Record, bool, error)
+}
+
+type callerKey struct{}
+
+func withCaller(ctx context.Context, caller callerIdentity) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// callerFromContext returns the caller authorized by writeRoute.
+func callerFromContext(ctx context.Context) callerIdentity {
+	caller, _ := ctx.Value(callerKey{}).(callerIdentity)
+	return caller
+}
+
+// writeRoute serves an idempotent POST route. It authorizes and rate limits
+// the caller, requires X-Request-ID and a body, and verifies the request
+// signature before next runs behind the idempotency middleware, so
+// unauthenticated requests never claim a key. Bodies over maxBody are
+// refused with 413. next finds the caller with callerFromContext and the
+// claim with idempotency.ClaimFromContext.
+func (a *httpAPI) writeRoute(scope, requiredScope string, maxBody int64, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		caller, err := a.authorizeAndRateLimit(w, r, scope, requiredScope)
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		if strings.TrimSpace(r.Header.Get(runtimeapi.HeaderRequestID)) == "" {
+			writeJSONError(w, http.StatusBadRequest, "X-Request-ID header is required")
+			return
+		}
+		if strings.TrimSpace(r.Header.Get(runtimeapi.HeaderIdempotencyKey)) == "" {
+			writeCallError(w, idempotencyCallError(r.Context(), idempotency.ErrKeyRequired))
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		if int64(len(body)) > maxBody {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBody))
+			return
+		}
+		if len(body) == 0 {
+			writeJSONError(w, http.StatusBadRequest, "request body is required")
+			return
+		}
+		if err := a.verifyRequestSignature(r, body, &caller); err != nil {
+			if !errors.Is(err, errAuthUnavailable) {
+				a.recordAbuse(abuseKindAuthFailure, extractClientIP(r, a.securityCfg.TrustProxyHeaders), caller, "", r.Header.Get("X-Session-ID"))
+			}
+			a.metrics.authFailure(authFailureReason(err))
+			writeAuthError(w, err)
+			return
+		}
+		if a.idempotencyKeys == nil {
+			writeJSONError(w, http.StatusServiceUnavailable, "idempotency store unavailable")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		a.idempotencyMiddleware(scope, maxBody).Wrap(next).ServeHTTP(w, r.WithContext(withCaller(r.Context(), caller)))
+	})
+}
+
+// idempotencyMiddleware keys a route's writes by the caller's credential.
+func (a *httpAPI) idempotencyMiddleware(scope string, maxBody int64) *idempotency.Middleware {
+	return &idempotency.Middleware{
+		Backend:     a.idempotencyKeys,
+		Scope:       scope,
+		Fingerprint: a.idempotencyFingerprint,
+		Credential:  func(r *http.Request) string { return callerFromContext(r.Context()).CredentialID },
+		MaxBody:     maxBody,
+		Required:    true,
+		Error: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeCallError(w, idempotencyCallError(r.Context(), err))
+		},
+		Observe: a.observeIdempotency,
+	}
+}
+
+// httpWriteCall collects the inputs writeRoute prepared for a handler.
+func httpWriteCall(r *http.Request) writeCall {
+	return writeCall{
+		caller:    callerFromContext(r.Context()),
+		requestID: strings.TrimSpace(r.Header.Get(runtimeapi.HeaderRequestID)),
+		claim:     idempotency.ClaimFromContext(r.Context()),
+	}
+}
+
+// claimWrite claims the idempotency key of a gRPC write, fingerprinted like
+// a POST to the write's HTTP path with the JSON form of the message as body.
+// Without a claim, the stored response or cerr answers the call.
+func (a *httpAPI) claimWrite(ctx context.Context, scope, path, key string, caller callerIdentity, body []byte) (*idempotency.Claim, *idempotency.Response, *callError) {
+	if a.idempotencyKeys == nil {
+		return nil, nil, newCallError(http.StatusServiceUnavailable, runtimeapi.CodeUnavailable, "idempotency store unavailable")
+	}
+	fingerprint := a.idempotencyFingerprint.Sum(http.MethodPost, path, body, caller.CredentialID)
+	claim, stored, err := a.idempotencyKeys.Claim(ctx, scope, key, fingerprint)
+	switch {
+	case errors.Is(err, idempotency.ErrKeyReused):
+		a.observeIdempotency(ctx, scope, idempotency.OutcomeConflict)
+		return nil, nil, idempotencyCallError(ctx, err)
+	case errors.Is(err, idempotency.ErrInProgress):
+		a.observeIdempotency(ctx, scope, idempotency.OutcomeInProgress)
+		return nil, nil, idempotencyCallError(ctx, err)
+	case err != nil:
+		return nil, nil, idempotencyCallError(ctx, err)
+	case stored != nil:
+		a.observeIdempotency(ctx, scope, idempotency.OutcomeReplay)
+		return nil, stored, nil
+	}
+	if claim.Recovered {
+		a.observeIdempotency(ctx, scope, idempotency.OutcomeRecovered)
+	}
+	return claim, nil, nil
+}
+
+// releaseClaim runs even when ctx is done, so a timed-out write does not
+// hold its key until the lease lapses.
+func (a *httpAPI) releaseClaim(ctx context.Context, claim *idempotency.Claim) {
+	if claim == nil || a.idempotencyKeys == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyReleaseTimeout)
+	defer cancel()
+	if err := a.idempotencyKeys.Release(ctx, claim); err != nil {
+		logging.FromContext(ctx).Warn("failed to release idempotency key", "scope", claim.Scope, "error", err)
+	}
+}
+
+func (a *httpAPI) observeIdempotency(ctx context.Context, scope string, outcome idempotency.Outcome) {
+	a.metrics.idempotencyHit(scope, outcome)
+	if outcome == idempotency.OutcomeRecovered {
+		logging.FromContext(ctx).Info("idempotency key recovered from a lapsed lease", "scope", scope)
+	}
+}
+
+func idempotencyCallError(ctx context.Context, err error) *callError {
+	switch {
+	case errors.Is(err, idempotency.ErrKeyRequired):
+		return newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "Idempotency-Key header is required")
+	case errors.Is(err, idempotency.ErrBodyTooLarge):
+		return newCallError(http.StatusRequestEntityTooLarge, runtimeapi.CodePayloadTooLarge, "request body too large")
+	case errors.Is(err, idempotency.ErrKeyReused):
+		return newCallError(http.StatusConflict, runtimeapi.CodeIdempotencyKeyReused, err.Error())
+	case errors.Is(err, idempotency.ErrInProgress), errors.Is(err, idempotency.ErrLeaseLost):
+		return newCallError(http.StatusConflict, runtimeapi.CodeRequestInProgress, "request is already in progress")
+	default:
+		return storeCallError(ctx, err, "failed to reserve idempotency key")
+	}
+}
+
+// handleIdempotencyKey shows a key's state and stored response, for
+// operators chasing a client's retries. Keys are not tenant scoped, so
+// tenant-bound credentials are refused.
+func (a *httpAPI) handleIdempotencyKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	caller, err := a.authorizeAndRateLimit(w, r, "v1/admin/idempotency-keys", scopeOpsAdmin)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	if !validateRequest(w, r, nil) {
+		return
+	}
+	if caller.TenantID != nil {
+		writeJSONError(w, http.StatusForbidden, "idempotency keys are not tenant scoped; use a credential without a tenant")
+		return
+	}
+	a.writeIdempotencyKey(w, r)
+}
+
+// writeIdempotencyKey serves an already authorized and validated key lookup;
+// the admin listener shares it.
+func (a *httpAPI) writeIdempotencyKey(w http.ResponseWriter, r *http.Request) {
+	if a.idempotencyKeys == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "idempotency store unavailable")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), a.writeTimeout)
+	defer cancel()
+	rec, found, err := a.idempotencyKeys.Lookup(ctx, r.URL.Query().Get("scope"), r.PathValue("key"))
+	if err != nil {
+		writeCallError(w, storeCallError(ctx, err, "failed to load idempotency key"))
+		return
+	}
+	if !found {
+		writeJSONError(w, http.StatusNotFound, "idempotency key not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, idempotencyRecordToAPI(rec))
+}
+
+func idempotencyRecordToAPI(rec idempotency.Record) runtimeapi.IdempotencyRecord {
+	out := runtimeapi.IdempotencyRecord{
+		Scope:       rec.Scope,
+		Key:         rec.Key,
+		Fingerprint: rec.Fingerprint,
+		State:       rec.State(),
+		CreatedAt:   rec.CreatedAt,
+		ExpiresAt:   rec.ExpiresAt,
+		LockedUntil: rec.LockedUntil,
+		CompletedAt: rec.CompletedAt,
+	}
+	if rec.Response != nil {
+		body := json.RawMessage(rec.Response.Body)
+		if len(body) > 0 && !json.Valid(body) {
+			body = mustMarshalJSON(string(rec.Response.Body))
+		}
+		out.Response = &runtimeapi.IdempotentResponse{
+			Status:  rec.Response.Status,
+			Headers: rec.Response.Header,
+			Body:    body,
+		}
+	}
+	return out
+}
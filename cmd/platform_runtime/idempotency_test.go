@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+	runtimepb "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/runtimepb"
+)
+
+// fakeIdempotencyStore answers every claim from stored, or grants it.
+type fakeIdempotencyStore struct {
+	stored       *idempotency.Response
+	fingerprints []string
+	released     int
+}
+
+func (s *fakeIdempotencyStore) Claim(_ context.Context, scope, key, fingerprint string) (*idempotency.Claim, *idempotency.Response, error) {
+	s.fingerprints = append(s.fingerprints, fingerprint)
+	if s.stored != nil {
+		return nil, s.stored, nil
+	}
+	return &idempotency.Claim{Scope: scope, Key: key}, nil, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(context.Context, *idempotency.Claim, idempotency.Response) error {
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(context.Context, *idempotency.Claim) error {
+	s.released++
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Lookup(_ context.Context, scope, key string) (idempotency.Record, bool, error) {
+	if s.stored == nil {
+		return idempotency.Record{}, false, nil
+	}
+	return idempotency.Record{Scope: scope, Key: key, Fingerprint: "fp", Response: s.stored}, true, nil
+}
+
+func newWriteRequest(token, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/things", strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	r.Header.Set(runtimeapi.HeaderRequestID, "req-1")
+	r.Header.Set(runtimeapi.HeaderIdempotencyKey, key)
+	return r
+}
+
+func TestWriteRouteClaimsOnlyAuthorizedRequests(t *testing.T) {
+	api := newTestGRPCRuntime(t, 10)
+	store := &fakeIdempotencyStore{}
+	api.idempotencyKeys = store
+	var claimed *idempotency.Claim
+	h := api.writeRoute("v1/things", scopeDecisionsWrite, maxWriteBody, func(w http.ResponseWriter, r *http.Request) {
+		call := httpWriteCall(r)
+		claimed = call.claim
+		if call.requestID != "req-1" || !strings.HasPrefix(call.caller.CredentialID, "static:") {
+			t.Errorf("unexpected write call %+v", call)
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newWriteRequest("", "k1", `{}`))
+	if rec.Code != http.StatusUnauthorized || len(store.fingerprints) != 0 {
+		t.Fatalf("expected 401 without a claim, got %d after %d claims", rec.Code, len(store.fingerprints))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newWriteRequest("grpc-token", "k1", `{"a":1}`))
+	if rec.Code != http.StatusUnprocessableEntity || claimed == nil || claimed.Key != "k1" || store.released != 1 {
+		t.Fatalf("expected the handler to run under a claim released on failure, got %d %+v (%d releases)", rec.Code, claimed, store.released)
+	}
+	want := idempotency.DefaultFingerprint.Sum(http.MethodPost, "/v1/things", []byte(`{"a":1}`), "static:"+dbpkg.SHA256Hex([]byte("grpc-token"))[:12])
+	if store.fingerprints[0] != want {
+		t.Fatalf("expected the fingerprint to cover the caller's credential")
+	}
+}
+
+func TestWriteRouteReplaysStoredResponse(t *testing.T) {
+	api := newTestGRPCRuntime(t, 10)
+	api.idempotencyKeys = &fakeIdempotencyStore{stored: &idempotency.Response{
+		Status: http.StatusAccepted,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   []byte(`{"status":"accepted"}`),
+	}}
+	h := api.writeRoute("v1/things", scopeDecisionsWrite, maxWriteBody, func(http.ResponseWriter, *http.Request) {
+		t.Fatalf("handler ran for a replay")
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newWriteRequest("grpc-token", "k1", `{}`))
+	if rec.Code != http.StatusAccepted || rec.Header().Get(runtimeapi.HeaderIdempotentReplayed) != "true" ||
+		rec.Body.String() != `{"status":"accepted"}` || rec.Header().Get("RateLimit-Remaining") == "" {
+		t.Fatalf("unexpected replay: %d %v %q", rec.Code, rec.Header(), rec.Body)
+	}
+}
+
+func TestWriteFailedLeaseLostIsInProgress(t *testing.T) {
+	api := &httpAPI{}
+	err := dbpkg.Classify("platform: record decision", fmt.Errorf("hook: %w", idempotency.ErrLeaseLost))
+	cerr := api.writeFailed(context.Background(), "v1/decisions", err, "failed to persist decision/event")
+	if cerr.problem.Status != http.StatusConflict || cerr.problem.Code != runtimeapi.CodeRequestInProgress {
+		t.Fatalf("expected 409 request_in_progress for a lost lease, got %+v", cerr.problem)
+	}
+}
+
+func TestGRPCWriteReplaysStoredResponse(t *testing.T) {
+	api := newTestGRPCRuntime(t, 10)
+	api.idempotencyKeys = &fakeIdempotencyStore{stored: &idempotency.Response{
+		Status: http.StatusAccepted,
+		Body:   []byte(`{"request_id":"r0","event_id":"e1","status":"accepted"}`),
+	}}
+	client := newTestGRPCClient(t, api)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "grpc-token")
+	var header metadata.MD
+	out, err := client.RecordTelemetryEvent(ctx, &runtimepb.RecordTelemetryEventRequest{RequestId: "r1", IdempotencyKey: "k1"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if !out.GetReplayed() || out.GetEventId() != "e1" || len(header.Get("idempotent-replayed")) != 1 {
+		t.Fatalf("expected a replayed response, got %+v %v", out, header)
+	}
+}
+
+func TestHandleIdempotencyKey(t *testing.T) {
+	tenant := "7f1c2d3e-4b5a-4c6d-8e9f-0a1b2c3d4e5f"
+	api := newTestGRPCRuntime(t, 10)
+	api.idempotencyKeys = &fakeIdempotencyStore{stored: &idempotency.Response{Status: http.StatusCreated, Body: []byte("plain")}}
+	api.writeTimeout = time.Second
+	get := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/admin/idempotency-keys/k1"+target, nil)
+		r.SetPathValue("key", "k1")
+		r.Header.Set("Authorization", "Bearer grpc-token")
+		rec := httptest.NewRecorder()
+		api.handleIdempotencyKey(rec, r)
+		return rec
+	}
+	if rec := get(""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected scope to be required, got %d", rec.Code)
+	}
+	rec := get("?scope=v1/decisions")
+	var out runtimeapi.IdempotencyRecord
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %q: %v", rec.Code, rec.Body, err)
+	}
+	if out.State != "completed" || out.Response == nil || string(out.Response.Body) != `"plain"` {
+		t.Fatalf("expected a completed key with its body as a string, got %+v", out)
+	}
+	if rec := get("?scope=v1/telemetry/events:batch"); rec.Code != http.StatusOK {
+		t.Fatalf("expected batch keys to be looked up, got %d %q", rec.Code, rec.Body)
+	}
+
+	api.securityCfg.AllowedTokens = nil
+	api.securityCfg.DBCredentials = true
+	api.credentials = fakeCredentialStore{creds: map[string]controlplanerepo.APICredential{
+		dbpkg.SHA256Hex([]byte("grpc-token")): {ID: "cred-1", TenantID: tenant, Scopes: []string{scopeOpsAdmin}},
+	}}
+	if rec := get("?scope=v1/decisions"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a tenant-bound credential to be refused, got %d", rec.Code)
+	}
+}
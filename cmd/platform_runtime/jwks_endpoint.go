@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultJWKSMaxAge = time.Hour
+
+// handleJWKS publishes the public runtime signing keys for offline verification.
+// It is unauthenticated by design: the set contains public parameters only.
+func (a *httpAPI) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if a.rt == nil || a.rt.Security == nil || a.rt.Security.JWKSPublisher == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "jwks unavailable")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), a.healthTimeout)
+	defer cancel()
+	published, err := a.rt.Security.JWKSPublisher.PublicKeySet(ctx)
+	if err != nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "jwks unavailable")
+		return
+	}
+
+	body := mustMarshalJSON(published.Set)
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	maxAge := jwksMaxAge(time.Now().UTC(), published.NextExpiry, a.securityCfg.JWKSMaxAge)
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeRawJSON(w, http.StatusOK, body)
+}
+
+// jwksMaxAge caps the cache lifetime at the next key expiry so verifiers
+// refetch as soon as a rotated-out key leaves its overlap window.
+func jwksMaxAge(now time.Time, nextExpiry *time.Time, limit time.Duration) int {
+	if limit <= 0 {
+		limit = defaultJWKSMaxAge
+	}
+	ttl := limit
+	if nextExpiry != nil {
+		if untilExpiry := nextExpiry.Sub(now); untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	if ttl < 0 {
+		return 0
+	}
+	return int(ttl / time.Second)
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJWKSMaxAge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	soon := now.Add(90 * time.Second)
+	past := now.Add(-time.Second)
+	later := now.Add(48 * time.Hour)
+
+	cases := []struct {
+		next  *time.Time
+		limit time.Duration
+		want  int
+	}{
+		{nil, 0, 3600},
+		{nil, 10 * time.Minute, 600},
+		{&soon, time.Hour, 90},
+		{&later, time.Hour, 3600},
+		{&past, time.Hour, 0},
+	}
+	for i, tc := range cases {
+		if got := jwksMaxAge(now, tc.next, tc.limit); got != tc.want {
+			t.Fatalf("case %d: jwksMaxAge = %d, want %d", i, got, tc.want)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	if !etagMatches(`"abc", W/"def"`, `"def"`) {
+		t.Fatalf("expected weak etag match")
+	}
+	if etagMatches(`"abc"`, `"def"`) || etagMatches("", `"def"`) {
+		t.Fatalf("expected no match")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/url"
@@ -13,15 +14,29 @@ import (
 	"syscall"
 	"time"
 
+	migrations "github.com/sarat-asymmetrica/vedic-platform-experiments/db/migrations"
 	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	outbox "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/outbox"
 	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
 	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func usage() {
 	fmt.Fprintf(os.Stderr, "Usage:\n")
 	fmt.Fprintf(os.Stderr, "  platform_runtime selfcheck [--node-name name] [--nonce-scope scope] [--nonce-window N]\n")
-	fmt.Fprintf(os.Stderr, "  platform_runtime serve [--host addr] [--port N] [--node-name name] [--nonce-scope scope] [--nonce-window N]\n")
+	fmt.Fprintf(os.Stderr, "  platform_runtime serve [--host addr] [--port N] [--grpc-port N] [--node-name name] [--nonce-scope scope] [--nonce-window N]\n")
+	fmt.Fprintf(os.Stderr, "                         [--tls-cert file --tls-key file] [--tls-client-ca file] [--tls-client-auth none|optional|require]\n")
+	fmt.Fprintf(os.Stderr, "                         [--maintenance=true|false] [--maintenance-cleanup-interval d] [--maintenance-refresh-interval d]\n")
+	fmt.Fprintf(os.Stderr, "                         [--maintenance-job-timeout d] [--maintenance-run-retention d] [--maintenance-outbox-retention d]\n")
+	fmt.Fprintf(os.Stderr, "                         [--admin-host addr] [--admin-port N] [--drain-delay d] [--config file]\n")
+	fmt.Fprintf(os.Stderr, "                         [--outbox --outbox-webhook-url url] [--outbox-poll-interval d] [--outbox-max-attempts N]\n")
+	fmt.Fprintf(os.Stderr, "  platform_runtime outbox --outbox-webhook-url url [--node-name name] [--outbox-* flags] [--shutdown-timeout d]\n")
+	fmt.Fprintf(os.Stderr, "  platform_runtime config check [serve flags]\n")
 }
 
 func main() {
@@ -35,6 +50,10 @@ func main() {
 		selfcheckCmd(os.Args[2:])
 	case "serve":
 		serveCmd(os.Args[2:])
+	case "config":
+		configCmd(os.Args[2:])
+	case "outbox":
+		outboxCmd(os.Args[2:])
 	default:
 		usage()
 		os.Exit(2)
@@ -42,6 +61,7 @@ func main() {
 }
 
 func selfcheckCmd(args []string) {
+	setupLogging()
 	fs := flag.NewFlagSet("selfcheck", flag.ExitOnError)
 	nodeName := fs.String("node-name", "platform-node", "node name for nonce watermark scope")
 	nonceScope := fs.String("nonce-scope", "default", "nonce scope")
@@ -68,89 +88,432 @@ func selfcheckCmd(args []string) {
 	if err := rt.HealthCheck(ctx, 5*time.Second); err != nil {
 		fatalf("health check: %v", err)
 	}
-	fmt.Fprintln(os.Stdout, "platform_runtime: selfcheck ok")
+	slog.Info("selfcheck ok")
 }
 
 func serveCmd(args []string) {
-	fs := flag.NewFlagSet("serve", flag.ExitOnError)
-	host := fs.String("host", getEnvOrDefault("HOST", "0.0.0.0"), "http bind host")
-	port := fs.Int("port", getEnvOrDefaultInt("PORT", 8080), "http bind port")
-	nodeName := fs.String("node-name", "platform-node", "node name for nonce watermark scope")
-	nonceScope := fs.String("nonce-scope", "default", "nonce scope")
-	nonceWindow := fs.Uint64("nonce-window", 1000, "nonce reservation window")
-	healthTimeout := fs.Duration("health-timeout", 5*time.Second, "database health check timeout")
-	writeTimeout := fs.Duration("write-timeout", 8*time.Second, "api write timeout")
-	idempotencyTTL := fs.Duration("idempotency-ttl", 24*time.Hour, "idempotency key retention window")
-	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "graceful shutdown timeout")
-	_ = fs.Parse(args)
-
-	dbCfg, err := dbpkg.FromEnv()
+	logCfg := setupLogging()
+	traceCfg, err := loadRuntimeTraceConfigFromEnv()
 	if err != nil {
-		fatalf("load db config: %v", err)
+		fatalf("load trace config: %v", err)
 	}
-	secCfg := securitypkg.RuntimeConfig{
-		NodeName:    *nodeName,
-		NonceScope:  *nonceScope,
-		NonceWindow: *nonceWindow,
+	tracer, err := newRuntimeTracer(traceCfg)
+	if err != nil {
+		fatalf("start tracer: %v", err)
 	}
-	serveSecCfg, err := loadServeSecurityConfigFromEnv()
+	tracing.SetDefault(tracer)
+	opts, err := loadServeOptions(args)
 	if err != nil {
-		fatalf("load runtime security config: %v", err)
+		fatalf("load config: %v", err)
 	}
+	tlsCfg, dbCfg, serveSecCfg := opts.tls, opts.db, opts.security
+	secCfg := securitypkg.RuntimeConfig{
+		NodeName:    opts.nodeName,
+		NonceScope:  opts.nonceScope,
+		NonceWindow: opts.nonceWindow,
+	}
+	// Registered before serving so an early SIGHUP reloads instead of
+	// terminating the process.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	// Background workers outlive the signal: they stop once requests have
+	// drained, not when it arrives.
+	workCtx, stopWork := context.WithCancel(context.Background())
+	defer stopWork()
 
+	expectedMigrations, err := migrations.Load()
+	if err != nil {
+		fatalf("load embedded migrations: %v", err)
+	}
 	rt, err := platform.BuildPhase1Runtime(ctx, dbCfg, secCfg)
 	if err != nil {
 		fatalf("build runtime: %v", err)
 	}
 	defer rt.Close()
 
-	if err := rt.HealthCheck(ctx, *healthTimeout); err != nil {
+	if err := rt.HealthCheck(ctx, opts.healthTimeout); err != nil {
 		fatalf("startup health check: %v", err)
 	}
 
-	logServeStartup(dbCfg, serveSecCfg, *host, *port, *healthTimeout, *writeTimeout, *idempotencyTTL)
+	logServeStartup(dbCfg, serveSecCfg, tlsCfg, opts.host, opts.port, opts.healthTimeout, opts.writeTimeout, opts.idempotencyTTL)
+	slog.Info("tracing", "exporter", traceCfg.Exporter, "sample_ratio", traceCfg.SampleRatio)
 
 	mux := http.NewServeMux()
-	api := newHTTPAPI(rt, *healthTimeout, *writeTimeout, *idempotencyTTL, serveSecCfg)
+	api, err := newHTTPAPI(rt, opts.healthTimeout, opts.writeTimeout, serveSecCfg)
+	if err != nil {
+		fatalf("build api: %v", err)
+	}
+	api.migrations = expectedMigrations
+	idempotencyKeys, err := idempotency.NewStore(rt.DB, idempotency.Config{TTL: opts.idempotencyTTL, Lease: opts.idempotencyLease})
+	if err != nil {
+		fatalf("build idempotency store: %v", err)
+	}
+	api.idempotencyKeys = idempotencyKeys
+	api.idempotencyFingerprint = opts.fingerprint
+	if serveSecCfg.OIDC.Enabled() {
+		api.oidc, err = buildOIDCVerifier(serveSecCfg.OIDC)
+		if err != nil {
+			fatalf("load oidc issuer: %v", err)
+		}
+	}
+	if opts.maintenance {
+		scheduler, err := startMaintenance(workCtx, rt, opts.nodeName, opts.jobs)
+		if err != nil {
+			fatalf("start maintenance: %v", err)
+		}
+		api.scheduler = scheduler
+		api.metrics.observeScheduler(scheduler)
+	}
+	var dispatcher *outbox.Dispatcher
+	if opts.outbox.Enabled {
+		dispatcher, err = startOutbox(workCtx, rt.DB, opts.outbox)
+		if err != nil {
+			fatalf("start outbox: %v", err)
+		}
+	}
+	if api.quotas != nil {
+		go api.usage.run(workCtx, api.quotas, serveSecCfg.Quotas.FlushInterval)
+	}
+	if serveSecCfg.Abuse.Enabled {
+		api.startThreatDecay(workCtx)
+	}
 	mux.HandleFunc("/livez", api.handleLiveness)
 	mux.HandleFunc("/healthz", api.handleHealthz)
 	mux.HandleFunc("/readyz", api.handleReadyz)
-	mux.HandleFunc("/v1/decisions", api.handleDecisionWrite)
-	mux.HandleFunc("/v1/telemetry/events", api.handleTelemetryWrite)
+	mux.HandleFunc("/.well-known/jwks.json", api.handleJWKS)
+	mux.HandleFunc("/openapi.json", api.handleOpenAPI)
+	mux.Handle("/v1/decisions", api.writeRoute("v1/decisions", scopeDecisionsWrite, maxWriteBody, api.handleDecisionWrite))
+	mux.Handle("/v1/telemetry/events", api.writeRoute("v1/telemetry/events", scopeTelemetryWrite, maxWriteBody, api.handleTelemetryWrite))
+	mux.Handle("/v1/telemetry/events:batch", api.writeRoute("v1/telemetry/events:batch", scopeTelemetryWrite, maxTelemetryBatchBody, api.handleTelemetryBatch))
+	mux.HandleFunc("/v1/security/threat-levels", api.handleThreatLevelHistory)
+	mux.HandleFunc("/v1/security/anomaly-reports", api.handleAnomalyReports)
+	mux.HandleFunc("/v1/security/anomaly-reports/{id}", api.handleAnomalyReport)
+	mux.HandleFunc("/v1/usage", api.handleUsage)
+	// The admin listener, when enabled, takes over the operational routes so
+	// they are not reachable on the public port.
+	if !opts.admin.Enabled() {
+		mux.HandleFunc("/v1/admin/maintenance", api.handleMaintenanceStatus)
+		mux.HandleFunc("/v1/admin/health", api.handleHealthDetails)
+		mux.HandleFunc("/v1/admin/idempotency-keys/{key}", api.handleIdempotencyKey)
+		if serveSecCfg.Metrics.Enabled {
+			mux.HandleFunc("/metrics", api.handleMetrics)
+		}
+	}
 	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("asymm-db-vedicq-runtime"))
 	})
 
-	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	addr := net.JoinHostPort(opts.host, strconv.Itoa(opts.port))
 	server := &http.Server{
 		Addr:              addr,
-		Handler:           withServeMiddlewares(mux, serveSecCfg),
+		Handler:           withAccessLog(withRequestMetrics(withServeMiddlewares(withTracing(mux, tracer), api.liveSecurity), api.metrics), slog.Default(), logCfg.AccessLog, serveSecCfg.TrustProxyHeaders),
 		ReadHeaderTimeout: 5 * time.Second,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	if tlsCfg.Enabled() {
+		reloader, err := newCertReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+		if err != nil {
+			fatalf("load tls certificate: %v", err)
+		}
+		server.TLSConfig, err = buildServerTLSConfig(tlsCfg, reloader)
+		if err != nil {
+			fatalf("build tls config: %v", err)
+		}
+		go reloader.watch(workCtx, tlsCfg.ReloadInterval)
 	}
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 3)
 	go func() {
+		if tlsCfg.Enabled() {
+			errCh <- server.ListenAndServeTLS("", "")
+			return
+		}
 		errCh <- server.ListenAndServe()
 	}()
-	fmt.Fprintf(os.Stdout, "platform_runtime: serving on %s\n", addr)
+	slog.Info("serving", "addr", addr, "tls", tlsCfg.Enabled())
+	var adminServer *http.Server
+	if opts.admin.Enabled() {
+		adminAddr := net.JoinHostPort(opts.admin.Host, strconv.Itoa(opts.admin.Port))
+		adminServer = &http.Server{
+			Addr:              adminAddr,
+			Handler:           withAccessLog(newAdminHandler(api, opts), slog.Default(), logCfg.AccessLog, false),
+			ReadHeaderTimeout: 5 * time.Second,
+			ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		}
+		go func() {
+			errCh <- adminServer.ListenAndServe()
+		}()
+		slog.Info("serving admin", "addr", adminAddr, "pprof", opts.admin.Pprof)
+	}
+	go reloadOnSignal(ctx, hup, opts.configFile, api)
+
+	var grpcServer *grpc.Server
+	if opts.grpcPort > 0 {
+		var creds credentials.TransportCredentials
+		if server.TLSConfig != nil {
+			creds = credentials.NewTLS(server.TLSConfig)
+		}
+		grpcServer = newGRPCServer(&grpcAPI{api: api, tracer: tracer, logger: slog.Default(), accessLog: logCfg.AccessLog}, creds)
+		grpcAddr := net.JoinHostPort(opts.host, strconv.Itoa(opts.grpcPort))
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			fatalf("grpc listen: %v", err)
+		}
+		go func() {
+			errCh <- grpcServer.Serve(lis)
+		}()
+		slog.Info("serving grpc", "addr", grpcAddr, "tls", creds != nil)
+	}
 
 	select {
 	case <-ctx.Done():
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		// Drain: fail readiness so load balancers stop routing here, keep
+		// serving for the drain delay, then let in-flight requests finish.
+		// A second signal exits at once.
+		stop()
+		api.draining.Store(true)
+		server.SetKeepAlivesEnabled(false)
+		slog.Info("draining", "drain_delay", opts.drainDelay.String())
+		time.Sleep(opts.drainDelay)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
 		defer cancel()
+		if grpcServer != nil {
+			stopGRPCServer(shutdownCtx, grpcServer)
+		}
 		if err := server.Shutdown(shutdownCtx); err != nil {
-			fatalf("graceful shutdown: %v", err)
+			// Keep going: jobs, the outbox and usage counters still need to
+			// stop cleanly before the pool closes.
+			slog.Warn("requests cut off at shutdown", "error", err)
+			_ = server.Close()
+		}
+		// Background work gets its own budget; the listeners may have spent
+		// all of shutdownCtx.
+		workCtx, cancelWork := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+		defer cancelWork()
+		if api.scheduler != nil {
+			if err := api.scheduler.Stop(workCtx); err != nil {
+				slog.Warn("maintenance jobs cancelled at shutdown", "error", err)
+			}
+		}
+		if dispatcher != nil {
+			if err := dispatcher.Stop(workCtx); err != nil {
+				slog.Warn("outbox deliveries cancelled at shutdown", "error", err)
+			}
+		}
+		stopWork()
+		if err := api.waitForAbuseWork(workCtx); err != nil {
+			slog.Warn("abuse responses cancelled at shutdown", "error", err)
 		}
-		fmt.Fprintln(os.Stdout, "platform_runtime: shutdown complete")
+		api.flushUsage(workCtx)
+		if adminServer != nil {
+			if err := adminServer.Shutdown(workCtx); err != nil {
+				slog.Warn("admin listener shutdown failed", "error", err)
+			}
+		}
+		if err := tracer.Shutdown(workCtx); err != nil {
+			slog.Warn("trace exporter shutdown failed", "error", err)
+		}
+		// The deferred rt.Close closes the pool after everything above.
+		slog.Info("shutdown complete")
 	case err := <-errCh:
 		if err != nil && err != http.ErrServerClosed {
-			fatalf("http server: %v", err)
+			fatalf("serve: %v", err)
+		}
+	}
+}
+
+// serveOptions is the serve configuration after flags, env and the config
+// file have been merged and validated.
+type serveOptions struct {
+	configFile       string
+	host             string
+	port             int
+	grpcPort         int
+	nodeName         string
+	nonceScope       string
+	nonceWindow      uint64
+	healthTimeout    time.Duration
+	writeTimeout     time.Duration
+	idempotencyTTL   time.Duration
+	idempotencyLease time.Duration
+	fingerprint      idempotency.Fingerprint
+	shutdownTimeout  time.Duration
+	drainDelay       time.Duration
+	tls              serveTLSConfig
+	db               dbpkg.Config
+	security         serveSecurityConfig
+	maintenance      bool
+	jobs             platform.MaintenanceConfig
+	admin            adminListenerConfig
+	outbox           outboxConfig
+}
+
+// loadServeOptions parses the serve flags and merges them with env and the
+// config file: a flag wins over env, env wins over the file.
+func loadServeOptions(args []string) (serveOptions, error) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configFile := fs.String("config", "", "YAML or JSON config file (default $RUNTIME_CONFIG_FILE)")
+	host := fs.String("host", getEnvOrDefault("HOST", "0.0.0.0"), "http bind host")
+	port := fs.Int("port", getEnvOrDefaultInt("PORT", 8080), "http bind port")
+	grpcPort := fs.Int("grpc-port", getEnvOrDefaultInt("GRPC_PORT", 0), "grpc bind port (0 disables the gRPC API)")
+	nodeName := fs.String("node-name", "platform-node", "node name for nonce watermark scope")
+	nonceScope := fs.String("nonce-scope", "default", "nonce scope")
+	nonceWindow := fs.Uint64("nonce-window", 1000, "nonce reservation window")
+	healthTimeout := fs.Duration("health-timeout", 5*time.Second, "database health check timeout")
+	writeTimeout := fs.Duration("write-timeout", 8*time.Second, "api write timeout")
+	idempotencyTTL := fs.Duration("idempotency-ttl", 24*time.Hour, "idempotency key retention window")
+	idempotencyLease := fs.Duration("idempotency-lease", idempotency.DefaultLease, "how long an unfinished idempotency key blocks retries before a retry may take it over")
+	idempotencyFingerprint := fs.String("idempotency-fingerprint", idempotency.DefaultFingerprint.String(), "request parts an idempotency key is bound to: method, path, body, credential")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "graceful shutdown timeout, after the drain delay")
+	drainDelay := fs.Duration("drain-delay", 5*time.Second, "how long /readyz fails before shutdown starts, so load balancers stop routing here")
+	tlsCert := fs.String("tls-cert", getEnvOrDefault("RUNTIME_TLS_CERT_FILE", ""), "tls certificate PEM file (enables https)")
+	tlsKey := fs.String("tls-key", getEnvOrDefault("RUNTIME_TLS_KEY_FILE", ""), "tls private key PEM file")
+	tlsClientCA := fs.String("tls-client-ca", getEnvOrDefault("RUNTIME_TLS_CLIENT_CA_FILE", ""), "client certificate CA bundle PEM file")
+	tlsClientAuth := fs.String("tls-client-auth", getEnvOrDefault("RUNTIME_TLS_CLIENT_AUTH", tlsClientAuthNone), "client certificate mode: none, optional, require")
+	tlsReloadInterval := fs.Duration("tls-reload-interval", 30*time.Second, "tls certificate file change poll interval")
+	maintenance := fs.Bool("maintenance", true, "run background maintenance jobs")
+	maintenanceCleanup := fs.Duration("maintenance-cleanup-interval", 10*time.Minute, "expired revocation/nonce/idempotency cleanup interval")
+	maintenanceRefresh := fs.Duration("maintenance-refresh-interval", 15*time.Minute, "materialized view refresh interval")
+	maintenanceTimeout := fs.Duration("maintenance-job-timeout", 5*time.Minute, "per-run maintenance job timeout")
+	maintenanceRetention := fs.Duration("maintenance-run-retention", 7*24*time.Hour, "how long maintenance run history is kept (0 keeps it forever)")
+	outboxRetention := fs.Duration("maintenance-outbox-retention", 7*24*time.Hour, "how long processed and failed outbox messages are kept (0 keeps them forever)")
+	adminHost := fs.String("admin-host", defaultAdminHost, "admin listener bind host (default $RUNTIME_ADMIN_HOST)")
+	adminPort := fs.Int("admin-port", 0, "admin listener port for metrics, health, jobs, config and pprof (0 disables; default $RUNTIME_ADMIN_PORT)")
+	outboxFlags := registerOutboxFlags(fs, false)
+	_ = fs.Parse(args)
+
+	opts := serveOptions{configFile: configFilePath(*configFile)}
+	file, err := loadRuntimeConfigFile(opts.configFile)
+	if err != nil {
+		return serveOptions{}, err
+	}
+	if err := file.Jobs.applyFlags(fs); err != nil {
+		return serveOptions{}, err
+	}
+
+	if *idempotencyLease <= *writeTimeout {
+		return serveOptions{}, fmt.Errorf("invalid --idempotency-lease: must exceed --write-timeout (%s)", writeTimeout.String())
+	}
+	opts.fingerprint, err = idempotency.ParseFingerprint(*idempotencyFingerprint)
+	if err != nil {
+		return serveOptions{}, fmt.Errorf("invalid --idempotency-fingerprint: %w", err)
+	}
+	if *drainDelay < 0 {
+		return serveOptions{}, fmt.Errorf("invalid --drain-delay: must be >= 0")
+	}
+	if *maintenanceCleanup <= 0 || *maintenanceRefresh <= 0 || *maintenanceTimeout <= 0 {
+		return serveOptions{}, fmt.Errorf("invalid maintenance schedule: intervals and job timeout must be > 0")
+	}
+
+	opts.tls = serveTLSConfig{
+		CertFile:       *tlsCert,
+		KeyFile:        *tlsKey,
+		ClientCAFile:   *tlsClientCA,
+		ClientAuth:     *tlsClientAuth,
+		ReloadInterval: *tlsReloadInterval,
+	}
+	if err := opts.tls.Validate(); err != nil {
+		return serveOptions{}, fmt.Errorf("load tls config: %w", err)
+	}
+	opts.db, err = loadDBConfig(file.Database)
+	if err != nil {
+		return serveOptions{}, fmt.Errorf("load db config: %w", err)
+	}
+	opts.security, err = loadServeSecurityConfig(file.Security)
+	if err != nil {
+		return serveOptions{}, fmt.Errorf("load runtime security config: %w", err)
+	}
+	opts.admin, err = loadAdminListenerConfig(file.Admin)
+	if err != nil {
+		return serveOptions{}, fmt.Errorf("load admin listener config: %w", err)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "admin-host":
+			opts.admin.Host = *adminHost
+		case "admin-port":
+			opts.admin.Port = *adminPort
 		}
+	})
+	if err := opts.admin.Validate(); err != nil {
+		return serveOptions{}, fmt.Errorf("load admin listener config: %w", err)
+	}
+	if opts.admin.Enabled() && (opts.admin.Port == *port || opts.admin.Port == *grpcPort) {
+		return serveOptions{}, fmt.Errorf("invalid --admin-port: must differ from --port and --grpc-port")
+	}
+
+	opts.host, opts.port, opts.grpcPort = *host, *port, *grpcPort
+	opts.nodeName, opts.nonceScope, opts.nonceWindow = *nodeName, *nonceScope, *nonceWindow
+	opts.healthTimeout, opts.writeTimeout, opts.shutdownTimeout = *healthTimeout, *writeTimeout, *shutdownTimeout
+	opts.drainDelay = *drainDelay
+	opts.idempotencyTTL, opts.idempotencyLease = *idempotencyTTL, *idempotencyLease
+	opts.maintenance = *maintenance
+	opts.outbox, err = outboxFlags.load(*nodeName)
+	if err != nil {
+		return serveOptions{}, err
+	}
+	opts.jobs = platform.MaintenanceConfig{
+		CleanupInterval: *maintenanceCleanup,
+		RefreshInterval: *maintenanceRefresh,
+		JobTimeout:      *maintenanceTimeout,
+		RunRetention:    *maintenanceRetention,
+		OutboxRetention: *outboxRetention,
+	}
+	if opts.jobs.RunRetention < 0 {
+		return serveOptions{}, fmt.Errorf("invalid --maintenance-run-retention: must be >= 0")
 	}
+	if opts.jobs.OutboxRetention < 0 {
+		return serveOptions{}, fmt.Errorf("invalid --maintenance-outbox-retention: must be >= 0")
+	}
+	return opts, nil
+}
+
+// configCmd runs "config check": it merges and validates the serve
+// configuration like serve does, without connecting to anything.
+func configCmd(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		usage()
+		os.Exit(2)
+	}
+	setupLogging()
+	if _, err := loadRuntimeTraceConfigFromEnv(); err != nil {
+		fatalf("load trace config: %v", err)
+	}
+	opts, err := loadServeOptions(args[1:])
+	if err != nil {
+		fatalf("load config: %v", err)
+	}
+	logServeStartup(opts.db, opts.security, opts.tls, opts.host, opts.port, opts.healthTimeout, opts.writeTimeout, opts.idempotencyTTL)
+	fmt.Println("config ok")
+}
+
+func startMaintenance(ctx context.Context, rt *platform.Runtime, nodeName string, cfg platform.MaintenanceConfig) (*platform.Scheduler, error) {
+	scheduler, err := platform.NewScheduler(rt.DB, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range rt.MaintenanceJobs(cfg) {
+		if err := scheduler.Register(job); err != nil {
+			return nil, err
+		}
+	}
+	scheduler.OnError(func(job string, err error) {
+		slog.Error("maintenance job failed", "job", job, "node_name", nodeName, "error", err)
+	})
+	scheduler.Start(ctx)
+	slog.Info(
+		"maintenance started",
+		"jobs", len(scheduler.Status()),
+		"cleanup_interval", cfg.CleanupInterval.String(),
+		"refresh_interval", cfg.RefreshInterval.String(),
+	)
+	return scheduler, nil
 }
 
 func getEnvOrDefault(name, fallback string) string {
@@ -174,13 +537,25 @@ func getEnvOrDefaultInt(name string, fallback int) int {
 }
 
 func fatalf(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	slog.Error(fmt.Sprintf(format, args...))
 	os.Exit(1)
 }
 
+// setupLogging installs the process-wide slog logger from RUNTIME_LOG_*.
+func setupLogging() runtimeLogConfig {
+	cfg, err := loadRuntimeLogConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load log config: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stderr, cfg.Logger).With("service", "platform_runtime"))
+	return cfg
+}
+
 func logServeStartup(
 	dbCfg dbpkg.Config,
 	serveSecCfg serveSecurityConfig,
+	tlsCfg serveTLSConfig,
 	host string,
 	port int,
 	healthTimeout time.Duration,
@@ -192,29 +567,49 @@ func logServeStartup(
 	if err == nil && u != nil && u.Hostname() != "" {
 		dbHost = u.Hostname()
 	}
-	fmt.Fprintf(
-		os.Stdout,
-		"platform_runtime: startup mode=serve host=%s port=%d db_driver=%s db_host=%s max_open=%d max_idle=%d stmt_timeout_ms=%d health_timeout=%s write_timeout=%s idempotency_ttl=%s\n",
-		host,
-		port,
-		dbCfg.DriverName,
-		dbHost,
-		dbCfg.MaxOpenConns,
-		dbCfg.MaxIdleConns,
-		dbCfg.StatementTimeoutMS,
-		// Security posture is safe to log as booleans/counts only.
-		healthTimeout.String(),
-		writeTimeout.String(),
-		idempotencyTTL.String(),
+	slog.Info(
+		"startup",
+		"mode", "serve",
+		"host", host,
+		"port", port,
+		"db_driver", dbCfg.DriverName,
+		"db_host", dbHost,
+		"max_open", dbCfg.MaxOpenConns,
+		"max_idle", dbCfg.MaxIdleConns,
+		"stmt_timeout_ms", dbCfg.StatementTimeoutMS,
+		"health_timeout", healthTimeout.String(),
+		"write_timeout", writeTimeout.String(),
+		"idempotency_ttl", idempotencyTTL.String(),
 	)
-	fmt.Fprintf(
-		os.Stdout,
-		"platform_runtime: security require_auth=%t token_count=%d allowed_origins=%d rate_limit_per_min=%d rate_limit_burst=%d trust_proxy_headers=%t\n",
-		serveSecCfg.RequireAuth,
-		len(serveSecCfg.AllowedTokens),
-		len(serveSecCfg.AllowedOrigins),
-		serveSecCfg.RateLimitPerMinute,
-		serveSecCfg.RateLimitBurst,
-		serveSecCfg.TrustProxyHeaders,
+	// Security posture is safe to log as booleans/counts only.
+	slog.Info(
+		"security posture",
+		"require_auth", serveSecCfg.RequireAuth,
+		"token_count", len(serveSecCfg.AllowedTokens),
+		"db_credentials", serveSecCfg.DBCredentials,
+		"allowed_origins", len(serveSecCfg.AllowedOrigins),
+		"rate_limit_per_min", serveSecCfg.RateLimitPerMinute,
+		"rate_limit_burst", serveSecCfg.RateLimitBurst,
+		"rate_limit_key", serveSecCfg.RateLimitKey,
+		"rate_limit_store", serveSecCfg.RateLimitStore,
+		"trust_proxy_headers", serveSecCfg.TrustProxyHeaders,
+		"require_request_signing", serveSecCfg.RequireRequestSigning,
+		"tls", tlsCfg.Enabled(),
+		"tls_client_auth", tlsCfg.ClientAuth,
+		"mtls_principals", len(serveSecCfg.MTLSPrincipals),
+		"metrics", serveSecCfg.Metrics.Enabled,
+		"metrics_token_set", serveSecCfg.Metrics.Token != "",
+		"abuse_detection", serveSecCfg.Abuse.Enabled,
+		"abuse_window", serveSecCfg.Abuse.Window.String(),
+		"abuse_ip_threshold", serveSecCfg.Abuse.IPThreshold,
+		"abuse_credential_threshold", serveSecCfg.Abuse.CredentialThreshold,
+		"abuse_session_threshold", serveSecCfg.Abuse.SessionThreshold,
+		"abuse_rate_limit_threshold", serveSecCfg.Abuse.RateLimitThreshold,
+		"abuse_revoke_for", serveSecCfg.Abuse.RevokeFor.String(),
+		"oidc", serveSecCfg.OIDC.Enabled(),
+		"oidc_issuer", serveSecCfg.OIDC.Issuer,
+		"oidc_jit_provision", serveSecCfg.OIDC.JITProvision,
+		"quotas", serveSecCfg.Quotas.Enabled,
+		"quota_cache_ttl", serveSecCfg.Quotas.CacheTTL.String(),
 	)
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+)
+
+const scopeOpsAdmin = "ops:admin"
+
+// handleMaintenanceStatus reports this replica's job status plus recent runs
+// recorded by any replica.
+func (a *httpAPI) handleMaintenanceStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, err := a.authorizeAndRateLimit(w, r, "v1/admin/maintenance", scopeOpsAdmin); err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	if !validateRequest(w, r, nil) {
+		return
+	}
+	a.writeMaintenanceStatus(w, r)
+}
+
+// writeMaintenanceStatus serves an already authorized and validated status
+// request; the admin listener shares it.
+func (a *httpAPI) writeMaintenanceStatus(w http.ResponseWriter, r *http.Request) {
+	if a.scheduler == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "maintenance scheduler disabled")
+		return
+	}
+	q := r.URL.Query()
+	limit := 0
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), a.writeTimeout)
+	defer cancel()
+	runs, err := a.scheduler.ListRuns(ctx, q.Get("job"), limit)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to load maintenance runs")
+		return
+	}
+	resp := runtimeapi.MaintenanceStatus{
+		NodeName:   a.scheduler.NodeName(),
+		Jobs:       make([]runtimeapi.MaintenanceJobStatus, 0),
+		RecentRuns: make([]runtimeapi.MaintenanceRun, 0, len(runs)),
+	}
+	for _, job := range a.scheduler.Status() {
+		resp.Jobs = append(resp.Jobs, runtimeapi.MaintenanceJobStatus(job))
+	}
+	for _, run := range runs {
+		resp.RecentRuns = append(resp.RecentRuns, runtimeapi.MaintenanceRun(run))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+	metrics "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/metrics"
+	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
+)
+
+// metricsConfig controls the /metrics endpoint (RUNTIME_METRICS*).
+type metricsConfig struct {
+	Enabled bool
+	Token   string
+}
+
+// runtimeMetrics holds the runtime's metric families. A nil *runtimeMetrics
+// records nothing, so handlers can be exercised without a registry.
+type runtimeMetrics struct {
+	registry        *metrics.Registry
+	httpRequests    *metrics.CounterVec
+	httpDuration    *metrics.HistogramVec
+	grpcRequests    *metrics.CounterVec
+	grpcDuration    *metrics.HistogramVec
+	authFailures    *metrics.CounterVec
+	rateLimited     *metrics.CounterVec
+	quotaRejections *metrics.CounterVec
+	idempotency     *metrics.CounterVec
+	repoWrites      *metrics.HistogramVec
+}
+
+func newRuntimeMetrics(rt *platform.Runtime) *runtimeMetrics {
+	reg := metrics.NewRegistry()
+	m := &runtimeMetrics{
+		registry: reg,
+		httpRequests: reg.NewCounterVec("http_requests_total",
+			"HTTP requests by method, route pattern and status code.", "method", "route", "status"),
+		httpDuration: reg.NewHistogramVec("http_request_duration_seconds",
+			"HTTP request latency by method and route pattern.", metrics.DefBuckets, "method", "route"),
+		grpcRequests: reg.NewCounterVec("grpc_requests_total",
+			"gRPC calls by full method name and status code.", "method", "code"),
+		grpcDuration: reg.NewHistogramVec("grpc_request_duration_seconds",
+			"gRPC call latency by full method name.", metrics.DefBuckets, "method"),
+		authFailures: reg.NewCounterVec("runtime_auth_failures_total",
+			"Rejected authentication or authorization attempts by reason.", "reason"),
+		rateLimited: reg.NewCounterVec("runtime_rate_limit_rejections_total",
+			"Requests rejected by the token bucket limiter, by route scope and policy (global or plan).", "scope", "policy"),
+		quotaRejections: reg.NewCounterVec("runtime_quota_rejections_total",
+			"Requests rejected because a tenant's daily quota is spent.", "scope", "quota"),
+		idempotency: reg.NewCounterVec("runtime_idempotency_requests_total",
+			"Idempotency-Key hits by route scope and outcome (replay, in_progress, conflict, recovered).", "scope", "outcome"),
+		repoWrites: reg.NewHistogramVec("runtime_repository_write_duration_seconds",
+			"Repository write latency by operation and outcome.", metrics.DefBuckets, "operation", "outcome"),
+	}
+	if rt != nil && rt.DB != nil {
+		metrics.RegisterDBStats(reg, rt.DB)
+		rt.ObserveWrites(m.observeWrite)
+	}
+	return m
+}
+
+// observeScheduler exposes maintenance job outcomes from the scheduler's status.
+func (m *runtimeMetrics) observeScheduler(s *platform.Scheduler) {
+	if m == nil || s == nil {
+		return
+	}
+	m.registry.NewFunc("maintenance_job_runs_total",
+		"Maintenance job runs on this replica by job and status.", metrics.TypeCounter, []string{"job", "status"},
+		func() []metrics.Sample {
+			var out []metrics.Sample
+			for _, st := range s.Status() {
+				out = append(out,
+					metrics.Sample{LabelValues: []string{st.Name, platform.MaintenanceRunCompleted}, Value: float64(st.RunCount - st.FailureCount)},
+					metrics.Sample{LabelValues: []string{st.Name, platform.MaintenanceRunFailed}, Value: float64(st.FailureCount)},
+					metrics.Sample{LabelValues: []string{st.Name, platform.MaintenanceRunSkipped}, Value: float64(st.SkipCount)},
+				)
+			}
+			return out
+		})
+	m.registry.NewFunc("maintenance_job_last_duration_seconds",
+		"Duration of the last maintenance run this replica executed.", metrics.TypeGauge, []string{"job"},
+		func() []metrics.Sample {
+			var out []metrics.Sample
+			for _, st := range s.Status() {
+				if st.LastStartedAt == nil || st.LastFinishedAt == nil || st.LastFinishedAt.Before(*st.LastStartedAt) {
+					continue
+				}
+				out = append(out, metrics.Sample{LabelValues: []string{st.Name}, Value: st.LastFinishedAt.Sub(*st.LastStartedAt).Seconds()})
+			}
+			return out
+		})
+}
+
+func (m *runtimeMetrics) observeRequest(method, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	method = metricMethod(method)
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+func (m *runtimeMetrics) observeGRPC(method, code string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+func (m *runtimeMetrics) authFailure(reason string) {
+	if m == nil {
+		return
+	}
+	m.authFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *runtimeMetrics) rateLimitRejected(scope, policy string) {
+	if m == nil {
+		return
+	}
+	m.rateLimited.WithLabelValues(scope, policy).Inc()
+}
+
+func (m *runtimeMetrics) quotaRejected(scope, quota string) {
+	if m == nil {
+		return
+	}
+	m.quotaRejections.WithLabelValues(scope, quota).Inc()
+}
+
+func (m *runtimeMetrics) idempotencyHit(scope string, outcome idempotency.Outcome) {
+	if m == nil {
+		return
+	}
+	m.idempotency.WithLabelValues(scope, string(outcome)).Inc()
+}
+
+func (m *runtimeMetrics) observeWrite(operation string, d time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.repoWrites.WithLabelValues(operation, outcome).Observe(d.Seconds())
+}
+
+// authFailureReason maps auth errors onto a bounded label set.
+func authFailureReason(err error) string {
+	switch {
+	case errors.Is(err, errMissingCredentials):
+		return "missing_credentials"
+	case errors.Is(err, errInvalidCredentials):
+		return "invalid_credentials"
+	case errors.Is(err, errRevokedCredentials):
+		return "revoked"
+	case errors.Is(err, errInsufficientScope):
+		return "insufficient_scope"
+	case errors.Is(err, errNoTenantMembership):
+		return "no_tenant_membership"
+	case errors.Is(err, errAuthUnavailable):
+		return "unavailable"
+	case errors.Is(err, errClientBlocked):
+		return "client_blocked"
+	case errors.Is(err, errSignatureRequired), errors.Is(err, errInvalidSignature):
+		return "invalid_signature"
+	default:
+		return "other"
+	}
+}
+
+// metricMethod bounds the method label to standard verbs.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// withRequestMetrics counts requests and latency per method, route pattern and status.
+func withRequestMetrics(next http.Handler, m *runtimeMetrics) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		m.observeRequest(r.Method, route, status, time.Since(start))
+	})
+}
+
+// handleMetrics serves the registry; with RUNTIME_METRICS_TOKEN set, scrapes
+// must present it as a bearer token.
+func (a *httpAPI) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if a.metrics == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "metrics disabled")
+		return
+	}
+	if token := a.securityCfg.Metrics.Token; token != "" {
+		if subtle.ConstantTimeCompare([]byte(extractAuthToken(r)), []byte(token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, errInvalidCredentials.Error())
+			return
+		}
+	}
+	a.metrics.registry.Handler().ServeHTTP(w, r)
+}
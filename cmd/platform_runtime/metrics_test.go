@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	idempotency "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/idempotency"
+)
+
+func TestRequestMetricsUseRoutePattern(t *testing.T) {
+	m := newRuntimeMetrics(nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/security/anomaly-reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONError(w, http.StatusNotFound, "anomaly report not found")
+	})
+	h := withRequestMetrics(mux, m)
+	for _, id := range []string{"a", "b"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/security/anomaly-reports/"+id, nil))
+	}
+	m.authFailure(authFailureReason(fmt.Errorf("wrap: %w", errRevokedCredentials)))
+
+	api := &httpAPI{metrics: m}
+	rec := httptest.NewRecorder()
+	api.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/v1/security/anomaly-reports/{id}",status="404"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/v1/security/anomaly-reports/{id}"} 2`,
+		`runtime_auth_failures_total{reason="revoked"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in exposition:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsTokenRequired(t *testing.T) {
+	api := &httpAPI{metrics: newRuntimeMetrics(nil), securityCfg: serveSecurityConfig{Metrics: metricsConfig{Enabled: true, Token: "scrape-token"}}}
+	rec := httptest.NewRecorder()
+	api.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer scrape-token")
+	rec = httptest.NewRecorder()
+	api.handleMetrics(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with token, got %d", rec.Code)
+	}
+}
+
+func TestNilRuntimeMetricsIsNoop(t *testing.T) {
+	var m *runtimeMetrics
+	m.authFailure("other")
+	m.rateLimitRejected("v1/decisions", "global")
+	m.idempotencyHit("v1/decisions", idempotency.OutcomeReplay)
+	m.observeScheduler(nil)
+}
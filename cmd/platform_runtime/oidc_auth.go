@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+const headerTenantID = "X-Tenant-ID"
+
+var errNoTenantMembership = errors.New("federated user has no active membership in the requested tenant")
+
+// serveOIDCConfig configures the external OpenID Connect issuer.
+type serveOIDCConfig struct {
+	Issuer       string
+	Audiences    []string
+	Provider     string // control_plane.identities.provider; defaults to Issuer
+	JWKSFile     string
+	JWKSURL      string
+	JWKSRefresh  time.Duration
+	ClockSkew    time.Duration
+	JITProvision bool
+}
+
+func (c serveOIDCConfig) Enabled() bool {
+	return c.Issuer != ""
+}
+
+func (c serveOIDCConfig) Validate() error {
+	if !c.Enabled() {
+		if c.JWKSFile != "" || c.JWKSURL != "" {
+			return fmt.Errorf("runtime: RUNTIME_OIDC_JWKS_* requires RUNTIME_OIDC_ISSUER")
+		}
+		return nil
+	}
+	if len(c.Audiences) == 0 {
+		return fmt.Errorf("runtime: RUNTIME_OIDC_ISSUER requires RUNTIME_OIDC_AUDIENCE")
+	}
+	if (c.JWKSFile == "") == (c.JWKSURL == "") {
+		return fmt.Errorf("runtime: set exactly one of RUNTIME_OIDC_JWKS_FILE or RUNTIME_OIDC_JWKS_URL")
+	}
+	return nil
+}
+
+// federationStore is the control-plane subset needed to map issuer subjects to users.
+type federationStore interface {
+	LookupFederatedUser(ctx context.Context, provider, subject string) (controlplanerepo.FederatedUser, bool, error)
+	ProvisionFederatedUser(ctx context.Context, p controlplanerepo.FederatedProfile) (controlplanerepo.FederatedUser, error)
+	UserTenantIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+// buildOIDCVerifier loads the issuer JWKS from a file or prepares the URL fetcher.
+func buildOIDCVerifier(cfg serveOIDCConfig) (*securitypkg.OIDCVerifier, error) {
+	var src securitypkg.JWKSSource
+	if cfg.JWKSFile != "" {
+		static, err := securitypkg.LoadJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		src = static
+	} else {
+		remote, err := securitypkg.NewRemoteJWKS(cfg.JWKSURL, cfg.JWKSRefresh, nil)
+		if err != nil {
+			return nil, err
+		}
+		src = remote
+	}
+	return securitypkg.NewOIDCVerifier(securitypkg.OIDCConfig{
+		Issuer:    cfg.Issuer,
+		Audiences: cfg.Audiences,
+		ClockSkew: cfg.ClockSkew,
+	}, src)
+}
+
+// federatedCaller verifies an issuer token and maps (iss, sub) to a user and tenant.
+func (a *httpAPI) federatedCaller(r *http.Request, token string) (callerIdentity, error) {
+	ctx := r.Context()
+	claims, err := a.oidc.Verify(ctx, token)
+	if err != nil {
+		if errors.Is(err, securitypkg.ErrInvalidIDToken) {
+			return callerIdentity{}, errInvalidCredentials
+		}
+		return callerIdentity{}, errAuthUnavailable
+	}
+	if a.federation == nil {
+		return callerIdentity{}, errAuthUnavailable
+	}
+	provider := a.securityCfg.OIDC.Provider
+	if provider == "" {
+		provider = claims.Issuer
+	}
+	user, found, err := a.federation.LookupFederatedUser(ctx, provider, claims.Subject)
+	if err != nil {
+		return callerIdentity{}, errAuthUnavailable
+	}
+	if !found {
+		if !a.securityCfg.OIDC.JITProvision {
+			return callerIdentity{}, errInvalidCredentials
+		}
+		user, err = a.federation.ProvisionFederatedUser(ctx, controlplanerepo.FederatedProfile{
+			Provider:      provider,
+			Subject:       claims.Subject,
+			DisplayName:   claims.Name,
+			Email:         claims.Email,
+			EmailVerified: claims.EmailVerified,
+		})
+		if err != nil {
+			return callerIdentity{}, errAuthUnavailable
+		}
+	}
+	if user.Status != "active" {
+		return callerIdentity{}, errInvalidCredentials
+	}
+
+	tenants, err := a.federation.UserTenantIDs(ctx, user.UserID)
+	if err != nil {
+		return callerIdentity{}, errAuthUnavailable
+	}
+	tenantID, err := selectFederatedTenant(tenants, r.Header.Get(headerTenantID))
+	if err != nil {
+		return callerIdentity{}, err
+	}
+	caller := callerIdentity{
+		CredentialID: "user:" + user.UserID,
+		TenantID:     &tenantID,
+		Scopes:       make(map[string]struct{}, len(claims.Scopes)),
+	}
+	for _, s := range claims.Scopes {
+		// Issuer tokens never grant the operator wildcard.
+		if s != scopeWildcard {
+			caller.Scopes[s] = struct{}{}
+		}
+	}
+	return caller, nil
+}
+
+// selectFederatedTenant picks the X-Tenant-ID membership, or the only one.
+func selectFederatedTenant(tenants []string, requested string) (string, error) {
+	requested = strings.TrimSpace(requested)
+	if requested != "" {
+		for _, t := range tenants {
+			if strings.EqualFold(t, requested) {
+				return t, nil
+			}
+		}
+		return "", errNoTenantMembership
+	}
+	if len(tenants) == 1 {
+		return tenants[0], nil
+	}
+	return "", errNoTenantMembership
+}
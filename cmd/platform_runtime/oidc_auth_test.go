@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+type fakeFederationStore struct {
+	users       map[string]controlplanerepo.FederatedUser
+	tenants     map[string][]string
+	provisioned int
+}
+
+func (f *fakeFederationStore) LookupFederatedUser(_ context.Context, provider, subject string) (controlplanerepo.FederatedUser, bool, error) {
+	u, ok := f.users[provider+"|"+subject]
+	return u, ok, nil
+}
+
+func (f *fakeFederationStore) ProvisionFederatedUser(_ context.Context, p controlplanerepo.FederatedProfile) (controlplanerepo.FederatedUser, error) {
+	f.provisioned++
+	u := controlplanerepo.FederatedUser{UserID: "u-new", Status: "active", Provisioned: true}
+	f.users[p.Provider+"|"+p.Subject] = u
+	return u, nil
+}
+
+func (f *fakeFederationStore) UserTenantIDs(_ context.Context, userID string) ([]string, error) {
+	return f.tenants[userID], nil
+}
+
+func testOIDCToken(t *testing.T, key *rsa.PrivateKey, sub, scope string) string {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   "https://idp.example.com",
+		"sub":   sub,
+		"aud":   "runtime-api",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"scope": scope,
+	})
+	input := enc(header) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return input + "." + enc(sig)
+}
+
+func newOIDCTestAPI(t *testing.T, jit bool) (*httpAPI, *rsa.PrivateKey, *fakeFederationStore) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	enc := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(securitypkg.JSONWebKeySet{Keys: []securitypkg.JSONWebKey{{
+		Kty: "RSA", Kid: "k1", N: enc(key.N.Bytes()), E: enc(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	src, err := securitypkg.NewStaticJWKS(jwks)
+	if err != nil {
+		t.Fatalf("jwks: %v", err)
+	}
+	verifier, err := securitypkg.NewOIDCVerifier(securitypkg.OIDCConfig{
+		Issuer:    "https://idp.example.com",
+		Audiences: []string{"runtime-api"},
+	}, src)
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	store := &fakeFederationStore{
+		users: map[string]controlplanerepo.FederatedUser{
+			"https://idp.example.com|known": {UserID: "u-1", Status: "active"},
+			"https://idp.example.com|gone":  {UserID: "u-2", Status: "suspended"},
+		},
+		tenants: map[string][]string{"u-1": {"tenant-a"}, "u-new": {"tenant-a", "tenant-b"}},
+	}
+	api := &httpAPI{
+		securityCfg: serveSecurityConfig{RequireAuth: true, OIDC: serveOIDCConfig{Issuer: "https://idp.example.com", JITProvision: jit}},
+		oidc:        verifier,
+		federation:  store,
+	}
+	return api, key, store
+}
+
+func TestFederatedCallerKnownUser(t *testing.T) {
+	api, key, _ := newOIDCTestAPI(t, false)
+	req := httptest.NewRequest("POST", "/v1/decisions", nil)
+	req.Header.Set("Authorization", "Bearer "+testOIDCToken(t, key, "known", "decisions:write *"))
+
+	caller, err := api.authenticate(req)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if caller.CredentialID != "user:u-1" || caller.TenantID == nil || *caller.TenantID != "tenant-a" {
+		t.Fatalf("unexpected caller: %+v", caller)
+	}
+	if !caller.hasScope(scopeDecisionsWrite) || caller.hasScope(scopePoliciesAdmin) {
+		t.Fatalf("unexpected scopes: %+v", caller.Scopes)
+	}
+}
+
+func TestFederatedCallerRejectsUnknownAndSuspended(t *testing.T) {
+	api, key, store := newOIDCTestAPI(t, false)
+	for _, sub := range []string{"unknown", "gone"} {
+		req := httptest.NewRequest("POST", "/v1/decisions", nil)
+		req.Header.Set("Authorization", "Bearer "+testOIDCToken(t, key, sub, "decisions:write"))
+		if _, err := api.authenticate(req); !errors.Is(err, errInvalidCredentials) {
+			t.Fatalf("%s: expected errInvalidCredentials, got %v", sub, err)
+		}
+	}
+	if store.provisioned != 0 {
+		t.Fatalf("expected no provisioning without JIT")
+	}
+}
+
+func TestFederatedCallerJITProvisioning(t *testing.T) {
+	api, key, store := newOIDCTestAPI(t, true)
+	req := httptest.NewRequest("POST", "/v1/decisions", nil)
+	req.Header.Set("Authorization", "Bearer "+testOIDCToken(t, key, "first-login", "decisions:write"))
+	if _, err := api.authenticate(req); !errors.Is(err, errNoTenantMembership) {
+		t.Fatalf("expected tenant selection error for multi-tenant user, got %v", err)
+	}
+	req.Header.Set(headerTenantID, "tenant-b")
+	caller, err := api.authenticate(req)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if *caller.TenantID != "tenant-b" || store.provisioned != 1 {
+		t.Fatalf("unexpected caller %+v provisioned=%d", caller, store.provisioned)
+	}
+}
+
+func TestServeOIDCConfigValidate(t *testing.T) {
+	cases := []struct {
+		cfg serveOIDCConfig
+		ok  bool
+	}{
+		{serveOIDCConfig{}, true},
+		{serveOIDCConfig{JWKSFile: "jwks.json"}, false},
+		{serveOIDCConfig{Issuer: "https://idp", JWKSFile: "jwks.json"}, false},
+		{serveOIDCConfig{Issuer: "https://idp", Audiences: []string{"a"}}, false},
+		{serveOIDCConfig{Issuer: "https://idp", Audiences: []string{"a"}, JWKSFile: "f", JWKSURL: "https://idp/jwks"}, false},
+		{serveOIDCConfig{Issuer: "https://idp", Audiences: []string{"a"}, JWKSURL: "https://idp/jwks"}, true},
+	}
+	for i, tc := range cases {
+		if err := tc.cfg.Validate(); (err == nil) != tc.ok {
+			t.Fatalf("case %d: Validate() = %v, want ok=%t", i, err, tc.ok)
+		}
+	}
+}
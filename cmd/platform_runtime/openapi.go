@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+)
+
+// openAPIDocument is the runtime's OpenAPI 3 description, served at
+// /openapi.json and used to validate request bodies and parameters.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+var runtimeAPISpec = mustLoadAPISpec(openAPIDocument)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// apiSchema is the subset of OpenAPI 3.0 schema objects the validator understands.
+type apiSchema struct {
+	Ref                  string                `json:"$ref"`
+	Type                 string                `json:"type"`
+	Format               string                `json:"format"`
+	Enum                 []string              `json:"enum"`
+	Nullable             bool                  `json:"nullable"`
+	Properties           map[string]*apiSchema `json:"properties"`
+	Required             []string              `json:"required"`
+	AdditionalProperties *bool                 `json:"-"`
+	Items                *apiSchema            `json:"items"`
+	AllOf                []*apiSchema          `json:"allOf"`
+	MinLength            *int                  `json:"minLength"`
+	MaxLength            *int                  `json:"maxLength"`
+	Minimum              *float64              `json:"minimum"`
+	Maximum              *float64              `json:"maximum"`
+}
+
+func (s *apiSchema) UnmarshalJSON(b []byte) error {
+	type plain apiSchema
+	var raw struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*s = apiSchema(raw.plain)
+	// Only the boolean form is enforced; a schema value allows any property.
+	if v := bytes.TrimSpace(raw.AdditionalProperties); len(v) > 0 && v[0] != '{' {
+		var allowed bool
+		if err := json.Unmarshal(v, &allowed); err != nil {
+			return err
+		}
+		s.AdditionalProperties = &allowed
+	}
+	return nil
+}
+
+type apiParameter struct {
+	Ref      string     `json:"$ref"`
+	Name     string     `json:"name"`
+	In       string     `json:"in"`
+	Required bool       `json:"required"`
+	Schema   *apiSchema `json:"schema"`
+}
+
+type apiOperation struct {
+	Parameters  []*apiParameter `json:"parameters"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *apiSchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type apiSpec struct {
+	Paths      map[string]map[string]*apiOperation `json:"paths"`
+	Components struct {
+		Schemas    map[string]*apiSchema    `json:"schemas"`
+		Parameters map[string]*apiParameter `json:"parameters"`
+	} `json:"components"`
+}
+
+func mustLoadAPISpec(doc []byte) *apiSpec {
+	var spec apiSpec
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		panic(fmt.Sprintf("runtime: invalid openapi.json: %v", err))
+	}
+	return &spec
+}
+
+func (s *apiSpec) resolveSchema(schema *apiSchema) *apiSchema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+func (s *apiSpec) resolveParameter(p *apiParameter) *apiParameter {
+	for p != nil && p.Ref != "" {
+		p = s.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+	}
+	return p
+}
+
+// operation finds the operation for a method and concrete path, returning
+// the path parameters captured from its template.
+func (s *apiSpec) operation(method, path string) (*apiOperation, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for template, ops := range s.Paths {
+		op, ok := ops[strings.ToLower(method)]
+		if !ok {
+			continue
+		}
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		params := map[string]string{}
+		matched := true
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && segments[i] != "" {
+				params[part[1:len(part)-1]] = segments[i]
+				continue
+			}
+			if part != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return op, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// validate checks query and path parameters and, when the operation has one,
+// the JSON request body. A malformed body is reported as an error rather than
+// field errors.
+func (s *apiSpec) validate(r *http.Request, body []byte) ([]runtimeapi.FieldError, error) {
+	op, pathParams, ok := s.operation(r.Method, r.URL.Path)
+	if !ok {
+		return nil, nil
+	}
+	var errs []runtimeapi.FieldError
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		p = s.resolveParameter(p)
+		if p == nil {
+			continue
+		}
+		var raw string
+		var present bool
+		switch p.In {
+		case "query":
+			raw = strings.TrimSpace(query.Get(p.Name))
+			present = raw != ""
+		case "path":
+			raw, present = pathParams[p.Name]
+		default:
+			// Headers are checked by the handlers themselves.
+			continue
+		}
+		if !present {
+			if p.Required {
+				errs = append(errs, runtimeapi.FieldError{Field: p.Name, Message: "is required"})
+			}
+			continue
+		}
+		errs = append(errs, s.validateParam(p.Name, raw, s.resolveSchema(p.Schema))...)
+	}
+	if op.RequestBody == nil || body == nil {
+		return errs, nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return errs, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON payload")
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON payload")
+	}
+	return append(errs, s.validateValue("body", doc, media.Schema)...), nil
+}
+
+func (s *apiSpec) validateParam(name, raw string, schema *apiSchema) []runtimeapi.FieldError {
+	if schema == nil {
+		return nil
+	}
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return []runtimeapi.FieldError{{Field: name, Message: "must be an integer"}}
+		}
+		return checkNumberBounds(name, float64(n), schema)
+	case "boolean":
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return []runtimeapi.FieldError{{Field: name, Message: "must be a boolean"}}
+		}
+		return nil
+	default:
+		return checkString(name, raw, schema)
+	}
+}
+
+func (s *apiSpec) validateValue(path string, v interface{}, schema *apiSchema) []runtimeapi.FieldError {
+	schema = s.resolveSchema(schema)
+	if schema == nil {
+		return nil
+	}
+	if v == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return []runtimeapi.FieldError{{Field: path, Message: "must not be null"}}
+	}
+	var errs []runtimeapi.FieldError
+	for _, sub := range schema.AllOf {
+		errs = append(errs, s.validateValue(path, v, sub)...)
+	}
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be an object"})
+		}
+		errs = append(errs, s.validateObject(path, obj, schema)...)
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be an array"})
+		}
+		for i, item := range arr {
+			errs = append(errs, s.validateValue(fmt.Sprintf("%s[%d]", path, i), item, schema.Items)...)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be a string"})
+		}
+		errs = append(errs, checkString(path, str, schema)...)
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be an integer"})
+		}
+		i, err := n.Int64()
+		if err != nil {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be an integer"})
+		}
+		errs = append(errs, checkNumberBounds(path, float64(i), schema)...)
+	case "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be a number"})
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be a number"})
+		}
+		errs = append(errs, checkNumberBounds(path, f, schema)...)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return append(errs, runtimeapi.FieldError{Field: path, Message: "must be a boolean"})
+		}
+	}
+	return errs
+}
+
+func (s *apiSpec) validateObject(path string, obj map[string]interface{}, schema *apiSchema) []runtimeapi.FieldError {
+	var errs []runtimeapi.FieldError
+	child := func(key string) string {
+		if path == "body" {
+			return key
+		}
+		return path + "." + key
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if prop, ok := schema.Properties[k]; ok {
+			errs = append(errs, s.validateValue(child(k), obj[k], prop)...)
+			continue
+		}
+		if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+			errs = append(errs, runtimeapi.FieldError{Field: child(k), Message: "unknown field"})
+		}
+	}
+	for _, k := range schema.Required {
+		if _, ok := obj[k]; !ok {
+			errs = append(errs, runtimeapi.FieldError{Field: child(k), Message: "is required"})
+		}
+	}
+	return errs
+}
+
+func checkString(path, v string, schema *apiSchema) []runtimeapi.FieldError {
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if v == allowed {
+				return nil
+			}
+		}
+		return []runtimeapi.FieldError{{Field: path, Message: "must be one of " + strings.Join(schema.Enum, ", ")}}
+	}
+	if schema.MinLength != nil && len(strings.TrimSpace(v)) < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			return []runtimeapi.FieldError{{Field: path, Message: "must not be empty"}}
+		}
+		return []runtimeapi.FieldError{{Field: path, Message: fmt.Sprintf("must be at least %d characters", *schema.MinLength)}}
+	}
+	if schema.MaxLength != nil && len(v) > *schema.MaxLength {
+		return []runtimeapi.FieldError{{Field: path, Message: fmt.Sprintf("must be at most %d characters", *schema.MaxLength)}}
+	}
+	switch schema.Format {
+	case "uuid":
+		if !uuidPattern.MatchString(v) {
+			return []runtimeapi.FieldError{{Field: path, Message: "must be a UUID"}}
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, v); err != nil {
+			return []runtimeapi.FieldError{{Field: path, Message: "must be a YYYY-MM-DD date"}}
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return []runtimeapi.FieldError{{Field: path, Message: "must be an RFC 3339 timestamp"}}
+		}
+	}
+	return nil
+}
+
+func checkNumberBounds(path string, v float64, schema *apiSchema) []runtimeapi.FieldError {
+	if schema.Minimum != nil && v < *schema.Minimum {
+		return []runtimeapi.FieldError{{Field: path, Message: "must be >= " + strconv.FormatFloat(*schema.Minimum, 'f', -1, 64)}}
+	}
+	if schema.Maximum != nil && v > *schema.Maximum {
+		return []runtimeapi.FieldError{{Field: path, Message: "must be <= " + strconv.FormatFloat(*schema.Maximum, 'f', -1, 64)}}
+	}
+	return nil
+}
+
+// checkRequest validates an operation against the OpenAPI document. Callers
+// that do not have the original *http.Request use operationRequest.
+func checkRequest(r *http.Request, body []byte) *callError {
+	fields, err := runtimeAPISpec.validate(r, body)
+	if err != nil {
+		return newCallError(http.StatusBadRequest, runtimeapi.CodeInvalidJSON, err.Error())
+	}
+	if len(fields) > 0 {
+		e := newCallError(http.StatusBadRequest, runtimeapi.CodeValidationFailed, "request validation failed")
+		e.problem.Fields = fields
+		return e
+	}
+	return nil
+}
+
+// operationRequest is the bare request checkRequest needs for an operation.
+func operationRequest(method, path string, query url.Values) *http.Request {
+	return &http.Request{Method: method, URL: &url.URL{Path: path, RawQuery: query.Encode()}}
+}
+
+// validateRequest checks r (and body, for writes) against the OpenAPI
+// document and writes a 400 with per-field details when it does not conform.
+func validateRequest(w http.ResponseWriter, r *http.Request, body []byte) bool {
+	if cerr := checkRequest(r, body); cerr != nil {
+		writeCallError(w, cerr)
+		return false
+	}
+	return true
+}
+
+// handleOpenAPI serves the OpenAPI document; it is public like /.well-known/jwks.json.
+func (a *httpAPI) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeRawJSON(w, http.StatusOK, openAPIDocument)
+}
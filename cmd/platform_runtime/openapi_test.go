@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+)
+
+func TestOpenAPIRefsResolve(t *testing.T) {
+	var walk func(name string, s *apiSchema)
+	walk = func(name string, s *apiSchema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" && runtimeAPISpec.resolveSchema(s) == nil {
+			t.Fatalf("%s: unresolved %s", name, s.Ref)
+		}
+		for k, p := range s.Properties {
+			walk(name+"."+k, p)
+		}
+		for _, sub := range s.AllOf {
+			walk(name, sub)
+		}
+		walk(name+"[]", s.Items)
+	}
+	for name, s := range runtimeAPISpec.Components.Schemas {
+		walk(name, s)
+	}
+	for path, ops := range runtimeAPISpec.Paths {
+		for method, op := range ops {
+			for _, p := range op.Parameters {
+				if runtimeAPISpec.resolveParameter(p) == nil {
+					t.Fatalf("%s %s: unresolved parameter %s", method, path, p.Ref)
+				}
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemasMatchRequestStructs(t *testing.T) {
+	cases := map[string]interface{}{
+		"DecisionWriteRequest":  runtimeapi.DecisionWriteRequest{},
+		"DecisionTraceStep":     runtimeapi.DecisionTraceStep{},
+		"TelemetryWriteRequest": runtimeapi.TelemetryWriteRequest{},
+		"TelemetryEventLink":    runtimeapi.TelemetryEventLink{},
+	}
+	for name, v := range cases {
+		var fields []string
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			fields = append(fields, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+		var props []string
+		for k := range runtimeAPISpec.Components.Schemas[name].Properties {
+			props = append(props, k)
+		}
+		sort.Strings(fields)
+		sort.Strings(props)
+		if !reflect.DeepEqual(fields, props) {
+			t.Fatalf("%s: struct fields %v != schema properties %v", name, fields, props)
+		}
+	}
+}
+
+func TestValidateRequestReportsFieldErrors(t *testing.T) {
+	body := `{
+		"tennant_id": "a",
+		"tenant_id": "not-a-uuid",
+		"subject": "user-1",
+		"tier": "T9",
+		"action": "read",
+		"resource_ref": "doc:1",
+		"reason_code": "ok",
+		"trace": [{"step_order": 0, "rule_id": "r1", "outcome": "maybe", "reason": "x"}, {"step_order": -1, "rule_id": "r2", "outcome": "deny", "reason": "y"}],
+		"actor_type": "robot",
+		"event_type": "authz.decision",
+		"severity": null
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/decisions", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	if validateRequest(rec, req, []byte(body)) {
+		t.Fatalf("expected validation failure")
+	}
+	var resp runtimeapi.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusBadRequest || resp.Code != runtimeapi.CodeValidationFailed {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	got := map[string]string{}
+	for _, f := range resp.Fields {
+		got[f.Field] = f.Message
+	}
+	want := map[string]string{
+		"tennant_id":          "unknown field",
+		"tenant_id":           "must be a UUID",
+		"tier":                "must be one of T1, T2, T3, T4",
+		"trace[0].outcome":    "must be one of no_match, allow, deny",
+		"trace[1].step_order": "must be >= 0",
+		"actor_type":          "must be one of user, service, system",
+		"severity":            "must not be null",
+		"message":             "is required",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected field errors:\n got %v\nwant %v", got, want)
+	}
+
+	valid := `{"subject":"u","action":"a","resource_ref":"r","reason_code":"c","actor_type":"user","event_type":"e","message":"m","tier":null,"tenant_id":"7f1c2d3e-4b5a-4c6d-8e9f-0a1b2c3d4e5f"}`
+	if !validateRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/decisions", nil), []byte(valid)) {
+		t.Fatalf("expected valid decision accepted")
+	}
+	if validateRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/decisions", nil), []byte(`{"subject":`)) {
+		t.Fatalf("expected malformed JSON rejected")
+	}
+}
+
+func TestValidateRequestChecksParameters(t *testing.T) {
+	cases := map[string][]string{
+		"/v1/usage?tenant_id=abc&from=2026-13-01":                           {"tenant_id", "from"},
+		"/v1/security/anomaly-reports?status=closed&limit=0":                {"status", "limit"},
+		"/v1/security/anomaly-reports/123":                                  {"id"},
+		"/v1/security/anomaly-reports/7f1c2d3e-4b5a-4c6d-8e9f-0a1b2c3d4e5f": nil,
+	}
+	for target, want := range cases {
+		fields, err := runtimeAPISpec.validate(httptest.NewRequest(http.MethodGet, target, nil), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		var got []string
+		for _, f := range fields {
+			got = append(got, f.Field)
+		}
+		sort.Strings(got)
+		sort.Strings(want)
+		if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Fatalf("%s: got %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&httpAPI{}).handleOpenAPI(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || doc["openapi"] != "3.0.3" {
+		t.Fatalf("unexpected document %d %v", rec.Code, err)
+	}
+}
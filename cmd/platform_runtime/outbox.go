@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	outbox "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/outbox"
+)
+
+// outboxConfig is the ops.outbox dispatcher setup shared by serve and the
+// outbox command. Every event type goes to one webhook.
+type outboxConfig struct {
+	Enabled       bool
+	WebhookURL    string
+	WebhookSecret string
+	Dispatch      outbox.Config
+}
+
+// outboxFlags holds the parsed --outbox-* flags.
+type outboxFlags struct {
+	enabled      *bool
+	webhookURL   *string
+	batchSize    *int
+	pollInterval *time.Duration
+	lease        *time.Duration
+	timeout      *time.Duration
+	maxAttempts  *int
+	baseBackoff  *time.Duration
+	maxBackoff   *time.Duration
+}
+
+// registerOutboxFlags adds the --outbox-* flags to fs. enabled is the
+// default of --outbox: off for serve, on for the outbox command.
+func registerOutboxFlags(fs *flag.FlagSet, enabled bool) outboxFlags {
+	return outboxFlags{
+		enabled:      fs.Bool("outbox", enabled, "dispatch ops.outbox messages to --outbox-webhook-url"),
+		webhookURL:   fs.String("outbox-webhook-url", getEnvOrDefault("RUNTIME_OUTBOX_WEBHOOK_URL", ""), "webhook that receives outbox messages (signed with $RUNTIME_OUTBOX_WEBHOOK_SECRET when set)"),
+		batchSize:    fs.Int("outbox-batch-size", outbox.DefaultBatchSize, "messages claimed per poll"),
+		pollInterval: fs.Duration("outbox-poll-interval", outbox.DefaultPollInterval, "how often an idle dispatcher looks for due messages"),
+		lease:        fs.Duration("outbox-lease", outbox.DefaultLease, "how long a claimed message is hidden from other dispatchers"),
+		timeout:      fs.Duration("outbox-delivery-timeout", outbox.DefaultDeliveryTimeout, "per-message delivery timeout"),
+		maxAttempts:  fs.Int("outbox-max-attempts", outbox.DefaultMaxAttempts, "attempts before a message is marked failed"),
+		baseBackoff:  fs.Duration("outbox-base-backoff", outbox.DefaultBaseBackoff, "retry delay after the first failed attempt; doubles per attempt"),
+		maxBackoff:   fs.Duration("outbox-max-backoff", outbox.DefaultMaxBackoff, "longest retry delay"),
+	}
+}
+
+// load validates the parsed flags. nodeName identifies the dispatcher in
+// ops.outbox_attempts.
+func (f outboxFlags) load(nodeName string) (outboxConfig, error) {
+	cfg := outboxConfig{
+		Enabled:       *f.enabled,
+		WebhookURL:    *f.webhookURL,
+		WebhookSecret: os.Getenv("RUNTIME_OUTBOX_WEBHOOK_SECRET"),
+		Dispatch: outbox.Config{
+			ProcessorName:   nodeName,
+			BatchSize:       *f.batchSize,
+			PollInterval:    *f.pollInterval,
+			Lease:           *f.lease,
+			DeliveryTimeout: *f.timeout,
+			MaxAttempts:     *f.maxAttempts,
+			BaseBackoff:     *f.baseBackoff,
+			MaxBackoff:      *f.maxBackoff,
+		},
+	}
+	if *f.batchSize <= 0 || *f.maxAttempts <= 0 || *f.pollInterval <= 0 || *f.lease <= 0 || *f.timeout <= 0 || *f.baseBackoff <= 0 || *f.maxBackoff <= 0 {
+		return outboxConfig{}, fmt.Errorf("invalid outbox config: batch size, attempts, intervals and backoff must be > 0")
+	}
+	if !cfg.Enabled {
+		return cfg, nil
+	}
+	if cfg.WebhookURL == "" {
+		return outboxConfig{}, fmt.Errorf("invalid outbox config: --outbox needs --outbox-webhook-url")
+	}
+	if _, err := outbox.NewHTTPSink(cfg.WebhookURL, cfg.WebhookSecret, cfg.Dispatch.DeliveryTimeout); err != nil {
+		return outboxConfig{}, fmt.Errorf("invalid outbox config: %w", err)
+	}
+	if err := cfg.Dispatch.Validate(); err != nil {
+		return outboxConfig{}, fmt.Errorf("invalid outbox config: %w", err)
+	}
+	return cfg, nil
+}
+
+// startOutbox starts a dispatcher that sends every event type to the
+// configured webhook.
+func startOutbox(ctx context.Context, db *sql.DB, cfg outboxConfig) (*outbox.Dispatcher, error) {
+	dispatcher, err := outbox.NewDispatcher(db, cfg.Dispatch)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := outbox.NewHTTPSink(cfg.WebhookURL, cfg.WebhookSecret, cfg.Dispatch.DeliveryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := dispatcher.Register(outbox.AnyEventType, sink); err != nil {
+		return nil, err
+	}
+	dispatcher.OnError(func(err error) {
+		slog.Error("outbox dispatch failed", "node_name", cfg.Dispatch.ProcessorName, "error", err)
+	})
+	dispatcher.Start(ctx)
+	slog.Info(
+		"outbox dispatcher started",
+		"webhook", redactWebhookURL(cfg.WebhookURL),
+		"signed", cfg.WebhookSecret != "",
+		"poll_interval", cfg.Dispatch.PollInterval.String(),
+		"max_attempts", cfg.Dispatch.MaxAttempts,
+	)
+	return dispatcher, nil
+}
+
+// outboxCmd runs only the outbox dispatcher, for deployments that keep it
+// out of the API processes. It stops claiming on SIGINT/SIGTERM and waits up
+// to --shutdown-timeout for deliveries in flight.
+func outboxCmd(args []string) {
+	setupLogging()
+	fs := flag.NewFlagSet("outbox", flag.ExitOnError)
+	nodeName := fs.String("node-name", "platform-node", "node name recorded with each delivery attempt")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "how long deliveries in flight may finish after a signal")
+	flags := registerOutboxFlags(fs, true)
+	_ = fs.Parse(args)
+
+	cfg, err := flags.load(*nodeName)
+	if err != nil {
+		fatalf("load config: %v", err)
+	}
+	if !cfg.Enabled {
+		fatalf("load config: the outbox command needs --outbox=true")
+	}
+	dbCfg, err := dbpkg.FromEnv()
+	if err != nil {
+		fatalf("load db config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	conn, err := dbpkg.Open(ctx, dbCfg)
+	if err != nil {
+		fatalf("open database: %v", err)
+	}
+	defer conn.Close()
+
+	dispatcher, err := startOutbox(context.Background(), conn, cfg)
+	if err != nil {
+		fatalf("start outbox: %v", err)
+	}
+	<-ctx.Done()
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := dispatcher.Stop(shutdownCtx); err != nil {
+		slog.Warn("outbox deliveries cancelled at shutdown", "error", err)
+	}
+	slog.Info("shutdown complete")
+}
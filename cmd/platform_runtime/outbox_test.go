@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func loadTestOutboxFlags(t *testing.T, args ...string) (outboxConfig, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := registerOutboxFlags(fs, false)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return flags.load("node-a")
+}
+
+func TestOutboxFlags(t *testing.T) {
+	t.Setenv("RUNTIME_OUTBOX_WEBHOOK_URL", "")
+	t.Setenv("RUNTIME_OUTBOX_WEBHOOK_SECRET", "hook-secret")
+
+	cfg, err := loadTestOutboxFlags(t)
+	if err != nil || cfg.Enabled {
+		t.Fatalf("expected the dispatcher to be off by default, got %+v %v", cfg, err)
+	}
+	if _, err := loadTestOutboxFlags(t, "--outbox"); err == nil {
+		t.Fatalf("expected --outbox without a webhook to be refused")
+	}
+	if _, err := loadTestOutboxFlags(t, "--outbox", "--outbox-webhook-url", "https://hooks.example.com/outbox", "--outbox-lease", "10s"); err == nil {
+		t.Fatalf("expected a lease shorter than the delivery timeout to be refused")
+	}
+	if _, err := loadTestOutboxFlags(t, "--outbox-max-attempts", "0"); err == nil {
+		t.Fatalf("expected zero attempts to be refused")
+	}
+
+	cfg, err = loadTestOutboxFlags(t, "--outbox", "--outbox-webhook-url", "https://hooks.example.com/outbox", "--outbox-max-attempts", "3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Enabled || cfg.WebhookSecret != "hook-secret" || cfg.Dispatch.ProcessorName != "node-a" ||
+		cfg.Dispatch.MaxAttempts != 3 || cfg.Dispatch.PollInterval != time.Second {
+		t.Fatalf("unexpected outbox config %+v", cfg)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+const (
+	problemContentType = runtimeapi.ProblemContentType
+	problemTypePrefix  = "urn:vedic-platform:problem:"
+)
+
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	writeProblemBody(w, runtimeapi.Problem{Status: status, Code: code, Detail: detail})
+}
+
+func writeProblemBody(w http.ResponseWriter, p runtimeapi.Problem) {
+	p = completeProblem(p)
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(mustMarshalJSON(p))
+}
+
+// completeProblem fills in the type and title implied by a problem's code and status.
+func completeProblem(p runtimeapi.Problem) runtimeapi.Problem {
+	p.Type = problemTypePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// writeJSONError writes a problem whose code is implied by the status.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeProblem(w, status, statusProblemCode(status), msg)
+}
+
+func statusProblemCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return runtimeapi.CodeBadRequest
+	case http.StatusUnauthorized:
+		return runtimeapi.CodeUnauthorized
+	case http.StatusForbidden:
+		return runtimeapi.CodeForbidden
+	case http.StatusNotFound:
+		return runtimeapi.CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return runtimeapi.CodeMethodNotAllowed
+	case http.StatusConflict:
+		return runtimeapi.CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return runtimeapi.CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return runtimeapi.CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return runtimeapi.CodeUnavailable
+	default:
+		return runtimeapi.CodeInternal
+	}
+}
+
+// callError is a failed operation: the HTTP handlers write it as a problem,
+// the gRPC service as a status with error details.
+type callError struct {
+	problem    runtimeapi.Problem
+	retryAfter time.Duration
+}
+
+func (e *callError) Error() string {
+	return e.problem.Code + ": " + e.problem.Detail
+}
+
+func newCallError(status int, code, detail string) *callError {
+	return &callError{problem: runtimeapi.Problem{Status: status, Code: code, Detail: detail}}
+}
+
+// writeCallError writes a failed operation as a problem, with Retry-After when set.
+func writeCallError(w http.ResponseWriter, e *callError) {
+	if e.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(e.retryAfter)))
+	}
+	writeProblemBody(w, e.problem)
+}
+
+// writeAuthError maps authentication, authorization and tenant binding errors.
+func writeAuthError(w http.ResponseWriter, err error) {
+	writeCallError(w, authCallError(err))
+}
+
+func authErrorCode(err error) string {
+	switch {
+	case errors.Is(err, errMissingCredentials):
+		return runtimeapi.CodeMissingCredentials
+	case errors.Is(err, errInvalidCredentials):
+		return runtimeapi.CodeInvalidCredentials
+	case errors.Is(err, errRevokedCredentials):
+		return runtimeapi.CodeCredentialsRevoked
+	case errors.Is(err, errSignatureRequired):
+		return runtimeapi.CodeSignatureRequired
+	case errors.Is(err, errInvalidSignature):
+		return runtimeapi.CodeInvalidSignature
+	case errors.Is(err, errInsufficientScope):
+		return runtimeapi.CodeInsufficientScope
+	case errors.Is(err, errTenantMismatch):
+		return runtimeapi.CodeTenantMismatch
+	case errors.Is(err, errWorkspaceMismatch):
+		return runtimeapi.CodeWorkspaceMismatch
+	case errors.Is(err, errInvalidTenantRef):
+		return runtimeapi.CodeValidationFailed
+	case errors.Is(err, errNoTenantMembership):
+		return runtimeapi.CodeNoTenantMembership
+	case errors.Is(err, errRateLimited):
+		return runtimeapi.CodeRateLimited
+	case errors.Is(err, errClientBlocked):
+		return runtimeapi.CodeClientBlocked
+	case errors.Is(err, errAuthUnavailable):
+		return runtimeapi.CodeAuthUnavailable
+	default:
+		return statusProblemCode(authErrorStatus(err))
+	}
+}
+
+// authCallError maps authentication, authorization and tenant binding errors.
+func authCallError(err error) *callError {
+	return newCallError(authErrorStatus(err), authErrorCode(err), err.Error())
+}
+
+func tenantBindingCallError(err error) *callError {
+	if errors.Is(err, errTenantMismatch) || errors.Is(err, errWorkspaceMismatch) || errors.Is(err, errInvalidTenantRef) {
+		return authCallError(err)
+	}
+	return newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to verify tenant binding")
+}
+
+// storeCallError maps a repository error. Only validation messages reach the
+// client; anything else is logged and reported generically.
+func storeCallError(ctx context.Context, err error, action string) *callError {
+	var dbErr *dbpkg.Error
+	switch {
+	case errors.Is(err, dbpkg.ErrValidation):
+		detail := "request failed validation"
+		if errors.As(err, &dbErr) && dbErr.Err == nil {
+			detail = dbErr.Message
+		}
+		return newCallError(http.StatusUnprocessableEntity, runtimeapi.CodeValidationFailed, detail)
+	case errors.Is(err, dbpkg.ErrForeignKey):
+		return newCallError(http.StatusUnprocessableEntity, runtimeapi.CodeReferenceNotFound, "a referenced tenant, workspace, policy set or record does not exist")
+	case errors.Is(err, dbpkg.ErrConflict):
+		return newCallError(http.StatusConflict, runtimeapi.CodeConflict, "the record conflicts with an existing one")
+	case errors.Is(err, dbpkg.ErrNotFound):
+		return newCallError(http.StatusNotFound, runtimeapi.CodeNotFound, "not found")
+	case errors.Is(err, dbpkg.ErrUnavailable):
+		logging.FromContext(ctx).Warn(action, "error", err)
+		e := newCallError(http.StatusServiceUnavailable, runtimeapi.CodeUnavailable, "storage temporarily unavailable, retry later")
+		e.retryAfter = time.Second
+		return e
+	default:
+		logging.FromContext(ctx).Error(action, "error", err)
+		return newCallError(http.StatusInternalServerError, runtimeapi.CodeInternal, action)
+	}
+}
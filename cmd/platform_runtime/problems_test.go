@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+)
+
+func TestStoreCallErrorHidesDriverDetails(t *testing.T) {
+	pgErr := &pgconn.PgError{Code: "23503", Message: `insert violates foreign key constraint "policy_decisions_tenant_id_fkey"`}
+	cases := []struct {
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{dbpkg.Classify("authz: persist decision", pgErr), http.StatusUnprocessableEntity, runtimeapi.CodeReferenceNotFound, ""},
+		{dbpkg.Classify("authz: persist decision", &pgconn.PgError{Code: "23505"}), http.StatusConflict, runtimeapi.CodeConflict, ""},
+		{dbpkg.Classify("authz: persist decision", &pgconn.PgError{Code: "57014"}), http.StatusServiceUnavailable, runtimeapi.CodeUnavailable, ""},
+		{dbpkg.Validationf("authz: subject is required"), http.StatusUnprocessableEntity, runtimeapi.CodeValidationFailed, "authz: subject is required"},
+		{errors.New(`pq: relation "authz.policy_decisions" does not exist`), http.StatusInternalServerError, runtimeapi.CodeInternal, "failed to persist decision/event"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		writeCallError(rec, storeCallError(context.Background(), tc.err, "failed to persist decision/event"))
+		var p runtimeapi.Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if rec.Code != tc.status || p.Status != tc.status || p.Code != tc.code || p.Type != problemTypePrefix+tc.code {
+			t.Fatalf("%v: unexpected problem %d %+v", tc.err, rec.Code, p)
+		}
+		if rec.Header().Get("Content-Type") != problemContentType {
+			t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+		}
+		if tc.detail != "" && p.Detail != tc.detail {
+			t.Fatalf("expected detail %q, got %q", tc.detail, p.Detail)
+		}
+		if body := rec.Body.String(); strings.Contains(body, "constraint") || strings.Contains(body, "relation") {
+			t.Fatalf("driver text leaked: %s", body)
+		}
+	}
+}
+
+func TestWriteAuthErrorCodes(t *testing.T) {
+	for err, code := range map[error]string{
+		errMissingCredentials: runtimeapi.CodeMissingCredentials,
+		errInsufficientScope:  runtimeapi.CodeInsufficientScope,
+		errTenantMismatch:     runtimeapi.CodeTenantMismatch,
+		errRateLimited:        runtimeapi.CodeRateLimited,
+		errInvalidSignature:   runtimeapi.CodeInvalidSignature,
+	} {
+		rec := httptest.NewRecorder()
+		writeAuthError(rec, err)
+		var p runtimeapi.Problem
+		if jsonErr := json.Unmarshal(rec.Body.Bytes(), &p); jsonErr != nil || p.Code != code || rec.Code != authErrorStatus(err) {
+			t.Fatalf("%v: unexpected problem %d %s", err, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	ratelimit "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/ratelimit"
+)
+
+const scopeUsageRead = "usage:read"
+
+const (
+	defaultQuotaCacheTTL      = time.Minute
+	defaultUsageFlushInterval = 10 * time.Second
+	defaultUsageWindowDays    = 30
+	maxUsageWindowDays        = 366
+	maxQuotaCacheEntries      = 10000
+	// quotaRefundTimeout bounds a refund, which runs after the write's own
+	// deadline may have passed.
+	quotaRefundTimeout = 5 * time.Second
+)
+
+var errQuotaExceeded = errors.New("daily event quota exceeded")
+
+// quotaConfig controls plan/tenant quota enforcement (RUNTIME_QUOTAS*).
+type quotaConfig struct {
+	Enabled       bool
+	CacheTTL      time.Duration
+	FlushInterval time.Duration
+}
+
+// quotaStore is the control-plane surface used for quotas and usage counters.
+type quotaStore interface {
+	ResolveTenantQuota(ctx context.Context, tenantID, endpoint string) (controlplanerepo.Quota, bool, error)
+	ConsumeUsage(ctx context.Context, tenantID, metric string, delta int64, limit *int64) (int64, bool, error)
+	ReleaseUsage(ctx context.Context, tenantID, metric string, delta int64) error
+	AddUsage(ctx context.Context, tenantID string, day time.Time, metric string, delta int64) error
+	ListUsage(ctx context.Context, tenantID string, from, to time.Time) ([]controlplanerepo.UsageCounter, error)
+}
+
+type quotaCacheEntry struct {
+	quota     controlplanerepo.Quota
+	found     bool
+	expiresAt time.Time
+}
+
+// quotaCache keeps resolved quotas for ttl so the request path does not query
+// the control plane on every call; quota edits take effect within ttl.
+type quotaCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]quotaCacheEntry
+	clockFn func() time.Time
+}
+
+func newQuotaCache(ttl time.Duration) *quotaCache {
+	if ttl <= 0 {
+		ttl = defaultQuotaCacheTTL
+	}
+	return &quotaCache{ttl: ttl, entries: make(map[string]quotaCacheEntry), clockFn: time.Now}
+}
+
+func (c *quotaCache) get(tenantID, endpoint string) (controlplanerepo.Quota, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[tenantID+"|"+endpoint]
+	if !ok || !c.clockFn().Before(e.expiresAt) {
+		return controlplanerepo.Quota{}, false, false
+	}
+	return e.quota, e.found, true
+}
+
+func (c *quotaCache) put(tenantID, endpoint string, q controlplanerepo.Quota, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxQuotaCacheEntries {
+		c.entries = make(map[string]quotaCacheEntry)
+	}
+	c.entries[tenantID+"|"+endpoint] = quotaCacheEntry{quota: q, found: found, expiresAt: c.clockFn().Add(c.ttl)}
+}
+
+// clear drops every cached quota and returns how many there were.
+func (c *quotaCache) clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	c.entries = make(map[string]quotaCacheEntry)
+	return n
+}
+
+type usageKey struct {
+	tenantID string
+	day      string
+	metric   string
+}
+
+// usageRecorder aggregates unlimited counters (requests, decisions) in memory
+// and flushes them periodically, keeping counter writes off the request path.
+type usageRecorder struct {
+	mu      sync.Mutex
+	pending map[usageKey]int64
+	clockFn func() time.Time
+}
+
+func newUsageRecorder() *usageRecorder {
+	return &usageRecorder{pending: make(map[usageKey]int64), clockFn: time.Now}
+}
+
+func (u *usageRecorder) add(tenantID, metric string, n int64) {
+	if tenantID == "" || n <= 0 {
+		return
+	}
+	day := u.clockFn().UTC().Format(time.DateOnly)
+	u.mu.Lock()
+	u.pending[usageKey{tenantID: tenantID, day: day, metric: metric}] += n
+	u.mu.Unlock()
+}
+
+// flush writes pending counters; counters that fail to write are kept for the next flush.
+func (u *usageRecorder) flush(ctx context.Context, store quotaStore) error {
+	u.mu.Lock()
+	batch := u.pending
+	u.pending = make(map[usageKey]int64)
+	u.mu.Unlock()
+
+	var firstErr error
+	for k, n := range batch {
+		day, err := time.Parse(time.DateOnly, k.day)
+		if err == nil {
+			err = store.AddUsage(ctx, k.tenantID, day, k.metric, n)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			u.mu.Lock()
+			u.pending[k] += n
+			u.mu.Unlock()
+		}
+	}
+	return firstErr
+}
+
+func (u *usageRecorder) run(ctx context.Context, store quotaStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultUsageFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.flush(ctx, store); err != nil {
+				logging.FromContext(ctx).Error("usage flush failed", "error", err)
+			}
+		}
+	}
+}
+
+// flushUsage writes this replica's pending usage counters (e.g. on shutdown).
+func (a *httpAPI) flushUsage(ctx context.Context) {
+	if a.quotas == nil {
+		return
+	}
+	if err := a.usage.flush(ctx, a.quotas); err != nil {
+		logging.FromContext(ctx).Error("usage flush failed", "error", err)
+	}
+}
+
+// tenantQuota resolves a tenant's effective quota through the cache. Lookup
+// failures are logged and treated as "no quota" so the global limits apply.
+func (a *httpAPI) tenantQuota(ctx context.Context, tenantID, endpoint string) (controlplanerepo.Quota, bool) {
+	if a.quotas == nil || tenantID == "" {
+		return controlplanerepo.Quota{}, false
+	}
+	if q, found, ok := a.quotaCache.get(tenantID, endpoint); ok {
+		return q, found
+	}
+	q, found, err := a.quotas.ResolveTenantQuota(ctx, tenantID, endpoint)
+	if err != nil {
+		logging.FromContext(ctx).Error("quota lookup failed", "tenant_id", tenantID, "error", err)
+		return controlplanerepo.Quota{}, false
+	}
+	a.quotaCache.put(tenantID, endpoint, q, found)
+	return q, found
+}
+
+// quotaRateLimitPolicy maps a quota onto a token bucket the way
+// rateLimitPolicy maps the global settings: the bucket holds
+// requests_per_minute+burst tokens (burst defaults to none) and refills at
+// requests_per_minute. It reports false when the quota has no request limit.
+func quotaRateLimitPolicy(q controlplanerepo.Quota) (ratelimit.Policy, bool) {
+	if q.RequestsPerMinute == nil || *q.RequestsPerMinute <= 0 {
+		return ratelimit.Policy{}, false
+	}
+	capacity := *q.RequestsPerMinute
+	if q.Burst != nil && *q.Burst > 0 {
+		capacity += *q.Burst
+	}
+	return ratelimit.Policy{Capacity: capacity, RefillPerMinute: *q.RequestsPerMinute}, true
+}
+
+// requestRateLimit picks the bucket for a request: tenant-bound callers whose
+// plan sets a request limit get a per-tenant bucket under that limit,
+// everyone else the configured global policy. It also names the policy kind.
+func (a *httpAPI) requestRateLimit(ctx context.Context, caller callerIdentity, clientIP, scope string) (string, ratelimit.Policy, string) {
+	if caller.TenantID != nil {
+		if q, found := a.tenantQuota(ctx, *caller.TenantID, scope); found {
+			if p, ok := quotaRateLimitPolicy(q); ok {
+				return "quota:" + *caller.TenantID + ":" + scope, p, "plan"
+			}
+		}
+	}
+	return rateLimitKey(a.securityCfg.RateLimitKey, caller, clientIP, scope), a.rateLimiter.Policy(), "global"
+}
+
+// recordUsage counts an unlimited metric for a tenant.
+func (a *httpAPI) recordUsage(tenantID *string, metric string, n int64) {
+	if a.quotas == nil || tenantID == nil {
+		return
+	}
+	a.usage.add(*tenantID, metric, n)
+}
+
+// consumeEventQuota charges events against the tenant's daily event volume
+// and returns how many were charged, for refundEventQuota should the write
+// fail. Counter failures are logged and the request is let through.
+func (a *httpAPI) consumeEventQuota(ctx context.Context, tenantID *string, events int64) (int64, error) {
+	if a.quotas == nil || tenantID == nil {
+		return 0, nil
+	}
+	q, found := a.tenantQuota(ctx, *tenantID, controlplanerepo.QuotaEndpointDefault)
+	if !found {
+		return 0, nil
+	}
+	_, ok, err := a.quotas.ConsumeUsage(ctx, *tenantID, controlplanerepo.UsageMetricEvents, events, q.DailyEventLimit)
+	if err != nil {
+		logging.FromContext(ctx).Error("usage counter failed", "tenant_id", *tenantID, "error", err)
+		return 0, nil
+	}
+	if !ok {
+		return 0, errQuotaExceeded
+	}
+	return events, nil
+}
+
+// refundEventQuota gives back events charged by consumeEventQuota for a write
+// that did not commit. It runs even when ctx has expired.
+func (a *httpAPI) refundEventQuota(ctx context.Context, tenantID *string, charged int64) {
+	if a.quotas == nil || tenantID == nil || charged <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), quotaRefundTimeout)
+	defer cancel()
+	if err := a.quotas.ReleaseUsage(ctx, *tenantID, controlplanerepo.UsageMetricEvents, charged); err != nil {
+		logging.FromContext(ctx).Error("usage refund failed", "tenant_id", *tenantID, "events", charged, "error", err)
+	}
+}
+
+// quotaCallError rejects a request whose daily quota is spent; daily
+// counters reset at midnight UTC.
+func quotaCallError(now time.Time) *callError {
+	e := newCallError(http.StatusTooManyRequests, runtimeapi.CodeQuotaExceeded, errQuotaExceeded.Error())
+	e.retryAfter = untilNextUTCDay(now)
+	return e
+}
+
+func untilNextUTCDay(now time.Time) time.Duration {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return next.Sub(now)
+}
+
+// handleUsage reports a tenant's effective quota and daily usage counters.
+func (a *httpAPI) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	caller, err := a.authorizeAndRateLimit(w, r, "v1/usage", scopeUsageRead)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	report, cerr := a.usageReport(r.Context(), caller, r.URL.Query())
+	if cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// parseUsageWindow parses an inclusive YYYY-MM-DD range, defaulting to the
+// last 30 UTC days.
+func parseUsageWindow(fromRaw, toRaw string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := strings.TrimSpace(toRaw); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be a YYYY-MM-DD date")
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(defaultUsageWindowDays - 1))
+	if v := strings.TrimSpace(fromRaw); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be a YYYY-MM-DD date")
+		}
+		from = t
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxUsageWindowDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("usage window cannot exceed %d days", maxUsageWindowDays)
+	}
+	return from, to, nil
+}
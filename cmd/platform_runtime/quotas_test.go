@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
+	ratelimit "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/ratelimit"
+)
+
+type fakeQuotaStore struct {
+	quota    controlplanerepo.Quota
+	found    bool
+	resolves int
+	events   int64
+	added    map[string]int64
+}
+
+func (f *fakeQuotaStore) ResolveTenantQuota(_ context.Context, tenantID, endpoint string) (controlplanerepo.Quota, bool, error) {
+	f.resolves++
+	q := f.quota
+	q.TenantID = tenantID
+	q.Endpoint = endpoint
+	return q, f.found, nil
+}
+
+func (f *fakeQuotaStore) ConsumeUsage(_ context.Context, _, _ string, delta int64, limit *int64) (int64, bool, error) {
+	if limit != nil && f.events+delta > *limit {
+		return f.events, false, nil
+	}
+	f.events += delta
+	return f.events, true, nil
+}
+
+func (f *fakeQuotaStore) ReleaseUsage(_ context.Context, _, _ string, delta int64) error {
+	f.events = max(f.events-delta, 0)
+	return nil
+}
+
+func (f *fakeQuotaStore) AddUsage(_ context.Context, tenantID string, day time.Time, metric string, delta int64) error {
+	if f.added == nil {
+		f.added = map[string]int64{}
+	}
+	f.added[tenantID+"|"+day.Format(time.DateOnly)+"|"+metric] += delta
+	return nil
+}
+
+func (f *fakeQuotaStore) ListUsage(context.Context, string, time.Time, time.Time) ([]controlplanerepo.UsageCounter, error) {
+	return nil, nil
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestRequestRateLimitUsesTenantPlan(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.Policy{Capacity: 30, RefillPerMinute: 120}, nil)
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	store := &fakeQuotaStore{found: true, quota: controlplanerepo.Quota{Plan: "free", RequestsPerMinute: intPtr(60), Burst: intPtr(20)}}
+	api := &httpAPI{rateLimiter: limiter, quotas: store, quotaCache: newQuotaCache(time.Minute), securityCfg: serveSecurityConfig{RateLimitKey: rateLimitKeyIP}}
+
+	tenant := "tenant-a"
+	key, p, _ := api.requestRateLimit(context.Background(), callerIdentity{CredentialID: "cred-1", TenantID: &tenant}, "10.0.0.1", "v1/decisions")
+	if key != "quota:tenant-a:v1/decisions" || p.Capacity != 80 || p.RefillPerMinute != 60 {
+		t.Fatalf("unexpected tenant bucket %q %+v", key, p)
+	}
+	_, _, _ = api.requestRateLimit(context.Background(), callerIdentity{CredentialID: "cred-1", TenantID: &tenant}, "10.0.0.1", "v1/decisions")
+	if store.resolves != 1 {
+		t.Fatalf("expected cached quota, got %d lookups", store.resolves)
+	}
+
+	key, p, _ = api.requestRateLimit(context.Background(), unboundCaller("static:abc"), "10.0.0.1", "v1/decisions")
+	if key != "ip:10.0.0.1:v1/decisions" || p.Capacity != 30 {
+		t.Fatalf("expected global policy for unbound caller, got %q %+v", key, p)
+	}
+
+	if p, ok := quotaRateLimitPolicy(controlplanerepo.Quota{RequestsPerMinute: intPtr(60)}); !ok || p.Capacity != 60 {
+		t.Fatalf("expected a plan without burst to hold one minute of requests, got %+v", p)
+	}
+
+	store.quota = controlplanerepo.Quota{Plan: "enterprise"}
+	other := "tenant-b"
+	if _, p, _ := api.requestRateLimit(context.Background(), callerIdentity{CredentialID: "cred-2", TenantID: &other}, "10.0.0.1", "v1/decisions"); p.Capacity != 30 {
+		t.Fatalf("expected global policy when plan has no request limit, got %+v", p)
+	}
+}
+
+func TestConsumeEventQuota(t *testing.T) {
+	limit := int64(2)
+	store := &fakeQuotaStore{found: true, quota: controlplanerepo.Quota{Plan: "free", DailyEventLimit: &limit}}
+	api := &httpAPI{quotas: store, quotaCache: newQuotaCache(time.Minute)}
+	tenant := "tenant-a"
+	for i := 0; i < 2; i++ {
+		if _, err := api.consumeEventQuota(context.Background(), &tenant, 1); err != nil {
+			t.Fatalf("event %d: unexpected error %v", i, err)
+		}
+	}
+	if _, err := api.consumeEventQuota(context.Background(), &tenant, 1); err != errQuotaExceeded {
+		t.Fatalf("expected quota exceeded, got %v", err)
+	}
+	if n, err := api.consumeEventQuota(context.Background(), nil, 1); n != 0 || err != nil {
+		t.Fatalf("expected unbound writes to skip quotas, got %d %v", n, err)
+	}
+
+	// A write that fails after its charge gets the events back.
+	store.events = 1
+	charged, err := api.consumeEventQuota(context.Background(), &tenant, 1)
+	if charged != 1 || err != nil {
+		t.Fatalf("expected one event charged, got %d %v", charged, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	api.refundEventQuota(ctx, &tenant, charged)
+	if store.events != 1 {
+		t.Fatalf("expected the refund to restore the counter, got %d", store.events)
+	}
+
+	rec := httptest.NewRecorder()
+	writeCallError(rec, quotaCallError(time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)))
+	if rec.Code != 429 || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("unexpected quota response %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestUsageRecorderFlush(t *testing.T) {
+	u := newUsageRecorder()
+	u.clockFn = func() time.Time { return time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC) }
+	u.add("tenant-a", "requests:v1/decisions", 1)
+	u.add("tenant-a", "requests:v1/decisions", 2)
+	u.add("", "decisions", 1)
+	store := &fakeQuotaStore{}
+	if err := u.flush(context.Background(), store); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if got := store.added["tenant-a|2026-03-04|requests:v1/decisions"]; got != 3 || len(store.added) != 1 {
+		t.Fatalf("unexpected flushed counters %v", store.added)
+	}
+	if len(u.pending) != 0 {
+		t.Fatalf("expected pending counters to be drained")
+	}
+}
+
+func TestParseUsageWindow(t *testing.T) {
+	now := time.Date(2026, 3, 31, 15, 0, 0, 0, time.UTC)
+	from, to, err := parseUsageWindow("", "", now)
+	if err != nil || from.Format(time.DateOnly) != "2026-03-02" || to.Format(time.DateOnly) != "2026-03-31" {
+		t.Fatalf("unexpected default window %s..%s err=%v", from, to, err)
+	}
+	if _, _, err := parseUsageWindow("2026-04-01", "2026-03-01", now); err == nil {
+		t.Fatalf("expected inverted window error")
+	}
+	if _, _, err := parseUsageWindow("2024-01-01", "2026-01-01", now); err == nil {
+		t.Fatalf("expected oversized window error")
+	}
+	if _, _, err := parseUsageWindow("03/01/2026", "", now); err == nil {
+		t.Fatalf("expected date format error")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+type recordingVerifier struct {
+	err  error
+	path string
+}
+
+func (v *recordingVerifier) Verify(_ string, path string, _ []byte, _ securitypkg.SignedRequest) error {
+	v.path = path
+	return v.err
+}
+
+func TestVerifyRequestSignatureRequired(t *testing.T) {
+	api := &httpAPI{securityCfg: serveSecurityConfig{RequireRequestSigning: true}}
+	req := httptest.NewRequest("POST", "/v1/decisions", nil)
+	var caller callerIdentity
+	if err := api.verifyRequestSignature(req, nil, &caller); !errors.Is(err, errSignatureRequired) {
+		t.Fatalf("expected signature required, got %v", err)
+	}
+}
+
+func TestVerifyRequestSignatureStampsKeyID(t *testing.T) {
+	verifier := &recordingVerifier{}
+	api := &httpAPI{signatures: verifier}
+	req := httptest.NewRequest("POST", "/v1/decisions?x=1", nil)
+	req.Header.Set(securitypkg.HeaderSignatureKeyID, "kid-1")
+	req.Header.Set(securitypkg.HeaderSignatureTimestamp, "1700000000")
+	req.Header.Set(securitypkg.HeaderSignatureNonce, "0123456789abcdef")
+	req.Header.Set(securitypkg.HeaderSignature, "deadbeef")
+
+	var caller callerIdentity
+	if err := api.verifyRequestSignature(req, nil, &caller); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if caller.SigningKeyID != "kid-1" || verifier.path != "/v1/decisions" {
+		t.Fatalf("unexpected caller/path: %q %q", caller.SigningKeyID, verifier.path)
+	}
+
+	verifier.err = errors.New("security: request nonce already used")
+	if err := api.verifyRequestSignature(req, nil, &caller); !errors.Is(err, errInvalidSignature) {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+	verifier.err = fmt.Errorf("%w: connection refused", securitypkg.ErrNonceStoreUnavailable)
+	if err := api.verifyRequestSignature(req, nil, &caller); !errors.Is(err, errAuthUnavailable) {
+		t.Fatalf("expected a nonce store outage to be auth_unavailable, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+const scopeSecurityRead = "security:read"
+
+func (a *httpAPI) handleThreatLevelHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	caller, err := a.authorizeAndRateLimit(w, r, "v1/security/threat-levels", scopeSecurityRead)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	items, cerr := a.threatLevels(r.Context(), caller, r.URL.Query())
+	if cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	writeJSON(w, http.StatusOK, runtimeapi.ThreatLevelList{Items: items})
+}
+
+func (a *httpAPI) handleAnomalyReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	caller, err := a.authorizeAndRateLimit(w, r, "v1/security/anomaly-reports", scopeSecurityRead)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	items, cerr := a.anomalyReports(r.Context(), caller, r.URL.Query())
+	if cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	writeJSON(w, http.StatusOK, runtimeapi.AnomalyReportList{Items: items})
+}
+
+func (a *httpAPI) handleAnomalyReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	caller, err := a.authorizeAndRateLimit(w, r, "v1/security/anomaly-reports", scopeSecurityRead)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	rep, cerr := a.anomalyReport(r.Context(), caller, r.PathValue("id"))
+	if cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+func newAnomalyReportView(rep securitypkg.AnomalyReport, links []securitypkg.AnomalyReportLink) runtimeapi.AnomalyReport {
+	view := runtimeapi.AnomalyReport{
+		ID:          rep.ID,
+		NodeName:    rep.NodeName,
+		TenantID:    rep.TenantID,
+		AnomalyType: rep.AnomalyType,
+		Severity:    rep.Severity,
+		Status:      rep.Status,
+		Score:       rep.Score,
+		Summary:     rep.Summary,
+		Details:     json.RawMessage(rep.DetailsJSON),
+		DetectedAt:  rep.DetectedAt.UTC(),
+		ResolvedAt:  rep.ResolvedAt,
+	}
+	if links != nil {
+		view.Links = make([]runtimeapi.AnomalyReportLink, 0, len(links))
+		for _, l := range links {
+			view.Links = append(view.Links, runtimeapi.AnomalyReportLink{
+				SecurityEventID: l.SecurityEventID,
+				EventType:       l.EventType,
+				Severity:        l.Severity,
+				DecisionID:      l.DecisionID,
+				LinkedAt:        l.LinkedAt.UTC(),
+			})
+		}
+	}
+	return view
+}
@@ -7,76 +7,350 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
+
+	ratelimit "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/ratelimit"
+	securitypkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
 )
 
 const (
 	defaultRateLimitPerMinute = 120
 	defaultRateLimitBurst     = 30
+
+	defaultAbuseWindow              = 5 * time.Minute
+	defaultAbuseIPThreshold         = 20
+	defaultAbuseCredentialThreshold = 10
+	defaultAbuseSessionThreshold    = 10
+	defaultAbuseRateLimitThreshold  = 300
+	defaultAbuseRevokeFor           = time.Hour
 )
 
 type serveSecurityConfig struct {
-	RequireAuth        bool
-	AllowedTokens      map[string]struct{}
-	AllowedOrigins     map[string]struct{}
-	RateLimitPerMinute int
-	RateLimitBurst     int
-	TrustProxyHeaders  bool
+	RequireAuth           bool
+	AllowedTokens         map[string]struct{}
+	DBCredentials         bool
+	AllowedOrigins        map[string]struct{}
+	RateLimitPerMinute    int
+	RateLimitBurst        int
+	RateLimitKey          string
+	RateLimitStore        string
+	RateLimitMaxKeys      int
+	TrustProxyHeaders     bool
+	RequireRequestSigning bool
+	SignatureMaxSkew      time.Duration
+	MTLSPrincipals        map[string]mtlsPrincipalConfig
+	Abuse                 abuseConfig
+	OIDC                  serveOIDCConfig
+	JWKSMaxAge            time.Duration
+	Quotas                quotaConfig
+	Metrics               metricsConfig
 }
 
-func loadServeSecurityConfigFromEnv() (serveSecurityConfig, error) {
-	cfg := serveSecurityConfig{
+// defaultServeSecurityConfig is what serve runs with when neither the config
+// file nor the environment says otherwise.
+func defaultServeSecurityConfig() serveSecurityConfig {
+	return serveSecurityConfig{
 		RequireAuth:        true,
 		AllowedTokens:      map[string]struct{}{},
 		AllowedOrigins:     map[string]struct{}{},
 		RateLimitPerMinute: defaultRateLimitPerMinute,
 		RateLimitBurst:     defaultRateLimitBurst,
+		RateLimitKey:       rateLimitKeyIP,
+		RateLimitStore:     rateLimitStoreMemory,
+		RateLimitMaxKeys:   ratelimit.DefaultMaxKeys,
 		TrustProxyHeaders:  true,
+		SignatureMaxSkew:   securitypkg.DefaultSignatureMaxSkew,
+		MTLSPrincipals:     map[string]mtlsPrincipalConfig{},
+		JWKSMaxAge:         defaultJWKSMaxAge,
+		OIDC: serveOIDCConfig{
+			JWKSRefresh: securitypkg.DefaultJWKSRefreshInterval,
+			ClockSkew:   securitypkg.DefaultOIDCClockSkew,
+		},
+		Metrics: metricsConfig{Enabled: true},
+		Quotas: quotaConfig{
+			Enabled:       true,
+			CacheTTL:      defaultQuotaCacheTTL,
+			FlushInterval: defaultUsageFlushInterval,
+		},
+		// Abuse detection blocks and revokes, so it is opt-in.
+		Abuse: abuseConfig{
+			Window:              defaultAbuseWindow,
+			IPThreshold:         defaultAbuseIPThreshold,
+			CredentialThreshold: defaultAbuseCredentialThreshold,
+			SessionThreshold:    defaultAbuseSessionThreshold,
+			RateLimitThreshold:  defaultAbuseRateLimitThreshold,
+			RevokeFor:           defaultAbuseRevokeFor,
+		},
+	}
+}
+
+// loadServeSecurityConfig layers the RUNTIME_* environment over the config
+// file's security section over the defaults, then validates the result.
+func loadServeSecurityConfig(file securityFileConfig) (serveSecurityConfig, error) {
+	cfg := defaultServeSecurityConfig()
+	if err := file.apply(&cfg); err != nil {
+		return serveSecurityConfig{}, err
+	}
+	if err := applyServeSecurityEnv(&cfg); err != nil {
+		return serveSecurityConfig{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return serveSecurityConfig{}, err
 	}
+	return cfg, nil
+}
 
+// applyServeSecurityEnv overrides cfg with the RUNTIME_* variables that are
+// set; lists such as RUNTIME_API_TOKENS replace the file's list.
+func applyServeSecurityEnv(cfg *serveSecurityConfig) error {
 	if v := strings.TrimSpace(os.Getenv("RUNTIME_REQUIRE_AUTH")); v != "" {
 		b, err := strconv.ParseBool(v)
 		if err != nil {
-			return serveSecurityConfig{}, fmt.Errorf("runtime: invalid RUNTIME_REQUIRE_AUTH")
+			return fmt.Errorf("runtime: invalid RUNTIME_REQUIRE_AUTH")
 		}
 		cfg.RequireAuth = b
 	}
-	for _, tok := range splitCSV(os.Getenv("RUNTIME_API_TOKENS")) {
-		cfg.AllowedTokens[tok] = struct{}{}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_DB_CREDENTIALS")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("runtime: invalid RUNTIME_DB_CREDENTIALS")
+		}
+		cfg.DBCredentials = b
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_API_TOKENS")); v != "" {
+		cfg.AllowedTokens = stringSet(splitCSV(v))
 	}
-	for _, origin := range splitCSV(os.Getenv("RUNTIME_ALLOWED_ORIGINS")) {
-		cfg.AllowedOrigins[origin] = struct{}{}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ALLOWED_ORIGINS")); v != "" {
+		cfg.AllowedOrigins = stringSet(splitCSV(v))
 	}
 	if v := strings.TrimSpace(os.Getenv("RUNTIME_RATE_LIMIT_PER_MINUTE")); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil || n <= 0 {
-			return serveSecurityConfig{}, fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_PER_MINUTE")
+			return fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_PER_MINUTE")
 		}
 		cfg.RateLimitPerMinute = n
 	}
 	if v := strings.TrimSpace(os.Getenv("RUNTIME_RATE_LIMIT_BURST")); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil || n < 0 {
-			return serveSecurityConfig{}, fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_BURST")
+			return fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_BURST")
 		}
 		cfg.RateLimitBurst = n
 	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_RATE_LIMIT_KEY")); v != "" {
+		switch v {
+		case rateLimitKeyIP, rateLimitKeyCredential, rateLimitKeyTenant:
+			cfg.RateLimitKey = v
+		default:
+			return fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_KEY")
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_RATE_LIMIT_STORE")); v != "" {
+		switch v {
+		case rateLimitStoreMemory, rateLimitStorePostgres:
+			cfg.RateLimitStore = v
+		default:
+			return fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_STORE")
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_RATE_LIMIT_MAX_KEYS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_RATE_LIMIT_MAX_KEYS")
+		}
+		cfg.RateLimitMaxKeys = n
+	}
 	if v := strings.TrimSpace(os.Getenv("RUNTIME_TRUST_PROXY_HEADERS")); v != "" {
 		b, err := strconv.ParseBool(v)
 		if err != nil {
-			return serveSecurityConfig{}, fmt.Errorf("runtime: invalid RUNTIME_TRUST_PROXY_HEADERS")
+			return fmt.Errorf("runtime: invalid RUNTIME_TRUST_PROXY_HEADERS")
 		}
 		cfg.TrustProxyHeaders = b
 	}
 
-	if cfg.RequireAuth && len(cfg.AllowedTokens) == 0 {
-		return serveSecurityConfig{}, fmt.Errorf("runtime: RUNTIME_REQUIRE_AUTH=true requires RUNTIME_API_TOKENS")
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_REQUIRE_REQUEST_SIGNING")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("runtime: invalid RUNTIME_REQUIRE_REQUEST_SIGNING")
+		}
+		cfg.RequireRequestSigning = b
 	}
-	if cfg.RateLimitBurst > cfg.RateLimitPerMinute {
-		return serveSecurityConfig{}, fmt.Errorf("runtime: RUNTIME_RATE_LIMIT_BURST cannot exceed per-minute limit")
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_SIGNATURE_MAX_SKEW_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_SIGNATURE_MAX_SKEW_SECONDS")
+		}
+		cfg.SignatureMaxSkew = time.Duration(n) * time.Second
 	}
-	return cfg, nil
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_MTLS_PRINCIPALS")); v != "" {
+		principals, err := parseMTLSPrincipals(v)
+		if err != nil {
+			return err
+		}
+		cfg.MTLSPrincipals = principals
+	}
+
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ABUSE_DETECTION")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("runtime: invalid RUNTIME_ABUSE_DETECTION")
+		}
+		cfg.Abuse.Enabled = b
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ABUSE_WINDOW_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_ABUSE_WINDOW_SECONDS")
+		}
+		cfg.Abuse.Window = time.Duration(n) * time.Second
+	}
+	for _, t := range []struct {
+		env string
+		dst *int
+	}{
+		{"RUNTIME_ABUSE_IP_THRESHOLD", &cfg.Abuse.IPThreshold},
+		{"RUNTIME_ABUSE_CREDENTIAL_THRESHOLD", &cfg.Abuse.CredentialThreshold},
+		{"RUNTIME_ABUSE_SESSION_THRESHOLD", &cfg.Abuse.SessionThreshold},
+		{"RUNTIME_ABUSE_RATE_LIMIT_THRESHOLD", &cfg.Abuse.RateLimitThreshold},
+	} {
+		if v := strings.TrimSpace(os.Getenv(t.env)); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("runtime: invalid %s", t.env)
+			}
+			*t.dst = n
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_ABUSE_REVOKE_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_ABUSE_REVOKE_SECONDS")
+		}
+		cfg.Abuse.RevokeFor = time.Duration(n) * time.Second
+	}
+
+	for _, t := range []struct {
+		env string
+		dst *string
+	}{
+		{"RUNTIME_OIDC_ISSUER", &cfg.OIDC.Issuer},
+		{"RUNTIME_OIDC_PROVIDER", &cfg.OIDC.Provider},
+		{"RUNTIME_OIDC_JWKS_FILE", &cfg.OIDC.JWKSFile},
+		{"RUNTIME_OIDC_JWKS_URL", &cfg.OIDC.JWKSURL},
+		{"RUNTIME_METRICS_TOKEN", &cfg.Metrics.Token},
+	} {
+		if v := strings.TrimSpace(os.Getenv(t.env)); v != "" {
+			*t.dst = v
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_OIDC_AUDIENCE")); v != "" {
+		cfg.OIDC.Audiences = splitCSV(v)
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_OIDC_JWKS_REFRESH_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_OIDC_JWKS_REFRESH_SECONDS")
+		}
+		cfg.OIDC.JWKSRefresh = time.Duration(n) * time.Second
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_OIDC_CLOCK_SKEW_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_OIDC_CLOCK_SKEW_SECONDS")
+		}
+		cfg.OIDC.ClockSkew = time.Duration(n) * time.Second
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_OIDC_JIT_PROVISION")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("runtime: invalid RUNTIME_OIDC_JIT_PROVISION")
+		}
+		cfg.OIDC.JITProvision = b
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_JWKS_MAX_AGE_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_JWKS_MAX_AGE_SECONDS")
+		}
+		cfg.JWKSMaxAge = time.Duration(n) * time.Second
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_METRICS")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("runtime: invalid RUNTIME_METRICS")
+		}
+		cfg.Metrics.Enabled = b
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_QUOTAS")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("runtime: invalid RUNTIME_QUOTAS")
+		}
+		cfg.Quotas.Enabled = b
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_QUOTA_CACHE_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_QUOTA_CACHE_SECONDS")
+		}
+		cfg.Quotas.CacheTTL = time.Duration(n) * time.Second
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_USAGE_FLUSH_SECONDS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("runtime: invalid RUNTIME_USAGE_FLUSH_SECONDS")
+		}
+		cfg.Quotas.FlushInterval = time.Duration(n) * time.Second
+	}
+	return nil
+}
+
+// Validate checks the merged settings, wherever each one came from.
+func (c serveSecurityConfig) Validate() error {
+	switch {
+	case c.RateLimitPerMinute <= 0:
+		return fmt.Errorf("runtime: rate limit per minute must be > 0")
+	case c.RateLimitBurst < 0:
+		return fmt.Errorf("runtime: rate limit burst must be >= 0")
+	case c.RateLimitKey != rateLimitKeyIP && c.RateLimitKey != rateLimitKeyCredential && c.RateLimitKey != rateLimitKeyTenant:
+		return fmt.Errorf("runtime: invalid rate limit key %q", c.RateLimitKey)
+	case c.RateLimitStore != rateLimitStoreMemory && c.RateLimitStore != rateLimitStorePostgres:
+		return fmt.Errorf("runtime: invalid rate limit store %q", c.RateLimitStore)
+	case c.RateLimitMaxKeys <= 0:
+		return fmt.Errorf("runtime: rate limit max keys must be > 0")
+	case c.SignatureMaxSkew <= 0:
+		return fmt.Errorf("runtime: signature max skew must be > 0")
+	case c.Abuse.Window <= 0:
+		return fmt.Errorf("runtime: abuse window must be > 0")
+	case c.Abuse.IPThreshold < 0 || c.Abuse.CredentialThreshold < 0 || c.Abuse.SessionThreshold < 0 || c.Abuse.RateLimitThreshold < 0:
+		return fmt.Errorf("runtime: abuse thresholds must be >= 0")
+	case c.Abuse.RevokeFor < 0:
+		return fmt.Errorf("runtime: abuse revoke duration must be >= 0")
+	case c.OIDC.JWKSRefresh <= 0 || c.OIDC.ClockSkew <= 0:
+		return fmt.Errorf("runtime: oidc jwks refresh and clock skew must be > 0")
+	case c.JWKSMaxAge < 0:
+		return fmt.Errorf("runtime: jwks max age must be >= 0")
+	case c.Quotas.CacheTTL <= 0 || c.Quotas.FlushInterval <= 0:
+		return fmt.Errorf("runtime: quota cache ttl and usage flush interval must be > 0")
+	}
+	if err := c.OIDC.Validate(); err != nil {
+		return err
+	}
+	if c.RequireAuth && len(c.AllowedTokens) == 0 && !c.DBCredentials && len(c.MTLSPrincipals) == 0 && !c.OIDC.Enabled() {
+		return fmt.Errorf("runtime: RUNTIME_REQUIRE_AUTH=true requires RUNTIME_API_TOKENS, RUNTIME_DB_CREDENTIALS, RUNTIME_MTLS_PRINCIPALS or RUNTIME_OIDC_ISSUER")
+	}
+	if c.RateLimitBurst > c.RateLimitPerMinute {
+		return fmt.Errorf("runtime: RUNTIME_RATE_LIMIT_BURST cannot exceed per-minute limit")
+	}
+	return nil
+}
+
+func stringSet(values []string) map[string]struct{} {
+	out := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		out[v] = struct{}{}
+	}
+	return out
 }
 
 func splitCSV(raw string) []string {
@@ -91,10 +365,12 @@ func splitCSV(raw string) []string {
 	return out
 }
 
-func withServeMiddlewares(next http.Handler, cfg serveSecurityConfig) http.Handler {
+// withServeMiddlewares reads the CORS origins from cfg on every request, so a
+// reload applies to the next request.
+func withServeMiddlewares(next http.Handler, cfg func() *serveSecurityConfig) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		setSecurityHeaders(w)
-		if !applyCORSHeaders(w, r, cfg) {
+		if !applyCORSHeaders(w, r, cfg()) {
 			http.Error(w, "origin not allowed", http.StatusForbidden)
 			return
 		}
@@ -113,7 +389,7 @@ func setSecurityHeaders(w http.ResponseWriter) {
 	w.Header().Set("Cache-Control", "no-store")
 }
 
-func applyCORSHeaders(w http.ResponseWriter, r *http.Request, cfg serveSecurityConfig) bool {
+func applyCORSHeaders(w http.ResponseWriter, r *http.Request, cfg *serveSecurityConfig) bool {
 	origin := strings.TrimSpace(r.Header.Get("Origin"))
 	if origin == "" {
 		return true
@@ -127,60 +403,21 @@ func applyCORSHeaders(w http.ResponseWriter, r *http.Request, cfg serveSecurityC
 	w.Header().Set("Vary", "Origin")
 	w.Header().Set("Access-Control-Allow-Origin", origin)
 	w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type,Idempotency-Key,X-Request-ID,X-API-Key")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization,Content-Type,Idempotency-Key,X-Request-ID,X-API-Key,X-Signature,X-Signature-Key-Id,X-Signature-Timestamp,X-Signature-Nonce,X-Session-ID,X-Tenant-ID")
+	w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,Retry-After")
 	w.Header().Set("Access-Control-Max-Age", "600")
 	return true
 }
 
-type requestRateLimiter struct {
-	mu      sync.Mutex
-	limits  map[string]*rateCounter
-	limit   int
-	window  time.Duration
-	clockFn func() time.Time
-}
-
-type rateCounter struct {
-	windowStart time.Time
-	count       int
-}
-
-func newRequestRateLimiter(perMinute int) *requestRateLimiter {
-	return &requestRateLimiter{
-		limits:  make(map[string]*rateCounter),
-		limit:   perMinute,
-		window:  time.Minute,
-		clockFn: time.Now,
-	}
-}
-
-func (l *requestRateLimiter) allow(key string, burst int) bool {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	now := l.clockFn()
-	c, ok := l.limits[key]
-	if !ok || now.Sub(c.windowStart) >= l.window {
-		l.limits[key] = &rateCounter{
-			windowStart: now,
-			count:       1,
-		}
-		return true
-	}
-	max := l.limit + burst
-	if c.count >= max {
-		return false
-	}
-	c.count++
-	return true
-}
-
+// extractClientIP returns the request's client address. Behind a trusted
+// proxy it takes the rightmost X-Forwarded-For hop, the one the proxy
+// appended; earlier hops are client-supplied and can be forged.
 func extractClientIP(r *http.Request, trustProxy bool) string {
 	if trustProxy {
-		if xff := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); xff != "" {
-			first := strings.TrimSpace(strings.Split(xff, ",")[0])
-			if first != "" {
-				return first
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			hops := strings.Split(values[len(values)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
 			}
 		}
 		if rip := strings.TrimSpace(r.Header.Get("X-Real-IP")); rip != "" {
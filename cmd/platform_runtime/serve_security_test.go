@@ -1,15 +1,17 @@
 package main
 
 import (
+	"context"
 	"net/http/httptest"
 	"testing"
-	"time"
+
+	ratelimit "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/ratelimit"
 )
 
 func TestLoadServeSecurityConfigRequiresTokenWhenAuthEnabled(t *testing.T) {
 	t.Setenv("RUNTIME_REQUIRE_AUTH", "true")
 	t.Setenv("RUNTIME_API_TOKENS", "")
-	_, err := loadServeSecurityConfigFromEnv()
+	_, err := loadServeSecurityConfig(securityFileConfig{})
 	if err == nil {
 		t.Fatalf("expected error when auth is enabled without tokens")
 	}
@@ -23,7 +25,7 @@ func TestLoadServeSecurityConfigSuccess(t *testing.T) {
 	t.Setenv("RUNTIME_RATE_LIMIT_BURST", "20")
 	t.Setenv("RUNTIME_TRUST_PROXY_HEADERS", "false")
 
-	cfg, err := loadServeSecurityConfigFromEnv()
+	cfg, err := loadServeSecurityConfig(securityFileConfig{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -51,17 +53,66 @@ func TestExtractAuthToken(t *testing.T) {
 	}
 }
 
-func TestRateLimiterAllow(t *testing.T) {
-	l := newRequestRateLimiter(2)
-	now := time.Now()
-	l.clockFn = func() time.Time { return now }
-	if !l.allow("k", 0) {
-		t.Fatalf("expected first request allowed")
-	}
-	if !l.allow("k", 0) {
-		t.Fatalf("expected second request allowed")
-	}
-	if l.allow("k", 0) {
-		t.Fatalf("expected third request blocked")
+func TestExtractClientIPUsesProxyAppendedHop(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/decisions", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Add("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+	req.Header.Add("X-Forwarded-For", "192.0.2.44")
+	if got := extractClientIP(req, true); got != "192.0.2.44" {
+		t.Fatalf("expected the rightmost hop, got %q", got)
+	}
+	if got := extractClientIP(req, false); got != "10.0.0.1" {
+		t.Fatalf("expected the remote address without trusted proxies, got %q", got)
+	}
+}
+
+func TestRateLimitPolicyAndHeaders(t *testing.T) {
+	p := rateLimitPolicy(serveSecurityConfig{RateLimitPerMinute: 2, RateLimitBurst: 2})
+	l, err := ratelimit.NewLimiter(p, nil)
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	api := &httpAPI{rateLimiter: l}
+	for i := 0; i < 4; i++ {
+		if !api.applyRateLimit(context.Background(), httptest.NewRecorder(), "k", p) {
+			t.Fatalf("request %d: expected allowed", i)
+		}
+	}
+	rec := httptest.NewRecorder()
+	if api.applyRateLimit(context.Background(), rec, "k", p) {
+		t.Fatalf("expected fifth request blocked")
+	}
+	if rec.Header().Get("Retry-After") != "30" || rec.Header().Get("RateLimit-Remaining") != "0" || rec.Header().Get("RateLimit-Limit") != "4" {
+		t.Fatalf("unexpected headers: %v", rec.Header())
+	}
+	if p := rateLimitPolicy(serveSecurityConfig{RateLimitPerMinute: 10}); p.Capacity != 10 {
+		t.Fatalf("expected zero burst to hold one minute of tokens, got %d", p.Capacity)
+	}
+	if p := rateLimitPolicy(serveSecurityConfig{}); p.Capacity != 1 {
+		t.Fatalf("expected a single-token bucket at minimum, got %d", p.Capacity)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	tenant := "tenant-a"
+	bound := callerIdentity{CredentialID: "cred-1", TenantID: &tenant}
+	unbound := unboundCaller("static:abc")
+	anonymous := unboundCaller("anonymous")
+
+	cases := []struct {
+		dimension string
+		caller    callerIdentity
+		want      string
+	}{
+		{rateLimitKeyIP, bound, "ip:10.0.0.1:scope"},
+		{rateLimitKeyCredential, bound, "credential:cred-1:scope"},
+		{rateLimitKeyTenant, bound, "tenant:tenant-a:scope"},
+		{rateLimitKeyTenant, unbound, "credential:static:abc:scope"},
+		{rateLimitKeyCredential, anonymous, "ip:10.0.0.1:scope"},
+	}
+	for _, tc := range cases {
+		if got := rateLimitKey(tc.dimension, tc.caller, "10.0.0.1", "scope"); got != tc.want {
+			t.Fatalf("rateLimitKey(%s) = %q, want %q", tc.dimension, got, tc.want)
+		}
 	}
 }
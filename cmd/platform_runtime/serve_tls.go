@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+const (
+	tlsClientAuthNone     = "none"
+	tlsClientAuthOptional = "optional"
+	tlsClientAuthRequire  = "require"
+)
+
+// serveTLSConfig holds native TLS/mTLS listener settings from serve flags.
+type serveTLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	ClientAuth     string
+	ReloadInterval time.Duration
+}
+
+func (c serveTLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c serveTLSConfig) Validate() error {
+	if !c.Enabled() {
+		if c.ClientCAFile != "" {
+			return fmt.Errorf("runtime: --tls-client-ca requires --tls-cert and --tls-key")
+		}
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("runtime: --tls-cert and --tls-key must be set together")
+	}
+	switch c.ClientAuth {
+	case tlsClientAuthNone:
+		if c.ClientCAFile != "" {
+			return fmt.Errorf("runtime: --tls-client-ca needs --tls-client-auth=optional or require")
+		}
+	case tlsClientAuthOptional, tlsClientAuthRequire:
+		if c.ClientCAFile == "" {
+			return fmt.Errorf("runtime: --tls-client-auth=%s requires --tls-client-ca", c.ClientAuth)
+		}
+	default:
+		return fmt.Errorf("runtime: invalid --tls-client-auth %q", c.ClientAuth)
+	}
+	if c.ReloadInterval <= 0 {
+		return fmt.Errorf("runtime: --tls-reload-interval must be > 0")
+	}
+	return nil
+}
+
+// buildServerTLSConfig returns a tls.Config whose certificate is served by reloader.
+func buildServerTLSConfig(cfg serveTLSConfig, reloader *certReloader) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if cfg.ClientCAFile == "" {
+		return tlsCfg, nil
+	}
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("runtime: read client ca bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("runtime: client ca bundle contains no certificates")
+	}
+	tlsCfg.ClientCAs = pool
+	switch cfg.ClientAuth {
+	case tlsClientAuthRequire:
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	case tlsClientAuthOptional:
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsCfg, nil
+}
+
+// certReloader serves a certificate pair and reloads it when either file changes.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	reloadFn func(string, string) (tls.Certificate, error)
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		reloadFn: tls.LoadX509KeyPair,
+	}
+	if _, err := r.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reloadIfChanged reloads the pair when a file mtime moved; a broken pair keeps the old cert.
+func (r *certReloader) reloadIfChanged() (bool, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("runtime: stat tls cert: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("runtime: stat tls key: %w", err)
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := r.reloadFn(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("runtime: load tls key pair: %w", err)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.certMod = certInfo.ModTime()
+	r.keyMod = keyInfo.ModTime()
+	r.mu.Unlock()
+	return true, nil
+}
+
+// watch polls the certificate files until ctx is done.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.reloadIfChanged()
+			if err != nil {
+				logging.FromContext(ctx).Error("tls reload failed", "error", err)
+				continue
+			}
+			if reloaded {
+				logging.FromContext(ctx).Info("tls certificate reloaded", "cert_file", r.certFile)
+			}
+		}
+	}
+}
+
+// mtlsPrincipalConfig is what a mapped client certificate authenticates as.
+// A principal holds only the scopes listed for it and, when TenantID is set,
+// is bound to that tenant like a database credential.
+type mtlsPrincipalConfig struct {
+	Principal string   `json:"principal"`
+	TenantID  string   `json:"tenant_id,omitempty"`
+	Scopes    []string `json:"scopes"`
+}
+
+// caller builds the identity for a request authenticated by this principal.
+func (p mtlsPrincipalConfig) caller() callerIdentity {
+	caller := callerIdentity{
+		CredentialID: "mtls:" + p.Principal,
+		Scopes:       make(map[string]struct{}, len(p.Scopes)),
+	}
+	if p.TenantID != "" {
+		tenantID := p.TenantID
+		caller.TenantID = &tenantID
+	}
+	for _, s := range p.Scopes {
+		caller.Scopes[s] = struct{}{}
+	}
+	return caller
+}
+
+// parseMTLSPrincipals parses RUNTIME_MTLS_PRINCIPALS entries of the form
+// "subject-cn=principal[;tenant=<id>][;scopes=<scope> <scope>]".
+func parseMTLSPrincipals(raw string) (map[string]mtlsPrincipalConfig, error) {
+	out := map[string]mtlsPrincipalConfig{}
+	for _, entry := range splitCSV(raw) {
+		fields := strings.Split(entry, ";")
+		subject, principal, ok := strings.Cut(fields[0], "=")
+		subject = strings.TrimSpace(subject)
+		cfg := mtlsPrincipalConfig{Principal: strings.TrimSpace(principal), Scopes: []string{}}
+		if !ok || subject == "" || cfg.Principal == "" {
+			return nil, fmt.Errorf("runtime: invalid RUNTIME_MTLS_PRINCIPALS entry %q", entry)
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			switch strings.TrimSpace(key) {
+			case "tenant":
+				cfg.TenantID = strings.TrimSpace(value)
+			case "scopes":
+				cfg.Scopes = strings.Fields(value)
+			default:
+				return nil, fmt.Errorf("runtime: invalid RUNTIME_MTLS_PRINCIPALS entry %q", entry)
+			}
+		}
+		out[subject] = cfg
+	}
+	return out, nil
+}
+
+// mtlsPrincipal maps a verified client certificate subject CN to a service principal.
+func mtlsPrincipal(r *http.Request, principals map[string]mtlsPrincipalConfig) (mtlsPrincipalConfig, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return mtlsPrincipalConfig{}, false
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	principal, ok := principals[leaf.Subject.CommonName]
+	return principal, ok
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSelfSignedPair(t *testing.T, dir, cn string, mod time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	writeFile(t, certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), mod)
+	writeFile(t, keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), mod)
+	return certPath, keyPath
+}
+
+func writeFile(t *testing.T, path string, content []byte, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestCertReloaderPicksUpRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now().Add(-time.Minute)
+	certPath, keyPath := writeSelfSignedPair(t, dir, "runtime-a", base)
+
+	r, err := newCertReloader(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, _ := r.GetCertificate(nil)
+	if reloaded, err := r.reloadIfChanged(); err != nil || reloaded {
+		t.Fatalf("expected no reload for unchanged files, got %t %v", reloaded, err)
+	}
+
+	writeSelfSignedPair(t, dir, "runtime-b", base.Add(30*time.Second))
+	if reloaded, err := r.reloadIfChanged(); err != nil || !reloaded {
+		t.Fatalf("expected reload after rotation, got %t %v", reloaded, err)
+	}
+	second, _ := r.GetCertificate(nil)
+	if first == second {
+		t.Fatalf("expected a new certificate after reload")
+	}
+}
+
+func TestServeTLSConfigValidate(t *testing.T) {
+	cfg := serveTLSConfig{CertFile: "a", KeyFile: "b", ClientAuth: tlsClientAuthRequire, ReloadInterval: time.Second}
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for client auth without ca bundle")
+	}
+	cfg.ClientCAFile = "ca.pem"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.ClientAuth = tlsClientAuthNone
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected error for a client ca bundle that would be ignored")
+	}
+	if err := (serveTLSConfig{CertFile: "a", ClientAuth: tlsClientAuthNone, ReloadInterval: time.Second}).Validate(); err == nil {
+		t.Fatalf("expected error for cert without key")
+	}
+}
+
+func TestMTLSPrincipalMapping(t *testing.T) {
+	principals, err := parseMTLSPrincipals("payments.internal=svc-payments;tenant=acme;scopes=decisions:write usage:read, relay.internal=svc-relay")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := httptest.NewRequest("POST", "/v1/decisions", nil)
+	if _, ok := mtlsPrincipal(req, principals); ok {
+		t.Fatalf("expected no principal without tls")
+	}
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "payments.internal"}}}},
+	}
+	got, ok := mtlsPrincipal(req, principals)
+	if !ok || got.Principal != "svc-payments" {
+		t.Fatalf("expected svc-payments principal, got %+v %t", got, ok)
+	}
+	caller := got.caller()
+	if caller.CredentialID != "mtls:svc-payments" || caller.TenantID == nil || *caller.TenantID != "acme" {
+		t.Fatalf("unexpected caller %+v", caller)
+	}
+	if !caller.hasScope(scopeDecisionsWrite) || !caller.hasScope(scopeUsageRead) || caller.hasScope(scopePoliciesAdmin) {
+		t.Fatalf("unexpected scopes %v", caller.Scopes)
+	}
+	relay := principals["relay.internal"].caller()
+	if relay.TenantID != nil || relay.hasScope(scopeTelemetryWrite) {
+		t.Fatalf("expected an unbound principal without scopes, got %+v", relay)
+	}
+	for _, raw := range []string{"missing-separator", "relay.internal=svc-relay;role=admin"} {
+		if _, err := parseMTLSPrincipals(raw); err == nil {
+			t.Fatalf("expected parse error for %q", raw)
+		}
+	}
+}
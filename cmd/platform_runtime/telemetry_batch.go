@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	platform "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/platform"
+	telemetryrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/telemetry"
+)
+
+// maxTelemetryBatchBody bounds the body of POST /v1/telemetry/events:batch.
+const maxTelemetryBatchBody = 8 << 20
+
+// handleTelemetryBatch runs behind writeRoute.
+func (a *httpAPI) handleTelemetryBatch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	res, cerr := a.recordTelemetryBatch(r.Context(), httpWriteCall(r), r.Header.Get("Content-Type"), body)
+	if cerr != nil {
+		writeCallError(w, cerr)
+		return
+	}
+	writeRawJSON(w, res.status, res.body)
+}
+
+// recordTelemetryBatch runs POST /v1/telemetry/events:batch. Each event goes
+// through the checks of a single telemetry write and is rejected on its own,
+// so one bad line does not cost the relay the rest of its burst. Quota is
+// charged per tenant for all of its valid events at once; a tenant without
+// room for them, or with more than its plan's max_batch_decisions, has all
+// of them rejected. Events the repository then rejects are refunded. Only a
+// malformed batch or a storage failure fails the whole request, and the
+// latter refunds the quota it charged.
+func (a *httpAPI) recordTelemetryBatch(ctx context.Context, call writeCall, contentType string, body []byte) (writeResult, *callError) {
+	const scope = "v1/telemetry/events:batch"
+	docs, cerr := splitTelemetryBatch(contentType, body)
+	if cerr != nil {
+		return writeResult{}, cerr
+	}
+	if len(docs) == 0 {
+		return writeResult{}, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "batch holds no events")
+	}
+	if len(docs) > runtimeapi.MaxTelemetryBatchEvents {
+		return writeResult{}, newCallError(http.StatusRequestEntityTooLarge, runtimeapi.CodePayloadTooLarge,
+			fmt.Sprintf("batch holds more than %d events", runtimeapi.MaxTelemetryBatchEvents))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.writeTimeout)
+	defer cancel()
+	results := make([]runtimeapi.TelemetryBatchResult, len(docs))
+	reject := func(i int, cerr *callError) {
+		p := completeProblem(cerr.problem)
+		results[i] = runtimeapi.TelemetryBatchResult{Index: i, Status: "rejected", Error: &p}
+	}
+
+	// Workspaces are verified once per distinct tenant and workspace.
+	type binding struct {
+		tenantID, workspaceID *string
+		err                   error
+	}
+	bindings := map[string]binding{}
+	events := make([]telemetryrepo.BatchEvent, 0, len(docs))
+	positions := make([]int, 0, len(docs))
+	perTenant := map[string]int64{}
+	for i, doc := range docs {
+		results[i].Index = i
+		req, cerr := decodeTelemetryEvent(doc)
+		if cerr != nil {
+			reject(i, cerr)
+			continue
+		}
+		key := trimmedPtr(req.TenantID) + "/" + trimmedPtr(req.WorkspaceID)
+		b, ok := bindings[key]
+		if !ok {
+			b.tenantID, b.workspaceID, b.err = a.bindCallerTenant(ctx, call.caller, req.TenantID, req.WorkspaceID)
+			bindings[key] = b
+		}
+		if b.err != nil {
+			reject(i, tenantBindingCallError(b.err))
+			continue
+		}
+		req.TenantID, req.WorkspaceID = b.tenantID, b.workspaceID
+		if req.TenantID != nil {
+			perTenant[*req.TenantID]++
+		}
+		events = append(events, telemetryBatchEvent(ctx, call, req))
+		positions = append(positions, i)
+	}
+
+	refused := map[string]*callError{}
+	charged := map[string]int64{}
+	for tenantID, n := range perTenant {
+		if q, found := a.tenantQuota(ctx, tenantID, scope); found && q.MaxBatchDecisions != nil && n > int64(*q.MaxBatchDecisions) {
+			a.metrics.quotaRejected(scope, "max_batch")
+			refused[tenantID] = newCallError(http.StatusRequestEntityTooLarge, runtimeapi.CodePayloadTooLarge,
+				fmt.Sprintf("batch holds more than %d events for this tenant", *q.MaxBatchDecisions))
+			continue
+		}
+		n, err := a.consumeEventQuota(ctx, &tenantID, n)
+		if err != nil {
+			a.metrics.quotaRejected(scope, "daily_events")
+			refused[tenantID] = quotaCallError(time.Now())
+			continue
+		}
+		charged[tenantID] = n
+	}
+	if len(refused) > 0 {
+		kept, keptPositions := events[:0], positions[:0]
+		for n, ev := range events {
+			if ev.Record.TenantID != nil && refused[*ev.Record.TenantID] != nil {
+				reject(positions[n], refused[*ev.Record.TenantID])
+				continue
+			}
+			kept, keptPositions = append(kept, ev), append(keptPositions, positions[n])
+		}
+		events, positions = kept, keptPositions
+	}
+
+	var resp []byte
+	// Events the repository rejects are refunded once the batch commits.
+	var unstored map[string]int64
+	_, err := a.rt.RecordSecurityEventBatch(ctx, events,
+		call.respond(http.StatusOK, func(ids platform.WriteIDs) []byte {
+			out := runtimeapi.TelemetryBatchResponse{RequestID: call.requestID, Results: results}
+			unstored = map[string]int64{}
+			for n, res := range ids.Batch {
+				i := positions[n]
+				if res.Err != nil {
+					reject(i, storeCallError(ctx, res.Err, "failed to persist telemetry event"))
+					if tenantID := events[n].Record.TenantID; tenantID != nil {
+						unstored[*tenantID]++
+					}
+					continue
+				}
+				results[i] = runtimeapi.TelemetryBatchResult{Index: i, Status: "accepted", EventID: res.EventID}
+			}
+			for _, res := range results {
+				if res.Status == "accepted" {
+					out.Accepted++
+				} else {
+					out.Rejected++
+				}
+			}
+			resp = mustMarshalJSON(out)
+			return resp
+		}))
+	if err != nil {
+		for tenantID, n := range charged {
+			a.refundEventQuota(ctx, &tenantID, n)
+		}
+		return writeResult{}, a.writeFailed(ctx, scope, err, "failed to persist telemetry batch")
+	}
+	for tenantID, n := range unstored {
+		a.refundEventQuota(ctx, &tenantID, min(n, charged[tenantID]))
+	}
+	return writeResult{status: http.StatusOK, body: resp}, nil
+}
+
+// splitTelemetryBatch splits a batch into one JSON document per event: the
+// elements of a JSON array, or the non-blank lines of NDJSON. Without a
+// Content-Type, a body starting with '[' is taken for an array.
+func splitTelemetryBatch(contentType string, body []byte) ([][]byte, *callError) {
+	mediaType := ""
+	if contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest, "invalid Content-Type")
+		}
+	} else if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		mediaType = "application/json"
+	}
+
+	switch mediaType {
+	case "application/json":
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, newCallError(http.StatusBadRequest, runtimeapi.CodeInvalidJSON, "batch must be a JSON array of events")
+		}
+		docs := make([][]byte, len(items))
+		for i, item := range items {
+			docs[i] = item
+		}
+		return docs, nil
+	case "", runtimeapi.NDJSONContentType, "application/jsonl":
+		var docs [][]byte
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				docs = append(docs, line)
+			}
+		}
+		return docs, nil
+	default:
+		return nil, newCallError(http.StatusBadRequest, runtimeapi.CodeBadRequest,
+			"Content-Type must be "+runtimeapi.NDJSONContentType+" or application/json")
+	}
+}
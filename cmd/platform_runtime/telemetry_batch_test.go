@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	runtimeapi "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+)
+
+func TestSplitTelemetryBatch(t *testing.T) {
+	cases := []struct {
+		name, contentType, body string
+		want                    []string
+	}{
+		{"ndjson", runtimeapi.NDJSONContentType, "{\"a\":1}\r\n\n  {\"b\":2}\n", []string{`{"a":1}`, `{"b":2}`}},
+		{"array", "application/json; charset=utf-8", `[{"a":1}, "x"]`, []string{`{"a":1}`, `"x"`}},
+		{"sniffed array", "", ` [{"a":1}]`, []string{`{"a":1}`}},
+		{"sniffed ndjson", "", "{\"a\":1}\nnot json", []string{`{"a":1}`, "not json"}},
+	}
+	for _, tc := range cases {
+		docs, cerr := splitTelemetryBatch(tc.contentType, []byte(tc.body))
+		if cerr != nil {
+			t.Fatalf("%s: unexpected error %v", tc.name, cerr)
+		}
+		if len(docs) != len(tc.want) {
+			t.Fatalf("%s: expected %d documents, got %q", tc.name, len(tc.want), docs)
+		}
+		for i := range docs {
+			if string(docs[i]) != tc.want[i] {
+				t.Fatalf("%s: document %d is %q, want %q", tc.name, i, docs[i], tc.want[i])
+			}
+		}
+	}
+
+	if _, cerr := splitTelemetryBatch("application/json", []byte(`{"a":1}`)); cerr == nil || cerr.problem.Code != runtimeapi.CodeInvalidJSON {
+		t.Fatalf("expected a JSON object to be refused as a batch, got %v", cerr)
+	}
+	if _, cerr := splitTelemetryBatch("text/csv", []byte("a,b")); cerr == nil || cerr.problem.Status != http.StatusBadRequest {
+		t.Fatalf("expected an unsupported content type to be refused, got %v", cerr)
+	}
+}
+
+func TestRecordTelemetryBatchLimits(t *testing.T) {
+	api := &httpAPI{}
+	if _, cerr := api.recordTelemetryBatch(context.Background(), writeCall{}, runtimeapi.NDJSONContentType, []byte("\n\n")); cerr == nil ||
+		cerr.problem.Status != http.StatusBadRequest {
+		t.Fatalf("expected an empty batch to be refused, got %v", cerr)
+	}
+	body := strings.Repeat("{}\n", runtimeapi.MaxTelemetryBatchEvents+1)
+	if _, cerr := api.recordTelemetryBatch(context.Background(), writeCall{}, runtimeapi.NDJSONContentType, []byte(body)); cerr == nil ||
+		cerr.problem.Code != runtimeapi.CodePayloadTooLarge {
+		t.Fatalf("expected an oversized batch to be refused, got %v", cerr)
+	}
+}
+
+func TestWriteRouteRefusesOversizedBody(t *testing.T) {
+	api := newTestGRPCRuntime(t, 10)
+	store := &fakeIdempotencyStore{}
+	api.idempotencyKeys = store
+	h := api.writeRoute("v1/things", scopeTelemetryWrite, 8, func(http.ResponseWriter, *http.Request) {
+		t.Fatalf("handler ran for an oversized body")
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newWriteRequest("grpc-token", "k1", `{"a":"123456"}`))
+	if rec.Code != http.StatusRequestEntityTooLarge || len(store.fingerprints) != 0 {
+		t.Fatalf("expected 413 without a claim, got %d after %d claims", rec.Code, len(store.fingerprints))
+	}
+	if !strings.Contains(rec.Body.String(), runtimeapi.CodePayloadTooLarge) {
+		t.Fatalf("expected a payload_too_large problem, got %q", rec.Body)
+	}
+}
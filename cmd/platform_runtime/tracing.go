@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+)
+
+// Trace exporters (RUNTIME_TRACE_EXPORTER).
+const (
+	traceExporterNone   = "none"
+	traceExporterStdout = "stdout"
+	traceExporterFile   = "file"
+	traceExporterOTLP   = "otlp"
+)
+
+const defaultOTLPTracesEndpoint = "http://localhost:4318/v1/traces"
+
+// runtimeTraceConfig is the tracer setup (RUNTIME_TRACE_*).
+type runtimeTraceConfig struct {
+	Exporter     string
+	File         string
+	OTLPEndpoint string
+	OTLPHeaders  map[string]string
+	SampleRatio  float64
+}
+
+func loadRuntimeTraceConfigFromEnv() (runtimeTraceConfig, error) {
+	cfg := runtimeTraceConfig{Exporter: traceExporterNone, OTLPEndpoint: defaultOTLPTracesEndpoint, SampleRatio: 1}
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("RUNTIME_TRACE_EXPORTER"))); v != "" {
+		switch v {
+		case traceExporterNone, traceExporterStdout, traceExporterFile, traceExporterOTLP:
+			cfg.Exporter = v
+		default:
+			return runtimeTraceConfig{}, fmt.Errorf("runtime: invalid RUNTIME_TRACE_EXPORTER")
+		}
+	}
+	cfg.File = strings.TrimSpace(os.Getenv("RUNTIME_TRACE_FILE"))
+	if cfg.Exporter == traceExporterFile && cfg.File == "" {
+		return runtimeTraceConfig{}, fmt.Errorf("runtime: RUNTIME_TRACE_FILE is required for the file exporter")
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_TRACE_OTLP_ENDPOINT")); v != "" {
+		cfg.OTLPEndpoint = v
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_TRACE_OTLP_HEADERS")); v != "" {
+		cfg.OTLPHeaders = map[string]string{}
+		for _, pair := range strings.Split(v, ",") {
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				return runtimeTraceConfig{}, fmt.Errorf("runtime: invalid RUNTIME_TRACE_OTLP_HEADERS")
+			}
+			cfg.OTLPHeaders[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("RUNTIME_TRACE_SAMPLE_RATIO")); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return runtimeTraceConfig{}, fmt.Errorf("runtime: invalid RUNTIME_TRACE_SAMPLE_RATIO")
+		}
+		cfg.SampleRatio = f
+	}
+	return cfg, nil
+}
+
+// newRuntimeTracer builds the tracer for cfg. With no exporter, spans are not
+// recorded but incoming trace ids are still propagated and stamped.
+func newRuntimeTracer(cfg runtimeTraceConfig) (*tracing.Tracer, error) {
+	var exp tracing.Exporter
+	switch cfg.Exporter {
+	case traceExporterStdout:
+		exp = tracing.NewWriterExporter(os.Stdout)
+	case traceExporterFile:
+		fileExp, err := tracing.NewFileExporter(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		exp = fileExp
+	case traceExporterOTLP:
+		otlpExp, err := tracing.NewOTLPExporter(tracing.OTLPConfig{
+			Endpoint:    cfg.OTLPEndpoint,
+			Headers:     cfg.OTLPHeaders,
+			ServiceName: "platform_runtime",
+		})
+		if err != nil {
+			return nil, err
+		}
+		exp = otlpExp
+	}
+	return tracing.NewTracer(tracing.Config{Exporter: exp, SampleRatio: cfg.SampleRatio}), nil
+}
+
+// withTracing starts a server span per request, continuing an incoming
+// traceparent, and adds trace_id to the request logger. It wraps the mux
+// directly so the span can be named after the matched route pattern.
+func withTracing(next http.Handler, tracer *tracing.Tracer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if sc, err := tracing.ParseTraceparent(r.Header.Get(tracing.TraceparentHeader)); err == nil {
+			ctx = tracing.ContextWithRemoteParent(ctx, sc)
+		}
+		ctx, span := tracer.Start(ctx, r.Method,
+			tracing.WithKind(tracing.SpanKindServer),
+			tracing.WithAttributes("http.request.method", r.Method, "url.path", r.URL.Path),
+		)
+		defer span.End()
+		traceID := span.SpanContext().TraceID.String()
+		ctx = logging.With(ctx, "trace_id", traceID)
+		noteAccessLogTrace(ctx, traceID)
+
+		traced := r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, traced)
+		// Surface the matched pattern to outer middleware, as the mux would.
+		r.Pattern = traced.Pattern
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if traced.Pattern != "" {
+			span.SetName(r.Method + " " + strings.TrimPrefix(traced.Pattern, r.Method+" "))
+			span.SetAttributes("http.route", traced.Pattern)
+		}
+		span.SetAttributes("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	})
+}
+
+// stampTraceID records the current trace id in a context_json/event_json map
+// so stored rows can be joined with the distributed trace.
+func stampTraceID(ctx context.Context, m map[string]interface{}) {
+	if traceID := tracing.TraceIDFromContext(ctx); traceID != "" {
+		m["trace_id"] = traceID
+	}
+}
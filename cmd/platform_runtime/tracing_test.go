@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+)
+
+func TestTracingContinuesTraceparent(t *testing.T) {
+	var spans, logs bytes.Buffer
+	tracer := tracing.NewTracer(tracing.Config{Exporter: tracing.NewWriterExporter(&spans), SampleRatio: 1, FlushInterval: time.Hour})
+	logger := logging.New(&logs, logging.Config{Level: slog.LevelInfo, Format: logging.FormatJSON})
+
+	var stamped map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/decisions", func(w http.ResponseWriter, r *http.Request) {
+		stamped = map[string]interface{}{"request_id": "req-1"}
+		stampTraceID(r.Context(), stamped)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	h := withAccessLog(withTracing(mux, tracer), logger, true, false)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/decisions", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if stamped["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected propagated trace id stamped, got %v", stamped)
+	}
+	var span map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(spans.Bytes()), &span); err != nil {
+		t.Fatalf("decode span %q: %v", spans.String(), err)
+	}
+	if span["name"] != "POST /v1/decisions" || span["parent_span_id"] != "00f067aa0ba902b7" || span["kind"] != "server" {
+		t.Fatalf("unexpected span %v", span)
+	}
+	if !strings.Contains(logs.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) || !strings.Contains(logs.String(), `"route":"/v1/decisions"`) {
+		t.Fatalf("expected access log with trace id and route, got %s", logs.String())
+	}
+}
+
+func TestTraceConfigFromEnv(t *testing.T) {
+	t.Setenv("RUNTIME_TRACE_EXPORTER", "otlp")
+	t.Setenv("RUNTIME_TRACE_OTLP_HEADERS", "Authorization=Bearer x, X-Tenant=a")
+	t.Setenv("RUNTIME_TRACE_SAMPLE_RATIO", "0.25")
+	cfg, err := loadRuntimeTraceConfigFromEnv()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Exporter != traceExporterOTLP || cfg.OTLPEndpoint != defaultOTLPTracesEndpoint || cfg.OTLPHeaders["X-Tenant"] != "a" || cfg.SampleRatio != 0.25 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	t.Setenv("RUNTIME_TRACE_EXPORTER", "file")
+	if _, err := loadRuntimeTraceConfigFromEnv(); err == nil {
+		t.Fatalf("expected file exporter without RUNTIME_TRACE_FILE rejected")
+	}
+}
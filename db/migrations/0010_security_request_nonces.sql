@@ -0,0 +1,25 @@
+-- Vedic x Betanet signed request replay protection (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- -------------------------------------------------------------------
+-- Seen nonces for HMAC-signed service requests
+-- -------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS security.request_nonces (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    key_id              TEXT NOT NULL, -- signing_key_versions.key_id used to sign
+    nonce               TEXT NOT NULL,
+    seen_at             TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at          TIMESTAMPTZ NOT NULL,
+    UNIQUE (key_id, nonce),
+    CONSTRAINT request_nonces_key_id_ck CHECK (length(trim(key_id)) > 0),
+    CONSTRAINT request_nonces_nonce_ck CHECK (length(trim(nonce)) > 0),
+    CONSTRAINT request_nonces_expiry_ck CHECK (expires_at > seen_at)
+);
+
+CREATE INDEX IF NOT EXISTS request_nonces_expiry_idx
+    ON security.request_nonces(expires_at);
+
+COMMIT;
@@ -0,0 +1,61 @@
+-- Vedic x Betanet threat level history and anomaly reports (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- -------------------------------------------------------------------
+-- Threat level transitions per node/tenant
+-- -------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS security.threat_level_history (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    node_name           TEXT NOT NULL,
+    tenant_id           UUID REFERENCES control_plane.tenants(id) ON DELETE SET NULL,
+    previous_level      TEXT,
+    new_level           TEXT NOT NULL,
+    reason_code         TEXT NOT NULL,
+    reason              TEXT NOT NULL,
+    security_event_id   UUID REFERENCES telemetry.security_events(id) ON DELETE SET NULL,
+    metadata_json       JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT threat_level_history_node_ck CHECK (length(trim(node_name)) > 0),
+    CONSTRAINT threat_level_history_previous_ck CHECK (previous_level IS NULL OR previous_level IN ('normal', 'elevated', 'high', 'critical')),
+    CONSTRAINT threat_level_history_new_ck CHECK (new_level IN ('normal', 'elevated', 'high', 'critical'))
+);
+
+CREATE INDEX IF NOT EXISTS threat_level_history_node_created_idx
+    ON security.threat_level_history(node_name, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS threat_level_history_tenant_created_idx
+    ON security.threat_level_history(tenant_id, created_at DESC);
+
+-- -------------------------------------------------------------------
+-- Anomaly reports (linked to events/decisions via telemetry.event_links
+-- rows with link_kind = 'anomaly_report')
+-- -------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS security.anomaly_reports (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    node_name           TEXT NOT NULL,
+    tenant_id           UUID REFERENCES control_plane.tenants(id) ON DELETE SET NULL,
+    anomaly_type        TEXT NOT NULL,
+    severity            TEXT NOT NULL,
+    status              TEXT NOT NULL DEFAULT 'open',
+    score               NUMERIC(10, 6),
+    summary             TEXT NOT NULL,
+    details_json        JSONB NOT NULL DEFAULT '{}'::jsonb,
+    detected_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at         TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT anomaly_reports_node_ck CHECK (length(trim(node_name)) > 0),
+    CONSTRAINT anomaly_reports_severity_ck CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT anomaly_reports_status_ck CHECK (status IN ('open', 'acknowledged', 'resolved', 'dismissed'))
+);
+
+CREATE INDEX IF NOT EXISTS anomaly_reports_tenant_detected_idx
+    ON security.anomaly_reports(tenant_id, detected_at DESC);
+
+CREATE INDEX IF NOT EXISTS anomaly_reports_status_detected_idx
+    ON security.anomaly_reports(status, detected_at DESC);
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Vedic x Betanet background maintenance run history (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- -------------------------------------------------------------------
+-- Maintenance job runs (one row per executed run, any replica)
+-- -------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS ops.maintenance_runs (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name            TEXT NOT NULL,
+    node_name           TEXT NOT NULL,
+    status              TEXT NOT NULL DEFAULT 'running',
+    started_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at         TIMESTAMPTZ,
+    affected_rows       BIGINT NOT NULL DEFAULT 0,
+    error_text          TEXT,
+    CONSTRAINT maintenance_runs_job_name_ck CHECK (length(trim(job_name)) > 0),
+    CONSTRAINT maintenance_runs_node_name_ck CHECK (length(trim(node_name)) > 0),
+    CONSTRAINT maintenance_runs_status_ck CHECK (status IN ('running', 'completed', 'failed')),
+    CONSTRAINT maintenance_runs_finished_ck CHECK (finished_at IS NULL OR finished_at >= started_at)
+);
+
+CREATE INDEX IF NOT EXISTS maintenance_runs_job_started_idx
+    ON ops.maintenance_runs(job_name, started_at DESC);
+
+CREATE INDEX IF NOT EXISTS maintenance_runs_started_idx
+    ON ops.maintenance_runs(started_at DESC);
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Vedic x Betanet public key material for asymmetric signing keys (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- -------------------------------------------------------------------
+-- Public JWK per key version, published at /.well-known/jwks.json
+-- -------------------------------------------------------------------
+
+-- Only public parameters may be stored; private members (d, p, q, dp, dq, qi, k) are rejected.
+ALTER TABLE security.signing_key_versions
+    ADD COLUMN IF NOT EXISTS public_jwk JSONB
+        CONSTRAINT signing_key_versions_public_jwk_ck CHECK (
+            public_jwk IS NULL
+            OR (
+                jsonb_typeof(public_jwk) = 'object'
+                AND public_jwk ? 'kty'
+                AND NOT (public_jwk ?| ARRAY['d', 'p', 'q', 'dp', 'dq', 'qi', 'k'])
+            )
+        );
+
+CREATE INDEX IF NOT EXISTS signing_key_versions_public_idx
+    ON security.signing_key_versions(valid_until)
+    WHERE public_jwk IS NOT NULL;
+
+COMMIT;
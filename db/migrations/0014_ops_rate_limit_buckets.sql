@@ -0,0 +1,24 @@
+-- Vedic x Betanet shared rate limit token buckets (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- -------------------------------------------------------------------
+-- Token buckets shared by all runtime replicas
+-- -------------------------------------------------------------------
+
+-- UNLOGGED: buckets are disposable state; a crash simply refills them.
+CREATE UNLOGGED TABLE IF NOT EXISTS ops.rate_limit_buckets (
+    bucket_key          TEXT PRIMARY KEY,
+    tokens              DOUBLE PRECISION NOT NULL,
+    last_allowed        BOOLEAN NOT NULL DEFAULT true,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at          TIMESTAMPTZ NOT NULL,
+    CONSTRAINT rate_limit_buckets_key_ck CHECK (length(trim(bucket_key)) > 0),
+    CONSTRAINT rate_limit_buckets_tokens_ck CHECK (tokens >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_expires_idx
+    ON ops.rate_limit_buckets(expires_at);
+
+COMMIT;
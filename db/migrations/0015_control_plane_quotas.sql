@@ -0,0 +1,82 @@
+-- Vedic x Betanet per-plan and per-tenant quotas (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- -------------------------------------------------------------------
+-- Plan tier quotas
+-- -------------------------------------------------------------------
+
+-- endpoint '*' is the plan default; a row for a specific runtime scope
+-- (e.g. 'v1/decisions') overrides it column by column. NULL means unlimited
+-- (request limits fall back to the runtime's global rate limit).
+-- burst means what RUNTIME_RATE_LIMIT_BURST means: extra tokens on top of one
+-- minute's refill, so the tenant's bucket holds requests_per_minute + burst
+-- and refills at requests_per_minute. NULL burst adds none.
+CREATE TABLE IF NOT EXISTS control_plane.plan_quotas (
+    plan                  TEXT NOT NULL,
+    endpoint              TEXT NOT NULL DEFAULT '*',
+    requests_per_minute   INTEGER,
+    burst                 INTEGER,
+    daily_event_limit     BIGINT,
+    max_batch_decisions   INTEGER,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (plan, endpoint),
+    CONSTRAINT plan_quotas_plan_ck CHECK (length(trim(plan)) > 0),
+    CONSTRAINT plan_quotas_endpoint_ck CHECK (length(trim(endpoint)) > 0),
+    CONSTRAINT plan_quotas_rpm_ck CHECK (requests_per_minute IS NULL OR requests_per_minute > 0),
+    CONSTRAINT plan_quotas_burst_ck CHECK (burst IS NULL OR burst > 0),
+    CONSTRAINT plan_quotas_daily_events_ck CHECK (daily_event_limit IS NULL OR daily_event_limit >= 0),
+    CONSTRAINT plan_quotas_batch_ck CHECK (max_batch_decisions IS NULL OR max_batch_decisions > 0)
+);
+
+INSERT INTO control_plane.plan_quotas (plan, endpoint, requests_per_minute, burst, daily_event_limit, max_batch_decisions)
+VALUES
+    ('free', '*', 60, 20, 10000, 100),
+    ('pro', '*', 600, 120, 1000000, 1000),
+    ('enterprise', '*', 6000, 1000, NULL, 5000)
+ON CONFLICT (plan, endpoint) DO NOTHING;
+
+-- -------------------------------------------------------------------
+-- Per-tenant overrides
+-- -------------------------------------------------------------------
+
+CREATE TABLE IF NOT EXISTS control_plane.tenant_quota_overrides (
+    tenant_id             UUID NOT NULL REFERENCES control_plane.tenants(id) ON DELETE CASCADE,
+    endpoint              TEXT NOT NULL DEFAULT '*',
+    requests_per_minute   INTEGER,
+    burst                 INTEGER,
+    daily_event_limit     BIGINT,
+    max_batch_decisions   INTEGER,
+    reason                TEXT,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, endpoint),
+    CONSTRAINT tenant_quota_overrides_endpoint_ck CHECK (length(trim(endpoint)) > 0),
+    CONSTRAINT tenant_quota_overrides_rpm_ck CHECK (requests_per_minute IS NULL OR requests_per_minute > 0),
+    CONSTRAINT tenant_quota_overrides_burst_ck CHECK (burst IS NULL OR burst > 0),
+    CONSTRAINT tenant_quota_overrides_daily_events_ck CHECK (daily_event_limit IS NULL OR daily_event_limit >= 0),
+    CONSTRAINT tenant_quota_overrides_batch_ck CHECK (max_batch_decisions IS NULL OR max_batch_decisions > 0)
+);
+
+-- -------------------------------------------------------------------
+-- Daily usage counters
+-- -------------------------------------------------------------------
+
+-- metric is 'events', 'decisions', or 'requests:<endpoint>'; usage_date is UTC.
+CREATE TABLE IF NOT EXISTS control_plane.usage_counters (
+    tenant_id             UUID NOT NULL REFERENCES control_plane.tenants(id) ON DELETE CASCADE,
+    usage_date            DATE NOT NULL,
+    metric                TEXT NOT NULL,
+    count                 BIGINT NOT NULL DEFAULT 0,
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, usage_date, metric),
+    CONSTRAINT usage_counters_metric_ck CHECK (length(trim(metric)) > 0),
+    CONSTRAINT usage_counters_count_ck CHECK (count >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS usage_counters_date_idx
+    ON control_plane.usage_counters(usage_date);
+
+COMMIT;
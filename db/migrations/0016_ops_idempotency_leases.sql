@@ -0,0 +1,21 @@
+-- Vedic x Betanet idempotency key leases (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- A request claims its key with a lease (locked_by/locked_until), then
+-- commits its write and the cached response in one transaction fenced on
+-- locked_by. A key left without a response by a dead owner may be taken
+-- over by a retry once its lease has lapsed. Rows written before this
+-- migration have no lease and are recoverable once they are older than the
+-- runtime's lease duration.
+ALTER TABLE ops.idempotency_keys
+    ADD COLUMN IF NOT EXISTS locked_by     TEXT,
+    ADD COLUMN IF NOT EXISTS locked_until  TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS completed_at  TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_in_progress_idx
+    ON ops.idempotency_keys(locked_until)
+    WHERE response_code IS NULL;
+
+COMMIT;
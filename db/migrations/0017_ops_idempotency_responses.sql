@@ -0,0 +1,14 @@
+-- Vedic x Betanet idempotent response metadata (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- Cached responses keep their status, the selected response headers and the
+-- raw body, so routes answering with something other than JSON can be made
+-- idempotent too. response_json stays readable for rows written before this
+-- migration; new rows leave it NULL.
+ALTER TABLE ops.idempotency_keys
+    ADD COLUMN IF NOT EXISTS response_headers  JSONB,
+    ADD COLUMN IF NOT EXISTS response_body     BYTEA;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Vedic x Betanet outbox dispatcher claims (v1)
+-- Target: PostgreSQL 14+
+
+BEGIN;
+
+-- A dispatcher claims due rows by moving them to 'processing' under a lease:
+-- locked_by names the claim and available_at becomes the lease expiry, so a
+-- row left behind by a dead dispatcher is claimed again once it lapses.
+-- attempt_count numbers the attempts (ops.outbox_attempts.attempt_no) and
+-- fences the result of each one to the claim that made it.
+ALTER TABLE ops.outbox
+    ADD COLUMN IF NOT EXISTS locked_by      TEXT,
+    ADD COLUMN IF NOT EXISTS attempt_count  INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS outbox_due_idx
+    ON ops.outbox(available_at)
+    WHERE status IN ('pending', 'processing');
+
+COMMIT;
@@ -0,0 +1,17 @@
+// Package migrations embeds the ordered SQL migrations, so a binary can check
+// the schema it was built against without the files on disk.
+package migrations
+
+import (
+	"embed"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Load returns the embedded migrations, ordered and validated.
+func Load() ([]dbpkg.Migration, error) {
+	return dbpkg.LoadMigrations(files)
+}
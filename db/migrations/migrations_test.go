@@ -0,0 +1,13 @@
+package migrations
+
+import "testing"
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migs, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(migs) == 0 || migs[0].Seq != 1 || migs[len(migs)-1].Seq != len(migs) {
+		t.Fatalf("expected a contiguous set from 0001, got %d migrations", len(migs))
+	}
+}
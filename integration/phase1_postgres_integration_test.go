@@ -3,11 +3,15 @@ package integration
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/sarat-asymmetrica/vedic-platform-experiments/db/migrations"
 	"github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	"github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/outbox"
+	"github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/telemetry"
 )
 
 // Phase 1 integration matrix:
@@ -15,6 +19,11 @@ import (
 // 2. security runtime tables from 0003 are available.
 // 3. decision lineage tables from 0004 are available.
 // 4. analytics foundation tables from 0005 are available.
+// 5. idempotency key lease columns from 0016 are available.
+// 6. a telemetry batch skips only the events a constraint rejects.
+// 7. the schema holds the embedded migrations unchanged, with pgcrypto.
+// 8. the outbox dispatcher delivers, retries with backoff and fails messages,
+//    recording every attempt.
 //
 // This suite is environment-gated and skips unless INTEGRATION_DATABASE_URL is set.
 
@@ -29,6 +38,14 @@ func TestPhase1SchemaMatrix(t *testing.T) {
 		{"security", "revoked_tokens"},
 		{"security", "revoked_sessions"},
 		{"security", "nonce_watermarks"},
+		{"security", "request_nonces"},
+		{"security", "threat_level_history"},
+		{"security", "anomaly_reports"},
+		{"ops", "maintenance_runs"},
+		{"ops", "rate_limit_buckets"},
+		{"control_plane", "plan_quotas"},
+		{"control_plane", "tenant_quota_overrides"},
+		{"control_plane", "usage_counters"},
 		{"authz", "policy_decisions"},
 		{"authz", "policy_decision_trace_steps"},
 		{"telemetry", "security_events"},
@@ -49,6 +66,160 @@ func TestPhase1SchemaMatrix(t *testing.T) {
 	}
 }
 
+func TestIdempotencyKeyColumns(t *testing.T) {
+	conn := openIntegrationDB(t)
+	defer conn.Close()
+
+	for _, column := range []string{"locked_by", "locked_until", "completed_at", "response_headers", "response_body"} {
+		t.Run(column, func(t *testing.T) {
+			var exists bool
+			err := conn.QueryRow(
+				`SELECT EXISTS (
+				   SELECT 1
+				     FROM information_schema.columns
+				    WHERE table_schema = 'ops'
+				      AND table_name = 'idempotency_keys'
+				      AND column_name = $1
+				)`,
+				column,
+			).Scan(&exists)
+			if err != nil {
+				t.Fatalf("column existence query failed for %s: %v", column, err)
+			}
+			if !exists {
+				t.Fatalf("missing column ops.idempotency_keys.%s", column)
+			}
+		})
+	}
+}
+
+func TestTelemetryEventBatchSkipsRejectedEvents(t *testing.T) {
+	conn := openIntegrationDB(t)
+	defer conn.Close()
+	repo, err := telemetry.NewRepository(conn)
+	if err != nil {
+		t.Fatalf("new repository: %v", err)
+	}
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	missingTenant := "00000000-0000-4000-8000-000000000000"
+	event := func(msg string) telemetry.SecurityEventRecord {
+		return telemetry.SecurityEventRecord{ActorType: "service", EventType: "integration.batch", Message: msg}
+	}
+	orphan := event("unknown tenant")
+	orphan.TenantID = &missingTenant
+	results, err := repo.PersistSecurityEventBatchTx(ctx, tx, []telemetry.BatchEvent{
+		{Record: event("first")},
+		{Record: orphan},
+		{Record: event("second")},
+		{Record: event("linked"), Links: []telemetry.EventLink{{LinkKind: "resource", LinkedID: missingTenant}}},
+	})
+	if err != nil {
+		t.Fatalf("persist batch: %v", err)
+	}
+	if results[0].Err != nil || results[3].Err != nil || !errors.Is(results[1].Err, db.ErrForeignKey) {
+		t.Fatalf("expected only the orphan event to be rejected, got %+v", results)
+	}
+	var links int
+	if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM telemetry.event_links WHERE event_id = $1`, results[3].EventID).Scan(&links); err != nil || links != 1 {
+		t.Fatalf("expected the linked event's link to be written, got %d: %v", links, err)
+	}
+}
+
+func TestSchemaMatchesEmbeddedMigrations(t *testing.T) {
+	conn := openIntegrationDB(t)
+	defer conn.Close()
+	migs, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("load embedded migrations: %v", err)
+	}
+	status, err := db.CheckMigrations(context.Background(), conn, migs)
+	if err != nil {
+		t.Fatalf("check migrations: %v", err)
+	}
+	if err := status.Err(); err != nil {
+		t.Fatalf("schema is not current: %v", err)
+	}
+	var pgcrypto bool
+	if err := conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pgcrypto')`).Scan(&pgcrypto); err != nil || !pgcrypto {
+		t.Fatalf("expected pgcrypto to be installed: %v", err)
+	}
+}
+
+func TestOutboxDispatcherRecordsAttempts(t *testing.T) {
+	conn := openIntegrationDB(t)
+	defer conn.Close()
+	ctx := context.Background()
+
+	// Event types unique to this run keep the dispatcher off other rows.
+	suffix := time.Now().UTC().Format("20060102T150405.000000000")
+	okType, retryType, badType := "integration.ok."+suffix, "integration.retry."+suffix, "integration.bad."+suffix
+	ids := map[string]string{}
+	for _, eventType := range []string{okType, retryType, badType} {
+		if _, err := outbox.Enqueue(ctx, conn, outbox.Message{AggregateType: "integration", EventType: eventType, Payload: []byte(`{}`), DedupeKey: eventType}); err != nil {
+			t.Fatalf("enqueue %s: %v", eventType, err)
+		}
+		if queued, err := outbox.Enqueue(ctx, conn, outbox.Message{AggregateType: "integration", EventType: eventType, Payload: []byte(`{}`), DedupeKey: eventType}); err != nil || queued {
+			t.Fatalf("expected a duplicate dedupe key to be ignored, got %v %v", queued, err)
+		}
+		var id string
+		if err := conn.QueryRowContext(ctx, `SELECT id::text FROM ops.outbox WHERE dedupe_key = $1`, eventType).Scan(&id); err != nil {
+			t.Fatalf("lookup %s: %v", eventType, err)
+		}
+		ids[eventType] = id
+	}
+	defer conn.ExecContext(ctx, `DELETE FROM ops.outbox WHERE dedupe_key IN ($1, $2, $3)`, okType, retryType, badType)
+
+	d, err := outbox.NewDispatcher(conn, outbox.Config{ProcessorName: "integration", BaseBackoff: time.Hour, MaxBackoff: time.Hour})
+	if err != nil {
+		t.Fatalf("new dispatcher: %v", err)
+	}
+	_ = d.Register(okType, outbox.SinkFunc(func(context.Context, outbox.Message) error { return nil }))
+	_ = d.Register(retryType, outbox.SinkFunc(func(context.Context, outbox.Message) error { return errors.New("receiver down") }))
+	_ = d.Register(badType, outbox.SinkFunc(func(context.Context, outbox.Message) error {
+		return &outbox.DeliveryError{Code: "rejected", Err: errors.New("bad payload"), Permanent: true}
+	}))
+	if n, err := d.RunOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("expected 3 messages claimed, got %d: %v", n, err)
+	}
+	// The retried message waits out its backoff, so a second run claims nothing.
+	if n, err := d.RunOnce(ctx); err != nil || n != 0 {
+		t.Fatalf("expected nothing due, got %d: %v", n, err)
+	}
+
+	for eventType, want := range map[string]struct {
+		status  string
+		success bool
+		code    string
+	}{
+		okType:    {outbox.StatusProcessed, true, ""},
+		retryType: {outbox.StatusPending, false, "delivery_failed"},
+		badType:   {outbox.StatusFailed, false, "rejected"},
+	} {
+		var status, code string
+		var success, delayed bool
+		err := conn.QueryRowContext(
+			ctx,
+			`SELECT o.status, o.available_at > now() + interval '30 minutes', a.success, COALESCE(a.error_code, '')
+			   FROM ops.outbox o
+			   JOIN ops.outbox_attempts a ON a.outbox_id = o.id AND a.attempt_no = 1
+			  WHERE o.id = $1::uuid AND o.locked_by IS NULL AND o.attempt_count = 1`,
+			ids[eventType],
+		).Scan(&status, &delayed, &success, &code)
+		if err != nil {
+			t.Fatalf("%s: read outcome: %v", eventType, err)
+		}
+		if status != want.status || success != want.success || code != want.code || delayed != (want.status == outbox.StatusPending) {
+			t.Fatalf("%s: expected %+v, got status=%s success=%v code=%q delayed=%v", eventType, want, status, success, code, delayed)
+		}
+	}
+}
+
 func openIntegrationDB(t *testing.T) *sql.DB {
 	t.Helper()
 	url := os.Getenv("INTEGRATION_DATABASE_URL")
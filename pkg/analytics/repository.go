@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
 )
 
 // Repository handles persistence for vedic analytics foundation tables.
@@ -101,7 +103,7 @@ func (r *Repository) UpsertModelVersion(ctx context.Context, rec ModelVersionRec
 		status,
 	).Scan(&id)
 	if err != nil {
-		return "", err
+		return "", dbpkg.Classify("analytics: upsert model version", err)
 	}
 	return id, nil
 }
@@ -149,7 +151,7 @@ func (r *Repository) UpsertTransaction(ctx context.Context, rec TransactionRecor
 		meta,
 	).Scan(&id)
 	if err != nil {
-		return "", err
+		return "", dbpkg.Classify("analytics: upsert transaction", err)
 	}
 	return id, nil
 }
@@ -188,7 +190,7 @@ func (r *Repository) UpsertTransactionFeatures(ctx context.Context, rec FeatureR
 		featureJSON,
 	).Scan(&id)
 	if err != nil {
-		return "", err
+		return "", dbpkg.Classify("analytics: upsert transaction features", err)
 	}
 	return id, nil
 }
@@ -227,77 +229,77 @@ func (r *Repository) UpsertScore(ctx context.Context, rec ScoreRecord) (string,
 		rec.Explanation,
 	).Scan(&id)
 	if err != nil {
-		return "", err
+		return "", dbpkg.Classify("analytics: upsert score", err)
 	}
 	return id, nil
 }
 
 func validateModelVersionRecord(rec ModelVersionRecord) error {
 	if strings.TrimSpace(rec.ModelKey) == "" {
-		return fmt.Errorf("analytics: model key is required")
+		return dbpkg.Validationf("analytics: model key is required")
 	}
 	if strings.TrimSpace(rec.ModelVersion) == "" {
-		return fmt.Errorf("analytics: model version is required")
+		return dbpkg.Validationf("analytics: model version is required")
 	}
 	if strings.TrimSpace(rec.ConfigChecksum) == "" {
-		return fmt.Errorf("analytics: config checksum is required")
+		return dbpkg.Validationf("analytics: config checksum is required")
 	}
 	status := strings.TrimSpace(rec.Status)
 	if status != "" && status != "active" && status != "deprecated" && status != "retired" {
-		return fmt.Errorf("analytics: invalid model status %q", rec.Status)
+		return dbpkg.Validationf("analytics: invalid model status %q", rec.Status)
 	}
 	return nil
 }
 
 func validateTransactionRecord(rec TransactionRecord) error {
 	if strings.TrimSpace(rec.ExternalTxnID) == "" {
-		return fmt.Errorf("analytics: external transaction id is required")
+		return dbpkg.Validationf("analytics: external transaction id is required")
 	}
 	if strings.TrimSpace(rec.SourceSystem) == "" {
-		return fmt.Errorf("analytics: source system is required")
+		return dbpkg.Validationf("analytics: source system is required")
 	}
 	if strings.TrimSpace(rec.EventTime) == "" {
-		return fmt.Errorf("analytics: event time is required")
+		return dbpkg.Validationf("analytics: event time is required")
 	}
 	if strings.TrimSpace(rec.TxnType) == "" {
-		return fmt.Errorf("analytics: txn type is required")
+		return dbpkg.Validationf("analytics: txn type is required")
 	}
 	if strings.TrimSpace(rec.Amount) == "" {
-		return fmt.Errorf("analytics: amount is required")
+		return dbpkg.Validationf("analytics: amount is required")
 	}
 	if strings.TrimSpace(rec.Currency) == "" {
-		return fmt.Errorf("analytics: currency is required")
+		return dbpkg.Validationf("analytics: currency is required")
 	}
 	return nil
 }
 
 func validateFeatureRecord(rec FeatureRecord) error {
 	if strings.TrimSpace(rec.TransactionID) == "" {
-		return fmt.Errorf("analytics: transaction id is required")
+		return dbpkg.Validationf("analytics: transaction id is required")
 	}
 	if rec.DigitalRoot < 0 || rec.DigitalRoot > 9 {
-		return fmt.Errorf("analytics: digital root must be between 0 and 9")
+		return dbpkg.Validationf("analytics: digital root must be between 0 and 9")
 	}
 	if strings.TrimSpace(rec.AmountBucket) == "" {
-		return fmt.Errorf("analytics: amount bucket is required")
+		return dbpkg.Validationf("analytics: amount bucket is required")
 	}
 	return nil
 }
 
 func validateScoreRecord(rec ScoreRecord) error {
 	if strings.TrimSpace(rec.TransactionID) == "" {
-		return fmt.Errorf("analytics: transaction id is required")
+		return dbpkg.Validationf("analytics: transaction id is required")
 	}
 	if strings.TrimSpace(rec.ModelVersionID) == "" {
-		return fmt.Errorf("analytics: model version id is required")
+		return dbpkg.Validationf("analytics: model version id is required")
 	}
 	if strings.TrimSpace(rec.RiskScore) == "" {
-		return fmt.Errorf("analytics: risk score is required")
+		return dbpkg.Validationf("analytics: risk score is required")
 	}
 	switch strings.TrimSpace(rec.RiskLevel) {
 	case "LOW", "MEDIUM", "HIGH", "CRITICAL":
 	default:
-		return fmt.Errorf("analytics: invalid risk level %q", rec.RiskLevel)
+		return dbpkg.Validationf("analytics: invalid risk level %q", rec.RiskLevel)
 	}
 	return nil
 }
@@ -0,0 +1,342 @@
+// Package api holds the JSON wire types of the runtime HTTP API. The
+// platform_runtime handlers and pkg/client both use them, so the server and
+// its Go callers cannot drift apart.
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Request and idempotency headers.
+const (
+	HeaderRequestID      = "X-Request-ID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderAPIKey         = "X-API-Key"
+	HeaderTenantID       = "X-Tenant-ID"
+	HeaderSessionID      = "X-Session-ID"
+	// HeaderIdempotentReplayed is "true" on a response replayed for a
+	// retried Idempotency-Key.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// ProblemContentType is the media type of every error response.
+const ProblemContentType = "application/problem+json"
+
+// NDJSONContentType is the media type of a newline-delimited JSON batch.
+const NDJSONContentType = "application/x-ndjson"
+
+// Stable problem codes (Problem.Code). Clients branch on the code; Detail is
+// for humans and may change.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidJSON          = "invalid_json"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeMissingCredentials   = "missing_credentials"
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeCredentialsRevoked   = "credentials_revoked"
+	CodeSignatureRequired    = "signature_required"
+	CodeInvalidSignature     = "invalid_signature"
+	CodeForbidden            = "forbidden"
+	CodeInsufficientScope    = "insufficient_scope"
+	CodeTenantMismatch       = "tenant_mismatch"
+	CodeWorkspaceMismatch    = "workspace_mismatch"
+	CodeNoTenantMembership   = "no_tenant_membership"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeRequestInProgress    = "request_in_progress"
+	CodeReferenceNotFound    = "reference_not_found"
+	CodeRateLimited          = "rate_limited"
+	CodeClientBlocked        = "client_blocked"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "unavailable"
+	CodeAuthUnavailable      = "auth_unavailable"
+)
+
+// DecisionWriteRequest is the body of POST /v1/decisions: one policy
+// decision plus the security event recorded with it.
+type DecisionWriteRequest struct {
+	TenantID        *string                `json:"tenant_id"`
+	WorkspaceID     *string                `json:"workspace_id"`
+	Subject         string                 `json:"subject"`
+	SessionID       *string                `json:"session_id"`
+	PolicySetID     *string                `json:"policy_set_id"`
+	PolicySetKey    *string                `json:"policy_set_key"`
+	Tier            *string                `json:"tier"`
+	Action          string                 `json:"action"`
+	ResourceRef     string                 `json:"resource_ref"`
+	Allow           bool                   `json:"allow"`
+	ReasonCode      string                 `json:"reason_code"`
+	MatchedRuleID   *string                `json:"matched_rule_id"`
+	Trace           []DecisionTraceStep    `json:"trace"`
+	DecisionContext map[string]interface{} `json:"decision_context"`
+	ActorType       string                 `json:"actor_type"`
+	ActorID         *string                `json:"actor_id"`
+	EventType       string                 `json:"event_type"`
+	Severity        string                 `json:"severity"`
+	Message         string                 `json:"message"`
+	Event           map[string]interface{} `json:"event"`
+}
+
+// DecisionTraceStep is one evaluated rule of a decision trace.
+type DecisionTraceStep struct {
+	StepOrder int    `json:"step_order"`
+	RuleID    string `json:"rule_id"`
+	Matched   bool   `json:"matched"`
+	Outcome   string `json:"outcome"`
+	Reason    string `json:"reason"`
+}
+
+// DecisionWriteResponse is the 202 body of POST /v1/decisions.
+type DecisionWriteResponse struct {
+	RequestID  string `json:"request_id"`
+	DecisionID string `json:"decision_id"`
+	EventID    string `json:"event_id"`
+	Status     string `json:"status"`
+}
+
+// TelemetryWriteRequest is the body of POST /v1/telemetry/events.
+type TelemetryWriteRequest struct {
+	TenantID    *string                `json:"tenant_id"`
+	WorkspaceID *string                `json:"workspace_id"`
+	ActorType   string                 `json:"actor_type"`
+	ActorID     *string                `json:"actor_id"`
+	EventType   string                 `json:"event_type"`
+	Severity    string                 `json:"severity"`
+	Message     string                 `json:"message"`
+	TraceHash   *string                `json:"trace_hash"`
+	Event       map[string]interface{} `json:"event"`
+	Links       []TelemetryEventLink   `json:"links"`
+}
+
+// TelemetryEventLink links a telemetry event to a decision or other record.
+type TelemetryEventLink struct {
+	LinkKind string                 `json:"link_kind"`
+	LinkedID string                 `json:"linked_id"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// TelemetryWriteResponse is the 202 body of POST /v1/telemetry/events.
+type TelemetryWriteResponse struct {
+	RequestID string `json:"request_id"`
+	EventID   string `json:"event_id"`
+	Status    string `json:"status"`
+}
+
+// MaxTelemetryBatchEvents is the most events POST /v1/telemetry/events:batch
+// accepts in one request.
+const MaxTelemetryBatchEvents = 5000
+
+// TelemetryBatchResponse is the 200 body of POST /v1/telemetry/events:batch.
+// Results hold one entry per event, in request order.
+type TelemetryBatchResponse struct {
+	RequestID string                 `json:"request_id"`
+	Accepted  int                    `json:"accepted"`
+	Rejected  int                    `json:"rejected"`
+	Results   []TelemetryBatchResult `json:"results"`
+}
+
+// TelemetryBatchResult is the outcome of one event of a batch. Index is its
+// zero-based position: the array index, or the NDJSON line not counting
+// blank lines. A rejected event carries the problem a single write of it
+// would have returned.
+type TelemetryBatchResult struct {
+	Index   int      `json:"index"`
+	Status  string   `json:"status"`
+	EventID string   `json:"event_id,omitempty"`
+	Error   *Problem `json:"error,omitempty"`
+}
+
+// ThreatLevel is one node threat level transition.
+type ThreatLevel struct {
+	ID              string          `json:"id"`
+	NodeName        string          `json:"node_name"`
+	TenantID        *string         `json:"tenant_id"`
+	PreviousLevel   *string         `json:"previous_level"`
+	NewLevel        string          `json:"new_level"`
+	ReasonCode      string          `json:"reason_code"`
+	Reason          string          `json:"reason"`
+	SecurityEventID *string         `json:"security_event_id"`
+	Metadata        json.RawMessage `json:"metadata"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// ThreatLevelList is the body of GET /v1/security/threat-levels.
+type ThreatLevelList struct {
+	Items []ThreatLevel `json:"items"`
+}
+
+// AnomalyReport is one anomaly report; Links is only set on single-report reads.
+type AnomalyReport struct {
+	ID          string              `json:"id"`
+	NodeName    string              `json:"node_name"`
+	TenantID    *string             `json:"tenant_id"`
+	AnomalyType string              `json:"anomaly_type"`
+	Severity    string              `json:"severity"`
+	Status      string              `json:"status"`
+	Score       *string             `json:"score"`
+	Summary     string              `json:"summary"`
+	Details     json.RawMessage     `json:"details"`
+	DetectedAt  time.Time           `json:"detected_at"`
+	ResolvedAt  *time.Time          `json:"resolved_at"`
+	Links       []AnomalyReportLink `json:"links,omitempty"`
+}
+
+// AnomalyReportLink is a security event (and its decision) behind a report.
+type AnomalyReportLink struct {
+	SecurityEventID string    `json:"security_event_id"`
+	EventType       string    `json:"event_type"`
+	Severity        string    `json:"severity"`
+	DecisionID      *string   `json:"decision_id"`
+	LinkedAt        time.Time `json:"linked_at"`
+}
+
+// AnomalyReportList is the body of GET /v1/security/anomaly-reports.
+type AnomalyReportList struct {
+	Items []AnomalyReport `json:"items"`
+}
+
+// HealthReport is the body of GET /v1/admin/health. Status is the worst
+// component status: "ok", "degraded" or "down".
+type HealthReport struct {
+	Status     string            `json:"status"`
+	NodeName   string            `json:"node_name,omitempty"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Components []ComponentHealth `json:"components"`
+}
+
+// ComponentHealth is one dependency of a HealthReport: db, pool, schema,
+// extensions, keyring or jobs.
+type ComponentHealth struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// MaintenanceStatus is the body of GET /v1/admin/maintenance.
+type MaintenanceStatus struct {
+	NodeName   string                 `json:"node_name"`
+	Jobs       []MaintenanceJobStatus `json:"jobs"`
+	RecentRuns []MaintenanceRun       `json:"recent_runs"`
+}
+
+// MaintenanceJobStatus is a maintenance job's state on the answering replica.
+type MaintenanceJobStatus struct {
+	Name           string     `json:"name"`
+	Interval       string     `json:"interval"`
+	Running        bool       `json:"running"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	RunCount       int64      `json:"run_count"`
+	FailureCount   int64      `json:"failure_count"`
+	SkipCount      int64      `json:"skip_count"`
+}
+
+// MaintenanceRun is one recorded maintenance run of any replica.
+type MaintenanceRun struct {
+	ID           string     `json:"id"`
+	JobName      string     `json:"job_name"`
+	NodeName     string     `json:"node_name"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	AffectedRows int64      `json:"affected_rows"`
+	ErrorText    *string    `json:"error_text"`
+}
+
+// MaintenanceTrigger is the body of POST /jobs/{name}/run on the admin
+// listener. Status is "skipped" when another replica held the job's lock.
+type MaintenanceTrigger struct {
+	JobName   string  `json:"job_name"`
+	NodeName  string  `json:"node_name"`
+	Status    string  `json:"status"`
+	ErrorText *string `json:"error_text"`
+}
+
+// CacheFlush is the body of POST /caches/flush on the admin listener and
+// names the caches that were emptied.
+type CacheFlush struct {
+	Flushed []string `json:"flushed"`
+}
+
+// IdempotencyRecord is the body of GET /v1/admin/idempotency-keys/{key}.
+// State is "in_progress" until a response is stored, then "completed".
+type IdempotencyRecord struct {
+	Scope       string              `json:"scope"`
+	Key         string              `json:"key"`
+	Fingerprint string              `json:"fingerprint"`
+	State       string              `json:"state"`
+	CreatedAt   time.Time           `json:"created_at"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+	LockedUntil *time.Time          `json:"locked_until,omitempty"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+	Response    *IdempotentResponse `json:"response,omitempty"`
+}
+
+// IdempotentResponse is a stored response. Body is inline if it is JSON,
+// otherwise a JSON string.
+type IdempotentResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    json.RawMessage     `json:"body,omitempty"`
+}
+
+// UsageReport is the body of GET /v1/usage. From and To are YYYY-MM-DD.
+type UsageReport struct {
+	TenantID string         `json:"tenant_id"`
+	Plan     string         `json:"plan"`
+	Quota    Quota          `json:"quota"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Items    []UsageCounter `json:"items"`
+}
+
+// Quota is a tenant's effective quota. Nil limits are unlimited.
+type Quota struct {
+	TenantID          string `json:"tenant_id"`
+	Plan              string `json:"plan"`
+	Endpoint          string `json:"endpoint"`
+	RequestsPerMinute *int   `json:"requests_per_minute"`
+	Burst             *int   `json:"burst"`
+	DailyEventLimit   *int64 `json:"daily_event_limit"`
+	MaxBatchDecisions *int   `json:"max_batch_decisions"`
+}
+
+// UsageCounter is one daily usage counter.
+type UsageCounter struct {
+	UsageDate time.Time `json:"usage_date"`
+	Metric    string    `json:"metric"`
+	Count     int64     `json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Status is the body of /livez, /healthz and /readyz.
+type Status struct {
+	Status string `json:"status"`
+}
+
+// Problem is an RFC 7807 problem details body with a stable code extension.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Code   string       `json:"code"`
+	Detail string       `json:"detail,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes one invalid field; Field is a path like trace[0].outcome.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
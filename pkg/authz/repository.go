@@ -5,6 +5,10 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
 )
 
 // DecisionRecord represents a policy decision row for authz.policy_decisions.
@@ -47,7 +51,35 @@ func NewRepository(db *sql.DB) (*Repository, error) {
 }
 
 // PersistDecisionWithTrace stores a decision and its trace steps in one transaction.
-func (r *Repository) PersistDecisionWithTrace(ctx context.Context, rec DecisionRecord, steps []TraceStep) (string, error) {
+func (r *Repository) PersistDecisionWithTrace(ctx context.Context, rec DecisionRecord, steps []TraceStep) (decisionID string, err error) {
+	err = dbpkg.WithTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		var txErr error
+		decisionID, txErr = r.PersistDecisionWithTraceTx(ctx, tx, rec, steps)
+		return txErr
+	})
+	if err != nil {
+		return "", dbpkg.Classify("authz: persist decision", err)
+	}
+	return decisionID, nil
+}
+
+// PersistDecisionWithTraceTx stores a decision and its trace steps in the
+// caller's transaction, so they commit together with the caller's other writes.
+func (r *Repository) PersistDecisionWithTraceTx(ctx context.Context, tx *sql.Tx, rec DecisionRecord, steps []TraceStep) (decisionID string, err error) {
+	ctx, span := tracing.Start(ctx, "authz.PersistDecisionWithTrace",
+		tracing.WithKind(tracing.SpanKindClient),
+		tracing.WithAttributes("db.system", "postgresql", "authz.action", rec.Action, "authz.trace_steps", len(steps)),
+	)
+	defer func() {
+		err = dbpkg.Classify("authz: persist decision", err)
+		span.SetAttributes("authz.decision_id", decisionID)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if tx == nil {
+		return "", fmt.Errorf("authz: nil tx")
+	}
 	if err := validateDecisionRecord(rec); err != nil {
 		return "", err
 	}
@@ -55,15 +87,7 @@ func (r *Repository) PersistDecisionWithTrace(ctx context.Context, rec DecisionR
 		return "", err
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	decisionID, err := insertDecision(ctx, tx, rec)
+	decisionID, err = insertDecision(ctx, tx, rec)
 	if err != nil {
 		return "", err
 	}
@@ -84,9 +108,14 @@ func (r *Repository) PersistDecisionWithTrace(ctx context.Context, rec DecisionR
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return "", err
-	}
+	logging.FromContext(ctx).Debug(
+		"authz decision persisted",
+		"decision_id", decisionID,
+		"action", rec.Action,
+		"allow", rec.Allow,
+		"reason_code", rec.ReasonCode,
+		"trace_steps", len(steps),
+	)
 	return decisionID, nil
 }
 
@@ -128,21 +157,21 @@ func insertDecision(ctx context.Context, tx *sql.Tx, rec DecisionRecord) (string
 
 func validateDecisionRecord(rec DecisionRecord) error {
 	if strings.TrimSpace(rec.Subject) == "" {
-		return fmt.Errorf("authz: subject is required")
+		return dbpkg.Validationf("authz: subject is required")
 	}
 	if strings.TrimSpace(rec.Action) == "" {
-		return fmt.Errorf("authz: action is required")
+		return dbpkg.Validationf("authz: action is required")
 	}
 	if strings.TrimSpace(rec.ResourceRef) == "" {
-		return fmt.Errorf("authz: resource ref is required")
+		return dbpkg.Validationf("authz: resource ref is required")
 	}
 	if strings.TrimSpace(rec.ReasonCode) == "" {
-		return fmt.Errorf("authz: reason code is required")
+		return dbpkg.Validationf("authz: reason code is required")
 	}
 	if rec.Tier != nil {
 		tier := strings.TrimSpace(*rec.Tier)
 		if tier != "" && tier != "T1" && tier != "T2" && tier != "T3" && tier != "T4" {
-			return fmt.Errorf("authz: invalid tier %q", tier)
+			return dbpkg.Validationf("authz: invalid tier %q", tier)
 		}
 	}
 	return nil
@@ -151,18 +180,18 @@ func validateDecisionRecord(rec DecisionRecord) error {
 func validateTraceSteps(steps []TraceStep) error {
 	for _, s := range steps {
 		if s.StepOrder < 0 {
-			return fmt.Errorf("authz: step order must be >= 0")
+			return dbpkg.Validationf("authz: step order must be >= 0")
 		}
 		if strings.TrimSpace(s.RuleID) == "" {
-			return fmt.Errorf("authz: trace rule id is required")
+			return dbpkg.Validationf("authz: trace rule id is required")
 		}
 		if strings.TrimSpace(s.Reason) == "" {
-			return fmt.Errorf("authz: trace reason is required")
+			return dbpkg.Validationf("authz: trace reason is required")
 		}
 		switch strings.TrimSpace(s.Outcome) {
 		case "no_match", "allow", "deny":
 		default:
-			return fmt.Errorf("authz: invalid trace outcome %q", s.Outcome)
+			return dbpkg.Validationf("authz: invalid trace outcome %q", s.Outcome)
 		}
 	}
 	return nil
@@ -0,0 +1,72 @@
+package client
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	security "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+// Authenticator adds credentials to an outgoing request. body is the exact
+// request body (nil for reads). It is called again for every retry.
+type Authenticator interface {
+	Authenticate(req *http.Request, body []byte) error
+}
+
+// APIKey authenticates with a database credential in X-API-Key.
+type APIKey string
+
+func (k APIKey) Authenticate(req *http.Request, _ []byte) error {
+	req.Header.Set(api.HeaderAPIKey, string(k))
+	return nil
+}
+
+// BearerToken authenticates with an Authorization: Bearer token, either a
+// database credential or an OIDC access token.
+type BearerToken string
+
+func (t BearerToken) Authenticate(req *http.Request, _ []byte) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// RequestSigner HMAC-signs requests with a security.signing_key_versions key
+// (see security.SignRequest) on top of the caller credential in Auth.
+type RequestSigner struct {
+	KeyID string
+	Key   []byte
+	Auth  Authenticator    // caller credential; may be nil for mTLS callers
+	Now   func() time.Time // defaults to time.Now
+}
+
+func (s RequestSigner) Authenticate(req *http.Request, body []byte) error {
+	if strings.TrimSpace(s.KeyID) == "" || len(s.Key) == 0 {
+		return fmt.Errorf("client: signing key id and key are required")
+	}
+	if s.Auth != nil {
+		if err := s.Auth.Authenticate(req, body); err != nil {
+			return err
+		}
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	var nonce [16]byte
+	if _, err := cryptorand.Read(nonce[:]); err != nil {
+		return fmt.Errorf("client: signature nonce: %w", err)
+	}
+	ts := now().Unix()
+	n := hex.EncodeToString(nonce[:])
+	req.Header.Set(security.HeaderSignatureKeyID, s.KeyID)
+	req.Header.Set(security.HeaderSignatureTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(security.HeaderSignatureNonce, n)
+	req.Header.Set(security.HeaderSignature, security.SignRequest(s.Key, req.Method, req.URL.Path, body, ts, n))
+	return nil
+}
@@ -0,0 +1,343 @@
+// Package client is a Go client for the platform runtime HTTP API. Every call
+// carries an X-Request-ID; writes also carry an Idempotency-Key, which is
+// reused across retries so a retried write is applied at most once.
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultMaxRetries   = 3
+	defaultMinBackoff   = 200 * time.Millisecond
+	defaultMaxBackoff   = 5 * time.Second
+	defaultMaxRetryWait = 30 * time.Second
+	maxErrorBodyBytes   = 64 << 10
+)
+
+// Config configures a Client.
+type Config struct {
+	BaseURL    string // e.g. https://runtime.internal:8080
+	HTTPClient *http.Client
+	Auth       Authenticator
+	UserAgent  string
+
+	// MaxRetries is the number of retries after the first attempt; negative disables retries.
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxRetryWait bounds how long a server Retry-After may be honored; a
+	// longer one (e.g. an exhausted daily quota) ends the call instead.
+	MaxRetryWait time.Duration
+}
+
+// Client calls the runtime API. It is safe for concurrent use.
+type Client struct {
+	baseURL      *url.URL
+	http         *http.Client
+	auth         Authenticator
+	userAgent    string
+	maxRetries   int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxRetryWait time.Duration
+	sleep        func(context.Context, time.Duration) error
+}
+
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", cfg.BaseURL)
+	}
+	c := &Client{
+		baseURL:      base,
+		http:         cfg.HTTPClient,
+		auth:         cfg.Auth,
+		userAgent:    cfg.UserAgent,
+		maxRetries:   cfg.MaxRetries,
+		minBackoff:   cfg.MinBackoff,
+		maxBackoff:   cfg.MaxBackoff,
+		maxRetryWait: cfg.MaxRetryWait,
+		sleep:        sleepContext,
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: defaultTimeout}
+	}
+	if c.userAgent == "" {
+		c.userAgent = "vedic-platform-client"
+	}
+	switch {
+	case c.maxRetries == 0:
+		c.maxRetries = defaultMaxRetries
+	case c.maxRetries < 0:
+		c.maxRetries = 0
+	}
+	if c.minBackoff <= 0 {
+		c.minBackoff = defaultMinBackoff
+	}
+	if c.maxBackoff < c.minBackoff {
+		c.maxBackoff = max(defaultMaxBackoff, c.minBackoff)
+	}
+	if c.maxRetryWait <= 0 {
+		c.maxRetryWait = defaultMaxRetryWait
+	}
+	return c, nil
+}
+
+// CallOption customizes one call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	requestID      string
+	idempotencyKey string
+	header         http.Header
+}
+
+// WithRequestID sets X-Request-ID instead of a generated one.
+func WithRequestID(id string) CallOption {
+	return func(o *callOptions) { o.requestID = id }
+}
+
+// WithIdempotencyKey sets the Idempotency-Key of a write instead of a
+// generated one, so a write can be retried safely across processes.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) { o.idempotencyKey = key }
+}
+
+// WithTenant selects the tenant (X-Tenant-ID) of a federated caller with several memberships.
+func WithTenant(tenantID string) CallOption {
+	return WithHeader(api.HeaderTenantID, tenantID)
+}
+
+// WithSession sets X-Session-ID.
+func WithSession(sessionID string) CallOption {
+	return WithHeader(api.HeaderSessionID, sessionID)
+}
+
+// WithHeader sets an extra request header.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Set(key, value)
+	}
+}
+
+// Error is a non-2xx response. Problem is decoded from the
+// application/problem+json body; Problem.Code is empty if the body was not one.
+type Error struct {
+	StatusCode int
+	Problem    api.Problem
+	RequestID  string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("client: %d", e.StatusCode)
+	if e.Problem.Code != "" {
+		msg += " " + e.Problem.Code
+	}
+	if e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+	return msg
+}
+
+// IsCode reports whether err is an *Error with the given problem code (api.Code*).
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Problem.Code == code
+}
+
+// request is one logical call; do may send it several times.
+type request struct {
+	method     string
+	path       string
+	query      url.Values
+	body       []byte
+	bodyType   string // Content-Type of body; default application/json
+	idempotent bool   // send an Idempotency-Key
+	auth       bool
+	noRetry    bool
+}
+
+func (c *Client) do(ctx context.Context, req request, out interface{}, opts []CallOption) error {
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.requestID == "" {
+		o.requestID = newRequestID()
+	}
+	if req.idempotent && o.idempotencyKey == "" {
+		o.idempotencyKey = newUUID()
+	}
+
+	maxRetries := c.maxRetries
+	if req.noRetry {
+		maxRetries = 0
+	}
+	for attempt := 0; ; attempt++ {
+		httpReq, err := c.newRequest(ctx, req, o)
+		if err != nil {
+			return err
+		}
+		resp, err := c.http.Do(httpReq)
+		if err != nil {
+			err = fmt.Errorf("client: %s %s: %w", req.method, req.path, err)
+			if ctx.Err() != nil || attempt >= maxRetries {
+				return err
+			}
+			if err := c.sleep(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			defer resp.Body.Close()
+			if out == nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				return nil
+			}
+			if raw, ok := out.(*[]byte); ok {
+				*raw, err = io.ReadAll(resp.Body)
+				return err
+			}
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("client: decode %s %s response: %w", req.method, req.path, err)
+			}
+			return nil
+		}
+
+		apiErr := decodeError(resp, o.requestID)
+		if !retryable(apiErr) || attempt >= maxRetries || apiErr.RetryAfter > c.maxRetryWait {
+			return apiErr
+		}
+		if err := c.sleep(ctx, max(c.backoff(attempt), apiErr.RetryAfter)); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, req request, o callOptions) (*http.Request, error) {
+	u := c.baseURL.JoinPath(req.path)
+	if len(req.query) > 0 {
+		u.RawQuery = req.query.Encode()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("client: build request: %w", err)
+	}
+	for k, v := range o.header {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set(api.HeaderRequestID, o.requestID)
+	if req.body != nil {
+		contentType := req.bodyType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if req.idempotent {
+		httpReq.Header.Set(api.HeaderIdempotencyKey, o.idempotencyKey)
+	}
+	// Authenticate every attempt: signatures carry a fresh timestamp and nonce.
+	if req.auth && c.auth != nil {
+		if err := c.auth.Authenticate(httpReq, req.body); err != nil {
+			return nil, fmt.Errorf("client: authenticate: %w", err)
+		}
+	}
+	return httpReq, nil
+}
+
+func decodeError(resp *http.Response, requestID string) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: requestID}
+	if err := json.Unmarshal(body, &apiErr.Problem); err != nil || apiErr.Problem.Status == 0 {
+		apiErr.Problem = api.Problem{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode), Detail: strings.TrimSpace(string(body))}
+	}
+	if v := strings.TrimSpace(resp.Header.Get("Retry-After")); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil {
+			apiErr.RetryAfter = max(time.Until(at), 0)
+		}
+	}
+	return apiErr
+}
+
+// retryable reports whether the same request may succeed later. Writes are
+// only resent with their original Idempotency-Key, so this is safe for them.
+func retryable(err *Error) bool {
+	switch err.Problem.Code {
+	case api.CodeRateLimited, api.CodeUnavailable, api.CodeAuthUnavailable, api.CodeRequestInProgress:
+		return true
+	case "":
+		switch err.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// backoff is exponential with jitter in [d/2, d).
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.minBackoff << min(attempt, 16)
+	if d <= 0 || d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = cryptorand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = cryptorand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	security "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc, auth Authenticator) (*Client, *[]time.Duration) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c, err := New(Config{BaseURL: srv.URL, Auth: auth})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	var waits []time.Duration
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return c, &waits
+}
+
+func writeTestProblem(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", api.ProblemContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(api.Problem{Status: status, Code: code, Detail: code + " detail"})
+}
+
+func TestWriteDecisionRetriesWithSameIdempotencyKey(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	var keys, requestIDs, nonces []string
+	c, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(security.HeaderSignatureTimestamp), 10, 64)
+		nonce := r.Header.Get(security.HeaderSignatureNonce)
+		if r.Header.Get(api.HeaderAPIKey) != "secret" ||
+			r.Header.Get(security.HeaderSignature) != security.SignRequest(key, r.Method, r.URL.Path, body, ts, nonce) {
+			writeTestProblem(w, http.StatusUnauthorized, api.CodeInvalidSignature)
+			return
+		}
+		keys = append(keys, r.Header.Get(api.HeaderIdempotencyKey))
+		requestIDs = append(requestIDs, r.Header.Get(api.HeaderRequestID))
+		nonces = append(nonces, nonce)
+		switch len(keys) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			writeTestProblem(w, http.StatusServiceUnavailable, api.CodeUnavailable)
+		case 2:
+			writeTestProblem(w, http.StatusConflict, api.CodeRequestInProgress)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(api.DecisionWriteResponse{RequestID: requestIDs[0], DecisionID: "d1", EventID: "e1", Status: "accepted"})
+		}
+	}, RequestSigner{KeyID: "k1", Key: key, Auth: APIKey("secret")})
+
+	resp, err := c.WriteDecision(context.Background(), api.DecisionWriteRequest{Subject: "user:1", Action: "read"})
+	if err != nil {
+		t.Fatalf("write decision: %v", err)
+	}
+	if resp.DecisionID != "d1" || len(keys) != 3 {
+		t.Fatalf("unexpected response %+v after %d attempts", resp, len(keys))
+	}
+	if keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] || requestIDs[0] != requestIDs[2] {
+		t.Fatalf("expected stable idempotency key and request id, got %v %v", keys, requestIDs)
+	}
+	if nonces[0] == nonces[1] || nonces[1] == nonces[2] {
+		t.Fatalf("expected a fresh signature nonce per attempt, got %v", nonces)
+	}
+	if len(*waits) != 2 || (*waits)[0] < time.Second {
+		t.Fatalf("expected two waits honoring Retry-After, got %v", *waits)
+	}
+}
+
+func TestWriteTelemetryBatchSendsNDJSON(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+		if r.URL.Path != "/v1/telemetry/events:batch" || r.Header.Get("Content-Type") != api.NDJSONContentType ||
+			r.Header.Get(api.HeaderIdempotencyKey) == "" || len(lines) != 2 {
+			writeTestProblem(w, http.StatusBadRequest, api.CodeBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.TelemetryBatchResponse{Accepted: 1, Rejected: 1, Results: []api.TelemetryBatchResult{
+			{Index: 0, Status: "accepted", EventID: "e1"},
+			{Index: 1, Status: "rejected", Error: &api.Problem{Code: api.CodeValidationFailed}},
+		}})
+	}, BearerToken("tok"))
+
+	resp, err := c.WriteTelemetryBatch(context.Background(), []api.TelemetryWriteRequest{
+		{ActorType: "service", EventType: "relay.burst", Message: "one"},
+		{ActorType: "robot", EventType: "relay.burst", Message: "two"},
+	})
+	if err != nil {
+		t.Fatalf("write batch: %v", err)
+	}
+	if resp.Accepted != 1 || resp.Results[1].Error == nil || resp.Results[1].Error.Code != api.CodeValidationFailed {
+		t.Fatalf("unexpected batch response %+v", resp)
+	}
+}
+
+func TestClientReturnsProblemErrors(t *testing.T) {
+	attempts := 0
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch r.URL.Path {
+		case "/v1/telemetry/events":
+			writeTestProblem(w, http.StatusConflict, api.CodeIdempotencyKeyReused)
+		case "/v1/decisions":
+			w.Header().Set("Retry-After", "3600")
+			writeTestProblem(w, http.StatusTooManyRequests, api.CodeQuotaExceeded)
+		default:
+			http.Error(w, "upstream down", http.StatusBadGateway)
+		}
+	}, BearerToken("tok"))
+
+	_, err := c.WriteTelemetryEvent(context.Background(), api.TelemetryWriteRequest{}, WithIdempotencyKey("fixed"))
+	if !IsCode(err, api.CodeIdempotencyKeyReused) || attempts != 1 {
+		t.Fatalf("expected idempotency_key_reused without retry, got %v after %d attempts", err, attempts)
+	}
+	attempts = 0
+	_, err = c.WriteDecision(context.Background(), api.DecisionWriteRequest{})
+	apiErr, ok := err.(*Error)
+	if !ok || apiErr.Problem.Code != api.CodeQuotaExceeded || apiErr.RetryAfter != time.Hour || attempts != 1 {
+		t.Fatalf("expected quota_exceeded without retry, got %v after %d attempts", err, attempts)
+	}
+	attempts = 0
+	if _, err := c.AnomalyReport(context.Background(), "r1"); err == nil || attempts != 1+defaultMaxRetries {
+		t.Fatalf("expected retried 502, got %v after %d attempts", err, attempts)
+	} else if apiErr := err.(*Error); apiErr.StatusCode != http.StatusBadGateway || apiErr.Problem.Detail != "upstream down" {
+		t.Fatalf("unexpected non-problem error %+v", apiErr)
+	}
+}
+
+func TestClientReadQueries(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" || r.Header.Get(api.HeaderRequestID) != "req-1" ||
+			r.Header.Get(api.HeaderIdempotencyKey) != "" {
+			writeTestProblem(w, http.StatusUnauthorized, api.CodeUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/usage":
+			if got := r.URL.RawQuery; got != "from=2026-01-01&tenant_id=t1&to=2026-01-31" {
+				t.Errorf("unexpected usage query %q", got)
+			}
+			_ = json.NewEncoder(w).Encode(api.UsageReport{TenantID: "t1", Plan: "pro", Items: []api.UsageCounter{{Metric: "events", Count: 7}}})
+		case "/v1/security/threat-levels":
+			if got := r.URL.RawQuery; got != "limit=5&node_name=n1" {
+				t.Errorf("unexpected threat level query %q", got)
+			}
+			_ = json.NewEncoder(w).Encode(api.ThreatLevelList{Items: []api.ThreatLevel{{ID: "tl1", NewLevel: "elevated"}}})
+		case "/v1/admin/idempotency-keys/k 1":
+			if got := r.URL.Query().Get("scope"); got != "v1/decisions" {
+				t.Errorf("unexpected idempotency key scope %q", got)
+			}
+			_ = json.NewEncoder(w).Encode(api.IdempotencyRecord{Scope: "v1/decisions", Key: "k 1", State: "in_progress"})
+		}
+	}, BearerToken("tok"))
+
+	ctx := context.Background()
+	usage, err := c.Usage(ctx, UsageQuery{
+		TenantID: "t1",
+		From:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+	}, WithRequestID("req-1"))
+	if err != nil || usage.Plan != "pro" || len(usage.Items) != 1 || usage.Items[0].Count != 7 {
+		t.Fatalf("unexpected usage %+v, %v", usage, err)
+	}
+	levels, err := c.ThreatLevels(ctx, SecurityQuery{NodeName: "n1", Status: "open", Limit: 5}, WithRequestID("req-1"))
+	if err != nil || len(levels) != 1 || levels[0].NewLevel != "elevated" {
+		t.Fatalf("unexpected threat levels %+v, %v", levels, err)
+	}
+	rec, err := c.IdempotencyKey(ctx, "v1/decisions", "k 1", WithRequestID("req-1"))
+	if err != nil || rec.Key != "k 1" || rec.State != "in_progress" {
+		t.Fatalf("unexpected idempotency record %+v, %v", rec, err)
+	}
+}
+
+func TestNewRejectsInvalidBaseURL(t *testing.T) {
+	if _, err := New(Config{BaseURL: "runtime:8080"}); err == nil {
+		t.Fatalf("expected invalid base URL error")
+	}
+}
@@ -0,0 +1,244 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/api"
+	security "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+// WriteDecision records a policy decision and its security event (POST /v1/decisions).
+func (c *Client) WriteDecision(ctx context.Context, req api.DecisionWriteRequest, opts ...CallOption) (*api.DecisionWriteResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode decision: %w", err)
+	}
+	var out api.DecisionWriteResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/v1/decisions", body: body, idempotent: true, auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WriteTelemetryEvent records a security event (POST /v1/telemetry/events).
+func (c *Client) WriteTelemetryEvent(ctx context.Context, req api.TelemetryWriteRequest, opts ...CallOption) (*api.TelemetryWriteResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("client: encode telemetry event: %w", err)
+	}
+	var out api.TelemetryWriteResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/v1/telemetry/events", body: body, idempotent: true, auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WriteTelemetryBatch records up to api.MaxTelemetryBatchEvents security
+// events in one request (POST /v1/telemetry/events:batch), sent as NDJSON.
+// Events are accepted or rejected one by one; check the per-event results.
+func (c *Client) WriteTelemetryBatch(ctx context.Context, events []api.TelemetryWriteRequest, opts ...CallOption) (*api.TelemetryBatchResponse, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for i, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return nil, fmt.Errorf("client: encode telemetry event %d: %w", i, err)
+		}
+	}
+	var out api.TelemetryBatchResponse
+	req := request{
+		method:     http.MethodPost,
+		path:       "/v1/telemetry/events:batch",
+		body:       body.Bytes(),
+		bodyType:   api.NDJSONContentType,
+		idempotent: true,
+		auth:       true,
+	}
+	if err := c.do(ctx, req, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SecurityQuery filters the security read endpoints. Zero fields are omitted;
+// Status only applies to anomaly reports.
+type SecurityQuery struct {
+	TenantID string
+	NodeName string
+	Status   string
+	Limit    int
+}
+
+func (q SecurityQuery) values() url.Values {
+	v := url.Values{}
+	setQuery(v, "tenant_id", q.TenantID)
+	setQuery(v, "node_name", q.NodeName)
+	setQuery(v, "status", q.Status)
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+// ThreatLevels lists node threat level transitions (GET /v1/security/threat-levels).
+func (c *Client) ThreatLevels(ctx context.Context, q SecurityQuery, opts ...CallOption) ([]api.ThreatLevel, error) {
+	q.Status = ""
+	var out api.ThreatLevelList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/security/threat-levels", query: q.values(), auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return out.Items, nil
+}
+
+// AnomalyReports lists anomaly reports (GET /v1/security/anomaly-reports).
+func (c *Client) AnomalyReports(ctx context.Context, q SecurityQuery, opts ...CallOption) ([]api.AnomalyReport, error) {
+	var out api.AnomalyReportList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/security/anomaly-reports", query: q.values(), auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return out.Items, nil
+}
+
+// AnomalyReport returns one report with its linked events and decisions.
+func (c *Client) AnomalyReport(ctx context.Context, id string, opts ...CallOption) (*api.AnomalyReport, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("client: anomaly report id is required")
+	}
+	var out api.AnomalyReport
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/security/anomaly-reports/" + url.PathEscape(id), auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MaintenanceStatus returns job status and recent runs (GET /v1/admin/maintenance).
+// Empty job and zero limit are omitted.
+func (c *Client) MaintenanceStatus(ctx context.Context, job string, limit int, opts ...CallOption) (*api.MaintenanceStatus, error) {
+	q := url.Values{}
+	setQuery(q, "job", job)
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var out api.MaintenanceStatus
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/maintenance", query: q, auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Health returns the replica's per-component health (GET /v1/admin/health).
+func (c *Client) Health(ctx context.Context, opts ...CallOption) (*api.HealthReport, error) {
+	var out api.HealthReport
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/health", auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IdempotencyKey returns the state and stored response of a write's
+// Idempotency-Key (GET /v1/admin/idempotency-keys/{key}). scope is the
+// write's route scope, such as "v1/decisions".
+func (c *Client) IdempotencyKey(ctx context.Context, scope, key string, opts ...CallOption) (*api.IdempotencyRecord, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("client: idempotency key is required")
+	}
+	q := url.Values{}
+	setQuery(q, "scope", scope)
+	var out api.IdempotencyRecord
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/admin/idempotency-keys/" + url.PathEscape(key), query: q, auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UsageQuery selects a tenant and an inclusive UTC date range; zero values
+// use the server defaults (the credential's tenant, the last 30 days).
+type UsageQuery struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+}
+
+// Usage returns a tenant's effective quota and daily usage counters (GET /v1/usage).
+func (c *Client) Usage(ctx context.Context, q UsageQuery, opts ...CallOption) (*api.UsageReport, error) {
+	v := url.Values{}
+	setQuery(v, "tenant_id", q.TenantID)
+	if !q.From.IsZero() {
+		v.Set("from", q.From.UTC().Format(time.DateOnly))
+	}
+	if !q.To.IsZero() {
+		v.Set("to", q.To.UTC().Format(time.DateOnly))
+	}
+	var out api.UsageReport
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/v1/usage", query: v, auth: true}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// JWKS fetches the published runtime signing keys (GET /.well-known/jwks.json).
+func (c *Client) JWKS(ctx context.Context, opts ...CallOption) (*security.JSONWebKeySet, error) {
+	var out security.JSONWebKeySet
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/.well-known/jwks.json"}, &out, opts); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// OpenAPI fetches the runtime's OpenAPI document (GET /openapi.json).
+func (c *Client) OpenAPI(ctx context.Context, opts ...CallOption) ([]byte, error) {
+	var out []byte
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/openapi.json"}, &out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Metrics scrapes /metrics in Prometheus text format. token is the
+// RUNTIME_METRICS_TOKEN, if one is configured.
+func (c *Client) Metrics(ctx context.Context, token string, opts ...CallOption) ([]byte, error) {
+	if token != "" {
+		opts = append(opts, WithHeader("Authorization", "Bearer "+token))
+	}
+	var out []byte
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/metrics"}, &out, opts); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Live checks /livez.
+func (c *Client) Live(ctx context.Context, opts ...CallOption) error {
+	return c.probe(ctx, "/livez", opts)
+}
+
+// Healthy checks /healthz.
+func (c *Client) Healthy(ctx context.Context, opts ...CallOption) error {
+	return c.probe(ctx, "/healthz", opts)
+}
+
+// Ready checks /readyz.
+func (c *Client) Ready(ctx context.Context, opts ...CallOption) error {
+	return c.probe(ctx, "/readyz", opts)
+}
+
+// probe is not retried: callers poll probes themselves.
+func (c *Client) probe(ctx context.Context, path string, opts []CallOption) error {
+	var out api.Status
+	return c.do(ctx, request{method: http.MethodGet, path: path, noRetry: true}, &out, opts)
+}
+
+func setQuery(v url.Values, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		v.Set(key, value)
+	}
+}
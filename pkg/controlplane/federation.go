@@ -0,0 +1,164 @@
+package controlplane
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// FederatedUser is a control_plane.users row reached through control_plane.identities.
+type FederatedUser struct {
+	UserID      string
+	IdentityID  string
+	DisplayName string
+	Status      string
+	Provisioned bool // created by this call (just-in-time provisioning)
+}
+
+// FederatedProfile carries verified issuer claims used for JIT provisioning.
+type FederatedProfile struct {
+	Provider      string
+	Subject       string
+	DisplayName   string
+	Email         string
+	EmailVerified bool
+}
+
+// LookupFederatedUser maps (provider, provider_subject) to its user.
+func (r *Repository) LookupFederatedUser(ctx context.Context, provider, subject string) (FederatedUser, bool, error) {
+	provider = strings.TrimSpace(provider)
+	subject = strings.TrimSpace(subject)
+	if provider == "" || subject == "" {
+		return FederatedUser{}, false, nil
+	}
+	var u FederatedUser
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT u.id::text, i.id::text, u.display_name, u.status
+		   FROM control_plane.identities i
+		   JOIN control_plane.users u ON u.id = i.user_id
+		  WHERE i.provider = $1
+		    AND i.provider_subject = $2`,
+		provider,
+		subject,
+	).Scan(&u.UserID, &u.IdentityID, &u.DisplayName, &u.Status)
+	if err == sql.ErrNoRows {
+		return FederatedUser{}, false, nil
+	}
+	if err != nil {
+		return FederatedUser{}, false, err
+	}
+	return u, true, nil
+}
+
+// ProvisionFederatedUser creates a user and identity for a first-seen subject.
+//
+// A verified email is stored as primary_email only when no other user already
+// holds it; existing accounts are never linked by email. Concurrent
+// provisioning of the same subject resolves to the identity that won.
+func (r *Repository) ProvisionFederatedUser(ctx context.Context, p FederatedProfile) (FederatedUser, error) {
+	p.Provider = strings.TrimSpace(p.Provider)
+	p.Subject = strings.TrimSpace(p.Subject)
+	if p.Provider == "" || p.Subject == "" {
+		return FederatedUser{}, fmt.Errorf("controlplane: provider and subject are required")
+	}
+	displayName := federatedDisplayName(p)
+	var email *string
+	if p.EmailVerified && strings.TrimSpace(p.Email) != "" {
+		v := strings.ToLower(strings.TrimSpace(p.Email))
+		email = &v
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return FederatedUser{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u := FederatedUser{DisplayName: displayName, Status: "active", Provisioned: true}
+	if err := tx.QueryRowContext(
+		ctx,
+		`INSERT INTO control_plane.users (display_name, primary_email)
+		 VALUES (
+		   $1,
+		   CASE WHEN $2::text IS NOT NULL
+		         AND NOT EXISTS (SELECT 1 FROM control_plane.users WHERE primary_email = $2::text)
+		        THEN $2::text END
+		 )
+		 RETURNING id::text`,
+		displayName,
+		email,
+	).Scan(&u.UserID); err != nil {
+		return FederatedUser{}, err
+	}
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO control_plane.identities (user_id, provider, provider_subject, verified_at)
+		 VALUES ($1::uuid, $2, $3, now())
+		 ON CONFLICT (provider, provider_subject) DO NOTHING
+		 RETURNING id::text`,
+		u.UserID,
+		p.Provider,
+		p.Subject,
+	).Scan(&u.IdentityID)
+	if err == sql.ErrNoRows {
+		_ = tx.Rollback()
+		existing, found, err := r.LookupFederatedUser(ctx, p.Provider, p.Subject)
+		if err != nil {
+			return FederatedUser{}, err
+		}
+		if !found {
+			return FederatedUser{}, fmt.Errorf("controlplane: identity provisioning conflict")
+		}
+		return existing, nil
+	}
+	if err != nil {
+		return FederatedUser{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return FederatedUser{}, err
+	}
+	return u, nil
+}
+
+// UserTenantIDs returns the active tenants in which the user has an active membership.
+func (r *Repository) UserTenantIDs(ctx context.Context, userID string) ([]string, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return nil, nil
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT DISTINCT m.tenant_id::text
+		   FROM control_plane.memberships m
+		   JOIN control_plane.tenants t ON t.id = m.tenant_id
+		  WHERE m.user_id::text = $1
+		    AND m.status = 'active'
+		    AND t.status = 'active'
+		  ORDER BY 1`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+func federatedDisplayName(p FederatedProfile) string {
+	for _, v := range []string{p.DisplayName, p.Email, p.Subject} {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return p.Subject
+}
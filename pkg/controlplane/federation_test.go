@@ -0,0 +1,29 @@
+package controlplane
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFederatedDisplayNameFallbacks(t *testing.T) {
+	cases := []struct {
+		p    FederatedProfile
+		want string
+	}{
+		{FederatedProfile{Subject: "sub-1", DisplayName: " Ada ", Email: "ada@example.com"}, "Ada"},
+		{FederatedProfile{Subject: "sub-1", Email: "ada@example.com"}, "ada@example.com"},
+		{FederatedProfile{Subject: "sub-1"}, "sub-1"},
+	}
+	for _, tc := range cases {
+		if got := federatedDisplayName(tc.p); got != tc.want {
+			t.Fatalf("federatedDisplayName(%+v) = %q, want %q", tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestProvisionFederatedUserRequiresSubject(t *testing.T) {
+	r := &Repository{}
+	if _, err := r.ProvisionFederatedUser(context.Background(), FederatedProfile{Provider: "https://idp.example.com"}); err == nil {
+		t.Fatalf("expected error for missing subject")
+	}
+}
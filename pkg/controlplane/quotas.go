@@ -0,0 +1,233 @@
+package controlplane
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Usage metrics recorded in control_plane.usage_counters.
+const (
+	UsageMetricEvents    = "events"
+	UsageMetricDecisions = "decisions"
+)
+
+// QuotaEndpointDefault is the plan_quotas / tenant_quota_overrides row that
+// applies when no endpoint-specific row exists.
+const QuotaEndpointDefault = "*"
+
+// RequestUsageMetric names the per-endpoint request counter.
+func RequestUsageMetric(endpoint string) string {
+	return "requests:" + strings.TrimSpace(endpoint)
+}
+
+// Quota is the effective quota of a tenant for one endpoint. Nil limits are unlimited.
+type Quota struct {
+	TenantID          string `json:"tenant_id"`
+	Plan              string `json:"plan"`
+	Endpoint          string `json:"endpoint"`
+	RequestsPerMinute *int   `json:"requests_per_minute"`
+	Burst             *int   `json:"burst"`
+	DailyEventLimit   *int64 `json:"daily_event_limit"`
+	MaxBatchDecisions *int   `json:"max_batch_decisions"`
+}
+
+// UsageCounter is one control_plane.usage_counters row.
+type UsageCounter struct {
+	UsageDate time.Time `json:"usage_date"`
+	Metric    string    `json:"metric"`
+	Count     int64     `json:"count"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ResolveTenantQuota returns the effective quota of an active tenant.
+//
+// Each limit is resolved independently, most specific first: tenant override
+// for the endpoint, tenant override '*', plan quota for the endpoint, plan
+// quota '*'.
+func (r *Repository) ResolveTenantQuota(ctx context.Context, tenantID, endpoint string) (Quota, bool, error) {
+	tenantID = strings.TrimSpace(tenantID)
+	endpoint = strings.TrimSpace(endpoint)
+	if tenantID == "" {
+		return Quota{}, false, nil
+	}
+	if endpoint == "" {
+		endpoint = QuotaEndpointDefault
+	}
+	var (
+		q                 Quota
+		rpm, burst, batch sql.NullInt32
+		dailyEvents       sql.NullInt64
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT t.id::text,
+		        t.plan,
+		        COALESCE(oe.requests_per_minute, ow.requests_per_minute, pe.requests_per_minute, pw.requests_per_minute),
+		        COALESCE(oe.burst, ow.burst, pe.burst, pw.burst),
+		        COALESCE(oe.daily_event_limit, ow.daily_event_limit, pe.daily_event_limit, pw.daily_event_limit),
+		        COALESCE(oe.max_batch_decisions, ow.max_batch_decisions, pe.max_batch_decisions, pw.max_batch_decisions)
+		   FROM control_plane.tenants t
+		   LEFT JOIN control_plane.tenant_quota_overrides oe ON oe.tenant_id = t.id AND oe.endpoint = $2
+		   LEFT JOIN control_plane.tenant_quota_overrides ow ON ow.tenant_id = t.id AND ow.endpoint = '*'
+		   LEFT JOIN control_plane.plan_quotas pe ON pe.plan = t.plan AND pe.endpoint = $2
+		   LEFT JOIN control_plane.plan_quotas pw ON pw.plan = t.plan AND pw.endpoint = '*'
+		  WHERE t.id::text = $1
+		    AND t.status = 'active'`,
+		tenantID,
+		endpoint,
+	).Scan(&q.TenantID, &q.Plan, &rpm, &burst, &dailyEvents, &batch)
+	if err == sql.ErrNoRows {
+		return Quota{}, false, nil
+	}
+	if err != nil {
+		return Quota{}, false, err
+	}
+	q.Endpoint = endpoint
+	q.RequestsPerMinute = nullInt(rpm)
+	q.Burst = nullInt(burst)
+	q.MaxBatchDecisions = nullInt(batch)
+	if dailyEvents.Valid {
+		q.DailyEventLimit = &dailyEvents.Int64
+	}
+	return q, true, nil
+}
+
+// ConsumeUsage adds delta to today's (UTC) counter unless that would take it
+// past limit; a nil limit always succeeds. The check and increment are one
+// statement, so concurrent replicas cannot overshoot the limit. It returns
+// the counter after the increment, or false when the quota is exhausted.
+func (r *Repository) ConsumeUsage(ctx context.Context, tenantID, metric string, delta int64, limit *int64) (int64, bool, error) {
+	tenantID = strings.TrimSpace(tenantID)
+	metric = strings.TrimSpace(metric)
+	if tenantID == "" || metric == "" {
+		return 0, false, fmt.Errorf("controlplane: tenant and metric are required")
+	}
+	if delta <= 0 {
+		return 0, false, fmt.Errorf("controlplane: usage delta must be > 0")
+	}
+	var limitArg interface{}
+	if limit != nil {
+		limitArg = *limit
+	}
+	var count int64
+	err := r.db.QueryRowContext(
+		ctx,
+		`INSERT INTO control_plane.usage_counters AS u (tenant_id, usage_date, metric, count)
+		 SELECT $1::uuid, (now() AT TIME ZONE 'UTC')::date, $2, $3
+		  WHERE $4::bigint IS NULL OR $3 <= $4::bigint
+		 ON CONFLICT (tenant_id, usage_date, metric) DO UPDATE
+		    SET count = u.count + EXCLUDED.count,
+		        updated_at = now()
+		  WHERE $4::bigint IS NULL OR u.count + EXCLUDED.count <= $4::bigint
+		 RETURNING count`,
+		tenantID,
+		metric,
+		delta,
+		limitArg,
+	).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return count, true, nil
+}
+
+// ReleaseUsage takes delta back off today's (UTC) counter, never below zero;
+// used to refund a ConsumeUsage charge whose write did not commit.
+func (r *Repository) ReleaseUsage(ctx context.Context, tenantID, metric string, delta int64) error {
+	tenantID = strings.TrimSpace(tenantID)
+	metric = strings.TrimSpace(metric)
+	if tenantID == "" || metric == "" {
+		return fmt.Errorf("controlplane: tenant and metric are required")
+	}
+	if delta <= 0 {
+		return nil
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`UPDATE control_plane.usage_counters
+		    SET count = GREATEST(count - $3, 0),
+		        updated_at = now()
+		  WHERE tenant_id = $1::uuid
+		    AND usage_date = (now() AT TIME ZONE 'UTC')::date
+		    AND metric = $2`,
+		tenantID,
+		metric,
+		delta,
+	)
+	return err
+}
+
+// AddUsage adds delta to the counter of a given day without a limit; used to
+// flush locally aggregated request counts.
+func (r *Repository) AddUsage(ctx context.Context, tenantID string, day time.Time, metric string, delta int64) error {
+	tenantID = strings.TrimSpace(tenantID)
+	metric = strings.TrimSpace(metric)
+	if tenantID == "" || metric == "" {
+		return fmt.Errorf("controlplane: tenant and metric are required")
+	}
+	if delta <= 0 {
+		return nil
+	}
+	_, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO control_plane.usage_counters AS u (tenant_id, usage_date, metric, count)
+		 VALUES ($1::uuid, $2::date, $3, $4)
+		 ON CONFLICT (tenant_id, usage_date, metric) DO UPDATE
+		    SET count = u.count + EXCLUDED.count,
+		        updated_at = now()`,
+		tenantID,
+		day.UTC().Format(time.DateOnly),
+		metric,
+		delta,
+	)
+	return err
+}
+
+// ListUsage returns a tenant's counters for the inclusive UTC date range.
+func (r *Repository) ListUsage(ctx context.Context, tenantID string, from, to time.Time) ([]UsageCounter, error) {
+	tenantID = strings.TrimSpace(tenantID)
+	if tenantID == "" {
+		return nil, fmt.Errorf("controlplane: tenant is required")
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT usage_date, metric, count, updated_at
+		   FROM control_plane.usage_counters
+		  WHERE tenant_id::text = $1
+		    AND usage_date BETWEEN $2::date AND $3::date
+		  ORDER BY usage_date DESC, metric`,
+		tenantID,
+		from.UTC().Format(time.DateOnly),
+		to.UTC().Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]UsageCounter, 0)
+	for rows.Next() {
+		var c UsageCounter
+		if err := rows.Scan(&c.UsageDate, &c.Metric, &c.Count, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.UsageDate = c.UsageDate.UTC()
+		c.UpdatedAt = c.UpdatedAt.UTC()
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func nullInt(v sql.NullInt32) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int32)
+	return &n
+}
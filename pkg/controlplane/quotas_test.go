@@ -0,0 +1,33 @@
+package controlplane
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestUsageMetric(t *testing.T) {
+	if got := RequestUsageMetric(" v1/decisions "); got != "requests:v1/decisions" {
+		t.Fatalf("unexpected metric %q", got)
+	}
+}
+
+func TestUsageInputsValidated(t *testing.T) {
+	r := &Repository{}
+	ctx := context.Background()
+	if _, _, err := r.ConsumeUsage(ctx, "", UsageMetricEvents, 1, nil); err == nil {
+		t.Fatalf("expected error for missing tenant")
+	}
+	if _, _, err := r.ConsumeUsage(ctx, "tenant-1", UsageMetricEvents, 0, nil); err == nil {
+		t.Fatalf("expected error for non-positive delta")
+	}
+	if err := r.AddUsage(ctx, "tenant-1", time.Now(), "", 1); err == nil {
+		t.Fatalf("expected error for missing metric")
+	}
+	if err := r.ReleaseUsage(ctx, "", UsageMetricEvents, 1); err == nil {
+		t.Fatalf("expected error for a release without a tenant")
+	}
+	if _, found, err := r.ResolveTenantQuota(ctx, " ", "*"); err != nil || found {
+		t.Fatalf("expected blank tenant to resolve to nothing, got found=%t err=%v", found, err)
+	}
+}
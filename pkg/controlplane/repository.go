@@ -0,0 +1,108 @@
+package controlplane
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// APICredential represents an active control_plane.api_credentials row.
+type APICredential struct {
+	ID          string
+	TenantID    string
+	WorkspaceID *string
+	Scopes      []string
+}
+
+// Repository reads control-plane identity and tenancy metadata.
+type Repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) (*Repository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("controlplane: nil db handle")
+	}
+	return &Repository{db: db}, nil
+}
+
+// LookupAPICredential resolves an active credential of an active tenant by key hash.
+func (r *Repository) LookupAPICredential(ctx context.Context, keyHash string) (APICredential, bool, error) {
+	keyHash = strings.TrimSpace(keyHash)
+	if keyHash == "" {
+		return APICredential{}, false, nil
+	}
+
+	var cred APICredential
+	var workspaceID sql.NullString
+	var scopesJSON []byte
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT c.id::text, c.tenant_id::text, c.workspace_id::text, c.scopes_json
+		   FROM control_plane.api_credentials c
+		   JOIN control_plane.tenants t ON t.id = c.tenant_id
+		  WHERE c.key_hash = $1
+		    AND c.status = 'active'
+		    AND t.status = 'active'`,
+		keyHash,
+	).Scan(&cred.ID, &cred.TenantID, &workspaceID, &scopesJSON)
+	if err == sql.ErrNoRows {
+		return APICredential{}, false, nil
+	}
+	if err != nil {
+		return APICredential{}, false, err
+	}
+	if workspaceID.Valid {
+		cred.WorkspaceID = &workspaceID.String
+	}
+	scopes, err := parseScopes(scopesJSON)
+	if err != nil {
+		return APICredential{}, false, err
+	}
+	cred.Scopes = scopes
+	return cred, true, nil
+}
+
+// WorkspaceInTenant reports whether an active workspace belongs to the tenant.
+func (r *Repository) WorkspaceInTenant(ctx context.Context, tenantID, workspaceID string) (bool, error) {
+	tenantID = strings.TrimSpace(tenantID)
+	workspaceID = strings.TrimSpace(workspaceID)
+	if tenantID == "" || workspaceID == "" {
+		return false, nil
+	}
+	var exists bool
+	if err := r.db.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (
+		   SELECT 1 FROM control_plane.workspaces
+		    WHERE id::text = $1
+		      AND tenant_id::text = $2
+		      AND status = 'active'
+		)`,
+		workspaceID,
+		tenantID,
+	).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func parseScopes(raw []byte) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var scopes []string
+	if err := json.Unmarshal(raw, &scopes); err != nil {
+		return nil, fmt.Errorf("controlplane: invalid scopes_json: %w", err)
+	}
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
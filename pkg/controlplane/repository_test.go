@@ -0,0 +1,19 @@
+package controlplane
+
+import "testing"
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := parseScopes([]byte(`["decisions:write", " telemetry:write ", ""]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scopes) != 2 || scopes[0] != "decisions:write" || scopes[1] != "telemetry:write" {
+		t.Fatalf("unexpected scopes: %v", scopes)
+	}
+}
+
+func TestParseScopesInvalid(t *testing.T) {
+	if _, err := parseScopes([]byte(`{"scope":"decisions:write"}`)); err == nil {
+		t.Fatalf("expected error for non-array scopes_json")
+	}
+}
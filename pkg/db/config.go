@@ -31,11 +31,10 @@ type Config struct {
 	StatementTimeoutMS int
 }
 
-// FromEnv loads DB config from environment variables.
-func FromEnv() (Config, error) {
-	cfg := Config{
-		DriverName:         firstNonEmpty(os.Getenv("DB_DRIVER"), defaultDriverName),
-		DatabaseURL:        strings.TrimSpace(os.Getenv("DATABASE_URL")),
+// Defaults returns the settings FromEnv starts from, without a database URL.
+func Defaults() Config {
+	return Config{
+		DriverName:         defaultDriverName,
 		ConnectTimeout:     defaultConnectTimeout,
 		MaxIdleConns:       defaultMaxIdleConns,
 		MaxOpenConns:       defaultMaxOpenConns,
@@ -43,7 +42,20 @@ func FromEnv() (Config, error) {
 		ConnMaxLifetime:    defaultConnMaxLife,
 		StatementTimeoutMS: defaultStmtTimeoutMS,
 	}
-	cfg.DriverName = normalizeDriverName(cfg.DriverName)
+}
+
+// FromEnv loads DB config from environment variables.
+func FromEnv() (Config, error) {
+	return LoadEnv(Defaults())
+}
+
+// LoadEnv overrides base with the DB_* and DATABASE_URL environment variables
+// that are set, then validates the result. Callers with settings from
+// elsewhere, such as a config file, pass them as base so env wins.
+func LoadEnv(base Config) (Config, error) {
+	cfg := base
+	cfg.DriverName = normalizeDriverName(firstNonEmpty(os.Getenv("DB_DRIVER"), cfg.DriverName))
+	cfg.DatabaseURL = firstNonEmpty(os.Getenv("DATABASE_URL"), cfg.DatabaseURL)
 
 	if v := strings.TrimSpace(os.Getenv("DB_CONNECT_TIMEOUT_SECONDS")); v != "" {
 		n, err := strconv.Atoi(v)
@@ -106,6 +118,9 @@ func (c Config) Validate() error {
 	if c.MaxIdleConns < 0 {
 		return fmt.Errorf("db: max idle conns must be >= 0")
 	}
+	if c.ConnMaxIdleTime < 0 || c.ConnMaxLifetime < 0 {
+		return fmt.Errorf("db: conn max idle time and lifetime must be >= 0")
+	}
 	if c.StatementTimeoutMS <= 0 {
 		return fmt.Errorf("db: statement timeout must be > 0")
 	}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error kinds shared by the repositories. Match them with errors.Is; the
+// concrete error is an *Error carrying a client-safe message and the cause.
+var (
+	ErrValidation  = errors.New("validation failed")
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrForeignKey  = errors.New("referenced row does not exist")
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// Error is a classified data-layer error. Message never contains driver
+// text; Err keeps the underlying cause for logs.
+type Error struct {
+	Kind       error
+	Message    string
+	Constraint string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Validationf returns an ErrValidation error with a client-safe message.
+func Validationf(format string, args ...any) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFoundf returns an ErrNotFound error with a client-safe message.
+func NotFoundf(format string, args ...any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Classify maps a driver error onto an error kind, prefixing the message
+// with op (e.g. "authz: persist decision"). Errors it cannot classify, and
+// errors that are already classified, are returned unchanged.
+func Classify(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	kind, constraint := classify(err)
+	if kind == nil {
+		return err
+	}
+	return &Error{Kind: kind, Message: op + ": " + kind.Error(), Constraint: constraint, Err: err}
+}
+
+func classify(err error) (error, string) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505":
+			return ErrConflict, pgErr.ConstraintName
+		case pgErr.Code == "23503":
+			return ErrForeignKey, pgErr.ConstraintName
+		case pgErr.Code == "23502", pgErr.Code == "23514", pgErr.Code == "22P02",
+			pgErr.Code == "22001", pgErr.Code == "22003", pgErr.Code == "22007", pgErr.Code == "22008":
+			// not null, check, invalid text representation, too long,
+			// out of range, invalid datetime format, datetime overflow
+			return ErrValidation, pgErr.ConstraintName
+		case pgErr.Code == "40001", pgErr.Code == "40P01", pgErr.Code == "55P03":
+			// serialization failure, deadlock, lock not available: retryable
+			return ErrUnavailable, ""
+		case pgErr.Code == "57014", pgErr.Code == "53300", pgErr.Code == "57P01",
+			pgErr.Code == "57P02", pgErr.Code == "57P03", strings.HasPrefix(pgErr.Code, "08"):
+			// statement timeout, too many connections, shutdown, connection exceptions
+			return ErrUnavailable, ""
+		}
+		return nil, ""
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound, ""
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled),
+		errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return ErrUnavailable, ""
+	}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return ErrUnavailable, ""
+	}
+	return nil, ""
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err  error
+		kind error
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "x_key"}, ErrConflict},
+		{&pgconn.PgError{Code: "23503"}, ErrForeignKey},
+		{&pgconn.PgError{Code: "22P02"}, ErrValidation},
+		{&pgconn.PgError{Code: "23514"}, ErrValidation},
+		{&pgconn.PgError{Code: "40001"}, ErrUnavailable},
+		{&pgconn.PgError{Code: "08006"}, ErrUnavailable},
+		{fmt.Errorf("scan: %w", sql.ErrNoRows), ErrNotFound},
+		{context.DeadlineExceeded, ErrUnavailable},
+	}
+	for _, tc := range cases {
+		err := Classify("authz: persist decision", tc.err)
+		if !errors.Is(err, tc.kind) || !errors.Is(err, tc.err) {
+			t.Fatalf("%v: expected kind %v, got %v", tc.err, tc.kind, err)
+		}
+		var dbErr *Error
+		if !errors.As(err, &dbErr) || strings.Contains(dbErr.Message, "SQLSTATE") || !strings.HasPrefix(dbErr.Message, "authz: persist decision: ") {
+			t.Fatalf("unexpected message %q", dbErr.Message)
+		}
+	}
+
+	plain := errors.New("boom")
+	if got := Classify("op", plain); got != plain {
+		t.Fatalf("expected unclassified error unchanged, got %v", got)
+	}
+	v := Validationf("authz: subject is required")
+	if got := Classify("op", v); got != v || v.Error() != "authz: subject is required" || !errors.Is(v, ErrValidation) {
+		t.Fatalf("expected validation error kept as-is, got %v", got)
+	}
+	if Classify("op", nil) != nil {
+		t.Fatalf("expected nil")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -25,7 +26,20 @@ type Migration struct {
 
 // DiscoverMigrations loads and validates ordered SQL migrations from a directory.
 func DiscoverMigrations(dir string) ([]Migration, error) {
-	entries, err := os.ReadDir(dir)
+	migs, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	for i := range migs {
+		migs[i].Path = filepath.Join(dir, migs[i].Name)
+	}
+	return migs, nil
+}
+
+// LoadMigrations loads and validates ordered SQL migrations from the root of
+// fsys, e.g. an embedded copy of db/migrations. Path is the file name.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return nil, err
 	}
@@ -43,8 +57,7 @@ func DiscoverMigrations(dir string) ([]Migration, error) {
 		if _, err := fmt.Sscanf(m[1], "%d", &seq); err != nil {
 			return nil, fmt.Errorf("db: invalid migration sequence: %s", e.Name())
 		}
-		path := filepath.Join(dir, e.Name())
-		raw, err := os.ReadFile(path)
+		raw, err := fs.ReadFile(fsys, e.Name())
 		if err != nil {
 			return nil, err
 		}
@@ -55,7 +68,7 @@ func DiscoverMigrations(dir string) ([]Migration, error) {
 		migs = append(migs, Migration{
 			Seq:    seq,
 			Name:   e.Name(),
-			Path:   path,
+			Path:   e.Name(),
 			SQL:    sqlText,
 			SHA256: SHA256Hex(raw),
 		})
@@ -122,6 +135,77 @@ func AppliedMigrations(ctx context.Context, db *sql.DB) (map[int]string, error)
 	return out, rows.Err()
 }
 
+// SchemaStatus compares the migrations recorded in ops.schema_migrations with
+// an expected set.
+type SchemaStatus struct {
+	Expected int
+	Applied  int
+	// Pending are expected migrations that are not applied.
+	Pending []int
+	// Mismatched are applied migrations whose checksum differs from the
+	// expected file.
+	Mismatched []int
+	// Unknown are applied migrations the expected set does not have, e.g.
+	// ones added by a newer release during a rolling deploy.
+	Unknown []int
+}
+
+// Current reports whether every expected migration is applied unchanged.
+// Unknown migrations do not count against it: migrations are additive.
+func (s SchemaStatus) Current() bool {
+	return len(s.Pending) == 0 && len(s.Mismatched) == 0
+}
+
+// Err describes why the schema is not current, or returns nil.
+func (s SchemaStatus) Err() error {
+	switch {
+	case len(s.Mismatched) > 0:
+		return fmt.Errorf("db: migration checksum mismatch for %s", formatSeqs(s.Mismatched))
+	case len(s.Pending) > 0:
+		return fmt.Errorf("db: migrations not applied: %s", formatSeqs(s.Pending))
+	}
+	return nil
+}
+
+// CheckMigrations compares the applied migrations with the expected ones
+// without changing anything.
+func CheckMigrations(ctx context.Context, db *sql.DB, migrations []Migration) (SchemaStatus, error) {
+	if db == nil {
+		return SchemaStatus{}, fmt.Errorf("db: nil handle")
+	}
+	applied, err := AppliedMigrations(ctx, db)
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+	status := SchemaStatus{Expected: len(migrations), Applied: len(applied)}
+	expected := make(map[int]struct{}, len(migrations))
+	for _, m := range migrations {
+		expected[m.Seq] = struct{}{}
+		sha, ok := applied[m.Seq]
+		switch {
+		case !ok:
+			status.Pending = append(status.Pending, m.Seq)
+		case sha != m.SHA256:
+			status.Mismatched = append(status.Mismatched, m.Seq)
+		}
+	}
+	for seq := range applied {
+		if _, ok := expected[seq]; !ok {
+			status.Unknown = append(status.Unknown, seq)
+		}
+	}
+	sort.Ints(status.Unknown)
+	return status, nil
+}
+
+func formatSeqs(seqs []int) string {
+	parts := make([]string, len(seqs))
+	for i, seq := range seqs {
+		parts[i] = fmt.Sprintf("%04d", seq)
+	}
+	return strings.Join(parts, ", ")
+}
+
 // ApplyMigrations applies pending migrations or prints them when dryRun=true.
 func ApplyMigrations(ctx context.Context, db *sql.DB, migrations []Migration, dryRun bool) error {
 	if err := ValidateMigrationOrder(migrations); err != nil {
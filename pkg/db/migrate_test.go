@@ -3,7 +3,9 @@ package db
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"testing/fstest"
 )
 
 func TestDiscoverMigrationsOrdered(t *testing.T) {
@@ -46,6 +48,39 @@ func TestDiscoverMigrationsRequiresTxGuards(t *testing.T) {
 	}
 }
 
+func TestLoadMigrationsFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_two.sql":  {Data: []byte("BEGIN;\nSELECT 2;\nCOMMIT;\n")},
+		"0001_one.sql":  {Data: []byte("BEGIN;\nSELECT 1;\nCOMMIT;\n")},
+		"migrations.go": {Data: []byte("package migrations\n")},
+	}
+	migs, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migs) != 2 || migs[0].Name != "0001_one.sql" || migs[0].Path != "0001_one.sql" || migs[1].SHA256 == "" {
+		t.Fatalf("unexpected migrations: %+v", migs)
+	}
+}
+
+func TestSchemaStatus(t *testing.T) {
+	s := SchemaStatus{Expected: 3, Applied: 4, Unknown: []int{4}}
+	if !s.Current() || s.Err() != nil {
+		t.Fatalf("expected migrations unknown to the binary to be tolerated")
+	}
+	s.Pending = []int{3}
+	if s.Current() || s.Err() == nil {
+		t.Fatalf("expected a pending migration to make the schema stale")
+	}
+	s.Mismatched = []int{1, 2}
+	if err := s.Err(); err == nil || err.Error() != "db: migration checksum mismatch for 0001, 0002" {
+		t.Fatalf("expected mismatches to be reported first, got %v", err)
+	}
+	if !slices.Equal(s.Pending, []int{3}) {
+		t.Fatalf("unexpected pending list %v", s.Pending)
+	}
+}
+
 func writeFile(t *testing.T, path string, content string) {
 	t.Helper()
 	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
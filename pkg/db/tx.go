@@ -3,7 +3,11 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
 )
 
 // WithTx wraps a function in a transaction with automatic rollback on error.
@@ -15,14 +19,23 @@ func WithTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.T
 		return fmt.Errorf("db: tx callback is required")
 	}
 
+	ctx, span := tracing.Start(ctx, "db.tx", tracing.WithKind(tracing.SpanKindClient), tracing.WithAttributes("db.system", "postgresql"))
+	defer span.End()
+
 	tx, err := db.BeginTx(ctx, opts)
 	if err != nil {
+		span.RecordError(err)
 		return err
 	}
 
 	if err := fn(tx); err != nil {
-		_ = tx.Rollback()
+		span.RecordError(err)
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			logging.FromContext(ctx).Warn("db transaction rollback failed", "error", rbErr)
+		}
 		return err
 	}
-	return tx.Commit()
+	err = tx.Commit()
+	span.RecordError(err)
+	return err
 }
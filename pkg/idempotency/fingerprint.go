@@ -0,0 +1,112 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint selects the parts of a request that must match for a retry to
+// be answered from the store; a key reused with a different fingerprint is
+// refused with ErrKeyReused.
+type Fingerprint struct {
+	Method bool
+	Path   bool
+	// Body hashes the body, canonicalized first if it is JSON so that key
+	// order and whitespace do not count.
+	Body bool
+	// Credential binds the key to the caller, so another caller reusing it is
+	// refused instead of being shown the first caller's response.
+	Credential bool
+}
+
+// DefaultFingerprint covers every part. Sum treats the zero Fingerprint
+// like it, so an unset fingerprint never matches every request.
+var DefaultFingerprint = Fingerprint{Method: true, Path: true, Body: true, Credential: true}
+
+// ParseFingerprint parses a comma-separated list of parts: method, path,
+// body and credential.
+func ParseFingerprint(s string) (Fingerprint, error) {
+	var f Fingerprint
+	for _, part := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "method":
+			f.Method = true
+		case "path":
+			f.Path = true
+		case "body":
+			f.Body = true
+		case "credential":
+			f.Credential = true
+		case "":
+		default:
+			return Fingerprint{}, fmt.Errorf("idempotency: unknown fingerprint part %q", strings.TrimSpace(part))
+		}
+	}
+	if f == (Fingerprint{}) {
+		return Fingerprint{}, fmt.Errorf("idempotency: fingerprint needs at least one part")
+	}
+	return f, nil
+}
+
+func (f Fingerprint) String() string {
+	parts := make([]string, 0, 4)
+	if f.Method {
+		parts = append(parts, "method")
+	}
+	if f.Path {
+		parts = append(parts, "path")
+	}
+	if f.Body {
+		parts = append(parts, "body")
+	}
+	if f.Credential {
+		parts = append(parts, "credential")
+	}
+	return strings.Join(parts, ",")
+}
+
+// Sum hashes the selected parts of a request.
+func (f Fingerprint) Sum(method, path string, body []byte, credential string) string {
+	if f == (Fingerprint{}) {
+		f = DefaultFingerprint
+	}
+	h := sha256.New()
+	part := func(name string, v []byte) {
+		fmt.Fprintf(h, "%s:%d:", name, len(v))
+		h.Write(v)
+	}
+	if f.Method {
+		part("method", []byte(strings.ToUpper(method)))
+	}
+	if f.Path {
+		part("path", []byte(path))
+	}
+	if f.Body {
+		sum := sha256.Sum256(canonicalBody(body))
+		part("body", sum[:])
+	}
+	if f.Credential {
+		part("credential", []byte(credential))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalBody re-encodes a JSON body with sorted object keys and no
+// insignificant whitespace; other bodies are used as sent.
+func canonicalBody(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
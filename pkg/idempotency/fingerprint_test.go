@@ -0,0 +1,45 @@
+package idempotency
+
+import "testing"
+
+func TestFingerprintCanonicalizesJSONBodies(t *testing.T) {
+	f := DefaultFingerprint
+	a := f.Sum("POST", "/v1/decisions", []byte(`{"b":1,"a":[true, null]}`), "cred-1")
+	b := f.Sum("post", "/v1/decisions", []byte("{\n  \"a\": [true,null],\n  \"b\": 1\n}"), "cred-1")
+	if a != b {
+		t.Fatalf("expected key order and whitespace to be ignored")
+	}
+	if a == f.Sum("POST", "/v1/decisions", []byte(`{"b":2,"a":[true,null]}`), "cred-1") {
+		t.Fatalf("expected a different body to change the fingerprint")
+	}
+	if a == f.Sum("POST", "/v1/decisions", []byte(`{"b":1,"a":[true,null]}`), "cred-2") {
+		t.Fatalf("expected the credential to change the fingerprint")
+	}
+	bodyOnly := Fingerprint{Body: true}
+	if bodyOnly.Sum("POST", "/a", []byte("x"), "c1") != bodyOnly.Sum("PUT", "/b", []byte("x"), "c2") {
+		t.Fatalf("expected unselected parts to be ignored")
+	}
+	if bodyOnly.Sum("POST", "/a", []byte("x "), "") == bodyOnly.Sum("POST", "/a", []byte("x"), "") {
+		t.Fatalf("expected non-JSON bodies to be hashed as sent")
+	}
+}
+
+func TestParseFingerprint(t *testing.T) {
+	f, err := ParseFingerprint(" body, Credential ")
+	if err != nil || f != (Fingerprint{Body: true, Credential: true}) || f.String() != "body,credential" {
+		t.Fatalf("unexpected parse: %+v %v", f, err)
+	}
+	if _, err := ParseFingerprint("body,query"); err == nil {
+		t.Fatalf("expected unknown part to fail")
+	}
+	if _, err := ParseFingerprint(" , "); err == nil {
+		t.Fatalf("expected empty fingerprint to fail")
+	}
+}
+
+func TestZeroFingerprintCoversEveryPart(t *testing.T) {
+	var zero Fingerprint
+	if zero.Sum("POST", "/a", []byte("x"), "c1") != DefaultFingerprint.Sum("POST", "/a", []byte("x"), "c1") {
+		t.Fatalf("expected the zero fingerprint to act as the default")
+	}
+}
@@ -0,0 +1,202 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+const (
+	// HeaderKey carries the client's idempotency key.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses served from the store.
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// Outcome is a notable result of the middleware, reported to Observe.
+type Outcome string
+
+const (
+	OutcomeReplay     Outcome = "replay"
+	OutcomeInProgress Outcome = "in_progress"
+	OutcomeConflict   Outcome = "conflict"
+	OutcomeRecovered  Outcome = "recovered"
+)
+
+// releaseTimeout bounds storing or releasing a claim after the handler ran.
+const releaseTimeout = 2 * time.Second
+
+// Backend is the storage the middleware needs; *Store implements it.
+type Backend interface {
+	Claim(ctx context.Context, scope, key, fingerprint string) (*Claim, *Response, error)
+	Complete(ctx context.Context, c *Claim, resp Response) error
+	Release(ctx context.Context, c *Claim) error
+}
+
+// Middleware makes a write route idempotent. It claims the request's key
+// before the handler runs and replays the stored response to retries. A
+// handler that writes in a transaction should store its response there via
+// ClaimFromContext and CompleteTx; otherwise a 2xx response is stored after
+// the handler returns. Any other response releases the key.
+type Middleware struct {
+	Backend Backend
+	// Scope namespaces the keys of the route.
+	Scope       string
+	Fingerprint Fingerprint
+	// Credential names the caller for the fingerprint, typically from the
+	// context set up by an earlier authentication step.
+	Credential func(r *http.Request) string
+	// MaxBody bounds the body read for the fingerprint; default 1 MiB. A
+	// larger body is refused with ErrBodyTooLarge.
+	MaxBody int64
+	// Required refuses requests without a key; otherwise they pass through.
+	Required bool
+	// Error writes a rejection: ErrKeyRequired, ErrBodyTooLarge,
+	// ErrKeyReused, ErrInProgress or a store error.
+	Error func(w http.ResponseWriter, r *http.Request, err error)
+	// Observe, if set, is told about replays, conflicts and recoveries.
+	Observe func(ctx context.Context, scope string, outcome Outcome)
+}
+
+// Wrap returns next behind the middleware.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(HeaderKey))
+		if key == "" {
+			if m.Required {
+				m.Error(w, r, ErrKeyRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		maxBody := m.MaxBody
+		if maxBody <= 0 {
+			maxBody = 1 << 20
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil {
+			m.Error(w, r, err)
+			return
+		}
+		if int64(len(body)) > maxBody {
+			m.Error(w, r, ErrBodyTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		credential := ""
+		if m.Credential != nil {
+			credential = m.Credential(r)
+		}
+		fingerprint := m.Fingerprint.Sum(r.Method, r.URL.Path, body, credential)
+		claim, stored, err := m.Backend.Claim(r.Context(), m.Scope, key, fingerprint)
+		switch {
+		case errors.Is(err, ErrKeyReused):
+			m.observe(r.Context(), OutcomeConflict)
+			m.Error(w, r, err)
+			return
+		case errors.Is(err, ErrInProgress):
+			m.observe(r.Context(), OutcomeInProgress)
+			m.Error(w, r, err)
+			return
+		case err != nil:
+			m.Error(w, r, err)
+			return
+		case stored != nil:
+			m.observe(r.Context(), OutcomeReplay)
+			Replay(w, *stored)
+			return
+		}
+		if claim.Recovered {
+			m.observe(r.Context(), OutcomeRecovered)
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(WithClaim(r.Context(), claim)))
+		m.settle(r, claim, rec)
+	})
+}
+
+// settle stores a successful response the handler did not store itself and
+// releases the key of any other. It runs even if the request was cancelled.
+func (m *Middleware) settle(r *http.Request, claim *Claim, rec *recorder) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), releaseTimeout)
+	defer cancel()
+	success := rec.status >= 200 && rec.status < 300
+	if success && claim.Completed() {
+		return
+	}
+	if success {
+		err := m.Backend.Complete(ctx, claim, Response{Status: rec.status, Header: rec.Header(), Body: rec.body.Bytes()})
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to store idempotent response", "scope", m.Scope, "error", err)
+		}
+		return
+	}
+	if err := m.Backend.Release(ctx, claim); err != nil {
+		logging.FromContext(ctx).Warn("failed to release idempotency key", "scope", m.Scope, "error", err)
+	}
+}
+
+func (m *Middleware) observe(ctx context.Context, outcome Outcome) {
+	if m.Observe != nil {
+		m.Observe(ctx, m.Scope, outcome)
+	}
+}
+
+// Replay writes a stored response with HeaderReplayed set.
+func Replay(w http.ResponseWriter, resp Response) {
+	for name, vs := range resp.Header {
+		w.Header()[name] = append([]string(nil), vs...)
+	}
+	w.Header().Set(HeaderReplayed, strconv.FormatBool(true))
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(resp.Body)
+}
+
+type claimKey struct{}
+
+// WithClaim returns ctx carrying the request's claim.
+func WithClaim(ctx context.Context, c *Claim) context.Context {
+	return context.WithValue(ctx, claimKey{}, c)
+}
+
+// ClaimFromContext returns the claim the middleware made for the request, or nil.
+func ClaimFromContext(ctx context.Context) *Claim {
+	c, _ := ctx.Value(claimKey{}).(*Claim)
+	return c
+}
+
+// recorder passes the response through while keeping its status and body.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
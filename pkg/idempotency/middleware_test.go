@@ -0,0 +1,185 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// memoryBackend keeps one response per key, ignoring leases.
+type memoryBackend struct {
+	fingerprints map[string]string
+	responses    map[string]*Response
+	released     int
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{fingerprints: map[string]string{}, responses: map[string]*Response{}}
+}
+
+func (b *memoryBackend) Claim(_ context.Context, scope, key, fingerprint string) (*Claim, *Response, error) {
+	full := scopedKey(scope, key)
+	if fp, ok := b.fingerprints[full]; ok {
+		if fp != fingerprint {
+			return nil, nil, ErrKeyReused
+		}
+		if resp := b.responses[full]; resp != nil {
+			return nil, resp, nil
+		}
+		return nil, nil, ErrInProgress
+	}
+	b.fingerprints[full] = fingerprint
+	return &Claim{Scope: scope, Key: key, fullKey: full, headers: []string{"Content-Type", "Location"}}, nil, nil
+}
+
+func (b *memoryBackend) Complete(_ context.Context, c *Claim, resp Response) error {
+	header := http.Header{}
+	for _, name := range c.headers {
+		if v := resp.Header.Get(name); v != "" {
+			header.Set(name, v)
+		}
+	}
+	b.responses[c.fullKey] = &Response{Status: resp.Status, Header: header, Body: resp.Body}
+	c.done.Store(true)
+	return nil
+}
+
+func (b *memoryBackend) Release(_ context.Context, c *Claim) error {
+	delete(b.fingerprints, c.fullKey)
+	b.released++
+	return nil
+}
+
+func newTestMiddleware(b Backend, outcomes *[]Outcome) *Middleware {
+	return &Middleware{
+		Backend:     b,
+		Scope:       "v1/things",
+		Fingerprint: DefaultFingerprint,
+		Credential:  func(r *http.Request) string { return r.Header.Get("X-Caller") },
+		Required:    true,
+		Error: func(w http.ResponseWriter, _ *http.Request, err error) {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, ErrKeyRequired):
+				status = http.StatusBadRequest
+			case errors.Is(err, ErrKeyReused), errors.Is(err, ErrInProgress):
+				status = http.StatusConflict
+			case errors.Is(err, ErrBodyTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+		},
+		Observe: func(_ context.Context, _ string, o Outcome) { *outcomes = append(*outcomes, o) },
+	}
+}
+
+func serve(h http.Handler, key, caller, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/things", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(HeaderKey, key)
+	}
+	r.Header.Set("X-Caller", caller)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewareStoresAndReplaysResponse(t *testing.T) {
+	var outcomes []Outcome
+	calls := 0
+	h := newTestMiddleware(newMemoryBackend(), &outcomes).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if ClaimFromContext(r.Context()) == nil || string(body) != `{"n":1}` {
+			t.Errorf("expected the claim in context and the body intact, got %q", body)
+		}
+		w.Header().Set("Location", "/v1/things/1")
+		w.Header().Set("X-Volatile", "1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id":1}`)
+	}))
+
+	first := serve(h, "k1", "alice", `{"n":1}`)
+	if first.Code != http.StatusCreated || first.Header().Get(HeaderReplayed) != "" {
+		t.Fatalf("unexpected first response: %d %v", first.Code, first.Header())
+	}
+	replay := serve(h, "k1", "alice", `{ "n": 1 }`)
+	if calls != 1 || replay.Code != http.StatusCreated || replay.Body.String() != `{"id":1}` {
+		t.Fatalf("expected a replay without running the handler, got %d %q after %d calls", replay.Code, replay.Body, calls)
+	}
+	if replay.Header().Get(HeaderReplayed) != "true" || replay.Header().Get("Location") != "/v1/things/1" || replay.Header().Get("X-Volatile") != "" {
+		t.Fatalf("expected selected headers and the replay marker, got %v", replay.Header())
+	}
+	if other := serve(h, "k1", "bob", `{"n":1}`); other.Code != http.StatusConflict || calls != 1 {
+		t.Fatalf("expected another caller reusing the key to be refused, got %d", other.Code)
+	}
+	if missing := serve(h, "", "alice", `{"n":1}`); missing.Code != http.StatusBadRequest {
+		t.Fatalf("expected a missing key to be refused, got %d", missing.Code)
+	}
+	if len(outcomes) != 2 || outcomes[0] != OutcomeReplay || outcomes[1] != OutcomeConflict {
+		t.Fatalf("unexpected outcomes %v", outcomes)
+	}
+}
+
+func TestMiddlewareReleasesFailedRequests(t *testing.T) {
+	var outcomes []Outcome
+	backend := newMemoryBackend()
+	status := http.StatusUnprocessableEntity
+	h := newTestMiddleware(backend, &outcomes).Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	if w := serve(h, "k1", "alice", "x"); w.Code != http.StatusUnprocessableEntity || backend.released != 1 {
+		t.Fatalf("expected the key released after a failure, got %d (%d releases)", w.Code, backend.released)
+	}
+	status = http.StatusNoContent
+	if w := serve(h, "k1", "alice", "x"); w.Code != http.StatusNoContent || backend.responses["v1/things:k1"] == nil {
+		t.Fatalf("expected a retry to run and store its response, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareRefusesOversizedBody(t *testing.T) {
+	var outcomes []Outcome
+	backend := newMemoryBackend()
+	m := newTestMiddleware(backend, &outcomes)
+	m.MaxBody = 4
+	calls := 0
+	h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	if w := serve(h, "k1", "alice", "12345"); w.Code != http.StatusRequestEntityTooLarge || calls != 0 || len(backend.fingerprints) != 0 {
+		t.Fatalf("expected a body over MaxBody to be refused before claiming, got %d", w.Code)
+	}
+	if w := serve(h, "k1", "alice", "1234"); w.Code != http.StatusNoContent || calls != 1 {
+		t.Fatalf("expected a body of exactly MaxBody to pass, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareKeepsResponseStoredInTransaction(t *testing.T) {
+	var outcomes []Outcome
+	backend := newMemoryBackend()
+	h := newTestMiddleware(backend, &outcomes).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stand-in for CompleteTx inside the handler's write.
+		_ = backend.Complete(r.Context(), ClaimFromContext(r.Context()), Response{Status: http.StatusAccepted, Body: []byte("tx")})
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, "tx")
+	}))
+	serve(h, "k1", "alice", "x")
+	if got := backend.responses["v1/things:k1"]; got == nil || string(got.Body) != "tx" || backend.released != 0 {
+		t.Fatalf("expected the transactional response kept, got %+v", got)
+	}
+}
+
+func TestStoreRequiresDB(t *testing.T) {
+	if _, err := NewStore((*sql.DB)(nil), Config{}); err == nil {
+		t.Fatalf("expected nil db handle error")
+	}
+	if seconds(0) != 1 || seconds(30e9) != 30 {
+		t.Fatalf("unexpected second rounding")
+	}
+}
@@ -0,0 +1,371 @@
+// Package idempotency makes write routes safe to retry. A request claims its
+// Idempotency-Key under a lease; the write then stores its response under the
+// claim, ideally in the write's own transaction, and later requests with the
+// same key and fingerprint are answered from the store.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrKeyRequired means the request carries no Idempotency-Key.
+	ErrKeyRequired = errors.New("idempotency key is required")
+	// ErrKeyReused means the key was claimed by a request with another fingerprint.
+	ErrKeyReused = errors.New("idempotency key reused with different payload")
+	// ErrInProgress means another request holds a live lease on the key.
+	ErrInProgress = errors.New("request is already in progress")
+	// ErrLeaseLost means another request took the key over after this one's
+	// lease lapsed; a write completing the claim in its transaction rolls back.
+	ErrLeaseLost = errors.New("idempotency key lease lost")
+	// ErrBodyTooLarge means the request body exceeds Middleware.MaxBody, so
+	// the fingerprint could not cover all of it.
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+const (
+	// DefaultTTL is how long a key and its response are kept.
+	DefaultTTL = 24 * time.Hour
+	// DefaultLease bounds how long a claimed key without a response blocks
+	// retries. It must outlast the write it guards.
+	DefaultLease = 30 * time.Second
+)
+
+// DefaultHeaders are the response headers stored with a response and
+// replayed with it.
+var DefaultHeaders = []string{"Content-Type", "Content-Location", "Location", "ETag"}
+
+// Config tunes a Store. Zero values take the defaults.
+type Config struct {
+	TTL     time.Duration
+	Lease   time.Duration
+	Headers []string
+}
+
+// Response is a stored response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Claim is a leased reservation of a key. Exactly one of completing it or
+// releasing it should follow; an abandoned claim lapses with its lease.
+type Claim struct {
+	Scope string
+	Key   string
+	// Recovered reports that the claim took over a key whose previous owner
+	// let its lease lapse without a response.
+	Recovered bool
+
+	fullKey string
+	owner   string
+	headers []string
+	done    atomic.Bool
+}
+
+// Completed reports whether a response was stored under the claim. A
+// transaction that stored it may still have rolled back.
+func (c *Claim) Completed() bool {
+	return c != nil && c.done.Load()
+}
+
+// Record is a key as seen by an operator.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LockedUntil *time.Time
+	CompletedAt *time.Time
+	// Response is nil while the key is in progress.
+	Response *Response
+}
+
+// State is "completed" once a response is stored, else "in_progress".
+func (r Record) State() string {
+	if r.Response != nil {
+		return "completed"
+	}
+	return "in_progress"
+}
+
+// Store keeps keys in ops.idempotency_keys.
+type Store struct {
+	db      *sql.DB
+	ttl     time.Duration
+	lease   time.Duration
+	headers []string
+}
+
+func NewStore(db *sql.DB, cfg Config) (*Store, error) {
+	if db == nil {
+		return nil, fmt.Errorf("idempotency: nil db handle")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = DefaultLease
+	}
+	if cfg.Headers == nil {
+		cfg.Headers = DefaultHeaders
+	}
+	headers := make([]string, 0, len(cfg.Headers))
+	for _, h := range cfg.Headers {
+		if h = strings.TrimSpace(h); h != "" {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+	}
+	return &Store{db: db, ttl: cfg.TTL, lease: cfg.Lease, headers: headers}, nil
+}
+
+// Claim claims key within scope under a lease. It returns the claim, or the
+// stored response of a completed request; ErrKeyReused if the key was used
+// with another fingerprint and ErrInProgress while another request holds it.
+func (s *Store) Claim(ctx context.Context, scope, key, fingerprint string) (*Claim, *Response, error) {
+	scope = strings.TrimSpace(scope)
+	key = strings.TrimSpace(key)
+	fingerprint = strings.TrimSpace(fingerprint)
+	if scope == "" || key == "" || fingerprint == "" {
+		return nil, nil, fmt.Errorf("idempotency: scope/key/fingerprint are required")
+	}
+	owner, err := newLeaseOwner()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Insert a fresh claim, or take over a row that expired or whose owner
+	// let its lease lapse without storing a response. Rows without a lease
+	// predate leases and count as lapsed once they are a lease old.
+	fullKey := scopedKey(scope, key)
+	var recovered bool
+	err = s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO ops.idempotency_keys
+		 (idempotency_key, scope, request_hash, expires_at, locked_by, locked_until)
+		 VALUES ($1, $2, $3, now() + make_interval(secs => $4), $5, now() + make_interval(secs => $6))
+		 ON CONFLICT (idempotency_key) DO UPDATE
+		 SET request_hash = EXCLUDED.request_hash,
+		     created_at = now(),
+		     expires_at = EXCLUDED.expires_at,
+		     locked_by = EXCLUDED.locked_by,
+		     locked_until = EXCLUDED.locked_until,
+		     response_code = NULL,
+		     response_json = NULL,
+		     response_headers = NULL,
+		     response_body = NULL,
+		     completed_at = NULL
+		 WHERE ops.idempotency_keys.expires_at <= now()
+		    OR (ops.idempotency_keys.response_code IS NULL
+		        AND ops.idempotency_keys.request_hash = EXCLUDED.request_hash
+		        AND COALESCE(ops.idempotency_keys.locked_until,
+		                     ops.idempotency_keys.created_at + make_interval(secs => $6)) <= now())
+		 RETURNING (xmax <> 0)`,
+		fullKey,
+		scope,
+		fingerprint,
+		seconds(s.ttl),
+		owner,
+		seconds(s.lease),
+	).Scan(&recovered)
+	if err == nil {
+		return &Claim{
+			Scope:     scope,
+			Key:       key,
+			Recovered: recovered,
+			fullKey:   fullKey,
+			owner:     owner,
+			headers:   s.headers,
+		}, nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+
+	// The key is held: either completed or leased by a live request.
+	rec, found, err := s.Lookup(ctx, scope, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		// Expired between the two statements; the client may simply retry.
+		return nil, nil, ErrInProgress
+	}
+	if rec.Fingerprint != fingerprint {
+		return nil, nil, ErrKeyReused
+	}
+	if rec.Response == nil {
+		return nil, nil, ErrInProgress
+	}
+	return nil, rec.Response, nil
+}
+
+// CompleteTx stores resp under the claim in the write's transaction, so the
+// data and the stored response commit together. It fails with ErrLeaseLost
+// if the key changed hands.
+func (c *Claim) CompleteTx(ctx context.Context, tx *sql.Tx, resp Response) error {
+	if tx == nil {
+		return fmt.Errorf("idempotency: nil transaction")
+	}
+	return c.complete(ctx, tx, resp)
+}
+
+// Complete stores resp under the claim on its own, for writes that cannot
+// store it in their transaction.
+func (s *Store) Complete(ctx context.Context, c *Claim, resp Response) error {
+	return c.complete(ctx, s.db, resp)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (c *Claim) complete(ctx context.Context, db execer, resp Response) error {
+	if c == nil {
+		return fmt.Errorf("idempotency: nil claim")
+	}
+	if resp.Status <= 0 {
+		return fmt.Errorf("idempotency: response status must be > 0")
+	}
+	header := make(http.Header, len(c.headers))
+	for _, name := range c.headers {
+		if vs := resp.Header.Values(name); len(vs) > 0 {
+			header[name] = append([]string(nil), vs...)
+		}
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("idempotency: encode headers: %w", err)
+	}
+	body := resp.Body
+	if body == nil {
+		body = []byte{}
+	}
+	res, err := db.ExecContext(
+		ctx,
+		`UPDATE ops.idempotency_keys
+		 SET response_code = $4,
+		     response_headers = $5,
+		     response_body = $6,
+		     completed_at = now(),
+		     locked_by = NULL,
+		     locked_until = NULL
+		 WHERE scope = $1
+		   AND idempotency_key = $2
+		   AND locked_by = $3
+		   AND response_code IS NULL`,
+		c.Scope,
+		c.fullKey,
+		c.owner,
+		resp.Status,
+		headerJSON,
+		body,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrLeaseLost
+	}
+	c.done.Store(true)
+	return nil
+}
+
+// Release drops a claim that never produced a response, so the client may
+// retry with the same key once the rejection no longer applies. If the
+// release itself fails, the lease still lapses on its own.
+func (s *Store) Release(ctx context.Context, c *Claim) error {
+	if c == nil {
+		return nil
+	}
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM ops.idempotency_keys
+		 WHERE scope = $1
+		   AND idempotency_key = $2
+		   AND locked_by = $3
+		   AND response_code IS NULL`,
+		c.Scope,
+		c.fullKey,
+		c.owner,
+	)
+	return err
+}
+
+// Lookup loads an unexpired key. Responses stored before response bodies
+// were kept as bytes are read from their JSON column.
+func (s *Store) Lookup(ctx context.Context, scope, key string) (Record, bool, error) {
+	rec := Record{Scope: scope, Key: key}
+	var (
+		code    sql.NullInt64
+		headers []byte
+		body    []byte
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT request_hash, created_at, expires_at, locked_until, completed_at,
+		        response_code, response_headers,
+		        COALESCE(response_body, convert_to(response_json::text, 'UTF8'))
+		 FROM ops.idempotency_keys
+		 WHERE scope = $1
+		   AND idempotency_key = $2
+		   AND expires_at > now()`,
+		scope,
+		scopedKey(scope, key),
+	).Scan(&rec.Fingerprint, &rec.CreatedAt, &rec.ExpiresAt, &rec.LockedUntil, &rec.CompletedAt, &code, &headers, &body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	if code.Valid && code.Int64 > 0 {
+		resp := &Response{Status: int(code.Int64), Header: http.Header{}, Body: body}
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &resp.Header); err != nil {
+				return Record{}, false, fmt.Errorf("idempotency: decode headers: %w", err)
+			}
+		} else if len(body) > 0 {
+			// Rows from before headers were stored only ever held JSON.
+			resp.Header.Set("Content-Type", "application/json")
+		}
+		rec.Response = resp
+	}
+	return rec, true, nil
+}
+
+func scopedKey(scope, key string) string {
+	return scope + ":" + key
+}
+
+func newLeaseOwner() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("idempotency: lease owner: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func seconds(d time.Duration) int64 {
+	s := int64(d / time.Second)
+	if s < 1 {
+		s = 1
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+// Package logging builds the platform's log/slog loggers and carries a
+// request-scoped logger through context so repositories log with the same
+// correlation attributes (request_id, tenant, ...) as the HTTP layer.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Output formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Redacted replaces the value of sensitive attributes.
+const Redacted = "[REDACTED]"
+
+// Config selects the minimum level and output format.
+type Config struct {
+	Level  slog.Level
+	Format string
+}
+
+// ParseLevel accepts debug, info, warn/warning and error (case-insensitive).
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("logging: unknown level %q", s)
+	}
+}
+
+// ParseFormat accepts json or text (case-insensitive).
+func ParseFormat(s string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(s)); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatText:
+		return f, nil
+	default:
+		return "", fmt.Errorf("logging: unknown format %q", s)
+	}
+}
+
+// New returns a logger writing to w. Attributes whose key names a credential
+// (see IsSensitiveKey) are redacted, including inside groups.
+func New(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level, ReplaceAttr: redactAttr}
+	if cfg.Format == FormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+var sensitiveKeyParts = []string{
+	"authorization",
+	"token",
+	"api_key",
+	"apikey",
+	"api-key",
+	"secret",
+	"password",
+	"signature",
+	"cookie",
+	"private_key",
+}
+
+// IsSensitiveKey reports whether an attribute, header or query parameter name
+// is likely to carry a credential: the name ends in one of the parts above,
+// optionally plural (access_token, X-API-Key, api_tokens). Names that only
+// mention one, like token_count, are left alone.
+func IsSensitiveKey(key string) bool {
+	k := strings.TrimSuffix(strings.ToLower(key), "s")
+	for _, part := range sensitiveKeyParts {
+		if strings.HasSuffix(k, part) {
+			return true
+		}
+	}
+	return false
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok && l != nil {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// With adds attributes to the context logger, e.g. once the caller's tenant is known.
+func With(ctx context.Context, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
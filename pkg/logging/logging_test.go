@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevelAndFormat(t *testing.T) {
+	if l, err := ParseLevel("WARNING"); err != nil || l != slog.LevelWarn {
+		t.Fatalf("unexpected level %v err=%v", l, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatalf("expected unknown level error")
+	}
+	if f, err := ParseFormat(""); err != nil || f != FormatJSON {
+		t.Fatalf("expected json default, got %q err=%v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatalf("expected unknown format error")
+	}
+}
+
+func TestNewRedactsSensitiveAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, Config{Level: slog.LevelInfo, Format: FormatJSON})
+	l.Info("request", "authorization", "Bearer abc", slog.Group("headers", "X-API-Key", "k-1", "accept", "json"), "tenant_id", "t-1")
+	l.Debug("hidden")
+
+	out := buf.String()
+	if strings.Contains(out, "abc") || strings.Contains(out, "k-1") || strings.Contains(out, "hidden") {
+		t.Fatalf("expected redacted output at info level, got %s", out)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected json output: %v", err)
+	}
+	if entry["authorization"] != Redacted || entry["tenant_id"] != "t-1" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+	headers := entry["headers"].(map[string]interface{})
+	if headers["X-API-Key"] != Redacted || headers["accept"] != "json" {
+		t.Fatalf("unexpected group %v", headers)
+	}
+}
+
+func TestIsSensitiveKey(t *testing.T) {
+	for _, k := range []string{"authorization", "Proxy-Authorization", "access_token", "X-API-Key", "apikey", "api_tokens", "client_secret", "Set-Cookie", "db_password", "X-Signature"} {
+		if !IsSensitiveKey(k) {
+			t.Fatalf("expected %q to be sensitive", k)
+		}
+	}
+	for _, k := range []string{"token_count", "metrics_token_set", "signature_required", "tenant_id", "status"} {
+		if IsSensitiveKey(k) {
+			t.Fatalf("expected %q to be logged as is", k)
+		}
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Fatalf("expected default logger without context logger")
+	}
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(&buf, Config{Format: FormatText}))
+	ctx = With(ctx, "request_id", "req-1")
+	FromContext(ctx).Info("persisted")
+	if !strings.Contains(buf.String(), "request_id=req-1") {
+		t.Fatalf("expected correlation attribute, got %s", buf.String())
+	}
+}
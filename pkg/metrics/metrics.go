@@ -0,0 +1,394 @@
+// Package metrics is a small Prometheus-compatible metrics registry: labeled
+// counters and histograms, callback gauges/counters for values owned elsewhere
+// (e.g. database/sql pool stats), and the text exposition format (0.0.4).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the Prometheus text exposition content type.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefBuckets are latency buckets in seconds, matching the Prometheus client default.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type family interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them in name order.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// register panics on invalid or duplicate names: both are programming errors
+// caught the first time the process starts.
+func (r *Registry) register(f family, labels []string) {
+	if !metricNameRE.MatchString(f.name()) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", f.name()))
+	}
+	for _, l := range labels {
+		if !labelNameRE.MatchString(l) || l == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %q", l, f.name()))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[f.name()]; ok {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", f.name()))
+	}
+	r.families[f.name()] = f
+}
+
+// WriteTo renders every family in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	fams := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		fams = append(fams, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(fams, func(i, j int) bool { return fams[i].name() < fams[j].name() })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, f := range fams {
+		f.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler serves the registry for Prometheus scrapes.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ---------------------------------------------------------------------------
+// Counters
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	bits atomic.Uint64
+}
+
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add increases the counter; negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if v < 0 || math.IsNaN(v) {
+		return
+	}
+	for {
+		old := c.bits.Load()
+		if c.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (c *Counter) value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	fam    string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values  []string
+	counter *Counter
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{fam: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+	r.register(v, labels)
+	return v
+}
+
+// WithLabelValues returns the counter for the given label values, in label order.
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	values = fitLabelValues(values, len(v.labels))
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &counterSeries{values: values, counter: &Counter{}}
+		v.series[key] = s
+	}
+	return s.counter
+}
+
+func (v *CounterVec) name() string { return v.fam }
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	writeHeader(w, v.fam, v.help, TypeCounter)
+	v.mu.Lock()
+	series := sortedSeries(v.series)
+	v.mu.Unlock()
+	for _, s := range series {
+		writeSample(w, v.fam, v.labels, s.values, "", "", s.counter.value())
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Histograms
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	upper []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *Histogram) Observe(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	i := sort.SearchFloat64s(h.upper, v)
+	h.mu.Lock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+	h.mu.Unlock()
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	fam     string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values    []string
+	histogram *Histogram
+}
+
+// NewHistogramVec creates a histogram with the given upper bounds (DefBuckets when empty).
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	v := &HistogramVec{fam: name, help: help, labels: labels, buckets: b, series: make(map[string]*histogramSeries)}
+	r.register(v, labels)
+	return v
+}
+
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	values = fitLabelValues(values, len(v.labels))
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &histogramSeries{values: values, histogram: &Histogram{upper: v.buckets, counts: make([]uint64, len(v.buckets))}}
+		v.series[key] = s
+	}
+	return s.histogram
+}
+
+func (v *HistogramVec) name() string { return v.fam }
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	writeHeader(w, v.fam, v.help, TypeHistogram)
+	v.mu.Lock()
+	series := sortedSeries(v.series)
+	v.mu.Unlock()
+	for _, s := range series {
+		h := s.histogram
+		h.mu.Lock()
+		counts := append([]uint64(nil), h.counts...)
+		sum, count := h.sum, h.count
+		h.mu.Unlock()
+
+		var cumulative uint64
+		for i, upper := range v.buckets {
+			cumulative += counts[i]
+			writeSample(w, v.fam+"_bucket", v.labels, s.values, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, v.fam+"_bucket", v.labels, s.values, "le", "+Inf", float64(count))
+		writeSample(w, v.fam+"_sum", v.labels, s.values, "", "", sum)
+		writeSample(w, v.fam+"_count", v.labels, s.values, "", "", float64(count))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Callback families
+
+// Sample is one labeled value reported by a callback family.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type funcFamily struct {
+	fam     string
+	help    string
+	typ     string
+	labels  []string
+	collect func() []Sample
+}
+
+// NewFunc registers a gauge or counter whose samples are read from collect at
+// scrape time, for values another component already tracks.
+func (r *Registry) NewFunc(name, help, typ string, labels []string, collect func() []Sample) {
+	if typ != TypeCounter && typ != TypeGauge {
+		panic(fmt.Sprintf("metrics: unsupported callback type %q for %q", typ, name))
+	}
+	r.register(&funcFamily{fam: name, help: help, typ: typ, labels: labels, collect: collect}, labels)
+}
+
+// NewGaugeFunc registers an unlabeled gauge read from fn at scrape time.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.NewFunc(name, help, TypeGauge, nil, func() []Sample { return []Sample{{Value: fn()}} })
+}
+
+// NewCounterFunc registers an unlabeled counter read from fn at scrape time.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.NewFunc(name, help, TypeCounter, nil, func() []Sample { return []Sample{{Value: fn()}} })
+}
+
+func (f *funcFamily) name() string { return f.fam }
+
+func (f *funcFamily) write(w *bufio.Writer) {
+	writeHeader(w, f.fam, f.help, f.typ)
+	samples := f.collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	for _, s := range samples {
+		writeSample(w, f.fam, f.labels, fitLabelValues(s.LabelValues, len(f.labels)), "", "", s.Value)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Exposition helpers
+
+type labeledSeries interface {
+	*counterSeries | *histogramSeries
+}
+
+func sortedSeries[S labeledSeries](m map[string]S) []S {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]S, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, m[k])
+	}
+	return out
+}
+
+// fitLabelValues pads or truncates values to the declared label count.
+func fitLabelValues(values []string, n int) []string {
+	out := make([]string, n)
+	copy(out, values)
+	return out
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		sep := ""
+		for i, l := range labels {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, l, escapeLabelValue(values[i]))
+			sep = ","
+		}
+		if extraLabel != "" {
+			fmt.Fprintf(w, `%s%s="%s"`, sep, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string       { return helpEscaper.Replace(s) }
+func escapeLabelValue(s string) string { return labelEscaper.Replace(s) }
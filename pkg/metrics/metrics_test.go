@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("http_requests_total", "Requests served.", "route", "status")
+	requests.WithLabelValues("/v1/decisions", "202").Add(2)
+	requests.WithLabelValues("/v1/decisions", "429").Inc()
+	requests.WithLabelValues(`we"ird\`, "500").Inc()
+
+	latency := r.NewHistogramVec("http_request_duration_seconds", "Latency.", []float64{0.1, 1}, "route")
+	latency.WithLabelValues("/v1/decisions").Observe(0.05)
+	latency.WithLabelValues("/v1/decisions").Observe(0.5)
+	latency.WithLabelValues("/v1/decisions").Observe(3)
+
+	r.NewFunc("jobs_total", "Jobs.\nMultiline", TypeCounter, []string{"job"}, func() []Sample {
+		return []Sample{{LabelValues: []string{"b"}, Value: 2}, {LabelValues: []string{"a"}, Value: 1}}
+	})
+	r.NewGaugeFunc("up", "Up.", func() float64 { return 1 })
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	want := `# HELP http_request_duration_seconds Latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/v1/decisions",le="0.1"} 1
+http_request_duration_seconds_bucket{route="/v1/decisions",le="1"} 2
+http_request_duration_seconds_bucket{route="/v1/decisions",le="+Inf"} 3
+http_request_duration_seconds_sum{route="/v1/decisions"} 3.55
+http_request_duration_seconds_count{route="/v1/decisions"} 3
+# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{route="/v1/decisions",status="202"} 2
+http_requests_total{route="/v1/decisions",status="429"} 1
+http_requests_total{route="we\"ird\\",status="500"} 1
+# HELP jobs_total Jobs.\nMultiline
+# TYPE jobs_total counter
+jobs_total{job="a"} 1
+jobs_total{job="b"} 2
+# HELP up Up.
+# TYPE up gauge
+up 1
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected exposition:\n%s", buf.String())
+	}
+}
+
+func TestRegistryRejectsDuplicatesAndBadNames(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("a_total", "A.")
+	for _, fn := range []func(){
+		func() { r.NewCounterVec("a_total", "A again.") },
+		func() { r.NewCounterVec("bad-name", "Bad.") },
+		func() { r.NewHistogramVec("h", "H.", nil, "le") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestHandlerContentType(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("c_total", "C.").WithLabelValues().Add(-1)
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Header().Get("Content-Type") != ContentType || !strings.Contains(rec.Body.String(), "c_total 0") {
+		t.Fatalf("unexpected response %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
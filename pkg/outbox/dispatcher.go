@@ -0,0 +1,570 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+// AnyEventType registers a sink for every event type without a sink of its own.
+const AnyEventType = "*"
+
+const (
+	DefaultBatchSize       = 50
+	DefaultPollInterval    = time.Second
+	DefaultLease           = time.Minute
+	DefaultDeliveryTimeout = 30 * time.Second
+	DefaultMaxAttempts     = 10
+	DefaultBaseBackoff     = time.Second
+	DefaultMaxBackoff      = time.Hour
+
+	// bookkeepingTimeout bounds recording an attempt, which runs even when
+	// the dispatcher's context has ended.
+	bookkeepingTimeout = 5 * time.Second
+	maxErrorMessageLen = 1024
+)
+
+// ErrLeaseLost means a message's lease lapsed before or during delivery and
+// another claim took it over; that claim delivers it.
+var ErrLeaseLost = errors.New("outbox: message lease lost")
+
+var errAttemptsExhausted = &DeliveryError{Code: "attempts_exhausted", Err: errors.New("no attempts left"), Permanent: true}
+
+// Sink delivers messages to one destination. Returning an error schedules a
+// retry, unless it is a permanent DeliveryError.
+type Sink interface {
+	Deliver(ctx context.Context, msg Message) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, msg Message) error
+
+func (f SinkFunc) Deliver(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// DeliveryError lets a sink set the attempt's error_code and mark a failure
+// that retrying cannot fix.
+type DeliveryError struct {
+	Code      string
+	Err       error
+	Permanent bool
+}
+
+func (e *DeliveryError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Config tunes a Dispatcher. Zero values take the defaults.
+type Config struct {
+	// ProcessorName is recorded with every attempt, e.g. the node name.
+	ProcessorName string
+	BatchSize     int
+	PollInterval  time.Duration
+	// Lease is how long a claimed message is hidden from other dispatchers.
+	// It is renewed right before each delivery, so it must outlast
+	// DeliveryTimeout rather than the whole batch.
+	Lease           time.Duration
+	DeliveryTimeout time.Duration
+	MaxAttempts     int
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	c.ProcessorName = strings.TrimSpace(c.ProcessorName)
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = DefaultPollInterval
+	}
+	if c.Lease <= 0 {
+		c.Lease = DefaultLease
+	}
+	if c.DeliveryTimeout <= 0 {
+		c.DeliveryTimeout = DefaultDeliveryTimeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = DefaultBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	return c
+}
+
+// Validate reports whether NewDispatcher would accept c, defaults applied.
+func (c Config) Validate() error {
+	c = c.withDefaults()
+	if c.ProcessorName == "" {
+		return fmt.Errorf("outbox: processor name is required")
+	}
+	if c.Lease <= c.DeliveryTimeout {
+		return fmt.Errorf("outbox: lease (%s) must exceed the delivery timeout (%s)", c.Lease, c.DeliveryTimeout)
+	}
+	if c.MaxBackoff < c.BaseBackoff {
+		return fmt.Errorf("outbox: max backoff must be >= base backoff")
+	}
+	return nil
+}
+
+// Backoff is the delay after a message's attempt-th failed attempt:
+// BaseBackoff doubled for every earlier attempt, capped at MaxBackoff.
+func (c Config) Backoff(attempt int) time.Duration {
+	c = c.withDefaults()
+	d := c.BaseBackoff
+	for i := 1; i < attempt && d < c.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, c.MaxBackoff)
+}
+
+// Dispatcher drains ops.outbox. Replicas may run one each: due messages are
+// claimed with FOR UPDATE SKIP LOCKED under a lease, so each is delivered by
+// one dispatcher at a time, and a message whose dispatcher died is claimed
+// again once its lease lapses. Delivery is at least once.
+type Dispatcher struct {
+	db  *sql.DB
+	cfg Config
+
+	mu         sync.Mutex
+	sinks      map[string]Sink
+	started    bool
+	stop       chan struct{} // closed by Stop; no claims after it
+	cancelRuns context.CancelFunc
+	loop       sync.WaitGroup
+	onError    func(err error)
+}
+
+// NewDispatcher creates a dispatcher over db.
+func NewDispatcher(db *sql.DB, cfg Config) (*Dispatcher, error) {
+	if db == nil {
+		return nil, fmt.Errorf("outbox: nil db handle")
+	}
+	cfg = cfg.withDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Dispatcher{db: db, cfg: cfg, sinks: make(map[string]Sink), stop: make(chan struct{})}, nil
+}
+
+// Config returns the effective configuration, defaults applied.
+func (d *Dispatcher) Config() Config {
+	return d.cfg
+}
+
+// Register routes eventType, or AnyEventType, to sink. Only event types with
+// a sink are claimed. It must be called before Start.
+func (d *Dispatcher) Register(eventType string, sink Sink) error {
+	eventType = strings.TrimSpace(eventType)
+	if eventType == "" || sink == nil {
+		return fmt.Errorf("outbox: event type and sink are required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started {
+		return fmt.Errorf("outbox: dispatcher already started")
+	}
+	if _, ok := d.sinks[eventType]; ok {
+		return fmt.Errorf("outbox: sink for %q already registered", eventType)
+	}
+	d.sinks[eventType] = sink
+	return nil
+}
+
+// OnError sets a callback for claim and bookkeeping failures; delivery
+// failures are recorded as attempts instead. It must be called before Start.
+func (d *Dispatcher) OnError(fn func(err error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onError = fn
+}
+
+// Start polls for due messages every PollInterval, and at once after a full
+// batch, until ctx is done or Stop is called.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.mu.Lock()
+	if d.started {
+		d.mu.Unlock()
+		return
+	}
+	d.started = true
+	ctx, d.cancelRuns = context.WithCancel(ctx)
+	d.loop.Add(1)
+	d.mu.Unlock()
+
+	go func() {
+		defer d.loop.Done()
+		d.run(ctx)
+	}()
+}
+
+// Stop stops claiming, waits for the message being delivered and releases
+// the rest of the batch for immediate pickup by another dispatcher. A
+// delivery still going when ctx ends is cancelled and Stop returns ctx's
+// error once it has been recorded. It is safe to call more than once, and
+// before Start.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	select {
+	case <-d.stop:
+	default:
+		close(d.stop)
+	}
+	cancelRuns := d.cancelRuns
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.loop.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if cancelRuns != nil {
+			cancelRuns()
+		}
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) stopping() bool {
+	select {
+	case <-d.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.stop:
+			return
+		case <-timer.C:
+		}
+		n, err := d.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.reportError(err)
+		}
+		wait := d.cfg.PollInterval
+		if err == nil && n == d.cfg.BatchSize {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+func (d *Dispatcher) reportError(err error) {
+	d.mu.Lock()
+	onError := d.onError
+	d.mu.Unlock()
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// RunOnce claims one batch of due messages and delivers them in order,
+// returning how many it claimed. After Stop, the undelivered rest of the
+// batch is released.
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	owner, err := newClaimOwner()
+	if err != nil {
+		return 0, err
+	}
+	msgs, err := d.claim(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+	var firstErr error
+	for i, msg := range msgs {
+		if d.stopping() || ctx.Err() != nil {
+			if err := d.release(ctx, owner); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			logging.FromContext(ctx).Info("outbox messages released", "count", len(msgs)-i)
+			break
+		}
+		if err := d.deliver(ctx, owner, msg); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(msgs), firstErr
+}
+
+func (d *Dispatcher) claim(ctx context.Context, owner string) ([]Message, error) {
+	d.mu.Lock()
+	types := make([]string, 0, len(d.sinks))
+	anyType := false
+	for t := range d.sinks {
+		if t == AnyEventType {
+			anyType = true
+			continue
+		}
+		types = append(types, t)
+	}
+	d.mu.Unlock()
+	if !anyType && len(types) == 0 {
+		return nil, nil
+	}
+	typesJSON, err := json.Marshal(types)
+	if err != nil {
+		return nil, err
+	}
+
+	// A 'processing' row whose available_at has passed is a lapsed lease.
+	rows, err := d.db.QueryContext(
+		ctx,
+		`UPDATE ops.outbox o
+		    SET status = 'processing',
+		        locked_by = $1,
+		        available_at = now() + make_interval(secs => $2::float8),
+		        attempt_count = o.attempt_count + 1
+		   FROM (SELECT id
+		           FROM ops.outbox
+		          WHERE status IN ('pending', 'processing')
+		            AND available_at <= now()
+		            AND ($3 OR event_type IN (SELECT jsonb_array_elements_text($4::jsonb)))
+		          ORDER BY available_at
+		          LIMIT $5
+		          FOR UPDATE SKIP LOCKED) due
+		  WHERE o.id = due.id
+		RETURNING o.id::text, o.aggregate_type, COALESCE(o.aggregate_id::text, ''), o.event_type,
+		          o.payload_json, o.headers_json, COALESCE(o.dedupe_key, ''), o.created_at, o.attempt_count`,
+		owner,
+		d.cfg.Lease.Seconds(),
+		anyType,
+		string(typesJSON),
+		d.cfg.BatchSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("outbox: claim: %w", err)
+	}
+	defer rows.Close()
+	var msgs []Message
+	for rows.Next() {
+		var msg Message
+		var payload, headers []byte
+		if err := rows.Scan(&msg.ID, &msg.AggregateType, &msg.AggregateID, &msg.EventType,
+			&payload, &headers, &msg.DedupeKey, &msg.CreatedAt, &msg.Attempt); err != nil {
+			return nil, fmt.Errorf("outbox: claim: %w", err)
+		}
+		msg.Payload = payload
+		// headers_json is written by Enqueue as a string map; anything else
+		// is delivered without headers.
+		_ = json.Unmarshal(headers, &msg.Headers)
+		msgs = append(msgs, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox: claim: %w", err)
+	}
+	slices.SortStableFunc(msgs, func(a, b Message) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return msgs, nil
+}
+
+func (d *Dispatcher) sinkFor(eventType string) Sink {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if sink, ok := d.sinks[eventType]; ok {
+		return sink
+	}
+	return d.sinks[AnyEventType]
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, owner string, msg Message) error {
+	if err := d.renew(ctx, owner, msg); err != nil {
+		return err
+	}
+	var deliveryErr error = errAttemptsExhausted
+	if msg.Attempt <= d.cfg.MaxAttempts {
+		deliverCtx, cancel := context.WithTimeout(ctx, d.cfg.DeliveryTimeout)
+		deliveryErr = d.sinkFor(msg.EventType).Deliver(deliverCtx, msg)
+		cancel()
+	}
+	status, err := d.record(ctx, owner, msg, deliveryErr)
+	if err != nil {
+		return err
+	}
+	if deliveryErr != nil {
+		logging.FromContext(ctx).Warn(
+			"outbox delivery failed",
+			"outbox_id", msg.ID,
+			"event_type", msg.EventType,
+			"attempt", msg.Attempt,
+			"status", status,
+			"error", deliveryErr,
+		)
+	}
+	return nil
+}
+
+// renew restarts a claimed message's lease just before its delivery, so the
+// messages at the end of a batch are not claimed again while the ones before
+// them are delivered. ErrLeaseLost means another dispatcher took it over in
+// the meantime, and it is skipped.
+func (d *Dispatcher) renew(ctx context.Context, owner string, msg Message) error {
+	res, err := d.db.ExecContext(
+		ctx,
+		`UPDATE ops.outbox
+		    SET available_at = now() + make_interval(secs => $4::float8)
+		  WHERE id = $1::uuid AND locked_by = $2 AND attempt_count = $3 AND status = 'processing'`,
+		msg.ID,
+		owner,
+		msg.Attempt,
+		d.cfg.Lease.Seconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("outbox: renew lease: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// record writes the attempt and moves the message on: processed on success,
+// failed when the error is permanent or no attempts are left, otherwise back
+// to pending after the backoff. The move is fenced on the claim, so a message
+// taken over after its lease lapsed is left to its new owner.
+func (d *Dispatcher) record(ctx context.Context, owner string, msg Message, deliveryErr error) (string, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), bookkeepingTimeout)
+	defer cancel()
+
+	status := StatusProcessed
+	var code, message *string
+	if deliveryErr != nil {
+		status = StatusPending
+		var de *DeliveryError
+		if msg.Attempt >= d.cfg.MaxAttempts || (errors.As(deliveryErr, &de) && de.Permanent) {
+			status = StatusFailed
+		}
+		c, m := errorCode(deliveryErr), truncate(deliveryErr.Error(), maxErrorMessageLen)
+		code, message = &c, &m
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("outbox: record attempt: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO ops.outbox_attempts (outbox_id, attempt_no, processor_name, success, error_code, error_message)
+		 VALUES ($1::uuid, $2, $3, $4, $5, $6)`,
+		msg.ID,
+		msg.Attempt,
+		d.cfg.ProcessorName,
+		deliveryErr == nil,
+		code,
+		message,
+	); err != nil {
+		return "", fmt.Errorf("outbox: record attempt: %w", err)
+	}
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE ops.outbox
+		    SET status = $4,
+		        locked_by = NULL,
+		        processed_at = CASE WHEN $4 = 'processed' THEN now() END,
+		        available_at = CASE WHEN $4 = 'pending' THEN now() + make_interval(secs => $5::float8) ELSE available_at END
+		  WHERE id = $1::uuid AND locked_by = $2 AND attempt_count = $3`,
+		msg.ID,
+		owner,
+		msg.Attempt,
+		status,
+		d.cfg.Backoff(msg.Attempt).Seconds(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("outbox: record attempt: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("outbox: record attempt: %w", err)
+	}
+	if n == 0 {
+		return "", ErrLeaseLost
+	}
+	return status, nil
+}
+
+// release hands the claim's undelivered messages back without using up an
+// attempt, due at once.
+func (d *Dispatcher) release(ctx context.Context, owner string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), bookkeepingTimeout)
+	defer cancel()
+	if _, err := d.db.ExecContext(
+		ctx,
+		`UPDATE ops.outbox
+		    SET status = 'pending', locked_by = NULL, available_at = now(), attempt_count = attempt_count - 1
+		  WHERE locked_by = $1 AND status = 'processing'`,
+		owner,
+	); err != nil {
+		return fmt.Errorf("outbox: release: %w", err)
+	}
+	return nil
+}
+
+func errorCode(err error) string {
+	var de *DeliveryError
+	switch {
+	case errors.As(err, &de) && de.Code != "":
+		return de.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	default:
+		return "delivery_failed"
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+func newClaimOwner() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("outbox: claim owner: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
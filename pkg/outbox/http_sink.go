@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxHTTPSinkResponseBytes = 64 << 10
+
+// HTTPSink POSTs each message's payload as JSON to a webhook URL. The
+// message ID is sent as Idempotency-Key so receivers can drop redeliveries.
+// A 2xx response is success; 4xx other than 408 and 429 fails the message
+// for good; anything else is retried.
+type HTTPSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewHTTPSink returns a sink for url. With a secret, requests carry
+// X-Outbox-Signature: sha256=<hex HMAC-SHA256 of the body>.
+func NewHTTPSink(url, secret string, timeout time.Duration) (*HTTPSink, error) {
+	url = strings.TrimSpace(url)
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("outbox: webhook url must be http(s)")
+	}
+	if timeout <= 0 {
+		timeout = DefaultDeliveryTimeout
+	}
+	return &HTTPSink{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (s *HTTPSink) Deliver(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return &DeliveryError{Code: "invalid_request", Err: err, Permanent: true}
+	}
+	for k, v := range msg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", msg.ID)
+	req.Header.Set("X-Outbox-Event-Type", msg.EventType)
+	req.Header.Set("X-Outbox-Aggregate-Type", msg.AggregateType)
+	if msg.AggregateID != "" {
+		req.Header.Set("X-Outbox-Aggregate-ID", msg.AggregateID)
+	}
+	req.Header.Set("X-Outbox-Attempt", strconv.Itoa(msg.Attempt))
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(msg.Payload)
+		req.Header.Set("X-Outbox-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHTTPSinkResponseBytes))
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		return nil
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500:
+		return &DeliveryError{Code: "http_" + strconv.Itoa(code), Err: fmt.Errorf("webhook answered %s", resp.Status)}
+	default:
+		return &DeliveryError{Code: "http_" + strconv.Itoa(code), Err: fmt.Errorf("webhook answered %s", resp.Status), Permanent: true}
+	}
+}
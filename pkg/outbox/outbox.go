@@ -0,0 +1,94 @@
+// Package outbox implements the transactional outbox on ops.outbox. A write
+// enqueues a message in its own transaction; a Dispatcher later claims due
+// messages, delivers them to the sink registered for their event type,
+// records every attempt in ops.outbox_attempts and retries failures with
+// exponential backoff until they are processed or out of attempts.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message states in ops.outbox.status.
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusProcessed  = "processed"
+	StatusFailed     = "failed"
+)
+
+// Message is one ops.outbox row.
+type Message struct {
+	ID            string
+	AggregateType string
+	// AggregateID is an optional UUID of the aggregate the event is about.
+	AggregateID string
+	EventType   string
+	Payload     json.RawMessage
+	Headers     map[string]string
+	// DedupeKey, when set, makes Enqueue ignore a second message with the
+	// same key.
+	DedupeKey string
+	// AvailableAt delays the first delivery; zero means now.
+	AvailableAt time.Time
+	CreatedAt   time.Time
+	// Attempt is the 1-based delivery attempt; set by the Dispatcher.
+	Attempt int
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Enqueue inserts msg through db, normally the transaction that writes the
+// change msg announces, so both commit or neither does. It reports false
+// when a message with the same DedupeKey is already queued.
+func Enqueue(ctx context.Context, db execer, msg Message) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("outbox: nil db handle")
+	}
+	if strings.TrimSpace(msg.AggregateType) == "" || strings.TrimSpace(msg.EventType) == "" {
+		return false, fmt.Errorf("outbox: aggregate type and event type are required")
+	}
+	if !json.Valid(msg.Payload) {
+		return false, fmt.Errorf("outbox: payload must be valid JSON")
+	}
+	headers := msg.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return false, fmt.Errorf("outbox: encode headers: %w", err)
+	}
+	var availableAt *time.Time
+	if !msg.AvailableAt.IsZero() {
+		availableAt = &msg.AvailableAt
+	}
+	res, err := db.ExecContext(
+		ctx,
+		`INSERT INTO ops.outbox (aggregate_type, aggregate_id, event_type, payload_json, headers_json, dedupe_key, available_at)
+		 VALUES ($1, NULLIF($2, '')::uuid, $3, $4::jsonb, $5::jsonb, NULLIF($6, ''), COALESCE($7, now()))
+		 ON CONFLICT (dedupe_key) WHERE dedupe_key IS NOT NULL DO NOTHING`,
+		msg.AggregateType,
+		msg.AggregateID,
+		msg.EventType,
+		string(msg.Payload),
+		string(headersJSON),
+		msg.DedupeKey,
+		availableAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("outbox: enqueue %s: %w", msg.EventType, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewDispatcherValidation(t *testing.T) {
+	if _, err := NewDispatcher(nil, Config{ProcessorName: "node-a"}); err == nil {
+		t.Fatalf("expected nil db error")
+	}
+	if _, err := NewDispatcher(&sql.DB{}, Config{ProcessorName: "  "}); err == nil {
+		t.Fatalf("expected processor name error")
+	}
+	if _, err := NewDispatcher(&sql.DB{}, Config{ProcessorName: "node-a", Lease: 10 * time.Second, DeliveryTimeout: 10 * time.Second}); err == nil {
+		t.Fatalf("expected a lease not above the delivery timeout to be refused")
+	}
+	if err := (Config{ProcessorName: "node-a", BaseBackoff: time.Minute, MaxBackoff: time.Second}).Validate(); err == nil {
+		t.Fatalf("expected a max backoff below the base backoff to be refused")
+	}
+	if err := (Config{ProcessorName: "node-a"}).Validate(); err != nil {
+		t.Fatalf("expected the defaults to be valid: %v", err)
+	}
+	d, err := NewDispatcher(&sql.DB{}, Config{ProcessorName: "node-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg := d.Config(); cfg.BatchSize != DefaultBatchSize || cfg.MaxAttempts != DefaultMaxAttempts || cfg.Lease != DefaultLease {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+	noop := SinkFunc(func(context.Context, Message) error { return nil })
+	if err := d.Register("", noop); err == nil {
+		t.Fatalf("expected empty event type error")
+	}
+	if err := d.Register("decision.recorded", noop); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Register("decision.recorded", noop); err == nil {
+		t.Fatalf("expected duplicate sink error")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := cfg.Backoff(i + 1); got != w {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, w, got)
+		}
+	}
+	if got := cfg.Backoff(200); got != 10*time.Second {
+		t.Fatalf("expected a large attempt to stay capped, got %s", got)
+	}
+}
+
+func TestDispatcherWithoutSinksClaimsNothing(t *testing.T) {
+	// With no sinks there is nothing to claim, so the zero DB is never used.
+	d, err := NewDispatcher(&sql.DB{}, Config{ProcessorName: "node-a", PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := d.RunOnce(context.Background()); n != 0 || err != nil {
+		t.Fatalf("expected an empty run, got %d %v", n, err)
+	}
+	d.Start(context.Background())
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := d.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if err := d.Stop(ctx); err != nil {
+		t.Fatalf("second stop: %v", err)
+	}
+}
+
+func TestEnqueueValidation(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Enqueue(ctx, nil, Message{AggregateType: "decision", EventType: "decision.recorded", Payload: []byte(`{}`)}); err == nil {
+		t.Fatalf("expected nil db error")
+	}
+	tx := &sql.Tx{}
+	if _, err := Enqueue(ctx, tx, Message{EventType: "decision.recorded", Payload: []byte(`{}`)}); err == nil {
+		t.Fatalf("expected aggregate type error")
+	}
+	if _, err := Enqueue(ctx, tx, Message{AggregateType: "decision", EventType: "decision.recorded", Payload: []byte(`{`)}); err == nil {
+		t.Fatalf("expected invalid payload error")
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	status := http.StatusAccepted
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTPSink("ftp://example.com", "", 0); err == nil {
+		t.Fatalf("expected a non-http url to be refused")
+	}
+	sink, err := NewHTTPSink(srv.URL, "webhook-secret", time.Second)
+	if err != nil {
+		t.Fatalf("new sink: %v", err)
+	}
+	msg := Message{
+		ID:            "6f1c2d3e-4b5a-4c6d-8e9f-0a1b2c3d4e5f",
+		AggregateType: "decision",
+		EventType:     "decision.recorded",
+		Payload:       []byte(`{"decision":"allow"}`),
+		Headers:       map[string]string{"X-Tenant-ID": "t-1"},
+		Attempt:       2,
+	}
+	if err := sink.Deliver(context.Background(), msg); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("webhook-secret"))
+	mac.Write(msg.Payload)
+	if string(body) != string(msg.Payload) || got.Header.Get("Idempotency-Key") != msg.ID ||
+		got.Header.Get("X-Outbox-Attempt") != "2" || got.Header.Get("X-Tenant-ID") != "t-1" ||
+		got.Header.Get("X-Outbox-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("unexpected webhook request %v %q", got.Header, body)
+	}
+
+	var de *DeliveryError
+	status = http.StatusServiceUnavailable
+	if err := sink.Deliver(context.Background(), msg); !errors.As(err, &de) || de.Permanent || errorCode(err) != "http_503" {
+		t.Fatalf("expected a retryable http_503, got %v", err)
+	}
+	status = http.StatusBadRequest
+	if err := sink.Deliver(context.Background(), msg); !errors.As(err, &de) || !de.Permanent {
+		t.Fatalf("expected a 400 to fail for good, got %v", err)
+	}
+	if code := errorCode(context.DeadlineExceeded); code != "timeout" {
+		t.Fatalf("expected a deadline to be coded timeout, got %q", code)
+	}
+}
@@ -0,0 +1,188 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	securityrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
+)
+
+// Component health states, from best to worst.
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// RequiredExtensions are the Postgres extensions the migrations and
+// repositories rely on (gen_random_uuid, digest).
+var RequiredExtensions = []string{"pgcrypto"}
+
+// PoolSaturationThreshold is the share of MaxOpenConnections in use at which
+// the pool is reported degraded.
+const PoolSaturationThreshold = 0.9
+
+// ComponentHealth is the outcome of checking one dependency.
+type ComponentHealth struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	// Detail is a short operator-facing summary, e.g. pool counts.
+	Detail string
+	Err    error
+}
+
+// WorstHealth returns the worst status among components, HealthOK for none.
+func WorstHealth(components []ComponentHealth) string {
+	worst := HealthOK
+	for _, c := range components {
+		switch {
+		case c.Status == HealthDown:
+			return HealthDown
+		case c.Status == HealthDegraded:
+			worst = HealthDegraded
+		}
+	}
+	return worst
+}
+
+// checkComponent times fn and maps its error onto a down component.
+func checkComponent(ctx context.Context, name string, fn func(ctx context.Context) (string, error)) ComponentHealth {
+	start := time.Now()
+	detail, err := fn(ctx)
+	c := ComponentHealth{Name: name, Status: HealthOK, Latency: time.Since(start), Detail: detail, Err: err}
+	if err != nil {
+		c.Status = HealthDown
+	}
+	return c
+}
+
+// Readiness runs the checks a replica must pass before taking traffic: the
+// database answers, its schema holds every expected migration unchanged,
+// the required extensions exist and a current signing key exists.
+func (r *Runtime) Readiness(ctx context.Context, migrations []dbpkg.Migration) []ComponentHealth {
+	return []ComponentHealth{
+		checkComponent(ctx, "db", r.checkDB),
+		checkComponent(ctx, "schema", func(ctx context.Context) (string, error) {
+			return r.checkSchema(ctx, migrations)
+		}),
+		checkComponent(ctx, "extensions", r.checkExtensions),
+		checkComponent(ctx, "keyring", r.checkKeyring),
+	}
+}
+
+// PoolHealth reports connection pool saturation from DB.Stats; it does not
+// query the database.
+func (r *Runtime) PoolHealth() ComponentHealth {
+	c := ComponentHealth{Name: "pool", Status: HealthOK}
+	if r == nil || r.DB == nil {
+		c.Status, c.Err = HealthDown, fmt.Errorf("platform: runtime db not initialized")
+		return c
+	}
+	s := r.DB.Stats()
+	c.Detail = fmt.Sprintf("in_use=%d idle=%d max_open=%d wait_count=%d wait=%s",
+		s.InUse, s.Idle, s.MaxOpenConnections, s.WaitCount, s.WaitDuration.Round(time.Millisecond))
+	if s.MaxOpenConnections > 0 && float64(s.InUse) >= PoolSaturationThreshold*float64(s.MaxOpenConnections) {
+		c.Status = HealthDegraded
+	}
+	return c
+}
+
+func (r *Runtime) checkDB(ctx context.Context) (string, error) {
+	if r == nil || r.DB == nil {
+		return "", fmt.Errorf("platform: runtime db not initialized")
+	}
+	return "", r.DB.PingContext(ctx)
+}
+
+func (r *Runtime) checkSchema(ctx context.Context, migrations []dbpkg.Migration) (string, error) {
+	if r == nil || r.DB == nil {
+		return "", fmt.Errorf("platform: runtime db not initialized")
+	}
+	if len(migrations) == 0 {
+		return "", fmt.Errorf("platform: no expected migrations")
+	}
+	status, err := dbpkg.CheckMigrations(ctx, r.DB, migrations)
+	if err != nil {
+		return "", err
+	}
+	detail := fmt.Sprintf("applied=%d expected=%d", status.Applied, status.Expected)
+	if len(status.Unknown) > 0 {
+		detail += fmt.Sprintf(" unknown=%d", len(status.Unknown))
+	}
+	return detail, status.Err()
+}
+
+func (r *Runtime) checkExtensions(ctx context.Context) (string, error) {
+	if r == nil || r.DB == nil {
+		return "", fmt.Errorf("platform: runtime db not initialized")
+	}
+	rows, err := r.DB.QueryContext(ctx, `SELECT extname FROM pg_extension`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	installed := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		installed[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	var missing []string
+	for _, name := range RequiredExtensions {
+		if !installed[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("platform: missing extensions: %s", strings.Join(missing, ", "))
+	}
+	return strings.Join(RequiredExtensions, ","), nil
+}
+
+// checkKeyring asks the key resolver for the key the runtime signs with.
+func (r *Runtime) checkKeyring(ctx context.Context) (string, error) {
+	if r == nil || r.Security == nil {
+		return "", fmt.Errorf("platform: runtime security not initialized")
+	}
+	keys, ok := r.Security.KeyResolver.(*securityrepo.PostgresKeyResolver)
+	if !ok {
+		return "", fmt.Errorf("platform: key resolver cannot be checked")
+	}
+	kid, err := keys.CurrentKeyID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "current_key_id=" + kid, nil
+}
+
+// JobsHealth summarizes the scheduler's jobs on this replica: degraded when a
+// job's last run failed. A nil scheduler (maintenance disabled) is ok.
+func JobsHealth(s *Scheduler) ComponentHealth {
+	c := ComponentHealth{Name: "jobs", Status: HealthOK}
+	if s == nil {
+		c.Detail = "maintenance disabled"
+		return c
+	}
+	jobs := s.Status()
+	var failing []string
+	for _, job := range jobs {
+		if job.LastStatus == MaintenanceRunFailed {
+			failing = append(failing, job.Name)
+		}
+	}
+	c.Detail = fmt.Sprintf("jobs=%d", len(jobs))
+	if len(failing) > 0 {
+		c.Status = HealthDegraded
+		c.Err = fmt.Errorf("platform: last run failed: %s", strings.Join(failing, ", "))
+	}
+	return c
+}
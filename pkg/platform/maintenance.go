@@ -0,0 +1,628 @@
+package platform
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+)
+
+// Maintenance run statuses recorded in ops.maintenance_runs.
+const (
+	MaintenanceRunRunning   = "running"
+	MaintenanceRunCompleted = "completed"
+	MaintenanceRunFailed    = "failed"
+	// MaintenanceRunSkipped is only reported in-memory: another replica held
+	// the job lock, or completed the job within its interval.
+	MaintenanceRunSkipped = "skipped"
+)
+
+const maintenanceLockNamespace = "platform.maintenance:"
+
+// maintenanceDueFraction is how much of its interval must have passed since a
+// job last completed on any replica before a scheduled run starts it again.
+// It is under 1 so replicas whose tickers drift a little still run every
+// interval.
+const maintenanceDueFraction = 0.9
+
+// MaintenanceJob is a named task run every Interval by at most one replica.
+// Run returns the number of rows it affected, when known.
+type MaintenanceJob struct {
+	Name     string
+	Interval time.Duration
+	Timeout  time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// MaintenanceJobStatus is the in-process view of one registered job.
+type MaintenanceJobStatus struct {
+	Name           string     `json:"name"`
+	Interval       string     `json:"interval"`
+	Running        bool       `json:"running"`
+	LastStatus     string     `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	RunCount       int64      `json:"run_count"`
+	FailureCount   int64      `json:"failure_count"`
+	SkipCount      int64      `json:"skip_count"`
+}
+
+// MaintenanceRun is one persisted row of ops.maintenance_runs.
+type MaintenanceRun struct {
+	ID           string     `json:"id"`
+	JobName      string     `json:"job_name"`
+	NodeName     string     `json:"node_name"`
+	Status       string     `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	AffectedRows int64      `json:"affected_rows"`
+	ErrorText    *string    `json:"error_text"`
+}
+
+type maintenanceEntry struct {
+	job    MaintenanceJob
+	status MaintenanceJobStatus
+}
+
+// Scheduler runs registered maintenance jobs on intervals. Each run takes a
+// Postgres session advisory lock so only one replica executes a job at a time,
+// and a scheduled run is skipped when any replica completed the job within
+// its interval, so all replicas together run it about once per interval.
+type Scheduler struct {
+	db       *sql.DB
+	nodeName string
+
+	mu         sync.Mutex
+	entries    map[string]*maintenanceEntry
+	started    bool
+	stop       chan struct{} // closed by Stop; loops claim no runs after it
+	cancelRuns context.CancelFunc
+	loops      sync.WaitGroup
+	clockFn    func() time.Time
+	onError    func(job string, err error)
+}
+
+// NewScheduler creates a scheduler recording runs under nodeName.
+func NewScheduler(db *sql.DB, nodeName string) (*Scheduler, error) {
+	if db == nil {
+		return nil, fmt.Errorf("platform: nil db handle")
+	}
+	nodeName = strings.TrimSpace(nodeName)
+	if nodeName == "" {
+		return nil, fmt.Errorf("platform: node name is required")
+	}
+	return &Scheduler{
+		db:       db,
+		nodeName: nodeName,
+		entries:  make(map[string]*maintenanceEntry),
+		stop:     make(chan struct{}),
+		clockFn:  time.Now,
+	}, nil
+}
+
+// OnError sets a callback for failed scheduled runs; it must be called before Start.
+func (s *Scheduler) OnError(fn func(job string, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onError = fn
+}
+
+// Register adds a job; it must be called before Start.
+func (s *Scheduler) Register(job MaintenanceJob) error {
+	if err := validateMaintenanceJob(job); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("platform: scheduler already started")
+	}
+	if _, ok := s.entries[job.Name]; ok {
+		return fmt.Errorf("platform: maintenance job %q already registered", job.Name)
+	}
+	s.entries[job.Name] = &maintenanceEntry{
+		job:    job,
+		status: MaintenanceJobStatus{Name: job.Name, Interval: job.Interval.String()},
+	}
+	return nil
+}
+
+func validateMaintenanceJob(job MaintenanceJob) error {
+	if strings.TrimSpace(job.Name) == "" {
+		return fmt.Errorf("platform: maintenance job name is required")
+	}
+	if job.Interval <= 0 {
+		return fmt.Errorf("platform: maintenance job %q interval must be > 0", job.Name)
+	}
+	if job.Timeout < 0 {
+		return fmt.Errorf("platform: maintenance job %q timeout must be >= 0", job.Name)
+	}
+	if job.Run == nil {
+		return fmt.Errorf("platform: maintenance job %q has no run func", job.Name)
+	}
+	return nil
+}
+
+// Start runs every job once immediately and then on its interval until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	ctx, s.cancelRuns = context.WithCancel(ctx)
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+	s.loops.Add(len(names))
+	s.mu.Unlock()
+
+	for _, name := range names {
+		go func() {
+			defer s.loops.Done()
+			s.loop(ctx, name)
+		}()
+	}
+}
+
+// Stop stops claiming new runs and waits for running jobs to finish and
+// release their advisory locks. Runs still going when ctx ends are cancelled
+// and Stop returns ctx's error once they have unwound. It is safe to call
+// more than once, and before Start.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	cancelRuns := s.cancelRuns
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if cancelRuns != nil {
+			cancelRuns()
+		}
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, name string) {
+	s.mu.Lock()
+	interval := s.entries[name].job.Interval
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		if _, err := s.run(ctx, name, false); err != nil && ctx.Err() == nil {
+			s.mu.Lock()
+			onError := s.onError
+			s.mu.Unlock()
+			if onError != nil {
+				onError(name, err)
+			}
+		}
+		s.mu.Lock()
+		next := s.clockFn().UTC().Add(interval)
+		s.entries[name].status.NextRunAt = &next
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce executes the named job now if this replica acquires its advisory
+// lock, even when it completed within its interval. It returns the resulting
+// status (MaintenanceRunSkipped when another replica holds the lock).
+func (s *Scheduler) RunOnce(ctx context.Context, name string) (string, error) {
+	return s.run(ctx, name, true)
+}
+
+// run executes the named job under its advisory lock. Unless force is set it
+// is skipped when the job is not yet due.
+func (s *Scheduler) run(ctx context.Context, name string, force bool) (string, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return "", fmt.Errorf("platform: unknown maintenance job %q", name)
+	}
+	if entry.status.Running {
+		s.mu.Unlock()
+		return MaintenanceRunSkipped, nil
+	}
+	entry.status.Running = true
+	job := entry.job
+	s.mu.Unlock()
+
+	status, runErr := s.runLocked(ctx, job, force)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.status.Running = false
+	entry.status.LastStatus = status
+	entry.status.LastError = ""
+	switch status {
+	case MaintenanceRunSkipped:
+		entry.status.SkipCount++
+	case MaintenanceRunFailed:
+		entry.status.RunCount++
+		entry.status.FailureCount++
+	default:
+		entry.status.RunCount++
+	}
+	if runErr != nil {
+		entry.status.LastError = runErr.Error()
+	}
+	return status, runErr
+}
+
+func (s *Scheduler) runLocked(ctx context.Context, job MaintenanceJob, force bool) (string, error) {
+	// Session advisory locks belong to one connection, so pin one for the run.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return MaintenanceRunFailed, err
+	}
+	defer conn.Close()
+
+	lockKey := maintenanceLockKey(job.Name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&acquired); err != nil {
+		return MaintenanceRunFailed, err
+	}
+	if !acquired {
+		return MaintenanceRunSkipped, nil
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+	}()
+
+	// Holding the lock means no replica is running the job, so a 'running'
+	// row left behind is from a node that died or lost its connection mid-run.
+	if _, err := conn.ExecContext(
+		ctx,
+		`UPDATE ops.maintenance_runs
+		    SET status = 'failed', finished_at = GREATEST(started_at, now()), error_text = 'abandoned: the run did not finish'
+		  WHERE job_name = $1 AND status = 'running'`,
+		job.Name,
+	); err != nil {
+		return MaintenanceRunFailed, err
+	}
+	if !force {
+		dueAfter := s.clockFn().UTC().Add(-time.Duration(float64(job.Interval) * maintenanceDueFraction))
+		var recent bool
+		if err := conn.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (
+			   SELECT 1 FROM ops.maintenance_runs
+			    WHERE job_name = $1 AND status = 'completed' AND started_at > $2
+			)`,
+			job.Name,
+			dueAfter,
+		).Scan(&recent); err != nil {
+			return MaintenanceRunFailed, err
+		}
+		if recent {
+			return MaintenanceRunSkipped, nil
+		}
+	}
+
+	startedAt := s.clockFn().UTC()
+	s.mu.Lock()
+	s.entries[job.Name].status.LastStartedAt = &startedAt
+	s.mu.Unlock()
+
+	var runID string
+	if err := conn.QueryRowContext(
+		ctx,
+		`INSERT INTO ops.maintenance_runs (job_name, node_name, status, started_at)
+		 VALUES ($1, $2, 'running', $3)
+		 RETURNING id::text`,
+		job.Name,
+		s.nodeName,
+		startedAt,
+	).Scan(&runID); err != nil {
+		return MaintenanceRunFailed, err
+	}
+
+	runCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	affected, runErr := job.Run(runCtx)
+
+	status := MaintenanceRunCompleted
+	var errText *string
+	if runErr != nil {
+		status = MaintenanceRunFailed
+		msg := runErr.Error()
+		errText = &msg
+	}
+	finishedAt := s.clockFn().UTC()
+	if _, err := conn.ExecContext(
+		context.Background(),
+		`UPDATE ops.maintenance_runs
+		    SET status = $2, finished_at = $3, affected_rows = $4, error_text = $5
+		  WHERE id = $1::uuid`,
+		runID,
+		status,
+		finishedAt,
+		affected,
+		errText,
+	); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	s.mu.Lock()
+	s.entries[job.Name].status.LastFinishedAt = &finishedAt
+	s.mu.Unlock()
+
+	// Failures surface through OnError; only completed runs are logged here.
+	if runErr == nil {
+		logging.FromContext(ctx).Info(
+			"maintenance job completed",
+			"job", job.Name,
+			"node_name", s.nodeName,
+			"affected_rows", affected,
+			"duration_ms", finishedAt.Sub(startedAt).Milliseconds(),
+		)
+	}
+	return status, runErr
+}
+
+// Status returns the in-process status of every registered job, sorted by name.
+func (s *Scheduler) Status() []MaintenanceJobStatus {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]MaintenanceJobStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, e.status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// NodeName returns the node name runs are recorded under.
+func (s *Scheduler) NodeName() string {
+	return s.nodeName
+}
+
+// ListRuns returns the most recent runs across all replicas, optionally for one job.
+func (s *Scheduler) ListRuns(ctx context.Context, jobName string, limit int) ([]MaintenanceRun, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	var job *string
+	if v := strings.TrimSpace(jobName); v != "" {
+		job = &v
+	}
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id::text, job_name, node_name, status, started_at, finished_at, affected_rows, error_text
+		   FROM ops.maintenance_runs
+		  WHERE ($1::text IS NULL OR job_name = $1)
+		  ORDER BY started_at DESC
+		  LIMIT $2`,
+		job,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]MaintenanceRun, 0)
+	for rows.Next() {
+		var (
+			run        MaintenanceRun
+			finishedAt sql.NullTime
+			errText    sql.NullString
+		)
+		if err := rows.Scan(
+			&run.ID,
+			&run.JobName,
+			&run.NodeName,
+			&run.Status,
+			&run.StartedAt,
+			&finishedAt,
+			&run.AffectedRows,
+			&errText,
+		); err != nil {
+			return nil, err
+		}
+		if finishedAt.Valid {
+			t := finishedAt.Time.UTC()
+			run.FinishedAt = &t
+		}
+		if errText.Valid {
+			v := errText.String
+			run.ErrorText = &v
+		}
+		run.StartedAt = run.StartedAt.UTC()
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// maintenanceLockKey derives a stable advisory lock key from the job name.
+func maintenanceLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(maintenanceLockNamespace + name))
+	return int64(h.Sum64())
+}
+
+// MaintenanceConfig sets the intervals of the built-in maintenance jobs.
+// RunRetention is how long ops.maintenance_runs history is kept and
+// OutboxRetention how long delivered or failed outbox messages are; zero
+// keeps them forever.
+type MaintenanceConfig struct {
+	CleanupInterval time.Duration
+	RefreshInterval time.Duration
+	JobTimeout      time.Duration
+	RunRetention    time.Duration
+	OutboxRetention time.Duration
+}
+
+type expiryCleaner interface {
+	CleanupExpired(now time.Time) error
+}
+
+// MaintenanceJobs returns the built-in jobs: expired revocations, request
+// nonces, idempotency keys and rate limit buckets cleanup, maintenance run
+// and outbox history retention, and materialized view refreshes.
+func (r *Runtime) MaintenanceJobs(cfg MaintenanceConfig) []MaintenanceJob {
+	if r == nil || r.DB == nil {
+		return nil
+	}
+	var jobs []MaintenanceJob
+	if r.Security != nil {
+		if cleaner, ok := r.Security.RevocationStore.(expiryCleaner); ok {
+			jobs = append(jobs, MaintenanceJob{
+				Name:     "security.revocations_cleanup",
+				Interval: cfg.CleanupInterval,
+				Timeout:  cfg.JobTimeout,
+				Run: func(context.Context) (int64, error) {
+					return 0, cleaner.CleanupExpired(time.Now().UTC())
+				},
+			})
+		}
+		if r.Security.RequestNonces != nil {
+			nonces := r.Security.RequestNonces
+			jobs = append(jobs, MaintenanceJob{
+				Name:     "security.request_nonces_cleanup",
+				Interval: cfg.CleanupInterval,
+				Timeout:  cfg.JobTimeout,
+				Run: func(context.Context) (int64, error) {
+					return 0, nonces.CleanupExpired(time.Now().UTC())
+				},
+			})
+		}
+	}
+	jobs = append(jobs,
+		MaintenanceJob{
+			Name:     "ops.idempotency_keys_cleanup",
+			Interval: cfg.CleanupInterval,
+			Timeout:  cfg.JobTimeout,
+			Run: func(ctx context.Context) (int64, error) {
+				res, err := r.DB.ExecContext(ctx, `DELETE FROM ops.idempotency_keys WHERE expires_at <= now()`)
+				if err != nil {
+					return 0, err
+				}
+				return res.RowsAffected()
+			},
+		},
+		MaintenanceJob{
+			Name:     "ops.rate_limit_buckets_cleanup",
+			Interval: cfg.CleanupInterval,
+			Timeout:  cfg.JobTimeout,
+			Run: func(ctx context.Context) (int64, error) {
+				res, err := r.DB.ExecContext(ctx, `DELETE FROM ops.rate_limit_buckets WHERE expires_at <= now()`)
+				if err != nil {
+					return 0, err
+				}
+				return res.RowsAffected()
+			},
+		},
+		refreshViewJob("vedic.mv_transaction_risk_daily", cfg, r.DB),
+		refreshViewJob("authz.mv_policy_decision_daily", cfg, r.DB),
+	)
+	if cfg.RunRetention > 0 {
+		jobs = append(jobs, MaintenanceJob{
+			Name:     "ops.maintenance_runs_cleanup",
+			Interval: cfg.CleanupInterval,
+			Timeout:  cfg.JobTimeout,
+			Run: func(ctx context.Context) (int64, error) {
+				// Each job's latest completed run is kept whatever its age:
+				// the scheduler reads it to decide whether the job is due.
+				res, err := r.DB.ExecContext(
+					ctx,
+					`DELETE FROM ops.maintenance_runs m
+					  WHERE m.status <> 'running'
+					    AND m.started_at < now() - make_interval(secs => $1::float8)
+					    AND m.id IS DISTINCT FROM (
+					        SELECT l.id FROM ops.maintenance_runs l
+					         WHERE l.job_name = m.job_name AND l.status = 'completed'
+					         ORDER BY l.started_at DESC
+					         LIMIT 1
+					    )`,
+					cfg.RunRetention.Seconds(),
+				)
+				if err != nil {
+					return 0, err
+				}
+				return res.RowsAffected()
+			},
+		})
+	}
+	if cfg.OutboxRetention > 0 {
+		jobs = append(jobs, MaintenanceJob{
+			Name:     "ops.outbox_cleanup",
+			Interval: cfg.CleanupInterval,
+			Timeout:  cfg.JobTimeout,
+			Run: func(ctx context.Context) (int64, error) {
+				// A failed message keeps the available_at of its last attempt.
+				// Its ops.outbox_attempts rows go with it (ON DELETE CASCADE).
+				res, err := r.DB.ExecContext(
+					ctx,
+					`DELETE FROM ops.outbox
+					  WHERE status IN ('processed', 'failed')
+					    AND COALESCE(processed_at, available_at) < now() - make_interval(secs => $1::float8)`,
+					cfg.OutboxRetention.Seconds(),
+				)
+				if err != nil {
+					return 0, err
+				}
+				return res.RowsAffected()
+			},
+		})
+	}
+	return jobs
+}
+
+func refreshViewJob(view string, cfg MaintenanceConfig, db *sql.DB) MaintenanceJob {
+	return MaintenanceJob{
+		Name:     "refresh." + view,
+		Interval: cfg.RefreshInterval,
+		Timeout:  cfg.JobTimeout,
+		Run: func(ctx context.Context) (int64, error) {
+			_, err := db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW `+view)
+			return 0, err
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package platform
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func noopMaintenanceRun(context.Context) (int64, error) { return 0, nil }
+
+func TestNewSchedulerValidation(t *testing.T) {
+	if _, err := NewScheduler(nil, "node-a"); err == nil {
+		t.Fatalf("expected error for nil db")
+	}
+	if _, err := NewScheduler(&sql.DB{}, "  "); err == nil {
+		t.Fatalf("expected error for empty node name")
+	}
+}
+
+func TestSchedulerRegister(t *testing.T) {
+	s, err := NewScheduler(&sql.DB{}, "node-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bad := []MaintenanceJob{
+		{Name: "", Interval: time.Minute, Run: noopMaintenanceRun},
+		{Name: "a", Interval: 0, Run: noopMaintenanceRun},
+		{Name: "a", Interval: time.Minute, Timeout: -time.Second, Run: noopMaintenanceRun},
+		{Name: "a", Interval: time.Minute},
+	}
+	for i, job := range bad {
+		if err := s.Register(job); err == nil {
+			t.Fatalf("case %d: expected validation error", i)
+		}
+	}
+	if err := s.Register(MaintenanceJob{Name: "b", Interval: time.Minute, Run: noopMaintenanceRun}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Register(MaintenanceJob{Name: "a", Interval: time.Hour, Run: noopMaintenanceRun}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Register(MaintenanceJob{Name: "a", Interval: time.Minute, Run: noopMaintenanceRun}); err == nil {
+		t.Fatalf("expected duplicate job error")
+	}
+	status := s.Status()
+	if len(status) != 2 || status[0].Name != "a" || status[1].Name != "b" {
+		t.Fatalf("unexpected status ordering: %+v", status)
+	}
+	if status[0].Interval != "1h0m0s" {
+		t.Fatalf("unexpected interval: %s", status[0].Interval)
+	}
+	if _, err := s.RunOnce(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected unknown job error")
+	}
+}
+
+func TestSchedulerStopClaimsNoRuns(t *testing.T) {
+	s, err := NewScheduler(&sql.DB{}, "node-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Register(MaintenanceJob{Name: "a", Interval: time.Minute, Run: noopMaintenanceRun}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("stop before start: %v", err)
+	}
+	// A stopped scheduler's loops exit before touching the database.
+	s.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if st := s.Status(); st[0].RunCount != 0 || st[0].SkipCount != 0 {
+		t.Fatalf("expected no runs after stop, got %+v", st[0])
+	}
+}
+
+func TestMaintenanceLockKeyStable(t *testing.T) {
+	if maintenanceLockKey("job-a") != maintenanceLockKey("job-a") {
+		t.Fatalf("expected stable lock key")
+	}
+	if maintenanceLockKey("job-a") == maintenanceLockKey("job-b") {
+		t.Fatalf("expected distinct lock keys")
+	}
+}
+
+func TestRuntimeMaintenanceJobsNil(t *testing.T) {
+	var r *Runtime
+	if jobs := r.MaintenanceJobs(MaintenanceConfig{CleanupInterval: time.Minute, RefreshInterval: time.Minute}); jobs != nil {
+		t.Fatalf("expected no jobs for nil runtime")
+	}
+}
+
+func TestRuntimeMaintenanceJobsRunRetention(t *testing.T) {
+	r := &Runtime{DB: &sql.DB{}}
+	has := func(cfg MaintenanceConfig) bool {
+		for _, job := range r.MaintenanceJobs(cfg) {
+			if job.Name == "ops.maintenance_runs_cleanup" {
+				return true
+			}
+		}
+		return false
+	}
+	cfg := MaintenanceConfig{CleanupInterval: time.Minute, RefreshInterval: time.Minute}
+	if has(cfg) {
+		t.Fatalf("expected no run history cleanup without a retention")
+	}
+	cfg.RunRetention = 24 * time.Hour
+	if !has(cfg) {
+		t.Fatalf("expected a run history cleanup job")
+	}
+}
+
+func TestRuntimeMaintenanceJobsOutboxRetention(t *testing.T) {
+	r := &Runtime{DB: &sql.DB{}}
+	count := func(cfg MaintenanceConfig) int {
+		n := 0
+		for _, job := range r.MaintenanceJobs(cfg) {
+			if job.Name == "ops.outbox_cleanup" {
+				n++
+			}
+		}
+		return n
+	}
+	cfg := MaintenanceConfig{CleanupInterval: time.Minute, RefreshInterval: time.Minute}
+	if count(cfg) != 0 {
+		t.Fatalf("expected no outbox cleanup without a retention")
+	}
+	cfg.OutboxRetention = 24 * time.Hour
+	if count(cfg) != 1 {
+		t.Fatalf("expected one outbox cleanup job")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	analyticsrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/analytics"
 	authzrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/authz"
+	controlplanerepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/controlplane"
 	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
 	securityrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/security"
 	telemetryrepo "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/telemetry"
@@ -20,6 +21,32 @@ type Runtime struct {
 	AuthzRepo     *authzrepo.Repository
 	TelemetryRepo *telemetryrepo.Repository
 	AnalyticsRepo *analyticsrepo.Repository
+	ControlPlane  *controlplanerepo.Repository
+	ThreatRepo    *securityrepo.ThreatRepository
+
+	writeObserver WriteObserver
+}
+
+// Repository write operations reported to a WriteObserver.
+const (
+	WriteAuthzDecision  = "authz.persist_decision"
+	WriteTelemetryEvent = "telemetry.persist_event"
+	WriteTelemetryBatch = "telemetry.persist_event_batch"
+)
+
+// WriteObserver receives the latency and outcome of each repository write
+// made through the Runtime, e.g. to feed metrics.
+type WriteObserver func(operation string, d time.Duration, err error)
+
+// ObserveWrites installs fn as the write observer; call it before serving.
+func (r *Runtime) ObserveWrites(fn WriteObserver) {
+	r.writeObserver = fn
+}
+
+func (r *Runtime) observeWrite(operation string, start time.Time, err error) {
+	if r.writeObserver != nil {
+		r.writeObserver(operation, time.Since(start), err)
+	}
 }
 
 // BuildPhase1Runtime opens a DB connection and wires repositories.
@@ -50,12 +77,25 @@ func BuildPhase1Runtime(ctx context.Context, dbCfg dbpkg.Config, secCfg security
 		return nil, err
 	}
 
+	cpRepo, err := controlplanerepo.NewRepository(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	threatRepo, err := securityrepo.NewThreatRepository(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
 	return &Runtime{
 		DB:            conn,
 		Security:      secDeps,
 		AuthzRepo:     authzRepo,
 		TelemetryRepo: tRepo,
 		AnalyticsRepo: aRepo,
+		ControlPlane:  cpRepo,
+		ThreatRepo:    threatRepo,
 	}, nil
 }
 
@@ -75,32 +115,120 @@ func (r *Runtime) HealthCheck(ctx context.Context, timeout time.Duration) error
 	return dbpkg.HealthCheck(ctx, r.DB, timeout)
 }
 
-// RecordDecisionAndEvent writes an auth decision plus linked security event.
+// WriteIDs are the ids of the rows a Runtime write inserted.
+type WriteIDs struct {
+	DecisionID string
+	EventID    string
+	// Batch holds the per-event results of a batch write.
+	Batch []telemetryrepo.BatchResult
+}
+
+// WriteHook runs inside a Runtime write's transaction after its rows are
+// inserted, e.g. to store an idempotent response with the data. An error
+// rolls the whole write back.
+type WriteHook func(ctx context.Context, tx *sql.Tx, ids WriteIDs) error
+
+// RecordDecisionAndEvent writes an auth decision plus linked security event
+// in one transaction, then runs hooks in that transaction.
 func (r *Runtime) RecordDecisionAndEvent(
 	ctx context.Context,
 	decision authzrepo.DecisionRecord,
 	trace []authzrepo.TraceStep,
 	event telemetryrepo.SecurityEventRecord,
+	hooks ...WriteHook,
 ) (string, string, error) {
-	if r == nil || r.AuthzRepo == nil || r.TelemetryRepo == nil {
+	if r == nil || r.DB == nil || r.AuthzRepo == nil || r.TelemetryRepo == nil {
 		return "", "", fmt.Errorf("platform: runtime repositories not initialized")
 	}
-	decisionID, err := r.AuthzRepo.PersistDecisionWithTrace(ctx, decision, trace)
-	if err != nil {
-		return "", "", err
-	}
-	eventID, err := r.TelemetryRepo.PersistSecurityEventWithLinks(
-		ctx,
-		event,
-		[]telemetryrepo.EventLink{
+	var ids WriteIDs
+	err := dbpkg.WithTx(ctx, r.DB, nil, func(tx *sql.Tx) error {
+		start := time.Now()
+		var err error
+		ids.DecisionID, err = r.AuthzRepo.PersistDecisionWithTraceTx(ctx, tx, decision, trace)
+		r.observeWrite(WriteAuthzDecision, start, err)
+		if err != nil {
+			return err
+		}
+		start = time.Now()
+		ids.EventID, err = r.TelemetryRepo.PersistSecurityEventWithLinksTx(ctx, tx, event, []telemetryrepo.EventLink{
 			{
 				LinkKind: "policy_decision",
-				LinkedID: decisionID,
+				LinkedID: ids.DecisionID,
 			},
-		},
-	)
+		})
+		r.observeWrite(WriteTelemetryEvent, start, err)
+		if err != nil {
+			return err
+		}
+		return runWriteHooks(ctx, tx, ids, hooks)
+	})
+	if err != nil {
+		return "", "", dbpkg.Classify("platform: record decision", err)
+	}
+	return ids.DecisionID, ids.EventID, nil
+}
+
+// RecordSecurityEvent writes a security event and its links in one
+// transaction, then runs hooks in that transaction.
+func (r *Runtime) RecordSecurityEvent(
+	ctx context.Context,
+	event telemetryrepo.SecurityEventRecord,
+	links []telemetryrepo.EventLink,
+	hooks ...WriteHook,
+) (string, error) {
+	if r == nil || r.DB == nil || r.TelemetryRepo == nil {
+		return "", fmt.Errorf("platform: runtime repositories not initialized")
+	}
+	var ids WriteIDs
+	err := dbpkg.WithTx(ctx, r.DB, nil, func(tx *sql.Tx) error {
+		start := time.Now()
+		var err error
+		ids.EventID, err = r.TelemetryRepo.PersistSecurityEventWithLinksTx(ctx, tx, event, links)
+		r.observeWrite(WriteTelemetryEvent, start, err)
+		if err != nil {
+			return err
+		}
+		return runWriteHooks(ctx, tx, ids, hooks)
+	})
 	if err != nil {
-		return decisionID, "", err
+		return "", dbpkg.Classify("platform: record security event", err)
+	}
+	return ids.EventID, nil
+}
+
+// RecordSecurityEventBatch writes the valid events of a batch and their
+// links in one transaction, then runs hooks in that transaction. Events the
+// repository rejects are reported in their result and skipped.
+func (r *Runtime) RecordSecurityEventBatch(
+	ctx context.Context,
+	events []telemetryrepo.BatchEvent,
+	hooks ...WriteHook,
+) ([]telemetryrepo.BatchResult, error) {
+	if r == nil || r.DB == nil || r.TelemetryRepo == nil {
+		return nil, fmt.Errorf("platform: runtime repositories not initialized")
+	}
+	var ids WriteIDs
+	err := dbpkg.WithTx(ctx, r.DB, nil, func(tx *sql.Tx) error {
+		start := time.Now()
+		var err error
+		ids.Batch, err = r.TelemetryRepo.PersistSecurityEventBatchTx(ctx, tx, events)
+		r.observeWrite(WriteTelemetryBatch, start, err)
+		if err != nil {
+			return err
+		}
+		return runWriteHooks(ctx, tx, ids, hooks)
+	})
+	if err != nil {
+		return nil, dbpkg.Classify("platform: record security event batch", err)
+	}
+	return ids.Batch, nil
+}
+
+func runWriteHooks(ctx context.Context, tx *sql.Tx, ids WriteIDs, hooks []WriteHook) error {
+	for _, hook := range hooks {
+		if err := hook(ctx, tx, ids); err != nil {
+			return err
+		}
 	}
-	return decisionID, eventID, nil
+	return nil
 }
@@ -13,3 +13,32 @@ func TestRuntimeHealthCheckNil(t *testing.T) {
 		t.Fatalf("expected error for nil runtime")
 	}
 }
+
+func TestReadinessNilRuntime(t *testing.T) {
+	var r *Runtime
+	checks := r.Readiness(context.Background(), nil)
+	if len(checks) != 4 || WorstHealth(checks) != HealthDown {
+		t.Fatalf("expected every readiness check to be down, got %+v", checks)
+	}
+	for _, c := range checks {
+		if c.Status != HealthDown || c.Err == nil {
+			t.Fatalf("expected %s to be down with an error, got %+v", c.Name, c)
+		}
+	}
+	if c := r.PoolHealth(); c.Status != HealthDown {
+		t.Fatalf("expected pool to be down without a db, got %+v", c)
+	}
+}
+
+func TestWorstHealthAndJobsHealth(t *testing.T) {
+	if got := WorstHealth(nil); got != HealthOK {
+		t.Fatalf("expected ok for no components, got %q", got)
+	}
+	got := WorstHealth([]ComponentHealth{{Status: HealthOK}, {Status: HealthDegraded}, {Status: HealthOK}})
+	if got != HealthDegraded {
+		t.Fatalf("expected degraded, got %q", got)
+	}
+	if c := JobsHealth(nil); c.Status != HealthOK || c.Detail != "maintenance disabled" {
+		t.Fatalf("expected disabled maintenance to be ok, got %+v", c)
+	}
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Policy is a token bucket: Capacity tokens, refilled at RefillPerMinute.
+type Policy struct {
+	Capacity        int
+	RefillPerMinute int
+}
+
+func (p Policy) Validate() error {
+	if p.Capacity <= 0 {
+		return fmt.Errorf("ratelimit: capacity must be > 0")
+	}
+	if p.RefillPerMinute <= 0 {
+		return fmt.Errorf("ratelimit: refill per minute must be > 0")
+	}
+	return nil
+}
+
+func (p Policy) refillPerSecond() float64 {
+	return float64(p.RefillPerMinute) / 60
+}
+
+// idleTTL is how long an untouched bucket takes to refill completely; after
+// that it is indistinguishable from a new bucket and can be dropped.
+func (p Policy) idleTTL() time.Duration {
+	return time.Duration(float64(p.Capacity) / p.refillPerSecond() * float64(time.Second))
+}
+
+// Decision is the outcome of taking one token.
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until one token is available; zero when allowed
+}
+
+// Store takes one token from a bucket; implementations must be safe for concurrent use.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy) (Decision, error)
+}
+
+// decide derives a Decision from the bucket level after the take attempt.
+func decide(p Policy, tokens float64, allowed bool) Decision {
+	rate := p.refillPerSecond()
+	d := Decision{
+		Allowed:    allowed,
+		Limit:      p.Capacity,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((float64(p.Capacity) - tokens) / rate),
+	}
+	if !allowed {
+		d.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return d
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Limiter applies one Policy through a Store. When the store fails (e.g. the
+// shared Postgres store is unreachable) it falls back to a local in-memory
+// bucket so limits degrade to per-replica instead of failing requests.
+type Limiter struct {
+	policy   atomic.Pointer[Policy]
+	store    Store
+	fallback *MemoryStore
+	onError  func(error)
+}
+
+func NewLimiter(p Policy, store Store) (*Limiter, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	fallback := NewMemoryStore(DefaultMaxKeys)
+	if store == nil {
+		store = fallback
+	}
+	l := &Limiter{store: store, fallback: fallback}
+	l.policy.Store(&p)
+	return l, nil
+}
+
+// OnError registers a callback for store failures that triggered the fallback.
+func (l *Limiter) OnError(fn func(error)) {
+	l.onError = fn
+}
+
+// Policy returns the configured bucket policy.
+func (l *Limiter) Policy() Policy {
+	return *l.policy.Load()
+}
+
+// SetPolicy replaces the configured policy, e.g. on a config reload. Buckets
+// keep their tokens and refill at the new rate from their next take.
+func (l *Limiter) SetPolicy(p Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	l.policy.Store(&p)
+	return nil
+}
+
+// Allow takes one token for key under the configured policy.
+func (l *Limiter) Allow(ctx context.Context, key string) Decision {
+	return l.AllowPolicy(ctx, key, l.Policy())
+}
+
+// AllowPolicy takes one token for key under p, e.g. a tenant's plan quota.
+// An invalid p falls back to the configured policy.
+func (l *Limiter) AllowPolicy(ctx context.Context, key string, p Policy) Decision {
+	if p.Validate() != nil {
+		p = l.Policy()
+	}
+	d, err := l.store.Take(ctx, key, p)
+	if err == nil {
+		return d
+	}
+	if l.onError != nil {
+		l.onError(err)
+	}
+	d, _ = l.fallback.Take(ctx, key, p)
+	return d
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	s := NewMemoryStore(10)
+	now := time.Unix(1700000000, 0)
+	s.clockFn = func() time.Time { return now }
+	p := Policy{Capacity: 2, RefillPerMinute: 60}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if d, _ := s.Take(ctx, "k", p); !d.Allowed {
+			t.Fatalf("take %d: expected allowed", i)
+		}
+	}
+	d, _ := s.Take(ctx, "k", p)
+	if d.Allowed || d.Remaining != 0 || d.RetryAfter != time.Second || d.Limit != 2 {
+		t.Fatalf("unexpected denied decision: %+v", d)
+	}
+	if d.ResetAfter != 2*time.Second {
+		t.Fatalf("expected reset after 2s, got %s", d.ResetAfter)
+	}
+
+	now = now.Add(time.Second)
+	if d, _ := s.Take(ctx, "k", p); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("expected one refilled token, got %+v", d)
+	}
+	if d, _ := s.Take(ctx, "other", p); !d.Allowed || d.Remaining != 1 {
+		t.Fatalf("expected independent bucket, got %+v", d)
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	s := NewMemoryStore(2)
+	now := time.Unix(1700000000, 0)
+	s.clockFn = func() time.Time { return now }
+	p := Policy{Capacity: 1, RefillPerMinute: 1}
+	ctx := context.Background()
+
+	_, _ = s.Take(ctx, "a", p)
+	_, _ = s.Take(ctx, "b", p)
+	_, _ = s.Take(ctx, "c", p)
+	if s.Len() != 2 {
+		t.Fatalf("expected LRU cap of 2, got %d", s.Len())
+	}
+	if d, _ := s.Take(ctx, "a", p); !d.Allowed {
+		t.Fatalf("expected evicted key to start with a full bucket")
+	}
+
+	now = now.Add(time.Minute)
+	_, _ = s.Take(ctx, "d", p)
+	if s.Len() != 1 {
+		t.Fatalf("expected idle buckets to be evicted, got %d", s.Len())
+	}
+}
+
+func TestMemoryStoreEvictsByEachBucketsPolicy(t *testing.T) {
+	s := NewMemoryStore(10)
+	now := time.Unix(1700000000, 0)
+	s.clockFn = func() time.Time { return now }
+	slow := Policy{Capacity: 10, RefillPerMinute: 1} // full again after 10m
+	fast := Policy{Capacity: 1, RefillPerMinute: 60} // full again after 1s
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, _ = s.Take(ctx, "slow", slow)
+	}
+	now = now.Add(time.Minute)
+	_, _ = s.Take(ctx, "fast", fast)
+	if s.Len() != 2 {
+		t.Fatalf("expected the slow bucket to outlive the fast policy's idle ttl, got %d", s.Len())
+	}
+	if d, _ := s.Take(ctx, "slow", slow); d.Remaining != 0 {
+		t.Fatalf("expected the drained slow bucket to keep its state, got %+v", d)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Policy) (Decision, error) {
+	return Decision{}, errors.New("db down")
+}
+
+func TestLimiterFallsBackToMemory(t *testing.T) {
+	l, err := NewLimiter(Policy{Capacity: 1, RefillPerMinute: 1}, failingStore{})
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	var failures int
+	l.OnError(func(error) { failures++ })
+	if d := l.Allow(context.Background(), "k"); !d.Allowed {
+		t.Fatalf("expected fallback to allow first request")
+	}
+	if d := l.Allow(context.Background(), "k"); d.Allowed {
+		t.Fatalf("expected fallback bucket to enforce the limit")
+	}
+	if failures != 2 {
+		t.Fatalf("expected 2 reported failures, got %d", failures)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	if err := (Policy{Capacity: 0, RefillPerMinute: 1}).Validate(); err == nil {
+		t.Fatalf("expected capacity error")
+	}
+	if err := (Policy{Capacity: 1, RefillPerMinute: 0}).Validate(); err == nil {
+		t.Fatalf("expected refill error")
+	}
+}
+
+func TestLimiterAllowPolicy(t *testing.T) {
+	l, err := NewLimiter(Policy{Capacity: 1, RefillPerMinute: 1}, nil)
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	ctx := context.Background()
+	plan := Policy{Capacity: 3, RefillPerMinute: 60}
+	for i := 0; i < 3; i++ {
+		if d := l.AllowPolicy(ctx, "tenant", plan); !d.Allowed || d.Limit != 3 {
+			t.Fatalf("take %d: expected plan policy to allow, got %+v", i, d)
+		}
+	}
+	if d := l.AllowPolicy(ctx, "tenant", plan); d.Allowed {
+		t.Fatalf("expected plan policy to be exhausted")
+	}
+	if d := l.AllowPolicy(ctx, "other", Policy{}); d.Limit != 1 {
+		t.Fatalf("expected invalid policy to fall back to default, got %+v", d)
+	}
+}
+
+func TestLimiterSetPolicy(t *testing.T) {
+	l, err := NewLimiter(Policy{Capacity: 1, RefillPerMinute: 1}, nil)
+	if err != nil {
+		t.Fatalf("new limiter: %v", err)
+	}
+	if err := l.SetPolicy(Policy{Capacity: 0, RefillPerMinute: 60}); err == nil {
+		t.Fatalf("expected an invalid policy to be refused")
+	}
+	if got := l.Policy(); got.Capacity != 1 {
+		t.Fatalf("expected a refused policy to keep the old one, got %+v", got)
+	}
+	if err := l.SetPolicy(Policy{Capacity: 5, RefillPerMinute: 60}); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+	if d := l.Allow(context.Background(), "k"); !d.Allowed || d.Limit != 5 {
+		t.Fatalf("expected the new policy to apply, got %+v", d)
+	}
+}
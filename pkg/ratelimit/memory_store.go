@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultMaxKeys bounds the number of buckets a MemoryStore keeps.
+const DefaultMaxKeys = 100000
+
+type memoryBucket struct {
+	key       string
+	tokens    float64
+	updatedAt time.Time
+	idle      time.Duration // idleTTL of the policy that last took from it
+}
+
+// MemoryStore keeps per-process buckets in an LRU. Buckets idle long enough
+// to be full again under their own policy are dropped on access, and the
+// least recently used bucket is evicted once maxKeys is reached, so memory
+// stays bounded.
+type MemoryStore struct {
+	mu      sync.Mutex
+	maxKeys int
+	buckets map[string]*list.Element
+	lru     *list.List // front = most recently used
+	clockFn func() time.Time
+}
+
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	if maxKeys <= 0 {
+		maxKeys = DefaultMaxKeys
+	}
+	return &MemoryStore{
+		maxKeys: maxKeys,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+		clockFn: time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clockFn()
+	s.evictIdleLocked(now)
+
+	var b *memoryBucket
+	if el, ok := s.buckets[key]; ok {
+		b = el.Value.(*memoryBucket)
+		elapsed := now.Sub(b.updatedAt).Seconds()
+		if elapsed > 0 {
+			b.tokens = math.Min(float64(p.Capacity), b.tokens+elapsed*p.refillPerSecond())
+		}
+		b.updatedAt = now
+		b.idle = p.idleTTL()
+		s.lru.MoveToFront(el)
+	} else {
+		if s.lru.Len() >= s.maxKeys {
+			oldest := s.lru.Back()
+			s.lru.Remove(oldest)
+			delete(s.buckets, oldest.Value.(*memoryBucket).key)
+		}
+		b = &memoryBucket{key: key, tokens: float64(p.Capacity), updatedAt: now, idle: p.idleTTL()}
+		s.buckets[key] = s.lru.PushFront(b)
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return decide(p, b.tokens, allowed), nil
+}
+
+// evictIdleLocked drops buckets from the LRU tail that have refilled
+// completely, each judged by its own policy's idle TTL. It stops at the first
+// bucket that is still refilling, so a bucket is never dropped early.
+func (s *MemoryStore) evictIdleLocked(now time.Time) {
+	for el := s.lru.Back(); el != nil; el = s.lru.Back() {
+		b := el.Value.(*memoryBucket)
+		if now.Sub(b.updatedAt) < b.idle {
+			return
+		}
+		s.lru.Remove(el)
+		delete(s.buckets, b.key)
+	}
+}
+
+// Len returns the number of tracked buckets.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PostgresStore keeps buckets in ops.rate_limit_buckets so every replica
+// shares one limit per key. Refill uses the database clock, and each take is
+// a single atomic upsert.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) (*PostgresStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("ratelimit: nil db handle")
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, p Policy) (Decision, error) {
+	if err := p.Validate(); err != nil {
+		return Decision{}, err
+	}
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := s.db.QueryRowContext(
+		ctx,
+		`WITH cfg AS (
+		   SELECT $2::float8 AS capacity,
+		          $3::float8 AS rate,
+		          make_interval(secs => $4::float8) AS idle
+		 )
+		 INSERT INTO ops.rate_limit_buckets AS b (bucket_key, tokens, last_allowed, updated_at, expires_at)
+		 SELECT $1, cfg.capacity - 1, true, now(), now() + cfg.idle FROM cfg
+		 ON CONFLICT (bucket_key) DO UPDATE SET
+		   tokens = CASE
+		              WHEN LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM (now() - b.updated_at))) * $3::float8) >= 1
+		              THEN LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM (now() - b.updated_at))) * $3::float8) - 1
+		              ELSE LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM (now() - b.updated_at))) * $3::float8)
+		            END,
+		   last_allowed = LEAST($2::float8, b.tokens + GREATEST(0, EXTRACT(EPOCH FROM (now() - b.updated_at))) * $3::float8) >= 1,
+		   updated_at = GREATEST(b.updated_at, now()),
+		   expires_at = now() + make_interval(secs => $4::float8)
+		 RETURNING tokens, last_allowed`,
+		key,
+		p.Capacity,
+		p.refillPerSecond(),
+		p.idleTTL().Seconds(),
+	).Scan(&tokens, &allowed)
+	if err != nil {
+		return Decision{}, err
+	}
+	return decide(p, tokens, allowed), nil
+}
+
+// CleanupExpired deletes buckets idle long enough to have refilled completely.
+func (s *PostgresStore) CleanupExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM ops.rate_limit_buckets WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,5 @@
+// Package runtimepb holds the generated protobuf and gRPC code for the
+// platform_runtime gRPC API described in runtime.proto.
+package runtimepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative runtime.proto
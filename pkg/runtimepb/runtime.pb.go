@@ -0,0 +1,2112 @@
+// gRPC surface of platform_runtime. Messages mirror the JSON types in pkg/api
+// field for field; the server converts them and runs the same checks as the
+// HTTP handlers (auth, rate limits, OpenAPI validation, quotas, idempotency).
+//
+// Credentials travel as metadata exactly like HTTP headers: authorization
+// (Bearer), x-api-key, x-tenant-id, x-session-id and the x-signature-* set.
+// A unary signature covers the deterministic protobuf encoding of the request
+// message with the full method name as path. Streams cannot sign each message
+// and are refused when signed or when the server requires signing.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: runtime.proto
+
+package runtimepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RecordDecisionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RequestId      string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	Decision       *Decision              `protobuf:"bytes,3,opt,name=decision,proto3" json:"decision,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RecordDecisionRequest) Reset() {
+	*x = RecordDecisionRequest{}
+	mi := &file_runtime_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordDecisionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordDecisionRequest) ProtoMessage() {}
+
+func (x *RecordDecisionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordDecisionRequest.ProtoReflect.Descriptor instead.
+func (*RecordDecisionRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RecordDecisionRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RecordDecisionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *RecordDecisionRequest) GetDecision() *Decision {
+	if x != nil {
+		return x.Decision
+	}
+	return nil
+}
+
+type Decision struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TenantId        *string                `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3,oneof" json:"tenant_id,omitempty"`
+	WorkspaceId     *string                `protobuf:"bytes,2,opt,name=workspace_id,json=workspaceId,proto3,oneof" json:"workspace_id,omitempty"`
+	Subject         string                 `protobuf:"bytes,3,opt,name=subject,proto3" json:"subject,omitempty"`
+	SessionId       *string                `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3,oneof" json:"session_id,omitempty"`
+	PolicySetId     *string                `protobuf:"bytes,5,opt,name=policy_set_id,json=policySetId,proto3,oneof" json:"policy_set_id,omitempty"`
+	PolicySetKey    *string                `protobuf:"bytes,6,opt,name=policy_set_key,json=policySetKey,proto3,oneof" json:"policy_set_key,omitempty"`
+	Tier            *string                `protobuf:"bytes,7,opt,name=tier,proto3,oneof" json:"tier,omitempty"`
+	Action          string                 `protobuf:"bytes,8,opt,name=action,proto3" json:"action,omitempty"`
+	ResourceRef     string                 `protobuf:"bytes,9,opt,name=resource_ref,json=resourceRef,proto3" json:"resource_ref,omitempty"`
+	Allow           bool                   `protobuf:"varint,10,opt,name=allow,proto3" json:"allow,omitempty"`
+	ReasonCode      string                 `protobuf:"bytes,11,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	MatchedRuleId   *string                `protobuf:"bytes,12,opt,name=matched_rule_id,json=matchedRuleId,proto3,oneof" json:"matched_rule_id,omitempty"`
+	Trace           []*DecisionTraceStep   `protobuf:"bytes,13,rep,name=trace,proto3" json:"trace,omitempty"`
+	DecisionContext *structpb.Struct       `protobuf:"bytes,14,opt,name=decision_context,json=decisionContext,proto3" json:"decision_context,omitempty"`
+	ActorType       string                 `protobuf:"bytes,15,opt,name=actor_type,json=actorType,proto3" json:"actor_type,omitempty"`
+	ActorId         *string                `protobuf:"bytes,16,opt,name=actor_id,json=actorId,proto3,oneof" json:"actor_id,omitempty"`
+	EventType       string                 `protobuf:"bytes,17,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Severity        string                 `protobuf:"bytes,18,opt,name=severity,proto3" json:"severity,omitempty"`
+	Message         string                 `protobuf:"bytes,19,opt,name=message,proto3" json:"message,omitempty"`
+	Event           *structpb.Struct       `protobuf:"bytes,20,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Decision) Reset() {
+	*x = Decision{}
+	mi := &file_runtime_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Decision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Decision) ProtoMessage() {}
+
+func (x *Decision) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Decision.ProtoReflect.Descriptor instead.
+func (*Decision) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Decision) GetTenantId() string {
+	if x != nil && x.TenantId != nil {
+		return *x.TenantId
+	}
+	return ""
+}
+
+func (x *Decision) GetWorkspaceId() string {
+	if x != nil && x.WorkspaceId != nil {
+		return *x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *Decision) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Decision) GetSessionId() string {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return ""
+}
+
+func (x *Decision) GetPolicySetId() string {
+	if x != nil && x.PolicySetId != nil {
+		return *x.PolicySetId
+	}
+	return ""
+}
+
+func (x *Decision) GetPolicySetKey() string {
+	if x != nil && x.PolicySetKey != nil {
+		return *x.PolicySetKey
+	}
+	return ""
+}
+
+func (x *Decision) GetTier() string {
+	if x != nil && x.Tier != nil {
+		return *x.Tier
+	}
+	return ""
+}
+
+func (x *Decision) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Decision) GetResourceRef() string {
+	if x != nil {
+		return x.ResourceRef
+	}
+	return ""
+}
+
+func (x *Decision) GetAllow() bool {
+	if x != nil {
+		return x.Allow
+	}
+	return false
+}
+
+func (x *Decision) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+func (x *Decision) GetMatchedRuleId() string {
+	if x != nil && x.MatchedRuleId != nil {
+		return *x.MatchedRuleId
+	}
+	return ""
+}
+
+func (x *Decision) GetTrace() []*DecisionTraceStep {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *Decision) GetDecisionContext() *structpb.Struct {
+	if x != nil {
+		return x.DecisionContext
+	}
+	return nil
+}
+
+func (x *Decision) GetActorType() string {
+	if x != nil {
+		return x.ActorType
+	}
+	return ""
+}
+
+func (x *Decision) GetActorId() string {
+	if x != nil && x.ActorId != nil {
+		return *x.ActorId
+	}
+	return ""
+}
+
+func (x *Decision) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *Decision) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Decision) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Decision) GetEvent() *structpb.Struct {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type DecisionTraceStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StepOrder     int32                  `protobuf:"varint,1,opt,name=step_order,json=stepOrder,proto3" json:"step_order,omitempty"`
+	RuleId        string                 `protobuf:"bytes,2,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`
+	Matched       bool                   `protobuf:"varint,3,opt,name=matched,proto3" json:"matched,omitempty"`
+	Outcome       string                 `protobuf:"bytes,4,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecisionTraceStep) Reset() {
+	*x = DecisionTraceStep{}
+	mi := &file_runtime_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecisionTraceStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecisionTraceStep) ProtoMessage() {}
+
+func (x *DecisionTraceStep) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecisionTraceStep.ProtoReflect.Descriptor instead.
+func (*DecisionTraceStep) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DecisionTraceStep) GetStepOrder() int32 {
+	if x != nil {
+		return x.StepOrder
+	}
+	return 0
+}
+
+func (x *DecisionTraceStep) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *DecisionTraceStep) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+func (x *DecisionTraceStep) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *DecisionTraceStep) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RecordDecisionResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RequestId  string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DecisionId string                 `protobuf:"bytes,2,opt,name=decision_id,json=decisionId,proto3" json:"decision_id,omitempty"`
+	EventId    string                 `protobuf:"bytes,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Status     string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	// replayed is set when the idempotency key was already completed.
+	Replayed      bool `protobuf:"varint,5,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordDecisionResponse) Reset() {
+	*x = RecordDecisionResponse{}
+	mi := &file_runtime_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordDecisionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordDecisionResponse) ProtoMessage() {}
+
+func (x *RecordDecisionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordDecisionResponse.ProtoReflect.Descriptor instead.
+func (*RecordDecisionResponse) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RecordDecisionResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RecordDecisionResponse) GetDecisionId() string {
+	if x != nil {
+		return x.DecisionId
+	}
+	return ""
+}
+
+func (x *RecordDecisionResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *RecordDecisionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RecordDecisionResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type RecordTelemetryEventRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RequestId      string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	Event          *TelemetryEvent        `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RecordTelemetryEventRequest) Reset() {
+	*x = RecordTelemetryEventRequest{}
+	mi := &file_runtime_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordTelemetryEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordTelemetryEventRequest) ProtoMessage() {}
+
+func (x *RecordTelemetryEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordTelemetryEventRequest.ProtoReflect.Descriptor instead.
+func (*RecordTelemetryEventRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RecordTelemetryEventRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RecordTelemetryEventRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *RecordTelemetryEventRequest) GetEvent() *TelemetryEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type TelemetryEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      *string                `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3,oneof" json:"tenant_id,omitempty"`
+	WorkspaceId   *string                `protobuf:"bytes,2,opt,name=workspace_id,json=workspaceId,proto3,oneof" json:"workspace_id,omitempty"`
+	ActorType     string                 `protobuf:"bytes,3,opt,name=actor_type,json=actorType,proto3" json:"actor_type,omitempty"`
+	ActorId       *string                `protobuf:"bytes,4,opt,name=actor_id,json=actorId,proto3,oneof" json:"actor_id,omitempty"`
+	EventType     string                 `protobuf:"bytes,5,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Severity      string                 `protobuf:"bytes,6,opt,name=severity,proto3" json:"severity,omitempty"`
+	Message       string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	TraceHash     *string                `protobuf:"bytes,8,opt,name=trace_hash,json=traceHash,proto3,oneof" json:"trace_hash,omitempty"`
+	Event         *structpb.Struct       `protobuf:"bytes,9,opt,name=event,proto3" json:"event,omitempty"`
+	Links         []*TelemetryEventLink  `protobuf:"bytes,10,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TelemetryEvent) Reset() {
+	*x = TelemetryEvent{}
+	mi := &file_runtime_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TelemetryEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelemetryEvent) ProtoMessage() {}
+
+func (x *TelemetryEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelemetryEvent.ProtoReflect.Descriptor instead.
+func (*TelemetryEvent) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TelemetryEvent) GetTenantId() string {
+	if x != nil && x.TenantId != nil {
+		return *x.TenantId
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetWorkspaceId() string {
+	if x != nil && x.WorkspaceId != nil {
+		return *x.WorkspaceId
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetActorType() string {
+	if x != nil {
+		return x.ActorType
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetActorId() string {
+	if x != nil && x.ActorId != nil {
+		return *x.ActorId
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetTraceHash() string {
+	if x != nil && x.TraceHash != nil {
+		return *x.TraceHash
+	}
+	return ""
+}
+
+func (x *TelemetryEvent) GetEvent() *structpb.Struct {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *TelemetryEvent) GetLinks() []*TelemetryEventLink {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type TelemetryEventLink struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LinkKind      string                 `protobuf:"bytes,1,opt,name=link_kind,json=linkKind,proto3" json:"link_kind,omitempty"`
+	LinkedId      string                 `protobuf:"bytes,2,opt,name=linked_id,json=linkedId,proto3" json:"linked_id,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TelemetryEventLink) Reset() {
+	*x = TelemetryEventLink{}
+	mi := &file_runtime_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TelemetryEventLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelemetryEventLink) ProtoMessage() {}
+
+func (x *TelemetryEventLink) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelemetryEventLink.ProtoReflect.Descriptor instead.
+func (*TelemetryEventLink) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TelemetryEventLink) GetLinkKind() string {
+	if x != nil {
+		return x.LinkKind
+	}
+	return ""
+}
+
+func (x *TelemetryEventLink) GetLinkedId() string {
+	if x != nil {
+		return x.LinkedId
+	}
+	return ""
+}
+
+func (x *TelemetryEventLink) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type RecordTelemetryEventResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	EventId       string                 `protobuf:"bytes,2,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Replayed      bool                   `protobuf:"varint,4,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordTelemetryEventResponse) Reset() {
+	*x = RecordTelemetryEventResponse{}
+	mi := &file_runtime_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordTelemetryEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordTelemetryEventResponse) ProtoMessage() {}
+
+func (x *RecordTelemetryEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordTelemetryEventResponse.ProtoReflect.Descriptor instead.
+func (*RecordTelemetryEventResponse) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RecordTelemetryEventResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RecordTelemetryEventResponse) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *RecordTelemetryEventResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RecordTelemetryEventResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+type StreamTelemetryEventsResponse struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Accepted      int32                         `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Replayed      int32                         `protobuf:"varint,2,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	Failed        int32                         `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	Results       []*StreamTelemetryEventResult `protobuf:"bytes,4,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTelemetryEventsResponse) Reset() {
+	*x = StreamTelemetryEventsResponse{}
+	mi := &file_runtime_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTelemetryEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTelemetryEventsResponse) ProtoMessage() {}
+
+func (x *StreamTelemetryEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTelemetryEventsResponse.ProtoReflect.Descriptor instead.
+func (*StreamTelemetryEventsResponse) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StreamTelemetryEventsResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *StreamTelemetryEventsResponse) GetReplayed() int32 {
+	if x != nil {
+		return x.Replayed
+	}
+	return 0
+}
+
+func (x *StreamTelemetryEventsResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *StreamTelemetryEventsResponse) GetResults() []*StreamTelemetryEventResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type StreamTelemetryEventResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// index is the zero-based position of the message in the stream.
+	Index     int32  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	RequestId string `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	EventId   string `protobuf:"bytes,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Replayed  bool   `protobuf:"varint,4,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	// error_code is a stable problem code (see pkg/api) when the event failed.
+	ErrorCode     string `protobuf:"bytes,5,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorDetail   string `protobuf:"bytes,6,opt,name=error_detail,json=errorDetail,proto3" json:"error_detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTelemetryEventResult) Reset() {
+	*x = StreamTelemetryEventResult{}
+	mi := &file_runtime_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTelemetryEventResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTelemetryEventResult) ProtoMessage() {}
+
+func (x *StreamTelemetryEventResult) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTelemetryEventResult.ProtoReflect.Descriptor instead.
+func (*StreamTelemetryEventResult) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamTelemetryEventResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *StreamTelemetryEventResult) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *StreamTelemetryEventResult) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *StreamTelemetryEventResult) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+func (x *StreamTelemetryEventResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *StreamTelemetryEventResult) GetErrorDetail() string {
+	if x != nil {
+		return x.ErrorDetail
+	}
+	return ""
+}
+
+type ListThreatLevelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	NodeName      string                 `protobuf:"bytes,2,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListThreatLevelsRequest) Reset() {
+	*x = ListThreatLevelsRequest{}
+	mi := &file_runtime_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListThreatLevelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListThreatLevelsRequest) ProtoMessage() {}
+
+func (x *ListThreatLevelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListThreatLevelsRequest.ProtoReflect.Descriptor instead.
+func (*ListThreatLevelsRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListThreatLevelsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListThreatLevelsRequest) GetNodeName() string {
+	if x != nil {
+		return x.NodeName
+	}
+	return ""
+}
+
+func (x *ListThreatLevelsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListThreatLevelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*ThreatLevel         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListThreatLevelsResponse) Reset() {
+	*x = ListThreatLevelsResponse{}
+	mi := &file_runtime_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListThreatLevelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListThreatLevelsResponse) ProtoMessage() {}
+
+func (x *ListThreatLevelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListThreatLevelsResponse.ProtoReflect.Descriptor instead.
+func (*ListThreatLevelsResponse) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListThreatLevelsResponse) GetItems() []*ThreatLevel {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ThreatLevel struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	NodeName        string                 `protobuf:"bytes,2,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	TenantId        *string                `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3,oneof" json:"tenant_id,omitempty"`
+	PreviousLevel   *string                `protobuf:"bytes,4,opt,name=previous_level,json=previousLevel,proto3,oneof" json:"previous_level,omitempty"`
+	NewLevel        string                 `protobuf:"bytes,5,opt,name=new_level,json=newLevel,proto3" json:"new_level,omitempty"`
+	ReasonCode      string                 `protobuf:"bytes,6,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	Reason          string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	SecurityEventId *string                `protobuf:"bytes,8,opt,name=security_event_id,json=securityEventId,proto3,oneof" json:"security_event_id,omitempty"`
+	Metadata        *structpb.Struct       `protobuf:"bytes,9,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ThreatLevel) Reset() {
+	*x = ThreatLevel{}
+	mi := &file_runtime_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreatLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreatLevel) ProtoMessage() {}
+
+func (x *ThreatLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreatLevel.ProtoReflect.Descriptor instead.
+func (*ThreatLevel) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ThreatLevel) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetNodeName() string {
+	if x != nil {
+		return x.NodeName
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetTenantId() string {
+	if x != nil && x.TenantId != nil {
+		return *x.TenantId
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetPreviousLevel() string {
+	if x != nil && x.PreviousLevel != nil {
+		return *x.PreviousLevel
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetNewLevel() string {
+	if x != nil {
+		return x.NewLevel
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetSecurityEventId() string {
+	if x != nil && x.SecurityEventId != nil {
+		return *x.SecurityEventId
+	}
+	return ""
+}
+
+func (x *ThreatLevel) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ThreatLevel) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type ListAnomalyReportsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	NodeName      string                 `protobuf:"bytes,2,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAnomalyReportsRequest) Reset() {
+	*x = ListAnomalyReportsRequest{}
+	mi := &file_runtime_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAnomalyReportsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnomalyReportsRequest) ProtoMessage() {}
+
+func (x *ListAnomalyReportsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnomalyReportsRequest.ProtoReflect.Descriptor instead.
+func (*ListAnomalyReportsRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListAnomalyReportsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListAnomalyReportsRequest) GetNodeName() string {
+	if x != nil {
+		return x.NodeName
+	}
+	return ""
+}
+
+func (x *ListAnomalyReportsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListAnomalyReportsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListAnomalyReportsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*AnomalyReport       `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAnomalyReportsResponse) Reset() {
+	*x = ListAnomalyReportsResponse{}
+	mi := &file_runtime_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAnomalyReportsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAnomalyReportsResponse) ProtoMessage() {}
+
+func (x *ListAnomalyReportsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAnomalyReportsResponse.ProtoReflect.Descriptor instead.
+func (*ListAnomalyReportsResponse) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListAnomalyReportsResponse) GetItems() []*AnomalyReport {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type GetAnomalyReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAnomalyReportRequest) Reset() {
+	*x = GetAnomalyReportRequest{}
+	mi := &file_runtime_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAnomalyReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAnomalyReportRequest) ProtoMessage() {}
+
+func (x *GetAnomalyReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAnomalyReportRequest.ProtoReflect.Descriptor instead.
+func (*GetAnomalyReportRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetAnomalyReportRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type AnomalyReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	NodeName      string                 `protobuf:"bytes,2,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
+	TenantId      *string                `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3,oneof" json:"tenant_id,omitempty"`
+	AnomalyType   string                 `protobuf:"bytes,4,opt,name=anomaly_type,json=anomalyType,proto3" json:"anomaly_type,omitempty"`
+	Severity      string                 `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Score         *string                `protobuf:"bytes,7,opt,name=score,proto3,oneof" json:"score,omitempty"`
+	Summary       string                 `protobuf:"bytes,8,opt,name=summary,proto3" json:"summary,omitempty"`
+	Details       *structpb.Struct       `protobuf:"bytes,9,opt,name=details,proto3" json:"details,omitempty"`
+	DetectedAt    *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=detected_at,json=detectedAt,proto3" json:"detected_at,omitempty"`
+	ResolvedAt    *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=resolved_at,json=resolvedAt,proto3" json:"resolved_at,omitempty"`
+	Links         []*AnomalyReportLink   `protobuf:"bytes,12,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnomalyReport) Reset() {
+	*x = AnomalyReport{}
+	mi := &file_runtime_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnomalyReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnomalyReport) ProtoMessage() {}
+
+func (x *AnomalyReport) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnomalyReport.ProtoReflect.Descriptor instead.
+func (*AnomalyReport) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AnomalyReport) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetNodeName() string {
+	if x != nil {
+		return x.NodeName
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetTenantId() string {
+	if x != nil && x.TenantId != nil {
+		return *x.TenantId
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetAnomalyType() string {
+	if x != nil {
+		return x.AnomalyType
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetScore() string {
+	if x != nil && x.Score != nil {
+		return *x.Score
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *AnomalyReport) GetDetails() *structpb.Struct {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *AnomalyReport) GetDetectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DetectedAt
+	}
+	return nil
+}
+
+func (x *AnomalyReport) GetResolvedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResolvedAt
+	}
+	return nil
+}
+
+func (x *AnomalyReport) GetLinks() []*AnomalyReportLink {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+type AnomalyReportLink struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SecurityEventId string                 `protobuf:"bytes,1,opt,name=security_event_id,json=securityEventId,proto3" json:"security_event_id,omitempty"`
+	EventType       string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Severity        string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	DecisionId      *string                `protobuf:"bytes,4,opt,name=decision_id,json=decisionId,proto3,oneof" json:"decision_id,omitempty"`
+	LinkedAt        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=linked_at,json=linkedAt,proto3" json:"linked_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AnomalyReportLink) Reset() {
+	*x = AnomalyReportLink{}
+	mi := &file_runtime_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnomalyReportLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnomalyReportLink) ProtoMessage() {}
+
+func (x *AnomalyReportLink) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnomalyReportLink.ProtoReflect.Descriptor instead.
+func (*AnomalyReportLink) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AnomalyReportLink) GetSecurityEventId() string {
+	if x != nil {
+		return x.SecurityEventId
+	}
+	return ""
+}
+
+func (x *AnomalyReportLink) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *AnomalyReportLink) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *AnomalyReportLink) GetDecisionId() string {
+	if x != nil && x.DecisionId != nil {
+		return *x.DecisionId
+	}
+	return ""
+}
+
+func (x *AnomalyReportLink) GetLinkedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LinkedAt
+	}
+	return nil
+}
+
+type GetUsageRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	TenantId string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	// from and to are inclusive YYYY-MM-DD dates; empty means the last 30 days.
+	From          string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsageRequest) Reset() {
+	*x = GetUsageRequest{}
+	mi := &file_runtime_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsageRequest) ProtoMessage() {}
+
+func (x *GetUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsageRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetUsageRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *GetUsageRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *GetUsageRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type UsageReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Plan          string                 `protobuf:"bytes,2,opt,name=plan,proto3" json:"plan,omitempty"`
+	Quota         *Quota                 `protobuf:"bytes,3,opt,name=quota,proto3" json:"quota,omitempty"`
+	From          string                 `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`
+	Items         []*UsageCounter        `protobuf:"bytes,6,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageReport) Reset() {
+	*x = UsageReport{}
+	mi := &file_runtime_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageReport) ProtoMessage() {}
+
+func (x *UsageReport) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageReport.ProtoReflect.Descriptor instead.
+func (*UsageReport) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UsageReport) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *UsageReport) GetPlan() string {
+	if x != nil {
+		return x.Plan
+	}
+	return ""
+}
+
+func (x *UsageReport) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *UsageReport) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UsageReport) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *UsageReport) GetItems() []*UsageCounter {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type Quota struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	TenantId          string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Plan              string                 `protobuf:"bytes,2,opt,name=plan,proto3" json:"plan,omitempty"`
+	Endpoint          string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	RequestsPerMinute *int32                 `protobuf:"varint,4,opt,name=requests_per_minute,json=requestsPerMinute,proto3,oneof" json:"requests_per_minute,omitempty"`
+	Burst             *int32                 `protobuf:"varint,5,opt,name=burst,proto3,oneof" json:"burst,omitempty"`
+	DailyEventLimit   *int64                 `protobuf:"varint,6,opt,name=daily_event_limit,json=dailyEventLimit,proto3,oneof" json:"daily_event_limit,omitempty"`
+	MaxBatchDecisions *int32                 `protobuf:"varint,7,opt,name=max_batch_decisions,json=maxBatchDecisions,proto3,oneof" json:"max_batch_decisions,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Quota) Reset() {
+	*x = Quota{}
+	mi := &file_runtime_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quota) ProtoMessage() {}
+
+func (x *Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quota.ProtoReflect.Descriptor instead.
+func (*Quota) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Quota) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *Quota) GetPlan() string {
+	if x != nil {
+		return x.Plan
+	}
+	return ""
+}
+
+func (x *Quota) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *Quota) GetRequestsPerMinute() int32 {
+	if x != nil && x.RequestsPerMinute != nil {
+		return *x.RequestsPerMinute
+	}
+	return 0
+}
+
+func (x *Quota) GetBurst() int32 {
+	if x != nil && x.Burst != nil {
+		return *x.Burst
+	}
+	return 0
+}
+
+func (x *Quota) GetDailyEventLimit() int64 {
+	if x != nil && x.DailyEventLimit != nil {
+		return *x.DailyEventLimit
+	}
+	return 0
+}
+
+func (x *Quota) GetMaxBatchDecisions() int32 {
+	if x != nil && x.MaxBatchDecisions != nil {
+		return *x.MaxBatchDecisions
+	}
+	return 0
+}
+
+type UsageCounter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UsageDate     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=usage_date,json=usageDate,proto3" json:"usage_date,omitempty"`
+	Metric        string                 `protobuf:"bytes,2,opt,name=metric,proto3" json:"metric,omitempty"`
+	Count         int64                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageCounter) Reset() {
+	*x = UsageCounter{}
+	mi := &file_runtime_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageCounter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageCounter) ProtoMessage() {}
+
+func (x *UsageCounter) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageCounter.ProtoReflect.Descriptor instead.
+func (*UsageCounter) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UsageCounter) GetUsageDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UsageDate
+	}
+	return nil
+}
+
+func (x *UsageCounter) GetMetric() string {
+	if x != nil {
+		return x.Metric
+	}
+	return ""
+}
+
+func (x *UsageCounter) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *UsageCounter) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+var File_runtime_proto protoreflect.FileDescriptor
+
+const file_runtime_proto_rawDesc = "" +
+	"\n" +
+	"\rruntime.proto\x12\x19vedic.platform.runtime.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa0\x01\n" +
+	"\x15RecordDecisionRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\x12?\n" +
+	"\bdecision\x18\x03 \x01(\v2#.vedic.platform.runtime.v1.DecisionR\bdecision\"\xe6\x06\n" +
+	"\bDecision\x12 \n" +
+	"\ttenant_id\x18\x01 \x01(\tH\x00R\btenantId\x88\x01\x01\x12&\n" +
+	"\fworkspace_id\x18\x02 \x01(\tH\x01R\vworkspaceId\x88\x01\x01\x12\x18\n" +
+	"\asubject\x18\x03 \x01(\tR\asubject\x12\"\n" +
+	"\n" +
+	"session_id\x18\x04 \x01(\tH\x02R\tsessionId\x88\x01\x01\x12'\n" +
+	"\rpolicy_set_id\x18\x05 \x01(\tH\x03R\vpolicySetId\x88\x01\x01\x12)\n" +
+	"\x0epolicy_set_key\x18\x06 \x01(\tH\x04R\fpolicySetKey\x88\x01\x01\x12\x17\n" +
+	"\x04tier\x18\a \x01(\tH\x05R\x04tier\x88\x01\x01\x12\x16\n" +
+	"\x06action\x18\b \x01(\tR\x06action\x12!\n" +
+	"\fresource_ref\x18\t \x01(\tR\vresourceRef\x12\x14\n" +
+	"\x05allow\x18\n" +
+	" \x01(\bR\x05allow\x12\x1f\n" +
+	"\vreason_code\x18\v \x01(\tR\n" +
+	"reasonCode\x12+\n" +
+	"\x0fmatched_rule_id\x18\f \x01(\tH\x06R\rmatchedRuleId\x88\x01\x01\x12B\n" +
+	"\x05trace\x18\r \x03(\v2,.vedic.platform.runtime.v1.DecisionTraceStepR\x05trace\x12B\n" +
+	"\x10decision_context\x18\x0e \x01(\v2\x17.google.protobuf.StructR\x0fdecisionContext\x12\x1d\n" +
+	"\n" +
+	"actor_type\x18\x0f \x01(\tR\tactorType\x12\x1e\n" +
+	"\bactor_id\x18\x10 \x01(\tH\aR\aactorId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x11 \x01(\tR\teventType\x12\x1a\n" +
+	"\bseverity\x18\x12 \x01(\tR\bseverity\x12\x18\n" +
+	"\amessage\x18\x13 \x01(\tR\amessage\x12-\n" +
+	"\x05event\x18\x14 \x01(\v2\x17.google.protobuf.StructR\x05eventB\f\n" +
+	"\n" +
+	"_tenant_idB\x0f\n" +
+	"\r_workspace_idB\r\n" +
+	"\v_session_idB\x10\n" +
+	"\x0e_policy_set_idB\x11\n" +
+	"\x0f_policy_set_keyB\a\n" +
+	"\x05_tierB\x12\n" +
+	"\x10_matched_rule_idB\v\n" +
+	"\t_actor_id\"\x97\x01\n" +
+	"\x11DecisionTraceStep\x12\x1d\n" +
+	"\n" +
+	"step_order\x18\x01 \x01(\x05R\tstepOrder\x12\x17\n" +
+	"\arule_id\x18\x02 \x01(\tR\x06ruleId\x12\x18\n" +
+	"\amatched\x18\x03 \x01(\bR\amatched\x12\x18\n" +
+	"\aoutcome\x18\x04 \x01(\tR\aoutcome\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\xa7\x01\n" +
+	"\x16RecordDecisionResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1f\n" +
+	"\vdecision_id\x18\x02 \x01(\tR\n" +
+	"decisionId\x12\x19\n" +
+	"\bevent_id\x18\x03 \x01(\tR\aeventId\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1a\n" +
+	"\breplayed\x18\x05 \x01(\bR\breplayed\"\xa6\x01\n" +
+	"\x1bRecordTelemetryEventRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\x12?\n" +
+	"\x05event\x18\x03 \x01(\v2).vedic.platform.runtime.v1.TelemetryEventR\x05event\"\xc1\x03\n" +
+	"\x0eTelemetryEvent\x12 \n" +
+	"\ttenant_id\x18\x01 \x01(\tH\x00R\btenantId\x88\x01\x01\x12&\n" +
+	"\fworkspace_id\x18\x02 \x01(\tH\x01R\vworkspaceId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"actor_type\x18\x03 \x01(\tR\tactorType\x12\x1e\n" +
+	"\bactor_id\x18\x04 \x01(\tH\x02R\aactorId\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x05 \x01(\tR\teventType\x12\x1a\n" +
+	"\bseverity\x18\x06 \x01(\tR\bseverity\x12\x18\n" +
+	"\amessage\x18\a \x01(\tR\amessage\x12\"\n" +
+	"\n" +
+	"trace_hash\x18\b \x01(\tH\x03R\ttraceHash\x88\x01\x01\x12-\n" +
+	"\x05event\x18\t \x01(\v2\x17.google.protobuf.StructR\x05event\x12C\n" +
+	"\x05links\x18\n" +
+	" \x03(\v2-.vedic.platform.runtime.v1.TelemetryEventLinkR\x05linksB\f\n" +
+	"\n" +
+	"_tenant_idB\x0f\n" +
+	"\r_workspace_idB\v\n" +
+	"\t_actor_idB\r\n" +
+	"\v_trace_hash\"\x83\x01\n" +
+	"\x12TelemetryEventLink\x12\x1b\n" +
+	"\tlink_kind\x18\x01 \x01(\tR\blinkKind\x12\x1b\n" +
+	"\tlinked_id\x18\x02 \x01(\tR\blinkedId\x123\n" +
+	"\bmetadata\x18\x03 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"\x8c\x01\n" +
+	"\x1cRecordTelemetryEventResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x19\n" +
+	"\bevent_id\x18\x02 \x01(\tR\aeventId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\breplayed\x18\x04 \x01(\bR\breplayed\"\xc0\x01\n" +
+	"\x1dStreamTelemetryEventsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x05R\baccepted\x12\x1a\n" +
+	"\breplayed\x18\x02 \x01(\x05R\breplayed\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x05R\x06failed\x12O\n" +
+	"\aresults\x18\x04 \x03(\v25.vedic.platform.runtime.v1.StreamTelemetryEventResultR\aresults\"\xca\x01\n" +
+	"\x1aStreamTelemetryEventResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12\x19\n" +
+	"\bevent_id\x18\x03 \x01(\tR\aeventId\x12\x1a\n" +
+	"\breplayed\x18\x04 \x01(\bR\breplayed\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x05 \x01(\tR\terrorCode\x12!\n" +
+	"\ferror_detail\x18\x06 \x01(\tR\verrorDetail\"i\n" +
+	"\x17ListThreatLevelsRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x1b\n" +
+	"\tnode_name\x18\x02 \x01(\tR\bnodeName\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"X\n" +
+	"\x18ListThreatLevelsResponse\x12<\n" +
+	"\x05items\x18\x01 \x03(\v2&.vedic.platform.runtime.v1.ThreatLevelR\x05items\"\xb6\x03\n" +
+	"\vThreatLevel\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tnode_name\x18\x02 \x01(\tR\bnodeName\x12 \n" +
+	"\ttenant_id\x18\x03 \x01(\tH\x00R\btenantId\x88\x01\x01\x12*\n" +
+	"\x0eprevious_level\x18\x04 \x01(\tH\x01R\rpreviousLevel\x88\x01\x01\x12\x1b\n" +
+	"\tnew_level\x18\x05 \x01(\tR\bnewLevel\x12\x1f\n" +
+	"\vreason_code\x18\x06 \x01(\tR\n" +
+	"reasonCode\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x12/\n" +
+	"\x11security_event_id\x18\b \x01(\tH\x02R\x0fsecurityEventId\x88\x01\x01\x123\n" +
+	"\bmetadata\x18\t \x01(\v2\x17.google.protobuf.StructR\bmetadata\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB\f\n" +
+	"\n" +
+	"_tenant_idB\x11\n" +
+	"\x0f_previous_levelB\x14\n" +
+	"\x12_security_event_id\"\x83\x01\n" +
+	"\x19ListAnomalyReportsRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x1b\n" +
+	"\tnode_name\x18\x02 \x01(\tR\bnodeName\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\\\n" +
+	"\x1aListAnomalyReportsResponse\x12>\n" +
+	"\x05items\x18\x01 \x03(\v2(.vedic.platform.runtime.v1.AnomalyReportR\x05items\")\n" +
+	"\x17GetAnomalyReportRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xf3\x03\n" +
+	"\rAnomalyReport\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tnode_name\x18\x02 \x01(\tR\bnodeName\x12 \n" +
+	"\ttenant_id\x18\x03 \x01(\tH\x00R\btenantId\x88\x01\x01\x12!\n" +
+	"\fanomaly_type\x18\x04 \x01(\tR\vanomalyType\x12\x1a\n" +
+	"\bseverity\x18\x05 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x19\n" +
+	"\x05score\x18\a \x01(\tH\x01R\x05score\x88\x01\x01\x12\x18\n" +
+	"\asummary\x18\b \x01(\tR\asummary\x121\n" +
+	"\adetails\x18\t \x01(\v2\x17.google.protobuf.StructR\adetails\x12;\n" +
+	"\vdetected_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"detectedAt\x12;\n" +
+	"\vresolved_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"resolvedAt\x12B\n" +
+	"\x05links\x18\f \x03(\v2,.vedic.platform.runtime.v1.AnomalyReportLinkR\x05linksB\f\n" +
+	"\n" +
+	"_tenant_idB\b\n" +
+	"\x06_score\"\xe9\x01\n" +
+	"\x11AnomalyReportLink\x12*\n" +
+	"\x11security_event_id\x18\x01 \x01(\tR\x0fsecurityEventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x02 \x01(\tR\teventType\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\x12$\n" +
+	"\vdecision_id\x18\x04 \x01(\tH\x00R\n" +
+	"decisionId\x88\x01\x01\x127\n" +
+	"\tlinked_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\blinkedAtB\x0e\n" +
+	"\f_decision_id\"R\n" +
+	"\x0fGetUsageRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\"\xd9\x01\n" +
+	"\vUsageReport\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x12\n" +
+	"\x04plan\x18\x02 \x01(\tR\x04plan\x126\n" +
+	"\x05quota\x18\x03 \x01(\v2 .vedic.platform.runtime.v1.QuotaR\x05quota\x12\x12\n" +
+	"\x04from\x18\x04 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x05 \x01(\tR\x02to\x12=\n" +
+	"\x05items\x18\x06 \x03(\v2'.vedic.platform.runtime.v1.UsageCounterR\x05items\"\xda\x02\n" +
+	"\x05Quota\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x12\n" +
+	"\x04plan\x18\x02 \x01(\tR\x04plan\x12\x1a\n" +
+	"\bendpoint\x18\x03 \x01(\tR\bendpoint\x123\n" +
+	"\x13requests_per_minute\x18\x04 \x01(\x05H\x00R\x11requestsPerMinute\x88\x01\x01\x12\x19\n" +
+	"\x05burst\x18\x05 \x01(\x05H\x01R\x05burst\x88\x01\x01\x12/\n" +
+	"\x11daily_event_limit\x18\x06 \x01(\x03H\x02R\x0fdailyEventLimit\x88\x01\x01\x123\n" +
+	"\x13max_batch_decisions\x18\a \x01(\x05H\x03R\x11maxBatchDecisions\x88\x01\x01B\x16\n" +
+	"\x14_requests_per_minuteB\b\n" +
+	"\x06_burstB\x14\n" +
+	"\x12_daily_event_limitB\x16\n" +
+	"\x14_max_batch_decisions\"\xb2\x01\n" +
+	"\fUsageCounter\x129\n" +
+	"\n" +
+	"usage_date\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tusageDate\x12\x16\n" +
+	"\x06metric\x18\x02 \x01(\tR\x06metric\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt2\xf2\x06\n" +
+	"\x0eRuntimeService\x12u\n" +
+	"\x0eRecordDecision\x120.vedic.platform.runtime.v1.RecordDecisionRequest\x1a1.vedic.platform.runtime.v1.RecordDecisionResponse\x12\x87\x01\n" +
+	"\x14RecordTelemetryEvent\x126.vedic.platform.runtime.v1.RecordTelemetryEventRequest\x1a7.vedic.platform.runtime.v1.RecordTelemetryEventResponse\x12\x8b\x01\n" +
+	"\x15StreamTelemetryEvents\x126.vedic.platform.runtime.v1.RecordTelemetryEventRequest\x1a8.vedic.platform.runtime.v1.StreamTelemetryEventsResponse(\x01\x12{\n" +
+	"\x10ListThreatLevels\x122.vedic.platform.runtime.v1.ListThreatLevelsRequest\x1a3.vedic.platform.runtime.v1.ListThreatLevelsResponse\x12\x81\x01\n" +
+	"\x12ListAnomalyReports\x124.vedic.platform.runtime.v1.ListAnomalyReportsRequest\x1a5.vedic.platform.runtime.v1.ListAnomalyReportsResponse\x12p\n" +
+	"\x10GetAnomalyReport\x122.vedic.platform.runtime.v1.GetAnomalyReportRequest\x1a(.vedic.platform.runtime.v1.AnomalyReport\x12^\n" +
+	"\bGetUsage\x12*.vedic.platform.runtime.v1.GetUsageRequest\x1a&.vedic.platform.runtime.v1.UsageReportBQZOgithub.com/sarat-asymmetrica/vedic-platform-experiments/pkg/runtimepb;runtimepbb\x06proto3"
+
+var (
+	file_runtime_proto_rawDescOnce sync.Once
+	file_runtime_proto_rawDescData []byte
+)
+
+func file_runtime_proto_rawDescGZIP() []byte {
+	file_runtime_proto_rawDescOnce.Do(func() {
+		file_runtime_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_runtime_proto_rawDesc), len(file_runtime_proto_rawDesc)))
+	})
+	return file_runtime_proto_rawDescData
+}
+
+var file_runtime_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_runtime_proto_goTypes = []any{
+	(*RecordDecisionRequest)(nil),         // 0: vedic.platform.runtime.v1.RecordDecisionRequest
+	(*Decision)(nil),                      // 1: vedic.platform.runtime.v1.Decision
+	(*DecisionTraceStep)(nil),             // 2: vedic.platform.runtime.v1.DecisionTraceStep
+	(*RecordDecisionResponse)(nil),        // 3: vedic.platform.runtime.v1.RecordDecisionResponse
+	(*RecordTelemetryEventRequest)(nil),   // 4: vedic.platform.runtime.v1.RecordTelemetryEventRequest
+	(*TelemetryEvent)(nil),                // 5: vedic.platform.runtime.v1.TelemetryEvent
+	(*TelemetryEventLink)(nil),            // 6: vedic.platform.runtime.v1.TelemetryEventLink
+	(*RecordTelemetryEventResponse)(nil),  // 7: vedic.platform.runtime.v1.RecordTelemetryEventResponse
+	(*StreamTelemetryEventsResponse)(nil), // 8: vedic.platform.runtime.v1.StreamTelemetryEventsResponse
+	(*StreamTelemetryEventResult)(nil),    // 9: vedic.platform.runtime.v1.StreamTelemetryEventResult
+	(*ListThreatLevelsRequest)(nil),       // 10: vedic.platform.runtime.v1.ListThreatLevelsRequest
+	(*ListThreatLevelsResponse)(nil),      // 11: vedic.platform.runtime.v1.ListThreatLevelsResponse
+	(*ThreatLevel)(nil),                   // 12: vedic.platform.runtime.v1.ThreatLevel
+	(*ListAnomalyReportsRequest)(nil),     // 13: vedic.platform.runtime.v1.ListAnomalyReportsRequest
+	(*ListAnomalyReportsResponse)(nil),    // 14: vedic.platform.runtime.v1.ListAnomalyReportsResponse
+	(*GetAnomalyReportRequest)(nil),       // 15: vedic.platform.runtime.v1.GetAnomalyReportRequest
+	(*AnomalyReport)(nil),                 // 16: vedic.platform.runtime.v1.AnomalyReport
+	(*AnomalyReportLink)(nil),             // 17: vedic.platform.runtime.v1.AnomalyReportLink
+	(*GetUsageRequest)(nil),               // 18: vedic.platform.runtime.v1.GetUsageRequest
+	(*UsageReport)(nil),                   // 19: vedic.platform.runtime.v1.UsageReport
+	(*Quota)(nil),                         // 20: vedic.platform.runtime.v1.Quota
+	(*UsageCounter)(nil),                  // 21: vedic.platform.runtime.v1.UsageCounter
+	(*structpb.Struct)(nil),               // 22: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),         // 23: google.protobuf.Timestamp
+}
+var file_runtime_proto_depIdxs = []int32{
+	1,  // 0: vedic.platform.runtime.v1.RecordDecisionRequest.decision:type_name -> vedic.platform.runtime.v1.Decision
+	2,  // 1: vedic.platform.runtime.v1.Decision.trace:type_name -> vedic.platform.runtime.v1.DecisionTraceStep
+	22, // 2: vedic.platform.runtime.v1.Decision.decision_context:type_name -> google.protobuf.Struct
+	22, // 3: vedic.platform.runtime.v1.Decision.event:type_name -> google.protobuf.Struct
+	5,  // 4: vedic.platform.runtime.v1.RecordTelemetryEventRequest.event:type_name -> vedic.platform.runtime.v1.TelemetryEvent
+	22, // 5: vedic.platform.runtime.v1.TelemetryEvent.event:type_name -> google.protobuf.Struct
+	6,  // 6: vedic.platform.runtime.v1.TelemetryEvent.links:type_name -> vedic.platform.runtime.v1.TelemetryEventLink
+	22, // 7: vedic.platform.runtime.v1.TelemetryEventLink.metadata:type_name -> google.protobuf.Struct
+	9,  // 8: vedic.platform.runtime.v1.StreamTelemetryEventsResponse.results:type_name -> vedic.platform.runtime.v1.StreamTelemetryEventResult
+	12, // 9: vedic.platform.runtime.v1.ListThreatLevelsResponse.items:type_name -> vedic.platform.runtime.v1.ThreatLevel
+	22, // 10: vedic.platform.runtime.v1.ThreatLevel.metadata:type_name -> google.protobuf.Struct
+	23, // 11: vedic.platform.runtime.v1.ThreatLevel.created_at:type_name -> google.protobuf.Timestamp
+	16, // 12: vedic.platform.runtime.v1.ListAnomalyReportsResponse.items:type_name -> vedic.platform.runtime.v1.AnomalyReport
+	22, // 13: vedic.platform.runtime.v1.AnomalyReport.details:type_name -> google.protobuf.Struct
+	23, // 14: vedic.platform.runtime.v1.AnomalyReport.detected_at:type_name -> google.protobuf.Timestamp
+	23, // 15: vedic.platform.runtime.v1.AnomalyReport.resolved_at:type_name -> google.protobuf.Timestamp
+	17, // 16: vedic.platform.runtime.v1.AnomalyReport.links:type_name -> vedic.platform.runtime.v1.AnomalyReportLink
+	23, // 17: vedic.platform.runtime.v1.AnomalyReportLink.linked_at:type_name -> google.protobuf.Timestamp
+	20, // 18: vedic.platform.runtime.v1.UsageReport.quota:type_name -> vedic.platform.runtime.v1.Quota
+	21, // 19: vedic.platform.runtime.v1.UsageReport.items:type_name -> vedic.platform.runtime.v1.UsageCounter
+	23, // 20: vedic.platform.runtime.v1.UsageCounter.usage_date:type_name -> google.protobuf.Timestamp
+	23, // 21: vedic.platform.runtime.v1.UsageCounter.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 22: vedic.platform.runtime.v1.RuntimeService.RecordDecision:input_type -> vedic.platform.runtime.v1.RecordDecisionRequest
+	4,  // 23: vedic.platform.runtime.v1.RuntimeService.RecordTelemetryEvent:input_type -> vedic.platform.runtime.v1.RecordTelemetryEventRequest
+	4,  // 24: vedic.platform.runtime.v1.RuntimeService.StreamTelemetryEvents:input_type -> vedic.platform.runtime.v1.RecordTelemetryEventRequest
+	10, // 25: vedic.platform.runtime.v1.RuntimeService.ListThreatLevels:input_type -> vedic.platform.runtime.v1.ListThreatLevelsRequest
+	13, // 26: vedic.platform.runtime.v1.RuntimeService.ListAnomalyReports:input_type -> vedic.platform.runtime.v1.ListAnomalyReportsRequest
+	15, // 27: vedic.platform.runtime.v1.RuntimeService.GetAnomalyReport:input_type -> vedic.platform.runtime.v1.GetAnomalyReportRequest
+	18, // 28: vedic.platform.runtime.v1.RuntimeService.GetUsage:input_type -> vedic.platform.runtime.v1.GetUsageRequest
+	3,  // 29: vedic.platform.runtime.v1.RuntimeService.RecordDecision:output_type -> vedic.platform.runtime.v1.RecordDecisionResponse
+	7,  // 30: vedic.platform.runtime.v1.RuntimeService.RecordTelemetryEvent:output_type -> vedic.platform.runtime.v1.RecordTelemetryEventResponse
+	8,  // 31: vedic.platform.runtime.v1.RuntimeService.StreamTelemetryEvents:output_type -> vedic.platform.runtime.v1.StreamTelemetryEventsResponse
+	11, // 32: vedic.platform.runtime.v1.RuntimeService.ListThreatLevels:output_type -> vedic.platform.runtime.v1.ListThreatLevelsResponse
+	14, // 33: vedic.platform.runtime.v1.RuntimeService.ListAnomalyReports:output_type -> vedic.platform.runtime.v1.ListAnomalyReportsResponse
+	16, // 34: vedic.platform.runtime.v1.RuntimeService.GetAnomalyReport:output_type -> vedic.platform.runtime.v1.AnomalyReport
+	19, // 35: vedic.platform.runtime.v1.RuntimeService.GetUsage:output_type -> vedic.platform.runtime.v1.UsageReport
+	29, // [29:36] is the sub-list for method output_type
+	22, // [22:29] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
+}
+
+func init() { file_runtime_proto_init() }
+func file_runtime_proto_init() {
+	if File_runtime_proto != nil {
+		return
+	}
+	file_runtime_proto_msgTypes[1].OneofWrappers = []any{}
+	file_runtime_proto_msgTypes[5].OneofWrappers = []any{}
+	file_runtime_proto_msgTypes[12].OneofWrappers = []any{}
+	file_runtime_proto_msgTypes[16].OneofWrappers = []any{}
+	file_runtime_proto_msgTypes[17].OneofWrappers = []any{}
+	file_runtime_proto_msgTypes[20].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_runtime_proto_rawDesc), len(file_runtime_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_runtime_proto_goTypes,
+		DependencyIndexes: file_runtime_proto_depIdxs,
+		MessageInfos:      file_runtime_proto_msgTypes,
+	}.Build()
+	File_runtime_proto = out.File
+	file_runtime_proto_goTypes = nil
+	file_runtime_proto_depIdxs = nil
+}
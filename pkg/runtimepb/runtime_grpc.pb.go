@@ -0,0 +1,371 @@
+// gRPC surface of platform_runtime. Messages mirror the JSON types in pkg/api
+// field for field; the server converts them and runs the same checks as the
+// HTTP handlers (auth, rate limits, OpenAPI validation, quotas, idempotency).
+//
+// Credentials travel as metadata exactly like HTTP headers: authorization
+// (Bearer), x-api-key, x-tenant-id, x-session-id and the x-signature-* set.
+// A unary signature covers the deterministic protobuf encoding of the request
+// message with the full method name as path. Streams cannot sign each message
+// and are refused when signed or when the server requires signing.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: runtime.proto
+
+package runtimepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RuntimeService_RecordDecision_FullMethodName        = "/vedic.platform.runtime.v1.RuntimeService/RecordDecision"
+	RuntimeService_RecordTelemetryEvent_FullMethodName  = "/vedic.platform.runtime.v1.RuntimeService/RecordTelemetryEvent"
+	RuntimeService_StreamTelemetryEvents_FullMethodName = "/vedic.platform.runtime.v1.RuntimeService/StreamTelemetryEvents"
+	RuntimeService_ListThreatLevels_FullMethodName      = "/vedic.platform.runtime.v1.RuntimeService/ListThreatLevels"
+	RuntimeService_ListAnomalyReports_FullMethodName    = "/vedic.platform.runtime.v1.RuntimeService/ListAnomalyReports"
+	RuntimeService_GetAnomalyReport_FullMethodName      = "/vedic.platform.runtime.v1.RuntimeService/GetAnomalyReport"
+	RuntimeService_GetUsage_FullMethodName              = "/vedic.platform.runtime.v1.RuntimeService/GetUsage"
+)
+
+// RuntimeServiceClient is the client API for RuntimeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RuntimeServiceClient interface {
+	// RecordDecision is POST /v1/decisions (scope decisions:write).
+	RecordDecision(ctx context.Context, in *RecordDecisionRequest, opts ...grpc.CallOption) (*RecordDecisionResponse, error)
+	// RecordTelemetryEvent is POST /v1/telemetry/events (scope telemetry:write).
+	RecordTelemetryEvent(ctx context.Context, in *RecordTelemetryEventRequest, opts ...grpc.CallOption) (*RecordTelemetryEventResponse, error)
+	// StreamTelemetryEvents records a client stream of events. Each message is
+	// rate limited, quota checked and idempotent on its own; failures are
+	// reported per message and do not end the stream.
+	StreamTelemetryEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RecordTelemetryEventRequest, StreamTelemetryEventsResponse], error)
+	// ListThreatLevels is GET /v1/security/threat-levels (scope security:read).
+	ListThreatLevels(ctx context.Context, in *ListThreatLevelsRequest, opts ...grpc.CallOption) (*ListThreatLevelsResponse, error)
+	// ListAnomalyReports is GET /v1/security/anomaly-reports (scope security:read).
+	ListAnomalyReports(ctx context.Context, in *ListAnomalyReportsRequest, opts ...grpc.CallOption) (*ListAnomalyReportsResponse, error)
+	// GetAnomalyReport is GET /v1/security/anomaly-reports/{id} (scope security:read).
+	GetAnomalyReport(ctx context.Context, in *GetAnomalyReportRequest, opts ...grpc.CallOption) (*AnomalyReport, error)
+	// GetUsage is GET /v1/usage (scope usage:read).
+	GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*UsageReport, error)
+}
+
+type runtimeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRuntimeServiceClient(cc grpc.ClientConnInterface) RuntimeServiceClient {
+	return &runtimeServiceClient{cc}
+}
+
+func (c *runtimeServiceClient) RecordDecision(ctx context.Context, in *RecordDecisionRequest, opts ...grpc.CallOption) (*RecordDecisionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordDecisionResponse)
+	err := c.cc.Invoke(ctx, RuntimeService_RecordDecision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeServiceClient) RecordTelemetryEvent(ctx context.Context, in *RecordTelemetryEventRequest, opts ...grpc.CallOption) (*RecordTelemetryEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordTelemetryEventResponse)
+	err := c.cc.Invoke(ctx, RuntimeService_RecordTelemetryEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeServiceClient) StreamTelemetryEvents(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RecordTelemetryEventRequest, StreamTelemetryEventsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RuntimeService_ServiceDesc.Streams[0], RuntimeService_StreamTelemetryEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RecordTelemetryEventRequest, StreamTelemetryEventsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_StreamTelemetryEventsClient = grpc.ClientStreamingClient[RecordTelemetryEventRequest, StreamTelemetryEventsResponse]
+
+func (c *runtimeServiceClient) ListThreatLevels(ctx context.Context, in *ListThreatLevelsRequest, opts ...grpc.CallOption) (*ListThreatLevelsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListThreatLevelsResponse)
+	err := c.cc.Invoke(ctx, RuntimeService_ListThreatLevels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeServiceClient) ListAnomalyReports(ctx context.Context, in *ListAnomalyReportsRequest, opts ...grpc.CallOption) (*ListAnomalyReportsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAnomalyReportsResponse)
+	err := c.cc.Invoke(ctx, RuntimeService_ListAnomalyReports_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeServiceClient) GetAnomalyReport(ctx context.Context, in *GetAnomalyReportRequest, opts ...grpc.CallOption) (*AnomalyReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnomalyReport)
+	err := c.cc.Invoke(ctx, RuntimeService_GetAnomalyReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *runtimeServiceClient) GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*UsageReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UsageReport)
+	err := c.cc.Invoke(ctx, RuntimeService_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RuntimeServiceServer is the server API for RuntimeService service.
+// All implementations must embed UnimplementedRuntimeServiceServer
+// for forward compatibility.
+type RuntimeServiceServer interface {
+	// RecordDecision is POST /v1/decisions (scope decisions:write).
+	RecordDecision(context.Context, *RecordDecisionRequest) (*RecordDecisionResponse, error)
+	// RecordTelemetryEvent is POST /v1/telemetry/events (scope telemetry:write).
+	RecordTelemetryEvent(context.Context, *RecordTelemetryEventRequest) (*RecordTelemetryEventResponse, error)
+	// StreamTelemetryEvents records a client stream of events. Each message is
+	// rate limited, quota checked and idempotent on its own; failures are
+	// reported per message and do not end the stream.
+	StreamTelemetryEvents(grpc.ClientStreamingServer[RecordTelemetryEventRequest, StreamTelemetryEventsResponse]) error
+	// ListThreatLevels is GET /v1/security/threat-levels (scope security:read).
+	ListThreatLevels(context.Context, *ListThreatLevelsRequest) (*ListThreatLevelsResponse, error)
+	// ListAnomalyReports is GET /v1/security/anomaly-reports (scope security:read).
+	ListAnomalyReports(context.Context, *ListAnomalyReportsRequest) (*ListAnomalyReportsResponse, error)
+	// GetAnomalyReport is GET /v1/security/anomaly-reports/{id} (scope security:read).
+	GetAnomalyReport(context.Context, *GetAnomalyReportRequest) (*AnomalyReport, error)
+	// GetUsage is GET /v1/usage (scope usage:read).
+	GetUsage(context.Context, *GetUsageRequest) (*UsageReport, error)
+	mustEmbedUnimplementedRuntimeServiceServer()
+}
+
+// UnimplementedRuntimeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRuntimeServiceServer struct{}
+
+func (UnimplementedRuntimeServiceServer) RecordDecision(context.Context, *RecordDecisionRequest) (*RecordDecisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordDecision not implemented")
+}
+func (UnimplementedRuntimeServiceServer) RecordTelemetryEvent(context.Context, *RecordTelemetryEventRequest) (*RecordTelemetryEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordTelemetryEvent not implemented")
+}
+func (UnimplementedRuntimeServiceServer) StreamTelemetryEvents(grpc.ClientStreamingServer[RecordTelemetryEventRequest, StreamTelemetryEventsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTelemetryEvents not implemented")
+}
+func (UnimplementedRuntimeServiceServer) ListThreatLevels(context.Context, *ListThreatLevelsRequest) (*ListThreatLevelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListThreatLevels not implemented")
+}
+func (UnimplementedRuntimeServiceServer) ListAnomalyReports(context.Context, *ListAnomalyReportsRequest) (*ListAnomalyReportsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAnomalyReports not implemented")
+}
+func (UnimplementedRuntimeServiceServer) GetAnomalyReport(context.Context, *GetAnomalyReportRequest) (*AnomalyReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnomalyReport not implemented")
+}
+func (UnimplementedRuntimeServiceServer) GetUsage(context.Context, *GetUsageRequest) (*UsageReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedRuntimeServiceServer) mustEmbedUnimplementedRuntimeServiceServer() {}
+func (UnimplementedRuntimeServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRuntimeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RuntimeServiceServer will
+// result in compilation errors.
+type UnsafeRuntimeServiceServer interface {
+	mustEmbedUnimplementedRuntimeServiceServer()
+}
+
+func RegisterRuntimeServiceServer(s grpc.ServiceRegistrar, srv RuntimeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRuntimeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RuntimeService_ServiceDesc, srv)
+}
+
+func _RuntimeService_RecordDecision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordDecisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).RecordDecision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_RecordDecision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).RecordDecision(ctx, req.(*RecordDecisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RuntimeService_RecordTelemetryEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordTelemetryEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).RecordTelemetryEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_RecordTelemetryEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).RecordTelemetryEvent(ctx, req.(*RecordTelemetryEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RuntimeService_StreamTelemetryEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RuntimeServiceServer).StreamTelemetryEvents(&grpc.GenericServerStream[RecordTelemetryEventRequest, StreamTelemetryEventsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RuntimeService_StreamTelemetryEventsServer = grpc.ClientStreamingServer[RecordTelemetryEventRequest, StreamTelemetryEventsResponse]
+
+func _RuntimeService_ListThreatLevels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListThreatLevelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).ListThreatLevels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_ListThreatLevels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).ListThreatLevels(ctx, req.(*ListThreatLevelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RuntimeService_ListAnomalyReports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAnomalyReportsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).ListAnomalyReports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_ListAnomalyReports_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).ListAnomalyReports(ctx, req.(*ListAnomalyReportsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RuntimeService_GetAnomalyReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnomalyReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).GetAnomalyReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_GetAnomalyReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).GetAnomalyReport(ctx, req.(*GetAnomalyReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RuntimeService_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RuntimeServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RuntimeService_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RuntimeServiceServer).GetUsage(ctx, req.(*GetUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RuntimeService_ServiceDesc is the grpc.ServiceDesc for RuntimeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RuntimeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "vedic.platform.runtime.v1.RuntimeService",
+	HandlerType: (*RuntimeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RecordDecision",
+			Handler:    _RuntimeService_RecordDecision_Handler,
+		},
+		{
+			MethodName: "RecordTelemetryEvent",
+			Handler:    _RuntimeService_RecordTelemetryEvent_Handler,
+		},
+		{
+			MethodName: "ListThreatLevels",
+			Handler:    _RuntimeService_ListThreatLevels_Handler,
+		},
+		{
+			MethodName: "ListAnomalyReports",
+			Handler:    _RuntimeService_ListAnomalyReports_Handler,
+		},
+		{
+			MethodName: "GetAnomalyReport",
+			Handler:    _RuntimeService_GetAnomalyReport_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _RuntimeService_GetUsage_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTelemetryEvents",
+			Handler:       _RuntimeService_StreamTelemetryEvents_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "runtime.proto",
+}
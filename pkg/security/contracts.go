@@ -15,3 +15,10 @@ type KeyResolver interface {
 	Current() (kid string, key []byte, err error)
 	Lookup(kid string) ([]byte, bool)
 }
+
+// RequestNonceStore records nonces of signed requests for replay protection.
+// A nonce is kept for ttl measured on the store's own clock, so replicas
+// with drifting clocks agree on when it expires.
+type RequestNonceStore interface {
+	MarkSeen(keyID, nonce string, ttl time.Duration) (bool, error)
+}
@@ -0,0 +1,276 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultJWKSRefreshInterval = time.Hour
+	// minJWKSForcedRefresh is the least time between two fetch attempts.
+	minJWKSForcedRefresh = 30 * time.Second
+	maxJWKSBytes         = 1 << 20
+)
+
+// JSONWebKey is one entry of a JWK Set (RFC 7517); only public parameters are read.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the {"keys": [...]} JWKS document.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKSSource supplies verification keys by key id. forceRefresh asks remote
+// sources to refetch, e.g. after an unknown kid (issuer key rotation).
+type JWKSSource interface {
+	Keys(ctx context.Context, forceRefresh bool) (map[string]crypto.PublicKey, error)
+}
+
+// ParseJWKS decodes a JWKS document into public keys by kid. Keys not meant
+// for signatures, unsupported key types and curves, and keys that fail to
+// decode or are too weak are skipped, so one new key type from the issuer
+// does not break the others. It fails when no usable key is left.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set JSONWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("security: invalid jwks: %w", err)
+	}
+	out := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil || pub == nil {
+			continue
+		}
+		if _, dup := out[k.Kid]; dup {
+			return nil, fmt.Errorf("security: duplicate jwks kid %q", k.Kid)
+		}
+		out[k.Kid] = pub
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("security: jwks contains no usable signing keys")
+	}
+	return out, nil
+}
+
+// PublicKey converts the JWK to a crypto public key; unsupported kty yields nil.
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("security: jwk %q: invalid n", k.Kid)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("security: jwk %q: invalid e", k.Kid)
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("security: jwk %q: rsa key shorter than 2048 bits", k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("security: jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, errX := decodeJWKInt(k.X)
+		y, errY := decodeJWKInt(k.Y)
+		if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("security: jwk %q: invalid ec point", k.Kid)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("security: jwk %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("security: jwk %q: invalid ed25519 key", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeJWKInt(v string) (*big.Int, error) {
+	if v == "" {
+		return nil, fmt.Errorf("empty")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// StaticJWKS serves a fixed key set, e.g. loaded from a local file.
+type StaticJWKS struct {
+	keys map[string]crypto.PublicKey
+}
+
+func NewStaticJWKS(data []byte) (*StaticJWKS, error) {
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticJWKS{keys: keys}, nil
+}
+
+// LoadJWKSFile reads a JWKS document from disk.
+func LoadJWKSFile(path string) (*StaticJWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("security: read jwks file: %w", err)
+	}
+	return NewStaticJWKS(data)
+}
+
+func (s *StaticJWKS) Keys(context.Context, bool) (map[string]crypto.PublicKey, error) {
+	return s.keys, nil
+}
+
+// JWKSFetcher retrieves a JWKS document; tests stub it to stay offline.
+type JWKSFetcher func(ctx context.Context, url string) ([]byte, error)
+
+// RemoteJWKS caches a JWKS fetched from an issuer URL and refetches it after
+// the refresh interval or on a forced refresh. Fetches run outside the lock,
+// one at a time, and callers arriving meanwhile wait for that fetch. After
+// any attempt, successful or not, the next one waits minJWKSForcedRefresh,
+// so a stream of tokens with unknown kids cannot hammer the issuer.
+type RemoteJWKS struct {
+	url     string
+	refresh time.Duration
+	fetch   JWKSFetcher
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	lastErr     error
+	inflight    chan struct{} // closed when the running fetch is done
+	clockFn     func() time.Time
+}
+
+func NewRemoteJWKS(url string, refresh time.Duration, fetch JWKSFetcher) (*RemoteJWKS, error) {
+	url = strings.TrimSpace(url)
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("security: jwks url must be http(s)")
+	}
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefreshInterval
+	}
+	if fetch == nil {
+		fetch = httpJWKSFetcher(&http.Client{Timeout: 10 * time.Second})
+	}
+	return &RemoteJWKS{
+		url:     url,
+		refresh: refresh,
+		fetch:   fetch,
+		clockFn: time.Now,
+	}, nil
+}
+
+func (s *RemoteJWKS) Keys(ctx context.Context, forceRefresh bool) (map[string]crypto.PublicKey, error) {
+	s.mu.Lock()
+	for s.inflight != nil {
+		done := s.inflight
+		s.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		s.mu.Lock()
+		// The fetch we waited for is the refresh this call wanted.
+		forceRefresh = false
+	}
+
+	now := s.clockFn()
+	stale := s.keys == nil || now.Sub(s.fetchedAt) >= s.refresh || forceRefresh
+	if !stale || now.Sub(s.attemptedAt) < minJWKSForcedRefresh {
+		keys, err := s.keys, s.lastErr
+		s.mu.Unlock()
+		if keys != nil {
+			return keys, nil
+		}
+		return nil, fmt.Errorf("security: fetch jwks: %w", err)
+	}
+	done := make(chan struct{})
+	s.inflight = done
+	s.attemptedAt = now
+	s.mu.Unlock()
+
+	// The fetch is shared, so one caller giving up must not cancel it.
+	data, err := s.fetch(context.WithoutCancel(ctx), s.url)
+	var keys map[string]crypto.PublicKey
+	if err == nil {
+		keys, err = ParseJWKS(data)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight = nil
+	close(done)
+	s.lastErr = err
+	if err == nil {
+		s.keys = keys
+		s.fetchedAt = now
+	}
+	// Keep serving the last good set if the issuer is briefly unavailable.
+	if s.keys != nil {
+		return s.keys, nil
+	}
+	return nil, fmt.Errorf("security: fetch jwks: %w", err)
+}
+
+func httpJWKSFetcher(client *http.Client) JWKSFetcher {
+	return func(ctx context.Context, url string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	}
+}
@@ -0,0 +1,322 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const DefaultOIDCClockSkew = time.Minute
+
+// ErrInvalidIDToken wraps every token verification failure.
+var ErrInvalidIDToken = errors.New("security: invalid id token")
+
+// OIDCConfig describes the single external issuer accepted by the runtime.
+type OIDCConfig struct {
+	Issuer    string
+	Audiences []string
+	ClockSkew time.Duration
+}
+
+// OIDCClaims are the verified claims the runtime uses from an ID/access token.
+type OIDCClaims struct {
+	Issuer        string
+	Subject       string
+	Audience      []string
+	ExpiresAt     time.Time
+	IssuedAt      time.Time
+	Email         string
+	EmailVerified bool
+	Name          string
+	Scopes        []string
+}
+
+// OIDCVerifier checks JWT signatures against a JWKSSource and validates
+// issuer, audience and time claims.
+type OIDCVerifier struct {
+	cfg     OIDCConfig
+	keys    JWKSSource
+	clockFn func() time.Time
+}
+
+func NewOIDCVerifier(cfg OIDCConfig, keys JWKSSource) (*OIDCVerifier, error) {
+	cfg.Issuer = strings.TrimSpace(cfg.Issuer)
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("security: oidc issuer is required")
+	}
+	if len(cfg.Audiences) == 0 {
+		return nil, fmt.Errorf("security: oidc audience is required")
+	}
+	if keys == nil {
+		return nil, fmt.Errorf("security: oidc jwks source is required")
+	}
+	if cfg.ClockSkew <= 0 {
+		cfg.ClockSkew = DefaultOIDCClockSkew
+	}
+	return &OIDCVerifier{cfg: cfg, keys: keys, clockFn: time.Now}, nil
+}
+
+// Issuer returns the configured issuer.
+func (v *OIDCVerifier) Issuer() string {
+	return v.cfg.Issuer
+}
+
+// RefreshKeys refetches the issuer's keys now, subject to the source's
+// forced-refresh rate limit.
+func (v *OIDCVerifier) RefreshKeys(ctx context.Context) error {
+	_, err := v.keys.Keys(ctx, true)
+	return err
+}
+
+// LooksLikeJWT reports whether a bearer token has the compact JWS shape.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2 && strings.HasPrefix(token, "ey")
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+type jwtClaims struct {
+	Iss           string          `json:"iss"`
+	Sub           string          `json:"sub"`
+	Aud           json.RawMessage `json:"aud"`
+	Exp           *float64        `json:"exp"`
+	Nbf           *float64        `json:"nbf"`
+	Iat           *float64        `json:"iat"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	Name          string          `json:"name"`
+	Scope         string          `json:"scope"`
+	Scp           json.RawMessage `json:"scp"`
+}
+
+// Verify validates a compact JWS token and returns its claims.
+func (v *OIDCVerifier) Verify(ctx context.Context, raw string) (OIDCClaims, error) {
+	parts := strings.Split(strings.TrimSpace(raw), ".")
+	if len(parts) != 3 {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed header", ErrInvalidIDToken)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed signature", ErrInvalidIDToken)
+	}
+
+	pub, err := v.lookupKey(ctx, header.Kid)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+	if err := verifyJWS(header.Alg, pub, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var c jwtClaims
+	if err := decodeJWTSegment(parts[1], &c); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+	return v.validateClaims(c)
+}
+
+func (v *OIDCVerifier) lookupKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	keys, err := v.keys.Keys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if pub, ok := keys[kid]; ok {
+		return pub, nil
+	}
+	// Unknown kid: the issuer may have rotated keys.
+	keys, err = v.keys.Keys(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if pub, ok := keys[kid]; ok {
+		return pub, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidIDToken, kid)
+}
+
+func (v *OIDCVerifier) validateClaims(c jwtClaims) (OIDCClaims, error) {
+	if c.Iss != v.cfg.Issuer {
+		return OIDCClaims{}, fmt.Errorf("%w: issuer mismatch", ErrInvalidIDToken)
+	}
+	if strings.TrimSpace(c.Sub) == "" {
+		return OIDCClaims{}, fmt.Errorf("%w: subject is required", ErrInvalidIDToken)
+	}
+	aud, err := parseStringOrList(c.Aud)
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed audience", ErrInvalidIDToken)
+	}
+	if !audienceAllowed(aud, v.cfg.Audiences) {
+		return OIDCClaims{}, fmt.Errorf("%w: audience mismatch", ErrInvalidIDToken)
+	}
+
+	now := v.clockFn()
+	skew := v.cfg.ClockSkew
+	if c.Exp == nil {
+		return OIDCClaims{}, fmt.Errorf("%w: exp is required", ErrInvalidIDToken)
+	}
+	exp := unixClaim(*c.Exp)
+	if !now.Before(exp.Add(skew)) {
+		return OIDCClaims{}, fmt.Errorf("%w: token expired", ErrInvalidIDToken)
+	}
+	if c.Nbf != nil && now.Add(skew).Before(unixClaim(*c.Nbf)) {
+		return OIDCClaims{}, fmt.Errorf("%w: token not yet valid", ErrInvalidIDToken)
+	}
+	var iat time.Time
+	if c.Iat != nil {
+		iat = unixClaim(*c.Iat)
+		if now.Add(skew).Before(iat) {
+			return OIDCClaims{}, fmt.Errorf("%w: token issued in the future", ErrInvalidIDToken)
+		}
+	}
+
+	scopes := strings.Fields(c.Scope)
+	if len(scopes) == 0 && len(c.Scp) > 0 {
+		scp, err := parseStringOrList(c.Scp)
+		if err != nil {
+			return OIDCClaims{}, fmt.Errorf("%w: malformed scp", ErrInvalidIDToken)
+		}
+		for _, s := range scp {
+			scopes = append(scopes, strings.Fields(s)...)
+		}
+	}
+	return OIDCClaims{
+		Issuer:        c.Iss,
+		Subject:       c.Sub,
+		Audience:      aud,
+		ExpiresAt:     exp,
+		IssuedAt:      iat,
+		Email:         strings.TrimSpace(c.Email),
+		EmailVerified: parseLooseBool(c.EmailVerified),
+		Name:          strings.TrimSpace(c.Name),
+		Scopes:        scopes,
+	}, nil
+}
+
+// verifyJWS checks a signature for the supported asymmetric JWS algorithms.
+// The key type must match the algorithm, so "none" and HMAC are always rejected.
+func verifyJWS(alg string, pub crypto.PublicKey, signingInput, sig []byte) error {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		key, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key type does not match alg %s", alg)
+		}
+		hash, digest := jwsDigest(alg[2:], signingInput)
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(key, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case "ES256", "ES384":
+		key, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key type does not match alg %s", alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if (alg == "ES256" && size != 32) || (alg == "ES384" && size != 48) || len(sig) != 2*size {
+			return fmt.Errorf("invalid ecdsa signature")
+		}
+		_, digest := jwsDigest(alg[2:], signingInput)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("signature mismatch")
+		}
+		return nil
+	case "EdDSA":
+		key, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key type does not match alg %s", alg)
+		}
+		if !ed25519.Verify(key, signingInput, sig) {
+			return fmt.Errorf("signature mismatch")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported alg %q", alg)
+	}
+}
+
+func jwsDigest(bits string, input []byte) (crypto.Hash, []byte) {
+	switch bits {
+	case "384":
+		sum := sha512.Sum384(input)
+		return crypto.SHA384, sum[:]
+	case "512":
+		sum := sha512.Sum512(input)
+		return crypto.SHA512, sum[:]
+	default:
+		sum := sha256.Sum256(input)
+		return crypto.SHA256, sum[:]
+	}
+}
+
+func decodeJWTSegment(seg string, dst interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+func parseStringOrList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, err
+	}
+	return many, nil
+}
+
+func audienceAllowed(aud, allowed []string) bool {
+	for _, a := range aud {
+		for _, want := range allowed {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseLooseBool accepts true and "true"; some issuers send email_verified as a string.
+func parseLooseBool(raw json.RawMessage) bool {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.EqualFold(s, "true")
+	}
+	return false
+}
+
+func unixClaim(v float64) time.Time {
+	sec := int64(v)
+	return time.Unix(sec, int64((v-float64(sec))*1e9)).UTC()
+}
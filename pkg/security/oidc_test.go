@@ -0,0 +1,277 @@
+package security
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testIssuer = "https://idp.example.com"
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWK(t *testing.T, kid string, key *rsa.PrivateKey) JSONWebKey {
+	t.Helper()
+	return JSONWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   b64(key.N.Bytes()),
+		E:   b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func jwksJSON(t *testing.T, keys ...JSONWebKey) []byte {
+	t.Helper()
+	data, err := json.Marshal(JSONWebKeySet{Keys: keys})
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+	return data
+}
+
+func signTestJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	body, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(body)
+	digest := sha256.Sum256([]byte(input))
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		s, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		sig = s
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return input + "." + b64(sig)
+}
+
+func validClaims(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            testIssuer,
+		"sub":            "user-123",
+		"aud":            []string{"runtime-api"},
+		"exp":            now.Add(10 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"email":          "ada@example.com",
+		"email_verified": "true",
+		"scope":          "decisions:write security:read",
+	}
+}
+
+func newTestOIDCVerifier(t *testing.T, src JWKSSource, now time.Time) *OIDCVerifier {
+	t.Helper()
+	v, err := NewOIDCVerifier(OIDCConfig{Issuer: testIssuer, Audiences: []string{"runtime-api"}}, src)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	v.clockFn = func() time.Time { return now }
+	return v
+}
+
+func TestOIDCVerifierRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	src, err := NewStaticJWKS(jwksJSON(t, rsaJWK(t, "k1", key)))
+	if err != nil {
+		t.Fatalf("static jwks: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	v := newTestOIDCVerifier(t, src, now)
+
+	claims, err := v.Verify(context.Background(), signTestJWT(t, "RS256", "k1", key, validClaims(now)))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.Subject != "user-123" || !claims.EmailVerified || len(claims.Scopes) != 2 {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	cases := map[string]func(map[string]interface{}){
+		"issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"audience": func(c map[string]interface{}) { c["aud"] = "other" },
+		"expired":  func(c map[string]interface{}) { c["exp"] = now.Add(-2 * time.Minute).Unix() },
+		"nbf":      func(c map[string]interface{}) { c["nbf"] = now.Add(5 * time.Minute).Unix() },
+		"subject":  func(c map[string]interface{}) { c["sub"] = "" },
+		"no exp":   func(c map[string]interface{}) { delete(c, "exp") },
+	}
+	for name, mutate := range cases {
+		c := validClaims(now)
+		mutate(c)
+		if _, err := v.Verify(context.Background(), signTestJWT(t, "RS256", "k1", key, c)); !errors.Is(err, ErrInvalidIDToken) {
+			t.Fatalf("%s: expected ErrInvalidIDToken, got %v", name, err)
+		}
+	}
+
+	token := signTestJWT(t, "RS256", "k1", key, validClaims(now))
+	parts := strings.Split(token, ".")
+	tampered, _ := json.Marshal(map[string]interface{}{"iss": testIssuer, "sub": "admin", "aud": "runtime-api", "exp": now.Add(time.Hour).Unix()})
+	if _, err := v.Verify(context.Background(), parts[0]+"."+b64(tampered)+"."+parts[2]); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected tampered token to fail, got %v", err)
+	}
+
+	noneHeader, _ := json.Marshal(map[string]string{"alg": "none", "kid": "k1"})
+	if _, err := v.Verify(context.Background(), b64(noneHeader)+"."+parts[1]+"."); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected alg none to fail, got %v", err)
+	}
+}
+
+func TestOIDCVerifierRemoteJWKSRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecJWK := func(kid string, k *ecdsa.PrivateKey) JSONWebKey {
+		return JSONWebKey{Kty: "EC", Kid: kid, Crv: "P-256", X: b64(k.X.FillBytes(make([]byte, 32))), Y: b64(k.Y.FillBytes(make([]byte, 32)))}
+	}
+
+	current := jwksJSON(t, ecJWK("old", oldKey))
+	fetches := 0
+	src, err := NewRemoteJWKS("https://idp.example.com/jwks", time.Hour, func(context.Context, string) ([]byte, error) {
+		fetches++
+		return current, nil
+	})
+	if err != nil {
+		t.Fatalf("remote jwks: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	src.clockFn = func() time.Time { return now }
+	v := newTestOIDCVerifier(t, src, now)
+
+	if _, err := v.Verify(context.Background(), signTestJWT(t, "ES256", "old", oldKey, validClaims(now))); err != nil {
+		t.Fatalf("verify old key: %v", err)
+	}
+	current = jwksJSON(t, ecJWK("old", oldKey), ecJWK("new", newKey))
+	now = now.Add(time.Minute)
+	if _, err := v.Verify(context.Background(), signTestJWT(t, "ES256", "new", newKey, validClaims(now))); err != nil {
+		t.Fatalf("verify rotated key: %v", err)
+	}
+	if fetches != 2 {
+		t.Fatalf("expected one forced refetch, got %d fetches", fetches)
+	}
+	if _, err := v.Verify(context.Background(), signTestJWT(t, "ES256", "missing", newKey, validClaims(now))); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected unknown kid to fail, got %v", err)
+	}
+	if fetches != 2 {
+		t.Fatalf("expected forced refetch to be rate limited, got %d fetches", fetches)
+	}
+	now = now.Add(time.Minute)
+	if err := v.RefreshKeys(context.Background()); err != nil || fetches != 3 {
+		t.Fatalf("expected RefreshKeys to refetch, got %d fetches: %v", fetches, err)
+	}
+}
+
+func TestParseJWKSRejectsWeakAndEmpty(t *testing.T) {
+	if _, err := ParseJWKS([]byte(`{"keys":[]}`)); err == nil {
+		t.Fatalf("expected error for empty jwks")
+	}
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if _, err := ParseJWKS(jwksJSON(t, rsaJWK(t, "weak", small))); err == nil {
+		t.Fatalf("expected error for short rsa key")
+	}
+	if _, err := NewOIDCVerifier(OIDCConfig{Issuer: testIssuer}, &StaticJWKS{}); err == nil {
+		t.Fatalf("expected error without audience")
+	}
+}
+
+func TestParseJWKSSkipsUnsupportedKeys(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys, err := ParseJWKS(jwksJSON(t,
+		rsaJWK(t, "k1", key),
+		JSONWebKey{Kty: "EC", Kid: "p521", Crv: "P-521", X: "AA", Y: "AA"},
+		JSONWebKey{Kty: "AKP", Kid: "pq", Alg: "ML-DSA-65"},
+	))
+	if err != nil {
+		t.Fatalf("expected unsupported keys to be skipped: %v", err)
+	}
+	if len(keys) != 1 || keys["k1"] == nil {
+		t.Fatalf("expected only the rsa key, got %v", keys)
+	}
+}
+
+func TestRemoteJWKSRateLimitsFailedRefreshes(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	good := jwksJSON(t, rsaJWK(t, "k1", key))
+	var fetches int
+	fail := false
+	src, err := NewRemoteJWKS("https://idp.example.com/jwks", time.Hour, func(context.Context, string) ([]byte, error) {
+		fetches++
+		if fail {
+			return nil, errors.New("issuer down")
+		}
+		return good, nil
+	})
+	if err != nil {
+		t.Fatalf("remote jwks: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	src.clockFn = func() time.Time { return now }
+	if _, err := src.Keys(context.Background(), false); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+
+	fail = true
+	now = now.Add(time.Minute)
+	for i := 0; i < 5; i++ {
+		if keys, err := src.Keys(context.Background(), true); err != nil || keys["k1"] == nil {
+			t.Fatalf("expected the last good set while the issuer is down, got %v %v", keys, err)
+		}
+	}
+	if fetches != 2 {
+		t.Fatalf("expected one attempt for five forced refreshes, got %d fetches", fetches)
+	}
+}
+
+func TestRemoteJWKSSharesOneFetch(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	src, err := NewRemoteJWKS("https://idp.example.com/jwks", time.Hour, func(context.Context, string) ([]byte, error) {
+		fetches.Add(1)
+		<-release
+		return jwksJSON(t, rsaJWK(t, "k1", key)), nil
+	})
+	if err != nil {
+		t.Fatalf("remote jwks: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := src.Keys(context.Background(), true); err != nil {
+				t.Errorf("keys: %v", err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected concurrent callers to share one fetch, got %d", n)
+	}
+}
@@ -0,0 +1,111 @@
+package security
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PostgresJWKSPublisher builds the public JWK Set of asymmetric signing key
+// versions stored in security.signing_key_versions.public_jwk.
+type PostgresJWKSPublisher struct {
+	db *sql.DB
+}
+
+func NewPostgresJWKSPublisher(db *sql.DB) (*PostgresJWKSPublisher, error) {
+	if db == nil {
+		return nil, fmt.Errorf("security: nil db handle")
+	}
+	return &PostgresJWKSPublisher{db: db}, nil
+}
+
+// PublishedKeySet is the JWKS document plus the earliest time a published key expires.
+type PublishedKeySet struct {
+	Set        JSONWebKeySet
+	NextExpiry *time.Time
+}
+
+// PublicKeySet returns every non-expired asymmetric key version, including
+// retired versions still inside their post-rotation overlap window and
+// versions scheduled to become valid. A retired version is only published
+// with a valid_until, so an open-ended one cannot stay verifiable forever.
+// Revoked keys are never published.
+func (p *PostgresJWKSPublisher) PublicKeySet(ctx context.Context) (PublishedKeySet, error) {
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT skv.key_id, sk.algorithm, skv.public_jwk, skv.valid_until
+		   FROM security.signing_key_versions skv
+		   JOIN security.signing_keys sk ON sk.id = skv.signing_key_id
+		  WHERE skv.public_jwk IS NOT NULL
+		    AND (
+		         (sk.status = 'active' AND (skv.valid_until IS NULL OR skv.valid_until > now()))
+		      OR (sk.status = 'retired' AND skv.valid_until > now())
+		    )
+		  ORDER BY skv.is_current DESC, skv.valid_from DESC, skv.key_id`,
+	)
+	if err != nil {
+		return PublishedKeySet{}, err
+	}
+	defer rows.Close()
+
+	out := PublishedKeySet{Set: JSONWebKeySet{Keys: make([]JSONWebKey, 0)}}
+	for rows.Next() {
+		var (
+			kid        string
+			algorithm  string
+			raw        []byte
+			validUntil sql.NullTime
+		)
+		if err := rows.Scan(&kid, &algorithm, &raw, &validUntil); err != nil {
+			return PublishedKeySet{}, err
+		}
+		jwk, ok := publishableJWK(kid, algorithm, raw)
+		if !ok {
+			continue
+		}
+		out.Set.Keys = append(out.Set.Keys, jwk)
+		if validUntil.Valid && (out.NextExpiry == nil || validUntil.Time.Before(*out.NextExpiry)) {
+			t := validUntil.Time.UTC()
+			out.NextExpiry = &t
+		}
+	}
+	return out, rows.Err()
+}
+
+// publishableJWK normalizes a stored public JWK; rows with a symmetric
+// algorithm, mismatched key type, or invalid parameters are skipped.
+func publishableJWK(kid, algorithm string, raw []byte) (JSONWebKey, bool) {
+	alg, kty, ok := jwsAlgorithm(algorithm)
+	if !ok {
+		return JSONWebKey{}, false
+	}
+	var jwk JSONWebKey
+	if err := json.Unmarshal(raw, &jwk); err != nil || jwk.Kty != kty {
+		return JSONWebKey{}, false
+	}
+	if pub, err := jwk.PublicKey(); err != nil || pub == nil {
+		return JSONWebKey{}, false
+	}
+	// Only public members survive: JSONWebKey carries no private fields.
+	jwk.Kid = kid
+	jwk.Alg = alg
+	jwk.Use = "sig"
+	return jwk, true
+}
+
+// jwsAlgorithm maps security.signing_keys.algorithm to a JWS alg and JWK kty.
+func jwsAlgorithm(algorithm string) (string, string, bool) {
+	switch strings.ToUpper(strings.TrimSpace(algorithm)) {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		return strings.ToUpper(strings.TrimSpace(algorithm)), "RSA", true
+	case "ES256", "ES384":
+		return strings.ToUpper(strings.TrimSpace(algorithm)), "EC", true
+	case "ED25519", "EDDSA":
+		return "EdDSA", "OKP", true
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,37 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestPublishableJWK(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	raw, _ := json.Marshal(map[string]string{
+		"kty": "RSA",
+		"n":   b64(key.N.Bytes()),
+		"e":   b64(big.NewInt(int64(key.E)).Bytes()),
+		"d":   "private-member-must-not-leak",
+	})
+	jwk, ok := publishableJWK("kid-1", "rs256", raw)
+	if !ok {
+		t.Fatalf("expected rsa key to be publishable")
+	}
+	if jwk.Kid != "kid-1" || jwk.Alg != "RS256" || jwk.Use != "sig" {
+		t.Fatalf("unexpected jwk: %+v", jwk)
+	}
+	out, _ := json.Marshal(jwk)
+	if strings.Contains(string(out), "private-member") {
+		t.Fatalf("private member leaked: %s", out)
+	}
+	if _, ok := publishableJWK("kid-2", "HS256", raw); ok {
+		t.Fatalf("expected symmetric algorithm to be skipped")
+	}
+	if _, ok := publishableJWK("kid-3", "ES256", raw); ok {
+		t.Fatalf("expected key type mismatch to be skipped")
+	}
+}
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"context"
 	"database/sql"
 	"encoding/hex"
 	"fmt"
@@ -26,9 +27,21 @@ func NewPostgresKeyResolver(db *sql.DB) (*PostgresKeyResolver, error) {
 }
 
 func (r *PostgresKeyResolver) Current() (string, []byte, error) {
+	return r.current(context.Background())
+}
+
+// CurrentKeyID returns the id of the current signing key, with ctx bounding
+// the query, e.g. for a readiness check.
+func (r *PostgresKeyResolver) CurrentKeyID(ctx context.Context) (string, error) {
+	kid, _, err := r.current(ctx)
+	return kid, err
+}
+
+func (r *PostgresKeyResolver) current(ctx context.Context) (string, []byte, error) {
 	var kid string
 	var stored string
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(
+		ctx,
 		`SELECT skv.key_id, skv.key_hash
 		   FROM security.signing_key_versions skv
 		   JOIN security.signing_keys sk ON sk.id = skv.signing_key_id
@@ -0,0 +1,62 @@
+package security
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PostgresRequestNonceStore records nonces of signed requests in security.request_nonces.
+type PostgresRequestNonceStore struct {
+	db *sql.DB
+}
+
+func NewPostgresRequestNonceStore(db *sql.DB) (*PostgresRequestNonceStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("security: nil db handle")
+	}
+	return &PostgresRequestNonceStore{db: db}, nil
+}
+
+// MarkSeen records the nonce until ttl past the database's now() and reports
+// false when it was already seen and unexpired.
+func (s *PostgresRequestNonceStore) MarkSeen(keyID, nonce string, ttl time.Duration) (bool, error) {
+	keyID = strings.TrimSpace(keyID)
+	nonce = strings.TrimSpace(nonce)
+	if keyID == "" || nonce == "" {
+		return false, fmt.Errorf("security: key id and nonce are required")
+	}
+	if ttl <= 0 {
+		return false, fmt.Errorf("security: nonce ttl must be > 0")
+	}
+	// An expired row for the same nonce is recycled; an unexpired one is a replay.
+	res, err := s.db.Exec(
+		`INSERT INTO security.request_nonces (key_id, nonce, seen_at, expires_at)
+		 VALUES ($1, $2, now(), now() + make_interval(secs => $3::float8))
+		 ON CONFLICT (key_id, nonce) DO UPDATE
+		 SET seen_at = now(),
+		     expires_at = EXCLUDED.expires_at
+		 WHERE security.request_nonces.expires_at <= now()`,
+		keyID,
+		nonce,
+		ttl.Seconds(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// CleanupExpired deletes expired nonce rows for table hygiene.
+func (s *PostgresRequestNonceStore) CleanupExpired(now time.Time) error {
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	_, err := s.db.Exec(`DELETE FROM security.request_nonces WHERE expires_at <= $1`, now.UTC())
+	return err
+}
@@ -0,0 +1,491 @@
+package security
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Threat levels recorded in security.threat_level_history, lowest first.
+const (
+	ThreatLevelNormal   = "normal"
+	ThreatLevelElevated = "elevated"
+	ThreatLevelHigh     = "high"
+	ThreatLevelCritical = "critical"
+)
+
+// anomalyReportLinkKind is the telemetry.event_links kind pointing at an anomaly report.
+const anomalyReportLinkKind = "anomaly_report"
+
+const maxThreatListLimit = 500
+
+// ThreatLevelTransition represents security.threat_level_history input and rows.
+type ThreatLevelTransition struct {
+	ID              string
+	NodeName        string
+	TenantID        *string
+	PreviousLevel   *string
+	NewLevel        string
+	ReasonCode      string
+	Reason          string
+	SecurityEventID *string
+	MetadataJSON    []byte
+	CreatedAt       time.Time
+}
+
+// AnomalyReport represents security.anomaly_reports input and rows.
+type AnomalyReport struct {
+	ID          string
+	NodeName    string
+	TenantID    *string
+	AnomalyType string
+	Severity    string
+	Status      string
+	Score       *string
+	Summary     string
+	DetailsJSON []byte
+	DetectedAt  time.Time
+	ResolvedAt  *time.Time
+}
+
+// AnomalyReportLink is a security event linked to a report, with the decision
+// that event is linked to (if any).
+type AnomalyReportLink struct {
+	SecurityEventID string
+	EventType       string
+	Severity        string
+	DecisionID      *string
+	LinkedAt        time.Time
+}
+
+// ThreatFilter narrows threat history and anomaly report reads.
+type ThreatFilter struct {
+	NodeName string
+	TenantID *string
+	Status   string
+	Limit    int
+}
+
+// ThreatRepository persists threat level transitions and anomaly reports.
+type ThreatRepository struct {
+	db *sql.DB
+}
+
+func NewThreatRepository(db *sql.DB) (*ThreatRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("security: nil db handle")
+	}
+	return &ThreatRepository{db: db}, nil
+}
+
+// RecordThreatLevelTransition stores a transition, deriving the previous level from
+// the latest row for the same node/tenant.
+func (r *ThreatRepository) RecordThreatLevelTransition(ctx context.Context, rec ThreatLevelTransition) (string, error) {
+	if err := validateThreatLevelTransition(rec); err != nil {
+		return "", err
+	}
+	meta := rec.MetadataJSON
+	if len(meta) == 0 {
+		meta = []byte("{}")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Serialize transitions per node/tenant so previous_level is never stale.
+	if _, err := tx.ExecContext(
+		ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('security.threat_level:' || $1 || ':' || COALESCE($2::text, '')))`,
+		rec.NodeName,
+		rec.TenantID,
+	); err != nil {
+		return "", err
+	}
+	var previous sql.NullString
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT new_level
+		   FROM security.threat_level_history
+		  WHERE node_name = $1
+		    AND tenant_id IS NOT DISTINCT FROM $2::uuid
+		  ORDER BY created_at DESC
+		  LIMIT 1`,
+		rec.NodeName,
+		rec.TenantID,
+	).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	var id string
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO security.threat_level_history
+		 (node_name, tenant_id, previous_level, new_level, reason_code, reason, security_event_id, metadata_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id::text`,
+		rec.NodeName,
+		rec.TenantID,
+		previous,
+		rec.NewLevel,
+		rec.ReasonCode,
+		rec.Reason,
+		rec.SecurityEventID,
+		meta,
+	).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// CurrentThreatLevel returns the latest level for a node/tenant, or normal when none.
+func (r *ThreatRepository) CurrentThreatLevel(ctx context.Context, nodeName string, tenantID *string) (string, error) {
+	var level string
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT new_level
+		   FROM security.threat_level_history
+		  WHERE node_name = $1
+		    AND tenant_id IS NOT DISTINCT FROM $2::uuid
+		  ORDER BY created_at DESC
+		  LIMIT 1`,
+		strings.TrimSpace(nodeName),
+		tenantID,
+	).Scan(&level)
+	if err == sql.ErrNoRows {
+		return ThreatLevelNormal, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return level, nil
+}
+
+// ListThreatLevelHistory returns transitions newest first.
+func (r *ThreatRepository) ListThreatLevelHistory(ctx context.Context, f ThreatFilter) ([]ThreatLevelTransition, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id::text, node_name, tenant_id::text, previous_level, new_level, reason_code, reason,
+		        security_event_id::text, metadata_json, created_at
+		   FROM security.threat_level_history
+		  WHERE ($1 = '' OR node_name = $1)
+		    AND ($2::uuid IS NULL OR tenant_id = $2::uuid)
+		  ORDER BY created_at DESC
+		  LIMIT $3`,
+		strings.TrimSpace(f.NodeName),
+		f.TenantID,
+		clampThreatLimit(f.Limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]ThreatLevelTransition, 0)
+	for rows.Next() {
+		var t ThreatLevelTransition
+		var tenantID, previous, eventID sql.NullString
+		if err := rows.Scan(
+			&t.ID, &t.NodeName, &tenantID, &previous, &t.NewLevel, &t.ReasonCode, &t.Reason,
+			&eventID, &t.MetadataJSON, &t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		t.TenantID = nullStringPtr(tenantID)
+		t.PreviousLevel = nullStringPtr(previous)
+		t.SecurityEventID = nullStringPtr(eventID)
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// RecordAnomalyReport stores a report and links it to security events and to the
+// events already linked to the given decisions, all in one transaction.
+func (r *ThreatRepository) RecordAnomalyReport(
+	ctx context.Context,
+	rec AnomalyReport,
+	eventIDs []string,
+	decisionIDs []string,
+) (string, error) {
+	if strings.TrimSpace(rec.Status) == "" {
+		rec.Status = "open"
+	}
+	if err := validateAnomalyReport(rec); err != nil {
+		return "", err
+	}
+	details := rec.DetailsJSON
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+	detectedAt := rec.DetectedAt
+	if detectedAt.IsZero() {
+		detectedAt = time.Now().UTC()
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var reportID string
+	err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO security.anomaly_reports
+		 (node_name, tenant_id, anomaly_type, severity, status, score, summary, details_json, detected_at)
+		 VALUES ($1, $2, $3, $4, $5, $6::numeric, $7, $8, $9)
+		 RETURNING id::text`,
+		rec.NodeName,
+		rec.TenantID,
+		rec.AnomalyType,
+		rec.Severity,
+		rec.Status,
+		rec.Score,
+		rec.Summary,
+		details,
+		detectedAt.UTC(),
+	).Scan(&reportID)
+	if err != nil {
+		return "", err
+	}
+
+	linkEvents := append([]string(nil), eventIDs...)
+	for _, decisionID := range decisionIDs {
+		var eventID string
+		err := tx.QueryRowContext(
+			ctx,
+			`SELECT event_id::text
+			   FROM telemetry.event_links
+			  WHERE link_kind = 'policy_decision'
+			    AND linked_id = $1::uuid
+			  ORDER BY created_at
+			  LIMIT 1`,
+			decisionID,
+		).Scan(&eventID)
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("security: decision %s has no linked security event", decisionID)
+		}
+		if err != nil {
+			return "", err
+		}
+		linkEvents = append(linkEvents, eventID)
+	}
+	for _, eventID := range linkEvents {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO telemetry.event_links (event_id, link_kind, linked_id)
+			 VALUES ($1::uuid, $2, $3::uuid)
+			 ON CONFLICT (event_id, link_kind, linked_id) DO NOTHING`,
+			eventID,
+			anomalyReportLinkKind,
+			reportID,
+		); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return reportID, nil
+}
+
+// ListAnomalyReports returns reports newest first.
+func (r *ThreatRepository) ListAnomalyReports(ctx context.Context, f ThreatFilter) ([]AnomalyReport, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT id::text, node_name, tenant_id::text, anomaly_type, severity, status, score::text,
+		        summary, details_json, detected_at, resolved_at
+		   FROM security.anomaly_reports
+		  WHERE ($1 = '' OR node_name = $1)
+		    AND ($2::uuid IS NULL OR tenant_id = $2::uuid)
+		    AND ($3 = '' OR status = $3)
+		  ORDER BY detected_at DESC
+		  LIMIT $4`,
+		strings.TrimSpace(f.NodeName),
+		f.TenantID,
+		strings.TrimSpace(f.Status),
+		clampThreatLimit(f.Limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]AnomalyReport, 0)
+	for rows.Next() {
+		rep, err := scanAnomalyReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rep)
+	}
+	return out, rows.Err()
+}
+
+// GetAnomalyReport loads one report with its linked events and decisions.
+func (r *ThreatRepository) GetAnomalyReport(ctx context.Context, id string) (AnomalyReport, []AnomalyReportLink, bool, error) {
+	row := r.db.QueryRowContext(
+		ctx,
+		`SELECT id::text, node_name, tenant_id::text, anomaly_type, severity, status, score::text,
+		        summary, details_json, detected_at, resolved_at
+		   FROM security.anomaly_reports
+		  WHERE id = $1::uuid`,
+		id,
+	)
+	rep, err := scanAnomalyReport(row)
+	if err == sql.ErrNoRows {
+		return AnomalyReport{}, nil, false, nil
+	}
+	if err != nil {
+		return AnomalyReport{}, nil, false, err
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT se.id::text, se.event_type, se.severity, dl.linked_id::text, al.created_at
+		   FROM telemetry.event_links al
+		   JOIN telemetry.security_events se ON se.id = al.event_id
+		   LEFT JOIN telemetry.event_links dl
+		          ON dl.event_id = al.event_id
+		         AND dl.link_kind = 'policy_decision'
+		  WHERE al.link_kind = $1
+		    AND al.linked_id = $2::uuid
+		  ORDER BY al.created_at`,
+		anomalyReportLinkKind,
+		rep.ID,
+	)
+	if err != nil {
+		return AnomalyReport{}, nil, false, err
+	}
+	defer rows.Close()
+
+	links := make([]AnomalyReportLink, 0)
+	for rows.Next() {
+		var l AnomalyReportLink
+		var decisionID sql.NullString
+		if err := rows.Scan(&l.SecurityEventID, &l.EventType, &l.Severity, &decisionID, &l.LinkedAt); err != nil {
+			return AnomalyReport{}, nil, false, err
+		}
+		l.DecisionID = nullStringPtr(decisionID)
+		links = append(links, l)
+	}
+	if err := rows.Err(); err != nil {
+		return AnomalyReport{}, nil, false, err
+	}
+	return rep, links, true, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAnomalyReport(row rowScanner) (AnomalyReport, error) {
+	var rep AnomalyReport
+	var tenantID, score sql.NullString
+	var resolvedAt sql.NullTime
+	if err := row.Scan(
+		&rep.ID, &rep.NodeName, &tenantID, &rep.AnomalyType, &rep.Severity, &rep.Status, &score,
+		&rep.Summary, &rep.DetailsJSON, &rep.DetectedAt, &resolvedAt,
+	); err != nil {
+		return AnomalyReport{}, err
+	}
+	rep.TenantID = nullStringPtr(tenantID)
+	rep.Score = nullStringPtr(score)
+	if resolvedAt.Valid {
+		t := resolvedAt.Time
+		rep.ResolvedAt = &t
+	}
+	return rep, nil
+}
+
+func validateThreatLevelTransition(rec ThreatLevelTransition) error {
+	if strings.TrimSpace(rec.NodeName) == "" {
+		return fmt.Errorf("security: threat node name is required")
+	}
+	if !IsThreatLevel(rec.NewLevel) {
+		return fmt.Errorf("security: invalid threat level %q", rec.NewLevel)
+	}
+	if strings.TrimSpace(rec.ReasonCode) == "" {
+		return fmt.Errorf("security: threat reason code is required")
+	}
+	if strings.TrimSpace(rec.Reason) == "" {
+		return fmt.Errorf("security: threat reason is required")
+	}
+	return nil
+}
+
+func validateAnomalyReport(rec AnomalyReport) error {
+	if strings.TrimSpace(rec.NodeName) == "" {
+		return fmt.Errorf("security: anomaly node name is required")
+	}
+	if strings.TrimSpace(rec.AnomalyType) == "" {
+		return fmt.Errorf("security: anomaly type is required")
+	}
+	if strings.TrimSpace(rec.Summary) == "" {
+		return fmt.Errorf("security: anomaly summary is required")
+	}
+	switch rec.Severity {
+	case "low", "medium", "high", "critical":
+	default:
+		return fmt.Errorf("security: invalid anomaly severity %q", rec.Severity)
+	}
+	switch rec.Status {
+	case "open", "acknowledged", "resolved", "dismissed":
+	default:
+		return fmt.Errorf("security: invalid anomaly status %q", rec.Status)
+	}
+	return nil
+}
+
+// IsThreatLevel reports whether level is a known threat level.
+func IsThreatLevel(level string) bool {
+	return ThreatLevelRank(level) >= 0
+}
+
+// ThreatLevelRank orders threat levels (normal=0 ... critical=3); unknown is -1.
+func ThreatLevelRank(level string) int {
+	switch level {
+	case ThreatLevelNormal:
+		return 0
+	case ThreatLevelElevated:
+		return 1
+	case ThreatLevelHigh:
+		return 2
+	case ThreatLevelCritical:
+		return 3
+	default:
+		return -1
+	}
+}
+
+func clampThreatLimit(limit int) int {
+	if limit <= 0 {
+		return 50
+	}
+	if limit > maxThreatListLimit {
+		return maxThreatListLimit
+	}
+	return limit
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	s := v.String
+	return &s
+}
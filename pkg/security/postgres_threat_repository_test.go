@@ -0,0 +1,47 @@
+package security
+
+import "testing"
+
+func TestValidateThreatLevelTransition(t *testing.T) {
+	rec := ThreatLevelTransition{
+		NodeName:   "node-a",
+		NewLevel:   ThreatLevelHigh,
+		ReasonCode: "auth.failures",
+		Reason:     "failed auth threshold exceeded",
+	}
+	if err := validateThreatLevelTransition(rec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec.NewLevel = "severe"
+	if err := validateThreatLevelTransition(rec); err == nil {
+		t.Fatalf("expected invalid level error")
+	}
+}
+
+func TestValidateAnomalyReport(t *testing.T) {
+	rec := AnomalyReport{
+		NodeName:    "node-a",
+		AnomalyType: "credential_stuffing",
+		Severity:    "high",
+		Status:      "open",
+		Summary:     "burst of invalid tokens",
+	}
+	if err := validateAnomalyReport(rec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec.Severity = "warn"
+	if err := validateAnomalyReport(rec); err == nil {
+		t.Fatalf("expected invalid severity error")
+	}
+}
+
+func TestThreatLevelRankOrdering(t *testing.T) {
+	if !(ThreatLevelRank(ThreatLevelNormal) < ThreatLevelRank(ThreatLevelElevated) &&
+		ThreatLevelRank(ThreatLevelElevated) < ThreatLevelRank(ThreatLevelHigh) &&
+		ThreatLevelRank(ThreatLevelHigh) < ThreatLevelRank(ThreatLevelCritical)) {
+		t.Fatalf("threat levels are not ordered")
+	}
+	if IsThreatLevel("unknown") {
+		t.Fatalf("expected unknown level to be rejected")
+	}
+}
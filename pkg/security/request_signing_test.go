@@ -0,0 +1,101 @@
+package security
+
+import (
+	"testing"
+	"time"
+)
+
+type staticKeyResolver map[string][]byte
+
+func (s staticKeyResolver) Current() (string, []byte, error) {
+	for kid, key := range s {
+		return kid, key, nil
+	}
+	return "", nil, nil
+}
+
+func (s staticKeyResolver) Lookup(kid string) ([]byte, bool) {
+	key, ok := s[kid]
+	return key, ok
+}
+
+// memoryNonceStore keeps each nonce's ttl and never expires them.
+type memoryNonceStore map[string]time.Duration
+
+func (m memoryNonceStore) MarkSeen(keyID, nonce string, ttl time.Duration) (bool, error) {
+	k := keyID + "/" + nonce
+	if _, ok := m[k]; ok {
+		return false, nil
+	}
+	m[k] = ttl
+	return true, nil
+}
+
+func newTestVerifier(t *testing.T, now time.Time) *RequestVerifier {
+	t.Helper()
+	v, err := NewRequestVerifier(staticKeyResolver{"kid-1": []byte("secret")}, memoryNonceStore{}, time.Minute)
+	if err != nil {
+		t.Fatalf("new verifier: %v", err)
+	}
+	v.clockFn = func() time.Time { return now }
+	return v
+}
+
+func TestRequestVerifierAcceptsAndRejectsReplay(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := newTestVerifier(t, now)
+	body := []byte(`{"subject":"svc"}`)
+	nonce := "0123456789abcdef"
+	req := SignedRequest{
+		KeyID:     "kid-1",
+		Timestamp: now.Unix(),
+		Nonce:     nonce,
+		Signature: SignRequest([]byte("secret"), "POST", "/v1/decisions", body, now.Unix(), nonce),
+	}
+	if err := v.Verify("POST", "/v1/decisions", body, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl := v.nonces.(memoryNonceStore)["kid-1/"+nonce]; ttl != 2*time.Minute {
+		t.Fatalf("expected the nonce kept for twice the skew, got %s", ttl)
+	}
+	if err := v.Verify("POST", "/v1/decisions", body, req); err == nil {
+		t.Fatalf("expected replayed nonce to be rejected")
+	}
+}
+
+func TestRequestVerifierRejectsTamperedBody(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := newTestVerifier(t, now)
+	nonce := "0123456789abcdef"
+	req := SignedRequest{
+		KeyID:     "kid-1",
+		Timestamp: now.Unix(),
+		Nonce:     nonce,
+		Signature: SignRequest([]byte("secret"), "POST", "/v1/decisions", []byte(`{"a":1}`), now.Unix(), nonce),
+	}
+	if err := v.Verify("POST", "/v1/decisions", []byte(`{"a":2}`), req); err == nil {
+		t.Fatalf("expected signature mismatch")
+	}
+}
+
+func TestRequestVerifierRejectsStaleTimestamp(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := newTestVerifier(t, now)
+	stale := now.Add(-2 * time.Minute).Unix()
+	nonce := "0123456789abcdef"
+	req := SignedRequest{
+		KeyID:     "kid-1",
+		Timestamp: stale,
+		Nonce:     nonce,
+		Signature: SignRequest([]byte("secret"), "POST", "/v1/decisions", nil, stale, nonce),
+	}
+	if err := v.Verify("POST", "/v1/decisions", nil, req); err == nil {
+		t.Fatalf("expected stale timestamp to be rejected")
+	}
+}
+
+func TestParseSignedRequestRequiresNonceLength(t *testing.T) {
+	if _, err := ParseSignedRequest("kid-1", "1700000000", "short", "abcd"); err == nil {
+		t.Fatalf("expected short nonce error")
+	}
+}
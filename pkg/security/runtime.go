@@ -32,11 +32,15 @@ func (c RuntimeConfig) Validate() error {
 // 1. KeyResolver for Current/Lookup key resolution.
 // 2. RevocationStore for token/session revocation checks.
 // 3. NonceStore for crash-safe nonce persistence callbacks.
+// 4. RequestNonces for signed request replay protection.
+// 5. JWKSPublisher for offline verification of runtime-signed tokens.
 type RuntimeDeps struct {
 	KeyResolver     KeyResolver
 	RevocationStore RevocationStore
 	NonceStore      *PostgresNonceStore
 	NonceStart      uint64
+	RequestNonces   *PostgresRequestNonceStore
+	JWKSPublisher   *PostgresJWKSPublisher
 }
 
 func BuildPostgresRuntime(db *sql.DB, cfg RuntimeConfig) (*RuntimeDeps, error) {
@@ -66,11 +70,22 @@ func BuildPostgresRuntime(db *sql.DB, cfg RuntimeConfig) (*RuntimeDeps, error) {
 	if err != nil {
 		return nil, err
 	}
+	requestNonces, err := NewPostgresRequestNonceStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	jwksPublisher, err := NewPostgresJWKSPublisher(db)
+	if err != nil {
+		return nil, err
+	}
 
 	return &RuntimeDeps{
 		KeyResolver:     keyResolver,
 		RevocationStore: revocation,
 		NonceStore:      nonceStore,
 		NonceStart:      start,
+		RequestNonces:   requestNonces,
+		JWKSPublisher:   jwksPublisher,
 	}, nil
 }
@@ -0,0 +1,246 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+)
+
+// BatchEvent is one event of a batch write with its links.
+type BatchEvent struct {
+	Record SecurityEventRecord
+	Links  []EventLink
+}
+
+// BatchResult is the outcome of one BatchEvent: the id it was stored under,
+// or the classified error it was skipped for.
+type BatchResult struct {
+	EventID string
+	Err     error
+}
+
+const (
+	// batchEventRows bounds the rows of one multi-row event insert; with 10
+	// parameters a row it stays well below Postgres' 65535 parameter limit.
+	batchEventRows = 500
+	// batchLinkRows bounds the rows of one multi-row link insert.
+	batchLinkRows = 2000
+)
+
+// PersistSecurityEventBatchTx writes the valid events of a batch and their
+// links in the caller's transaction, a chunk of events per multi-row insert.
+// An event that fails validation, or that a constraint rejects (a missing
+// tenant, a duplicate link), is skipped with its error in its result while
+// the rest are written; any other error aborts the batch. Results line up
+// with events.
+func (r *Repository) PersistSecurityEventBatchTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	events []BatchEvent,
+) (results []BatchResult, err error) {
+	ctx, span := tracing.Start(ctx, "telemetry.PersistSecurityEventBatch",
+		tracing.WithKind(tracing.SpanKindClient),
+		tracing.WithAttributes("db.system", "postgresql", "telemetry.events", len(events)),
+	)
+	defer func() {
+		err = dbpkg.Classify("telemetry: persist event batch", err)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if tx == nil {
+		return nil, fmt.Errorf("telemetry: nil tx")
+	}
+	results = make([]BatchResult, len(events))
+	valid := make([]int, 0, len(events))
+	for i := range events {
+		ev := &events[i]
+		if strings.TrimSpace(ev.Record.Severity) == "" {
+			ev.Record.Severity = "info"
+		}
+		if err := validateSecurityEvent(ev.Record); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if err := validateEventLinks(ev.Links); err != nil {
+			results[i].Err = err
+			continue
+		}
+		id, err := newEventID()
+		if err != nil {
+			return nil, err
+		}
+		results[i].EventID = id
+		valid = append(valid, i)
+	}
+
+	written := 0
+	for start := 0; start < len(valid); start += batchEventRows {
+		chunk := valid[start:min(start+batchEventRows, len(valid))]
+		n, err := persistEventChunk(ctx, tx, events, results, chunk)
+		if err != nil {
+			return nil, err
+		}
+		written += n
+	}
+
+	span.SetAttributes("telemetry.events_written", written)
+	logging.FromContext(ctx).Debug(
+		"telemetry event batch persisted",
+		"events", len(events),
+		"written", written,
+	)
+	return results, nil
+}
+
+// persistEventChunk inserts a chunk of events and their links. If a
+// constraint rejects the chunk, it is retried one event at a time so only
+// the offending events are skipped. It returns how many events were written.
+func persistEventChunk(ctx context.Context, tx *sql.Tx, events []BatchEvent, results []BatchResult, chunk []int) (int, error) {
+	rejected, err := withSavepoint(ctx, tx, func() error {
+		return insertEventRows(ctx, tx, events, results, chunk)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if rejected == nil {
+		return len(chunk), nil
+	}
+	if len(chunk) == 1 {
+		results[chunk[0]] = BatchResult{Err: rejected}
+		return 0, nil
+	}
+	written := 0
+	for _, i := range chunk {
+		n, err := persistEventChunk(ctx, tx, events, results, []int{i})
+		if err != nil {
+			return 0, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// withSavepoint runs fn under a savepoint. If a constraint rejects what fn
+// wrote, it rolls back to the savepoint and returns the rejection; other
+// errors are returned as err and leave the transaction aborted.
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func() error) (rejected error, err error) {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT telemetry_batch`); err != nil {
+		return nil, err
+	}
+	if err := fn(); err != nil {
+		if !rejectsEvent(err) {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT telemetry_batch`); err != nil {
+			return nil, err
+		}
+		rejected = dbpkg.Classify("telemetry: persist event", err)
+	}
+	if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT telemetry_batch`); err != nil {
+		return nil, err
+	}
+	return rejected, nil
+}
+
+// rejectsEvent reports whether err is about the data of an event rather
+// than the database, so the event can be skipped.
+func rejectsEvent(err error) bool {
+	err = dbpkg.Classify("telemetry: persist event", err)
+	return errors.Is(err, dbpkg.ErrValidation) || errors.Is(err, dbpkg.ErrForeignKey) || errors.Is(err, dbpkg.ErrConflict)
+}
+
+// insertEventRows inserts the events at idx under their pre-assigned ids,
+// then their links.
+func insertEventRows(ctx context.Context, tx *sql.Tx, events []BatchEvent, results []BatchResult, idx []int) error {
+	const eventCols = 10
+	var sb strings.Builder
+	sb.WriteString(`INSERT INTO telemetry.security_events
+		 (id, tenant_id, workspace_id, actor_type, actor_id, event_type, severity, message, trace_hash, event_json)
+		 VALUES `)
+	args := make([]interface{}, 0, len(idx)*eventCols)
+	for n, i := range idx {
+		rec := events[i].Record
+		evJSON := rec.EventJSON
+		if len(evJSON) == 0 {
+			evJSON = []byte("{}")
+		}
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		writePlaceholders(&sb, len(args), eventCols)
+		args = append(args, results[i].EventID, rec.TenantID, rec.WorkspaceID, rec.ActorType, rec.ActorID,
+			rec.EventType, rec.Severity, rec.Message, rec.TraceHash, evJSON)
+	}
+	if _, err := tx.ExecContext(ctx, sb.String(), args...); err != nil {
+		return err
+	}
+
+	const linkCols = 4
+	sb.Reset()
+	args = args[:0]
+	flush := func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, sb.String(), args...)
+		sb.Reset()
+		args = args[:0]
+		return err
+	}
+	for _, i := range idx {
+		for _, link := range events[i].Links {
+			if len(args) == 0 {
+				sb.WriteString(`INSERT INTO telemetry.event_links
+		 (event_id, link_kind, linked_id, metadata_json)
+		 VALUES `)
+			} else {
+				sb.WriteString(", ")
+			}
+			meta := link.MetadataJSON
+			if len(meta) == 0 {
+				meta = []byte("{}")
+			}
+			writePlaceholders(&sb, len(args), linkCols)
+			args = append(args, results[i].EventID, link.LinkKind, link.LinkedID, meta)
+			if len(args) >= batchLinkRows*linkCols {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return flush()
+}
+
+// writePlaceholders writes one VALUES row of n parameters numbered after offset.
+func writePlaceholders(sb *strings.Builder, offset, n int) {
+	sb.WriteByte('(')
+	for j := 1; j <= n; j++ {
+		if j > 1 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(sb, "$%d", offset+j)
+	}
+	sb.WriteByte(')')
+}
+
+// newEventID returns a random (version 4) UUID. Batch events get their ids
+// up front, so each result lines up with its event without relying on the
+// order of RETURNING rows.
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("telemetry: event id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,37 @@
+package telemetry
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewEventIDIsUUIDv4(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, err := newEventID()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := newEventID()
+	if !pattern.MatchString(a) || a == b {
+		t.Fatalf("expected distinct v4 UUIDs, got %q and %q", a, b)
+	}
+}
+
+func TestWritePlaceholders(t *testing.T) {
+	var sb strings.Builder
+	writePlaceholders(&sb, 0, 3)
+	sb.WriteString(", ")
+	writePlaceholders(&sb, 3, 3)
+	if got := sb.String(); got != "($1, $2, $3), ($4, $5, $6)" {
+		t.Fatalf("unexpected placeholders %q", got)
+	}
+}
+
+func TestPersistSecurityEventBatchTxNilTx(t *testing.T) {
+	r := &Repository{}
+	if _, err := r.PersistSecurityEventBatchTx(context.Background(), nil, []BatchEvent{{}}); err == nil {
+		t.Fatalf("expected error for nil tx")
+	}
+}
@@ -5,6 +5,10 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+
+	dbpkg "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/db"
+	logging "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/logging"
+	tracing "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
 )
 
 // SecurityEventRecord represents telemetry.security_events input.
@@ -44,7 +48,40 @@ func (r *Repository) PersistSecurityEventWithLinks(
 	ctx context.Context,
 	rec SecurityEventRecord,
 	links []EventLink,
-) (string, error) {
+) (eventID string, err error) {
+	err = dbpkg.WithTx(ctx, r.db, nil, func(tx *sql.Tx) error {
+		var txErr error
+		eventID, txErr = r.PersistSecurityEventWithLinksTx(ctx, tx, rec, links)
+		return txErr
+	})
+	if err != nil {
+		return "", dbpkg.Classify("telemetry: persist event", err)
+	}
+	return eventID, nil
+}
+
+// PersistSecurityEventWithLinksTx writes an event and related links in the
+// caller's transaction, so they commit together with the caller's other writes.
+func (r *Repository) PersistSecurityEventWithLinksTx(
+	ctx context.Context,
+	tx *sql.Tx,
+	rec SecurityEventRecord,
+	links []EventLink,
+) (eventID string, err error) {
+	ctx, span := tracing.Start(ctx, "telemetry.PersistSecurityEventWithLinks",
+		tracing.WithKind(tracing.SpanKindClient),
+		tracing.WithAttributes("db.system", "postgresql", "telemetry.event_type", rec.EventType, "telemetry.links", len(links)),
+	)
+	defer func() {
+		err = dbpkg.Classify("telemetry: persist event", err)
+		span.SetAttributes("telemetry.event_id", eventID)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if tx == nil {
+		return "", fmt.Errorf("telemetry: nil tx")
+	}
 	if strings.TrimSpace(rec.Severity) == "" {
 		rec.Severity = "info"
 	}
@@ -55,15 +92,7 @@ func (r *Repository) PersistSecurityEventWithLinks(
 		return "", err
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	eventID, err := insertSecurityEvent(ctx, tx, rec)
+	eventID, err = insertSecurityEvent(ctx, tx, rec)
 	if err != nil {
 		return "", err
 	}
@@ -87,9 +116,13 @@ func (r *Repository) PersistSecurityEventWithLinks(
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return "", err
-	}
+	logging.FromContext(ctx).Debug(
+		"telemetry event persisted",
+		"event_id", eventID,
+		"event_type", rec.EventType,
+		"severity", rec.Severity,
+		"links", len(links),
+	)
 	return eventID, nil
 }
 
@@ -124,35 +157,35 @@ func insertSecurityEvent(ctx context.Context, tx *sql.Tx, rec SecurityEventRecor
 
 func validateSecurityEvent(rec SecurityEventRecord) error {
 	if strings.TrimSpace(rec.ActorType) == "" {
-		return fmt.Errorf("telemetry: actor type is required")
+		return dbpkg.Validationf("telemetry: actor type is required")
 	}
 	switch strings.TrimSpace(rec.ActorType) {
 	case "user", "service", "system":
 	default:
-		return fmt.Errorf("telemetry: invalid actor type %q", rec.ActorType)
+		return dbpkg.Validationf("telemetry: invalid actor type %q", rec.ActorType)
 	}
 	if strings.TrimSpace(rec.EventType) == "" {
-		return fmt.Errorf("telemetry: event type is required")
+		return dbpkg.Validationf("telemetry: event type is required")
 	}
 	if strings.TrimSpace(rec.Message) == "" {
-		return fmt.Errorf("telemetry: message is required")
+		return dbpkg.Validationf("telemetry: message is required")
 	}
 	sev := strings.TrimSpace(rec.Severity)
 	switch sev {
 	case "debug", "info", "warn", "error":
 		return nil
 	default:
-		return fmt.Errorf("telemetry: invalid severity %q", rec.Severity)
+		return dbpkg.Validationf("telemetry: invalid severity %q", rec.Severity)
 	}
 }
 
 func validateEventLinks(links []EventLink) error {
 	for _, l := range links {
 		if strings.TrimSpace(l.LinkKind) == "" {
-			return fmt.Errorf("telemetry: link kind is required")
+			return dbpkg.Validationf("telemetry: link kind is required")
 		}
 		if strings.TrimSpace(l.LinkedID) == "" {
-			return fmt.Errorf("telemetry: linked id is required")
+			return dbpkg.Validationf("telemetry: linked id is required")
 		}
 	}
 	return nil
@@ -0,0 +1,291 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter ships finished spans. ExportSpans is called from a single goroutine.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// WriterExporter writes one JSON object per span, for stdout or a file
+// when no collector is reachable.
+type WriterExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriterExporter writes spans to w; Shutdown leaves w open.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// NewFileExporter appends spans to path, creating it if needed; Shutdown closes it.
+func NewFileExporter(path string) (*WriterExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: open span file: %w", err)
+	}
+	return &WriterExporter{w: f, closer: f}, nil
+}
+
+type jsonSpan struct {
+	TraceID       string         `json:"trace_id"`
+	SpanID        string         `json:"span_id"`
+	ParentSpanID  string         `json:"parent_span_id,omitempty"`
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	Start         time.Time      `json:"start"`
+	End           time.Time      `json:"end"`
+	DurationMS    float64        `json:"duration_ms"`
+	Status        string         `json:"status,omitempty"`
+	StatusMessage string         `json:"status_message,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+}
+
+func (e *WriterExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		out := jsonSpan{
+			TraceID:       s.TraceID.String(),
+			SpanID:        s.SpanID.String(),
+			Name:          s.Name,
+			Kind:          kindName(s.Kind),
+			Start:         s.Start.UTC(),
+			End:           s.End.UTC(),
+			DurationMS:    float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			StatusMessage: s.StatusMessage,
+		}
+		if s.ParentSpanID.IsValid() {
+			out.ParentSpanID = s.ParentSpanID.String()
+		}
+		switch s.StatusCode {
+		case StatusOK:
+			out.Status = "ok"
+		case StatusError:
+			out.Status = "error"
+		}
+		if len(s.Attributes) > 0 {
+			out.Attributes = make(map[string]any, len(s.Attributes))
+			for _, a := range s.Attributes {
+				out.Attributes[a.Key] = jsonAttrValue(a.Value)
+			}
+		}
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("tracing: encode span: %w", err)
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *WriterExporter) Shutdown(context.Context) error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+func kindName(k SpanKind) string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+func jsonAttrValue(v any) any {
+	switch v := v.(type) {
+	case string, bool, int, int64, float64:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// OTLPConfig configures the OTLP/HTTP exporter.
+type OTLPConfig struct {
+	// Endpoint is the full traces URL, e.g. http://localhost:4318/v1/traces.
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	Timeout     time.Duration
+	Client      *http.Client
+}
+
+// OTLPExporter posts spans to an OpenTelemetry collector using the OTLP/HTTP
+// JSON encoding.
+type OTLPExporter struct {
+	cfg    OTLPConfig
+	client *http.Client
+}
+
+// NewOTLPExporter validates cfg and returns an exporter.
+func NewOTLPExporter(cfg OTLPConfig) (*OTLPExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("tracing: otlp endpoint is required")
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "unknown_service"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &OTLPExporter{cfg: cfg, client: client}, nil
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+const instrumentationScope = "github.com/sarat-asymmetrica/vedic-platform-experiments/pkg/tracing"
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(encodeOTLP(e.cfg.ServiceName, spans))
+	if err != nil {
+		return fmt.Errorf("tracing: encode otlp: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("tracing: build otlp request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing: otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("tracing: otlp export: collector returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func encodeOTLP(serviceName string, spans []SpanData) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              int(s.Kind),
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: s.StatusCode, Message: s.StatusMessage},
+		}
+		if s.ParentSpanID.IsValid() {
+			span.ParentSpanID = s.ParentSpanID.String()
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpKeyValue{Key: a.Key, Value: otlpValue(a.Value)})
+		}
+		out = append(out, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpValue(serviceName)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: out}},
+	}}}
+}
+
+func otlpValue(v any) otlpAnyValue {
+	switch v := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}
+	case bool:
+		return otlpAnyValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		return otlpAnyValue{DoubleValue: &v}
+	case error:
+		s := v.Error()
+		return otlpAnyValue{StringValue: &s}
+	default:
+		s := fmt.Sprint(v)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
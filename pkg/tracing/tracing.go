@@ -0,0 +1,504 @@
+// Package tracing is a small span tracer with W3C Trace Context propagation.
+// Spans travel through context like the request-scoped logger; finished,
+// sampled spans are batched to a pluggable Exporter (OTLP/HTTP or JSON lines).
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceparentHeader is the W3C Trace Context propagation header.
+const TraceparentHeader = "traceparent"
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsValid reports whether the id is non-zero.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// IsValid reports whether the id is non-zero.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// SpanContext is the propagated part of a span.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both ids are set.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Traceparent formats sc as a version-00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value. Unknown future versions
+// are accepted as long as the version-00 prefix fields are well formed.
+func ParseTraceparent(v string) (SpanContext, error) {
+	v = strings.TrimSpace(v)
+	parts := strings.Split(v, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, fmt.Errorf("tracing: malformed traceparent")
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("tracing: unsupported traceparent version")
+	}
+	var sc SpanContext
+	if !decodeLowerHex(sc.TraceID[:], parts[1]) || !decodeLowerHex(sc.SpanID[:], parts[2]) {
+		return SpanContext{}, fmt.Errorf("tracing: malformed traceparent ids")
+	}
+	var flags [1]byte
+	if !decodeLowerHex(flags[:], parts[3]) {
+		return SpanContext{}, fmt.Errorf("tracing: malformed traceparent flags")
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil {
+		return SpanContext{}, fmt.Errorf("tracing: malformed traceparent version")
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("tracing: zero traceparent ids")
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+	return sc, nil
+}
+
+func decodeLowerHex(dst []byte, s string) bool {
+	if strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// SpanKind follows the OTLP span kinds.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Span status codes (OTLP numbering).
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Attr is a span attribute.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// SpanData is a finished span as handed to exporters.
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attr
+	StatusCode    int
+	StatusMessage string
+}
+
+// Span is an in-flight span. Its methods are safe on a nil *Span.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	ended  atomic.Bool
+
+	mu   sync.Mutex
+	data SpanData
+}
+
+// SpanContext returns the span's propagated context.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetName renames the span, e.g. once the HTTP route is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttributes adds alternating key/value pairs, like slog.Logger.With.
+func (s *Span) SetAttributes(kv ...any) {
+	if s == nil || !s.sc.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		s.data.Attributes = append(s.data.Attributes, Attr{Key: key, Value: kv[i+1]})
+	}
+}
+
+// RecordError marks the span failed; a nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+// SetStatus sets the span status.
+func (s *Span) SetStatus(code int, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.StatusCode = code
+	s.data.StatusMessage = message
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export if sampled. Later calls are no-ops.
+func (s *Span) End() {
+	if s == nil || !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+	if !s.sc.Sampled || s.tracer == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.enqueue(data)
+}
+
+// Config configures a Tracer. A nil Exporter records nothing but still
+// assigns and propagates ids.
+type Config struct {
+	Exporter      Exporter
+	SampleRatio   float64
+	BatchSize     int
+	FlushInterval time.Duration
+	QueueSize     int
+}
+
+// Tracer starts spans and batches finished ones to its exporter.
+type Tracer struct {
+	exporter      Exporter
+	sampleRatio   float64
+	batchSize     int
+	flushInterval time.Duration
+
+	queue   chan SpanData
+	flushCh chan chan struct{}
+	done    chan struct{}
+	stopped atomic.Bool
+	dropped atomic.Int64
+}
+
+// NewTracer returns a tracer; with an exporter it starts the batching loop,
+// which Shutdown stops.
+func NewTracer(cfg Config) *Tracer {
+	t := &Tracer{
+		exporter:      cfg.Exporter,
+		sampleRatio:   cfg.SampleRatio,
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+	}
+	if t.exporter == nil {
+		return t
+	}
+	if t.batchSize <= 0 {
+		t.batchSize = 256
+	}
+	if t.flushInterval <= 0 {
+		t.flushInterval = 5 * time.Second
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 4096
+	}
+	t.queue = make(chan SpanData, queueSize)
+	t.flushCh = make(chan chan struct{})
+	t.done = make(chan struct{})
+	go t.loop()
+	return t
+}
+
+// Dropped reports spans discarded because the export queue was full.
+func (t *Tracer) Dropped() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.dropped.Load()
+}
+
+// SpanOption customizes Start.
+type SpanOption func(*SpanData)
+
+// WithKind sets the span kind (default internal).
+func WithKind(k SpanKind) SpanOption {
+	return func(d *SpanData) { d.Kind = k }
+}
+
+// WithAttributes sets initial attributes as alternating key/value pairs.
+func WithAttributes(kv ...any) SpanOption {
+	return func(d *SpanData) {
+		for i := 0; i+1 < len(kv); i += 2 {
+			if key, ok := kv[i].(string); ok {
+				d.Attributes = append(d.Attributes, Attr{Key: key, Value: kv[i+1]})
+			}
+		}
+	}
+}
+
+// Start begins a span that is a child of the span (or remote parent) in ctx,
+// or a new root that is sampled per SampleRatio.
+func (t *Tracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	parent := spanContextFrom(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample()
+	}
+	if t == nil || t.exporter == nil {
+		sc.Sampled = parent.IsValid() && parent.Sampled
+	}
+	s := &Span{tracer: t, sc: sc}
+	s.data = SpanData{
+		Name:         name,
+		Kind:         SpanKindInternal,
+		TraceID:      sc.TraceID,
+		SpanID:       sc.SpanID,
+		ParentSpanID: parent.SpanID,
+		Start:        time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&s.data)
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *Tracer) sample() bool {
+	switch {
+	case t == nil || t.sampleRatio <= 0:
+		return false
+	case t.sampleRatio >= 1:
+		return true
+	default:
+		return rand.Float64() < t.sampleRatio
+	}
+}
+
+func (t *Tracer) enqueue(d SpanData) {
+	if t.queue == nil || t.stopped.Load() {
+		return
+	}
+	select {
+	case t.queue <- d:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, t.batchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), t.flushInterval)
+		if err := t.exporter.ExportSpans(ctx, batch); err != nil {
+			slog.Warn("trace export failed", "spans", len(batch), "error", err)
+		}
+		cancel()
+		batch = make([]SpanData, 0, t.batchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case d := <-t.queue:
+				batch = append(batch, d)
+				if len(batch) >= t.batchSize {
+					export()
+				}
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case d := <-t.queue:
+			batch = append(batch, d)
+			if len(batch) >= t.batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-t.flushCh:
+			drain()
+			export()
+			if ack == nil {
+				return
+			}
+			close(ack)
+		}
+	}
+}
+
+// ForceFlush exports queued spans and waits for the export to finish.
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	if t == nil || t.exporter == nil || t.stopped.Load() {
+		return nil
+	}
+	ack := make(chan struct{})
+	select {
+	case t.flushCh <- ack:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown flushes queued spans, stops the batching loop and shuts the exporter down.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil || t.exporter == nil || !t.stopped.CompareAndSwap(false, true) {
+		return nil
+	}
+	select {
+	case t.flushCh <- nil:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.exporter.Shutdown(ctx)
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// SpanFromContext returns the current span, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteParent makes sc (e.g. from an incoming traceparent) the
+// parent of the next span started from the returned context.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func spanContextFrom(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// TraceIDFromContext returns the hex trace id of the current span, or "".
+func TraceIDFromContext(ctx context.Context) string {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc.TraceID.String()
+	}
+	return ""
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func init() {
+	defaultTracer.Store(NewTracer(Config{}))
+}
+
+// SetDefault installs the tracer used by Start.
+func SetDefault(t *Tracer) {
+	if t != nil {
+		defaultTracer.Store(t)
+	}
+}
+
+// Default returns the process-wide tracer.
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Start begins a span on the default tracer.
+func Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	return Default().Start(ctx, name, opts...)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		hi, lo := rand.Uint64(), rand.Uint64()
+		for i := 0; i < 8; i++ {
+			id[i] = byte(hi >> (56 - 8*i))
+			id[8+i] = byte(lo >> (56 - 8*i))
+		}
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		v := rand.Uint64()
+		for i := 0; i < 8; i++ {
+			id[i] = byte(v >> (56 - 8*i))
+		}
+	}
+	return id
+}
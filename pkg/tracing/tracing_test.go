@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Fatalf("unexpected span context %+v", sc)
+	}
+	if sc.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected round trip %q", sc.Traceparent())
+	}
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future"); err != nil {
+		t.Fatalf("expected future version accepted: %v", err)
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Fatalf("expected %q rejected", bad)
+		}
+	}
+}
+
+type recordingExporter struct {
+	spans []SpanData
+}
+
+func (r *recordingExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *recordingExporter) Shutdown(context.Context) error { return nil }
+
+func TestTracerPropagatesRemoteParent(t *testing.T) {
+	exp := &recordingExporter{}
+	tr := NewTracer(Config{Exporter: exp, SampleRatio: 0, FlushInterval: time.Hour})
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, server := tr.Start(ContextWithRemoteParent(context.Background(), remote), "POST /v1/decisions", WithKind(SpanKindServer))
+	_, child := tr.Start(ctx, "authz.persist_decision")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	server.SetAttributes("http.response.status_code", 500)
+	server.End()
+	server.End()
+
+	if got := TraceIDFromContext(ctx); got != remote.TraceID.String() {
+		t.Fatalf("expected remote trace id, got %q", got)
+	}
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if len(exp.spans) != 2 {
+		t.Fatalf("expected 2 exported spans, got %d", len(exp.spans))
+	}
+	c, s := exp.spans[0], exp.spans[1]
+	if c.ParentSpanID != s.SpanID || s.ParentSpanID != remote.SpanID || c.StatusCode != StatusError || s.Kind != SpanKindServer {
+		t.Fatalf("unexpected span tree %+v %+v", c, s)
+	}
+}
+
+func TestTracerWithoutExporterStillAssignsIDs(t *testing.T) {
+	tr := NewTracer(Config{SampleRatio: 1})
+	ctx, span := tr.Start(context.Background(), "root")
+	span.End()
+	if TraceIDFromContext(ctx) == "" || span.SpanContext().Sampled {
+		t.Fatalf("expected unsampled span with a trace id, got %+v", span.SpanContext())
+	}
+	if TraceIDFromContext(context.Background()) != "" {
+		t.Fatalf("expected no trace id without a span")
+	}
+}
+
+func TestWriterExporterWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	tr := NewTracer(Config{Exporter: NewWriterExporter(&buf), SampleRatio: 1, FlushInterval: time.Hour})
+	_, span := tr.Start(context.Background(), "db.tx", WithKind(SpanKindClient), WithAttributes("db.system", "postgresql"))
+	span.End()
+	if err := tr.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &out); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if out["name"] != "db.tx" || out["kind"] != "client" || out["attributes"].(map[string]any)["db.system"] != "postgresql" {
+		t.Fatalf("unexpected span line %v", out)
+	}
+	_ = tr.Shutdown(context.Background())
+}
+
+func TestOTLPExporterPostsJSON(t *testing.T) {
+	var body []byte
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exp, err := NewOTLPExporter(OTLPConfig{Endpoint: srv.URL + "/v1/traces", ServiceName: "platform_runtime", Headers: map[string]string{"Authorization": "Bearer x"}})
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	tr := NewTracer(Config{Exporter: exp, SampleRatio: 1, FlushInterval: time.Hour})
+	_, span := tr.Start(context.Background(), "root", WithAttributes("rows", 3, "ok", true))
+	span.End()
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if auth != "Bearer x" {
+		t.Fatalf("expected configured headers, got %q", auth)
+	}
+	for _, want := range []string{`"service.name"`, `"stringValue":"platform_runtime"`, `"name":"root"`, `"intValue":"3"`, `"boolValue":true`, `"kind":1`} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("expected %s in %s", want, body)
+		}
+	}
+}